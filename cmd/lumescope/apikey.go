@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"lumescope/internal/apikeys"
+	"lumescope/internal/config"
+	"lumescope/internal/db"
+)
+
+const apiKeyUsage = `usage:
+  lumescope apikey create -name NAME [-tier TIER] [-rpm N] [-burst N]
+  lumescope apikey list [-all]
+  lumescope apikey revoke ID`
+
+// runAPIKeyCommand implements the "apikey" subcommand and returns the exit code.
+// Keys live in the default network's database.
+func runAPIKeyCommand(cfg config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool, err := db.ConnectSchema(ctx, cfg.DB_DSN, 2, cfg.DBSchema)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "db connect failed: %v\n", err)
+		return 1
+	}
+	defer db.Close(pool)
+	if err := db.Bootstrap(ctx, pool); err != nil {
+		fmt.Fprintf(os.Stderr, "db bootstrap failed: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "create":
+		return apiKeyCreate(ctx, cfg, pool, args[1:])
+	case "list":
+		return apiKeyList(ctx, pool, args[1:])
+	case "revoke":
+		return apiKeyRevoke(ctx, pool, args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+}
+
+func apiKeyCreate(ctx context.Context, cfg config.Config, pool *db.Pool, args []string) int {
+	fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	name := fs.String("name", "", "who or what the key is for (required)")
+	tier := fs.String("tier", cfg.APIKeyDefaultTier, "rate limit tier (RATE_LIMIT_TIERS)")
+	rpm := fs.Float64("rpm", -1, "override the tier's requests per minute (0 = unlimited)")
+	burst := fs.Int("burst", -1, "override the tier's burst size")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *name == "" {
+		fmt.Fprintln(os.Stderr, "apikey create: -name is required")
+		return 2
+	}
+	if _, ok := cfg.RateLimitTiers[*tier]; !ok {
+		fmt.Fprintf(os.Stderr, "apikey create: unknown tier %q (configure it in RATE_LIMIT_TIERS)\n", *tier)
+		return 2
+	}
+
+	key, err := apikeys.Generate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "generate key: %v\n", err)
+		return 1
+	}
+	k := db.APIKey{Name: *name, KeyPrefix: apikeys.DisplayPrefix(key), Tier: *tier}
+	if *rpm >= 0 {
+		k.RateLimitPerMinute = rpm
+	}
+	if *burst >= 0 {
+		b := int32(*burst)
+		k.RateLimitBurst = &b
+	}
+	created, err := db.CreateAPIKey(ctx, pool, k, apikeys.Hash(key))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create key: %v\n", err)
+		return 1
+	}
+	fmt.Printf("id:   %d\nname: %s\ntier: %s\nkey:  %s\n", created.ID, created.Name, created.Tier, key)
+	fmt.Fprintln(os.Stderr, "Store the key now; it cannot be shown again.")
+	return 0
+}
+
+func apiKeyList(ctx context.Context, pool *db.Pool, args []string) int {
+	fs := flag.NewFlagSet("apikey list", flag.ContinueOnError)
+	all := fs.Bool("all", false, "include revoked keys")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	keys, err := db.ListAPIKeys(ctx, pool, *all)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "list keys: %v\n", err)
+		return 1
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tTIER\tLIMIT\tCREATED\tLAST USED\tREVOKED")
+	for _, k := range keys {
+		limit := "tier"
+		if k.RateLimitPerMinute != nil || k.RateLimitBurst != nil {
+			limit = formatLimitOverride(k)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, k.KeyPrefix, k.Tier, limit,
+			k.CreatedAt.Format(time.RFC3339), formatOptionalTime(k.LastUsedAt), formatOptionalTime(k.RevokedAt))
+	}
+	tw.Flush()
+	return 0
+}
+
+func apiKeyRevoke(ctx context.Context, pool *db.Pool, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apikey revoke: invalid id %q\n", args[0])
+		return 2
+	}
+	if err := db.RevokeAPIKey(ctx, pool, id); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "apikey revoke: no active key with id %d\n", id)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "revoke key: %v\n", err)
+		return 1
+	}
+	fmt.Printf("revoked key %d\n", id)
+	return 0
+}
+
+func formatLimitOverride(k db.APIKey) string {
+	rpm, burst := "tier", "tier"
+	if k.RateLimitPerMinute != nil {
+		rpm = strconv.FormatFloat(*k.RateLimitPerMinute, 'f', -1, 64)
+	}
+	if k.RateLimitBurst != nil {
+		burst = strconv.Itoa(int(*k.RateLimitBurst))
+	}
+	return rpm + ":" + burst
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"lumescope/internal/config"
+	"lumescope/internal/db"
+	"lumescope/internal/export"
+)
+
+const exportUsage = `usage:
+  lumescope export DATASET [-format csv|ndjson|parquet] [-o FILE] [-network NAME] [filters]
+
+datasets: actions, action-transactions, supernodes
+filters:  -type -creator -state -supernode  (actions, action-transactions)
+          -from -to  block heights; -fromTime -toTime  RFC3339 (actions, action-transactions)
+          -txType  (action-transactions)
+          -currentState -version  (supernodes)`
+
+// exportFilterNames are the filter flags, named like the /v1/export query parameters.
+var exportFilterNames = []string{
+	db.ExportFilterType, db.ExportFilterCreator, db.ExportFilterState, db.ExportFilterSupernode,
+	db.ExportFilterFromHeight, db.ExportFilterToHeight, db.ExportFilterFromTime, db.ExportFilterToTime,
+	db.ExportFilterTxType, db.ExportFilterCurrentState, db.ExportFilterVersion,
+}
+
+// runExportCommand implements the "export" subcommand and returns the exit code. It
+// streams a dataset from a network's database to a file or stdout, like /v1/export.
+func runExportCommand(cfg config.Config, args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(os.Stderr, exportUsage)
+		return 2
+	}
+	ds, ok := db.ExportDatasetByName(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "export: unknown dataset %q\n\n%s\n", args[0], exportUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("export "+ds.Name, flag.ContinueOnError)
+	formatName := fs.String("format", string(export.CSV), "output format: csv, ndjson or parquet")
+	outPath := fs.String("o", "", "output file (default stdout)")
+	network := fs.String("network", "", "network to export (default: the default network)")
+	filters := make(map[string]*string, len(exportFilterNames))
+	for _, name := range exportFilterNames {
+		filters[name] = fs.String(name, "", "filter by "+name)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 2
+	}
+	filter, err := export.ParseFilter(func(name string) string { return *filters[name] })
+	if err == nil {
+		err = ds.Validate(filter)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 2
+	}
+
+	ncfg := cfg.Networks[0]
+	if *network != "" {
+		found := false
+		for _, n := range cfg.Networks {
+			if n.Network == *network {
+				ncfg, found = n, true
+				break
+			}
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "export: unknown network %q\n", *network)
+			return 2
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	pool, err := db.ConnectSchema(ctx, ncfg.DB_DSN, 2, ncfg.DBSchema)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "db connect failed: %v\n", err)
+		return 1
+	}
+	defer db.Close(pool)
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+
+	n, err := export.Run(ctx, pool, ds, filter, format, out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export %s: %v\n", ds.Name, err)
+		if *outPath != "" {
+			os.Remove(*outPath)
+		}
+		return 1
+	}
+	if *outPath != "" {
+		fmt.Fprintf(os.Stderr, "exported %d %s rows to %s\n", n, ds.Name, *outPath)
+	}
+	return 0
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,30 +16,54 @@ import (
 	"lumescope/internal/db"
 	lclient "lumescope/internal/lumera"
 	"lumescope/internal/server"
+	"lumescope/internal/webhooks"
 )
 
 func main() {
 	cfg := config.Load()
 
-	// Init DB
-	ctx := context.Background()
-	pool, err := db.Connect(ctx, cfg.DB_DSN, cfg.DB_MaxConns)
-	if err != nil {
-		log.Fatalf("db connect failed: %v", err)
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		os.Exit(runAPIKeyCommand(cfg, os.Args[2:]))
 	}
-	if err := db.Bootstrap(ctx, pool); err != nil {
-		log.Fatalf("db bootstrap failed: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		os.Exit(runExportCommand(cfg, os.Args[2:]))
 	}
 
-	// Lumera client
-	lc := lclient.NewClient(cfg.LumeraAPIBase, cfg.HTTPTimeout)
-
-	// Start background workers
+	// One pool, Lumera client, runner and webhook dispatcher per network.
+	// Each network keeps its tables in its own schema.
+	ctx := context.Background()
 	bgCtx, bgCancel := context.WithCancel(context.Background())
-	runner := background.NewRunner(cfg, pool, lc)
-	runner.Start(bgCtx)
+	backends := make([]server.Backend, 0, len(cfg.Networks))
+	pools := make([]*db.Pool, 0, len(cfg.Networks))
+	for _, ncfg := range cfg.Networks {
+		pool, err := db.ConnectSchema(ctx, ncfg.DB_DSN, ncfg.DB_MaxConns, ncfg.DBSchema)
+		if err != nil {
+			log.Fatalf("db connect failed (network %s): %v", ncfg.Network, err)
+		}
+		if err := db.Bootstrap(ctx, pool); err != nil {
+			log.Fatalf("db bootstrap failed (network %s): %v", ncfg.Network, err)
+		}
+		pools = append(pools, pool)
+		db.RegisterPoolMetrics(ncfg.Network, pool)
 
-	r := server.NewRouter(cfg, pool, runner)
+		chain, source, err := newChainClient(ncfg)
+		if err != nil {
+			log.Fatalf("chain client failed (network %s): %v", ncfg.Network, err)
+		}
+		runner := background.NewRunner(ncfg, pool, chain)
+		runner.Start(bgCtx)
+		webhooks.NewDispatcher(ncfg, pool).Start(bgCtx)
+
+		backend := server.Backend{Cfg: ncfg, Pool: pool, SyncTrigger: runner, LoopStatus: runner, TxFetcher: chain}
+		if lcd, ok := chain.(*lclient.Client); ok {
+			backend.ChainEndpoints = lcd
+		}
+		backends = append(backends, backend)
+		log.Printf("network %s: chain %q, %s, schema %s", ncfg.Network, ncfg.ChainID, source, ncfg.DBSchema)
+	}
+
+	r := server.NewRouter(cfg, backends)
 
 	srv := &http.Server{
 		Addr:              ":" + cfg.Port,
@@ -67,6 +92,23 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		log.Printf("graceful shutdown error: %v", err)
 	}
-	db.Close(pool)
+	for _, pool := range pools {
+		db.Close(pool)
+	}
 	log.Printf("LumeScope API stopped")
 }
+
+// newChainClient builds the chain client selected by CHAIN_CLIENT and describes its
+// source for the startup log.
+func newChainClient(cfg config.Config) (lclient.Chain, string, error) {
+	if cfg.ChainClient == config.ChainClientGRPC {
+		gc, err := lclient.NewGRPCClient(cfg.LumeraGRPCAddr, cfg.HTTPTimeout, cfg.LumeraGRPCTLS)
+		if err != nil {
+			return nil, "", err
+		}
+		return gc, "gRPC " + cfg.LumeraGRPCAddr, nil
+	}
+	lcd := lclient.NewClientEndpoints(cfg.LumeraAPIBases, cfg.HTTPTimeout)
+	lcd.MaxRetries, lcd.RetryBackoff = cfg.LumeraAPIRetries, cfg.LumeraAPIRetryBackoff
+	return lcd, "LCD " + strings.Join(cfg.LumeraAPIBases, ", "), nil
+}
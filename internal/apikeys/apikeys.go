@@ -0,0 +1,169 @@
+// Package apikeys issues API keys and resolves presented keys to their stored record.
+//
+// Keys look like "lsk_<48 hex chars>". Only their SHA-256 hash is stored; lookups are
+// cached briefly so authenticating a request rarely touches the database.
+package apikeys
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/ratelimit"
+)
+
+// KeyPrefix starts every issued key.
+const KeyPrefix = "lsk_"
+
+// displayPrefixLen is how much of a key is stored in clear to identify it.
+const displayPrefixLen = len(KeyPrefix) + 8
+
+// maxCacheEntries bounds the lookup cache so a flood of random keys cannot grow memory
+// without limit. When full, the least recently used entry is evicted.
+const maxCacheEntries = 10000
+
+// Generate returns a new random API key.
+func Generate() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return KeyPrefix + hex.EncodeToString(b), nil
+}
+
+// Hash returns the hex SHA-256 of key, as stored in api_keys."keyHash".
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// DisplayPrefix returns the leading part of key that is stored in clear.
+func DisplayPrefix(key string) string {
+	if len(key) <= displayPrefixLen {
+		return key
+	}
+	return key[:displayPrefixLen]
+}
+
+// Limit returns the rate limit for k: its per-key overrides, else its tier's limit,
+// else def when the tier is unknown.
+func Limit(k db.APIKey, tiers map[string]ratelimit.Limit, def ratelimit.Limit) ratelimit.Limit {
+	l, ok := tiers[k.Tier]
+	if !ok {
+		l = def
+	}
+	if k.RateLimitPerMinute != nil {
+		l.PerMinute = *k.RateLimitPerMinute
+	}
+	if k.RateLimitBurst != nil {
+		l.Burst = int(*k.RateLimitBurst)
+	}
+	return l
+}
+
+type cacheEntry struct {
+	hash    string
+	key     *db.APIKey // nil: unknown or revoked
+	expires time.Time
+}
+
+// Store resolves presented keys against api_keys with a short-lived cache. Revoking a
+// key takes effect once its cache entry expires.
+type Store struct {
+	pool       *db.Pool
+	ttl        time.Duration
+	maxEntries int
+
+	mu    sync.Mutex
+	cache map[string]*list.Element // key hash -> element of order
+	order *list.List               // *cacheEntry, least recently used first
+}
+
+// NewStore returns a Store caching lookups for ttl.
+func NewStore(pool *db.Pool, ttl time.Duration) *Store {
+	return &Store{
+		pool:       pool,
+		ttl:        ttl,
+		maxEntries: maxCacheEntries,
+		cache:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// cached returns the unexpired cache entry for hash, marking it recently used.
+func (s *Store) cached(hash string, now time.Time) (cacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.cache[hash]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !now.Before(e.expires) {
+		return cacheEntry{}, false
+	}
+	s.order.MoveToBack(el)
+	return *e, true
+}
+
+// store caches e, evicting the least recently used entries beyond maxEntries.
+func (s *Store) store(e cacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.cache[e.hash]; ok {
+		el.Value = &e
+		s.order.MoveToBack(el)
+		return
+	}
+	for s.order.Len() >= s.maxEntries {
+		oldest := s.order.Front()
+		s.order.Remove(oldest)
+		delete(s.cache, oldest.Value.(*cacheEntry).hash)
+	}
+	s.cache[e.hash] = s.order.PushBack(&e)
+}
+
+// Lookup returns the active key matching the presented key. ok is false for unknown
+// or revoked keys.
+func (s *Store) Lookup(ctx context.Context, presented string) (k db.APIKey, ok bool, err error) {
+	if !strings.HasPrefix(presented, KeyPrefix) {
+		return db.APIKey{}, false, nil
+	}
+	hash := Hash(presented)
+	now := time.Now()
+
+	if e, hit := s.cached(hash, now); hit {
+		if e.key == nil {
+			return db.APIKey{}, false, nil
+		}
+		return *e.key, true, nil
+	}
+
+	found, err := db.GetActiveAPIKeyByHash(ctx, s.pool, hash)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return db.APIKey{}, false, err
+	}
+	entry := cacheEntry{hash: hash, expires: now.Add(s.ttl)}
+	if err == nil {
+		entry.key = &found
+		// Record usage once per cache period rather than on every request
+		if terr := db.TouchAPIKey(ctx, s.pool, found.ID, now.UTC()); terr != nil {
+			log.Printf("touch api key %d: %v", found.ID, terr)
+		}
+	}
+
+	s.store(entry)
+
+	if entry.key == nil {
+		return db.APIKey{}, false, nil
+	}
+	return found, true, nil
+}
@@ -0,0 +1,80 @@
+package apikeys
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/ratelimit"
+)
+
+// TestGenerateAndHash verifies key format, uniqueness and stable hashing
+func TestGenerateAndHash(t *testing.T) {
+	a, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Generate()
+	if a == b || !strings.HasPrefix(a, KeyPrefix) || len(a) != len(KeyPrefix)+48 {
+		t.Errorf("keys %q, %q: want distinct lsk_ keys of 52 chars", a, b)
+	}
+	if Hash(a) != Hash(a) || Hash(a) == Hash(b) || len(Hash(a)) != 64 {
+		t.Errorf("unexpected hashes %q / %q", Hash(a), Hash(b))
+	}
+	if p := DisplayPrefix(a); p != a[:12] {
+		t.Errorf("DisplayPrefix = %q, want %q", p, a[:12])
+	}
+}
+
+// TestLimit verifies tier lookup, per-key overrides and the unknown-tier fallback
+func TestLimit(t *testing.T) {
+	tiers := map[string]ratelimit.Limit{
+		"basic": {PerMinute: 600, Burst: 100},
+		"pro":   {PerMinute: 6000, Burst: 1000},
+	}
+	def := ratelimit.Limit{PerMinute: 60, Burst: 20}
+
+	if l := Limit(db.APIKey{Tier: "pro"}, tiers, def); l != tiers["pro"] {
+		t.Errorf("pro = %+v", l)
+	}
+	if l := Limit(db.APIKey{Tier: "gold"}, tiers, def); l != def {
+		t.Errorf("unknown tier = %+v, want default", l)
+	}
+	rpm, burst := 120.0, int32(5)
+	l := Limit(db.APIKey{Tier: "basic", RateLimitPerMinute: &rpm, RateLimitBurst: &burst}, tiers, def)
+	if l.PerMinute != 120 || l.Burst != 5 {
+		t.Errorf("override = %+v, want 120:5", l)
+	}
+}
+
+// TestStoreCacheEviction verifies a full cache evicts its least recently used entry
+// rather than dropping everything, and that expired entries are misses
+func TestStoreCacheEviction(t *testing.T) {
+	s := NewStore(nil, time.Minute)
+	s.maxEntries = 3
+	now := time.Now()
+	for _, h := range []string{"a", "b", "c"} {
+		s.store(cacheEntry{hash: h, expires: now.Add(time.Minute)})
+	}
+	if _, ok := s.cached("a", now); !ok {
+		t.Fatal("a missing")
+	}
+	s.store(cacheEntry{hash: "d", expires: now.Add(time.Minute)})
+	for h, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if _, ok := s.cached(h, now); ok != want {
+			t.Errorf("cached(%q) = %v, want %v", h, ok, want)
+		}
+	}
+	if len(s.cache) != 3 || s.order.Len() != 3 {
+		t.Errorf("cache holds %d/%d entries, want 3", len(s.cache), s.order.Len())
+	}
+
+	s.store(cacheEntry{hash: "a", expires: now.Add(-time.Second)})
+	if _, ok := s.cached("a", now); ok {
+		t.Error("expired entry was a hit")
+	}
+	if len(s.cache) != 3 {
+		t.Errorf("refreshing an entry changed the size to %d", len(s.cache))
+	}
+}
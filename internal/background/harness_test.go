@@ -0,0 +1,513 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"lumescope/internal/config"
+	"lumescope/internal/db"
+	"lumescope/internal/lumera/lumeratest"
+)
+
+// The harness runs the sync, enricher, block indexer and probe passes against a
+// lumeratest.Chain and a throwaway schema of the Postgres database named by
+// LUMESCOPE_TEST_DSN (see `make test-integration`). Without it these tests are skipped.
+
+const (
+	testCreator   = "lumera1creator"
+	testValidator = "lumeravaloper1val"
+	testSupernode = "lumera1supernode"
+)
+
+type harness struct {
+	t     *testing.T
+	ctx   context.Context
+	chain *lumeratest.Chain
+	pool  *db.Pool
+	r     *Runner
+}
+
+// newHarness connects to a fresh schema, dropped when the test ends, and returns a
+// Runner reading from an empty fake chain. configure, if set, adjusts the config.
+func newHarness(t *testing.T, configure func(*config.Config)) *harness {
+	t.Helper()
+	dsn := os.Getenv("LUMESCOPE_TEST_DSN")
+	if dsn == "" {
+		t.Skip("LUMESCOPE_TEST_DSN not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	t.Cleanup(cancel)
+
+	schema := fmt.Sprintf("lumescope_test_%d", time.Now().UnixNano())
+	pool, err := db.ConnectSchema(ctx, dsn, 4, schema)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := pool.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Logf("drop schema %s: %v", schema, err)
+		}
+		pool.Close()
+	})
+	if err := db.Bootstrap(ctx, pool); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+
+	cfg := config.Config{
+		Network:              "test",
+		ActionsHotWindow:     100,
+		ActionsHotWindowDone: 100,
+		DialTimeout:          time.Second,
+		ActionTxIndexer:      config.TxIndexerActions,
+		BlockIndexerRange:    100,
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+	chain := lumeratest.NewChain()
+	return &harness{t: t, ctx: ctx, chain: chain, pool: pool, r: NewRunner(cfg, pool, chain)}
+}
+
+// must fails the test if a pass returns an error.
+func (h *harness) must(name string, err error) {
+	h.t.Helper()
+	if err != nil {
+		h.t.Fatalf("%s: %v", name, err)
+	}
+}
+
+// cycle runs one pass of every chain loop, in the order Start schedules them.
+func (h *harness) cycle() {
+	h.t.Helper()
+	h.must("validators", h.r.syncValidators(h.ctx))
+	h.must("supernodes", h.r.syncSupernodes(h.ctx))
+	h.must("actions", h.r.syncActions(h.ctx))
+	if h.r.Cfg.ActionTxIndexer == config.TxIndexerBlocks {
+		h.must("block indexer", h.r.runBlockIndexer(h.ctx))
+	}
+	h.must("enricher", h.r.runActionTxEnricher(h.ctx))
+}
+
+func (h *harness) action(id uint64) db.ActionDB {
+	h.t.Helper()
+	a, err := db.GetActionByID(h.ctx, h.pool, id)
+	if err != nil {
+		h.t.Fatalf("action %d: %v", id, err)
+	}
+	return a
+}
+
+// txTypes returns the stored transaction types of an action, oldest first.
+func (h *harness) txTypes(id uint64) []string {
+	h.t.Helper()
+	txs, err := db.GetActionTransactions(h.ctx, h.pool, id)
+	if err != nil {
+		h.t.Fatalf("action %d txs: %v", id, err)
+	}
+	var types []string
+	for _, tx := range txs {
+		types = append(types, tx.TxType)
+	}
+	return types
+}
+
+// actionEvents counts the logged action events by type.
+func (h *harness) actionEvents() map[string]int {
+	h.t.Helper()
+	events, err := db.ListActionEventsAfter(h.ctx, h.pool, 0, db.ActionEventsFilter{}, 1000)
+	if err != nil {
+		h.t.Fatalf("action events: %v", err)
+	}
+	counts := map[string]int{}
+	for _, e := range events {
+		counts[e.EventType]++
+	}
+	return counts
+}
+
+func (h *harness) checkpoint(name string) db.SyncCheckpoint {
+	h.t.Helper()
+	cp, err := db.GetSyncCheckpoint(h.ctx, h.pool, name)
+	if err != nil {
+		h.t.Fatalf("checkpoint %s: %v", name, err)
+	}
+	return cp
+}
+
+// TestHarnessActionLifecycle verifies actions, their state transitions and register
+// transactions flow from the chain into the store and the event log
+func TestHarnessActionLifecycle(t *testing.T) {
+	h := newHarness(t, nil)
+	h.chain.AddValidator(testValidator, "val-one")
+	h.chain.SetSupernode(h.chain.Supernode(testSupernode, testValidator, "127.0.0.1:4444", "4445", "SUPERNODE_STATE_ACTIVE"))
+	first := h.chain.RegisterAction(testCreator, "ACTION_TYPE_CASCADE", "10000ulume")
+	second := h.chain.RegisterAction(testCreator, "ACTION_TYPE_SENSE", "2500ulume")
+	h.cycle()
+
+	sn, err := db.GetSupernodeByID(h.ctx, h.pool, testSupernode)
+	if err != nil {
+		t.Fatalf("supernode: %v", err)
+	}
+	if sn.ValidatorMoniker != "val-one" || sn.CurrentState != "SUPERNODE_STATE_ACTIVE" || sn.IPAddress != "127.0.0.1:4444" {
+		t.Errorf("supernode = %+v", sn)
+	}
+
+	a := h.action(first)
+	if a.State != "ACTION_STATE_PENDING" || a.PriceAmount != "10000" || a.BlockHeight != h.chain.Height()-1 {
+		t.Errorf("action %d = %+v", first, a)
+	}
+	txs, err := db.GetActionTransactions(h.ctx, h.pool, first)
+	if err != nil || len(txs) != 1 {
+		t.Fatalf("action %d txs = %+v, %v", first, txs, err)
+	}
+	reg := txs[0]
+	if reg.TxType != "register" || reg.FlowPayer == nil || *reg.FlowPayer != testCreator ||
+		reg.FlowPayee == nil || *reg.FlowPayee != lumeratest.DefaultModuleAccount {
+		t.Errorf("register tx = %+v", reg)
+	}
+	flows, err := db.GetTxFlows(h.ctx, h.pool, []string{reg.TxHash})
+	if err != nil || len(flows[reg.TxHash]) != 1 || flows[reg.TxHash][0].Amount != "10000" {
+		t.Errorf("register flows = %+v, %v", flows, err)
+	}
+	if cp := h.checkpoint(db.CheckpointActions); cp.LastActionID != second || cp.LastFullSyncAt == nil {
+		t.Errorf("actions checkpoint = %+v", cp)
+	}
+
+	h.chain.FinalizeAction(first, testSupernode)
+	h.chain.SetActionState(second, "ACTION_STATE_EXPIRED")
+	third := h.chain.RegisterAction(testCreator, "ACTION_TYPE_CASCADE", "10000ulume")
+	h.cycle()
+
+	if got := h.action(first).State; got != "ACTION_STATE_DONE" {
+		t.Errorf("action %d state = %s", first, got)
+	}
+	if got := h.action(second).State; got != "ACTION_STATE_EXPIRED" {
+		t.Errorf("action %d state = %s", second, got)
+	}
+	if got := h.txTypes(third); len(got) != 1 || got[0] != "register" {
+		t.Errorf("action %d txs = %v", third, got)
+	}
+	if cp := h.checkpoint(db.CheckpointActions); cp.LastActionID != third {
+		t.Errorf("actions checkpoint = %+v", cp)
+	}
+	// The first pass backfilled first and second, which records no events
+	want := map[string]int{db.ActionEventCreated: 1, db.ActionEventStateChanged: 2, db.ActionEventTxAttached: 1}
+	if got := h.actionEvents(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("action events = %v, want %v", got, want)
+	}
+
+	// A pass over an unchanged chain changes nothing
+	h.cycle()
+	if got := h.actionEvents(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("action events after idle pass = %v, want %v", got, want)
+	}
+}
+
+// TestHarnessBackfillHeight verifies a failed backfill keeps events off, and that the
+// backfill height survives a restart so re-indexed history records no events
+func TestHarnessBackfillHeight(t *testing.T) {
+	h := newHarness(t, nil)
+	first := h.chain.RegisterAction(testCreator, "ACTION_TYPE_CASCADE", "10000ulume")
+	h.chain.Fail("GetActions", 1, errors.New("chain unavailable"))
+	if err := h.r.syncActions(h.ctx); err == nil {
+		t.Fatal("syncActions succeeded despite the failure")
+	}
+	if got := h.r.eventsFromHeight.Load(); got != math.MaxInt64 {
+		t.Errorf("eventsFromHeight after a failed backfill = %d, want none", got)
+	}
+	h.cycle()
+	if cp := h.checkpoint(db.CheckpointActions); cp.BackfillHeight != h.chain.Height() {
+		t.Errorf("backfill height = %d, want %d", cp.BackfillHeight, h.chain.Height())
+	}
+
+	// A restarted runner re-attaching the register tx treats it as history
+	if _, err := h.pool.Exec(h.ctx, `DELETE FROM action_transactions`); err != nil {
+		t.Fatal(err)
+	}
+	h.r = NewRunner(h.r.Cfg, h.pool, h.chain)
+	second := h.chain.RegisterAction(testCreator, "ACTION_TYPE_CASCADE", "10000ulume")
+	h.cycle()
+	if got := h.txTypes(first); len(got) != 1 {
+		t.Errorf("action %d txs = %v", first, got)
+	}
+	want := map[string]int{db.ActionEventCreated: 1, db.ActionEventTxAttached: 1}
+	if got := h.actionEvents(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("action events = %v, want %v for action %d only", got, want, second)
+	}
+}
+
+// TestHarnessStaleActionWrite verifies a snapshot of an action read before a newer
+// state was stored, as a full sync page can be, neither regresses the state nor
+// records a backwards transition
+func TestHarnessStaleActionWrite(t *testing.T) {
+	h := newHarness(t, nil)
+	h.cycle()
+	id := h.chain.RegisterAction(testCreator, "ACTION_TYPE_CASCADE", "10000ulume")
+	h.cycle()
+	stale, err := h.chain.GetAction(h.ctx, strconv.FormatUint(id, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.chain.FinalizeAction(id, testSupernode)
+	h.cycle()
+	before := h.actionEvents()
+
+	if _, _, ok := h.r.upsertChainAction(h.ctx, *stale); !ok {
+		t.Fatal("stale upsert failed")
+	}
+	if got := h.action(id).State; got != "ACTION_STATE_DONE" {
+		t.Errorf("state after a stale write = %s, want ACTION_STATE_DONE", got)
+	}
+	if got := h.actionEvents(); fmt.Sprint(got) != fmt.Sprint(before) {
+		t.Errorf("action events after a stale write = %v, want %v", got, before)
+	}
+}
+
+// TestHarnessLegacyMsgIndex verifies re-indexing a tx stored before message indexes
+// were recorded replaces its msgIndex 0 row instead of adding a second one
+func TestHarnessLegacyMsgIndex(t *testing.T) {
+	h := newHarness(t, nil)
+	id := h.chain.RegisterAction(testCreator, "ACTION_TYPE_CASCADE", "10000ulume")
+	h.cycle()
+	reindexed := h.chain.FinalizeAction(id, testSupernode)
+	legacy := &db.ActionTransaction{ActionID: id, TxType: "finalize", TxHash: reindexed, Height: h.chain.Height(), BlockTime: time.Now().UTC()}
+	if _, err := db.UpsertActionTransaction(h.ctx, h.pool, legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := *legacy
+	tx.MsgIndex = 2
+	if inserted, err := db.UpsertActionTransaction(h.ctx, h.pool, &tx); err != nil || inserted {
+		t.Fatalf("re-indexed upsert = %v, %v, want a replaced row", inserted, err)
+	}
+	txs, err := db.GetActionTransactions(h.ctx, h.pool, id)
+	if err != nil || len(txs) != 2 || txs[1].TxHash != reindexed || txs[1].MsgIndex != 2 {
+		t.Errorf("action %d txs = %+v, %v, want register and one finalize", id, txs, err)
+	}
+}
+
+// TestHarnessChainFailures verifies failed chain calls fail or skip a pass without
+// losing data, and that the next pass catches up
+func TestHarnessChainFailures(t *testing.T) {
+	h := newHarness(t, nil)
+	errChain := errors.New("chain unavailable")
+	first := h.chain.RegisterAction(testCreator, "ACTION_TYPE_CASCADE", "10000ulume")
+
+	// The first pass runs a full reconciliation through GetActions
+	h.chain.Fail("GetActions", 1, errChain)
+	if err := h.r.syncActions(h.ctx); !errors.Is(err, errChain) {
+		t.Fatalf("syncActions err = %v", err)
+	}
+	if _, err := db.GetSyncCheckpoint(h.ctx, h.pool, db.CheckpointActions); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("checkpoint after failed full sync: %v", err)
+	}
+	h.cycle()
+
+	// Incremental passes discover new actions through GetAction
+	second := h.chain.RegisterAction(testCreator, "ACTION_TYPE_CASCADE", "10000ulume")
+	h.chain.Fail("GetAction", 0, errChain)
+	if err := h.r.syncActions(h.ctx); !errors.Is(err, errChain) {
+		t.Fatalf("syncActions err = %v", err)
+	}
+	if _, err := db.GetActionByID(h.ctx, h.pool, second); err == nil {
+		t.Errorf("action %d stored despite the failure", second)
+	}
+	if cp := h.checkpoint(db.CheckpointActions); cp.LastActionID != first {
+		t.Errorf("checkpoint moved past the failure: %+v", cp)
+	}
+	h.chain.Recover("GetAction")
+
+	// The enricher skips an action whose txs can't be fetched and retries it next run
+	h.chain.Fail("GetActionTransactions", 1, errChain)
+	h.must("actions", h.r.syncActions(h.ctx))
+	h.must("enricher", h.r.runActionTxEnricher(h.ctx))
+	if got := h.txTypes(second); len(got) != 0 {
+		t.Errorf("action %d txs after failed fetch = %v", second, got)
+	}
+	h.must("enricher", h.r.runActionTxEnricher(h.ctx))
+	if got := h.txTypes(second); len(got) != 1 || got[0] != "register" {
+		t.Errorf("action %d txs = %v", second, got)
+	}
+	if calls := h.chain.Calls("GetActionTransactions"); calls != 3 {
+		t.Errorf("GetActionTransactions calls = %d, want 3", calls)
+	}
+
+	// Validator and supernode failures fail their pass
+	h.chain.Fail("GetSupernodes", 1, errChain)
+	if err := h.r.syncSupernodes(h.ctx); !errors.Is(err, errChain) {
+		t.Errorf("syncSupernodes err = %v", err)
+	}
+}
+
+// TestHarnessConcurrentSupernodeSyncs verifies overlapping supernode syncs, as a manual
+// trigger during a loop pass runs them, record a state change once
+func TestHarnessConcurrentSupernodeSyncs(t *testing.T) {
+	h := newHarness(t, nil)
+	h.chain.AddValidator(testValidator, "val-one")
+	h.chain.SetSupernode(h.chain.Supernode(testSupernode, testValidator, "127.0.0.1:4444", "4445", "SUPERNODE_STATE_ACTIVE"))
+	h.must("supernodes", h.r.syncSupernodes(h.ctx))
+
+	h.chain.SetSupernode(h.chain.Supernode(testSupernode, testValidator, "127.0.0.1:4444", "4445", "SUPERNODE_STATE_STOPPED"))
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- h.r.syncSupernodes(h.ctx) }()
+	}
+	for i := 0; i < 2; i++ {
+		h.must("supernodes", <-errs)
+	}
+	kind := db.SupernodeEventStateChanged
+	events, _, err := db.ListSupernodeEvents(h.ctx, h.pool, db.SupernodeEventsFilter{EventType: &kind})
+	if err != nil || len(events) != 1 {
+		t.Errorf("state_changed events = %+v, %v, want one", events, err)
+	}
+}
+
+// TestHarnessBlockIndexer verifies the block indexer stores every lifecycle tx,
+// retries a failed range and leaves the enricher nothing to do
+func TestHarnessBlockIndexer(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.ActionTxIndexer = config.TxIndexerBlocks
+		cfg.BlockIndexerRange = 2
+	})
+	errChain := errors.New("chain unavailable")
+	first := h.chain.RegisterAction(testCreator, "ACTION_TYPE_CASCADE", "10000ulume")
+	h.chain.AdvanceBlocks(3)
+	h.cycle()
+	if got := h.txTypes(first); len(got) != 1 || got[0] != "register" {
+		t.Fatalf("action %d txs = %v", first, got)
+	}
+	if cp := h.checkpoint(db.CheckpointBlockIndexer); cp.LastHeight != h.chain.Height() {
+		t.Errorf("block indexer checkpoint = %d, want %d", cp.LastHeight, h.chain.Height())
+	}
+
+	// A finalize tx of an enriched action is only found by the block indexer
+	h.chain.ApproveAction(first)
+	h.chain.FinalizeAction(first, testSupernode)
+	h.chain.AdvanceBlocks(2)
+	before := h.checkpoint(db.CheckpointBlockIndexer).LastHeight
+	h.chain.Fail("GetActionTransactionsInRange", 1, errChain)
+	if err := h.r.runBlockIndexer(h.ctx); !errors.Is(err, errChain) {
+		t.Fatalf("runBlockIndexer err = %v", err)
+	}
+	if got := h.checkpoint(db.CheckpointBlockIndexer).LastHeight; got != before {
+		t.Errorf("checkpoint moved to %d after a failed range, want %d", got, before)
+	}
+	h.cycle()
+	if got := h.txTypes(first); len(got) != 3 {
+		t.Errorf("action %d txs = %v, want register, approve and finalize", first, got)
+	}
+	if got := h.action(first).State; got != "ACTION_STATE_DONE" {
+		t.Errorf("action %d state = %s", first, got)
+	}
+	if calls := h.chain.Calls("GetActionTransactions"); calls != 0 {
+		t.Errorf("enricher fetched %d actions the indexer covers", calls)
+	}
+}
+
+// TestHarnessProbe verifies probes of a local supernode record availability and
+// report transitions when a port goes down
+func TestHarnessProbe(t *testing.T) {
+	h := newHarness(t, nil)
+	api, p2p := listen(t), listen(t)
+	status := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version":"2.3.0","uptime_seconds":"60","rank":4,"network":{"peers_count":7}}`))
+	}))
+	t.Cleanup(status.Close)
+	_, port, _ := net.SplitHostPort(status.Listener.Addr().String())
+	prevPort := statusPort
+	statusPort = port
+	t.Cleanup(func() { statusPort = prevPort })
+
+	h.chain.AddValidator(testValidator, "val-one")
+	h.chain.SetSupernode(h.chain.Supernode(testSupernode, testValidator, api.Addr().String(), portOf(p2p), "SUPERNODE_STATE_ACTIVE"))
+	h.cycle()
+	h.must("probe", h.r.probeSupernodes(h.ctx))
+
+	sn, err := db.GetSupernodeByID(h.ctx, h.pool, testSupernode)
+	if err != nil {
+		t.Fatalf("supernode: %v", err)
+	}
+	if !sn.IsStatusAPIAvailable || sn.ActualVersion != "2.3.0" || sn.PeersCount == nil || *sn.PeersCount != 7 {
+		t.Errorf("probed supernode = %+v", sn)
+	}
+
+	p2p.Close()
+	h.must("probe", h.r.probeSupernodes(h.ctx))
+	kind := db.SupernodeEventPortsDown
+	events, _, err := db.ListSupernodeEvents(h.ctx, h.pool, db.SupernodeEventsFilter{EventType: &kind})
+	if err != nil || len(events) != 1 || events[0].SupernodeAccount != testSupernode {
+		t.Errorf("ports_down events = %+v, %v", events, err)
+	}
+}
+
+// TestHarnessProbeRollupCatchUp verifies a rollup pass starts from the newest 5m bucket,
+// so samples from before a long gap are rolled up and a sample stored after its bucket
+// was rolled up is folded in by the next pass
+func TestHarnessProbeRollupCatchUp(t *testing.T) {
+	h := newHarness(t, nil)
+	now := time.Now().UTC()
+	old := now.Add(-6 * time.Hour).Truncate(5 * time.Minute)
+	recent := now.Add(-15 * time.Minute).Truncate(5 * time.Minute)
+	sample := func(at time.Time) {
+		t.Helper()
+		if err := db.InsertProbeSample(h.ctx, h.pool, db.ProbeSample{SupernodeAccount: testSupernode, SampledAt: at, FullyAvailable: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count := func(bucket time.Time) int64 {
+		t.Helper()
+		var n int64
+		err := h.pool.QueryRow(h.ctx, `SELECT COALESCE(SUM("sampleCount"), 0)::BIGINT FROM supernode_probe_rollups
+			WHERE "resolution" = '5m' AND "bucketStart" = $1`, bucket).Scan(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	sample(old.Add(time.Minute))
+	sample(recent.Add(time.Minute))
+	h.must("rollup", h.r.rollupProbeSamples(h.ctx))
+	if count(old) != 1 || count(recent) != 1 {
+		t.Fatalf("rolled up %d samples at -6h and %d at -15m, want 1 and 1", count(old), count(recent))
+	}
+
+	sample(recent.Add(2 * time.Minute))
+	h.must("rollup", h.r.rollupProbeSamples(h.ctx))
+	if got := count(recent); got != 2 {
+		t.Errorf("latest bucket has %d samples after a late sample, want 2", got)
+	}
+}
+
+// listen opens a local TCP listener accepting and dropping connections until the test ends.
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return ln
+}
+
+func portOf(ln net.Listener) string {
+	return strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+}
@@ -0,0 +1,249 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	lclient "lumescope/internal/lumera"
+)
+
+// liveQueries are the websocket subscriptions of the live loop: transactions carrying
+// each action lifecycle event, and every block's own events, which report action
+// changes made outside transactions (such as expirations) and the latest height.
+var liveQueries = []string{
+	"tm.event='Tx' AND action_registered.action_id EXISTS",
+	"tm.event='Tx' AND action_finalized.action_id EXISTS",
+	"tm.event='Tx' AND action_approved.action_id EXISTS",
+	"tm.event='NewBlockEvents'",
+}
+
+// liveCatchUpMaxBlocks bounds the blocks replayed after a reconnect; larger gaps are
+// left to the polling loops.
+const liveCatchUpMaxBlocks = 100
+
+// liveTxAttempts is how often a transaction announced by the websocket is looked up
+// through the chain client, whose node may index it a moment after the event.
+const liveTxAttempts = 3
+
+// loopLive keeps a websocket subscription to the CometBFT RPC node so new actions and
+// lifecycle transactions are stored within a block. While it is connected the
+// incremental actions sync only runs every ActionsSyncIntervalLive as a safety net;
+// when it drops, polling resumes at ActionsSyncInterval until it reconnects.
+func (r *Runner) loopLive(ctx context.Context) {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := r.runLive(ctx)
+		r.live.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("live subscription: %v; polling until reconnected", err)
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, time.Minute)
+	}
+}
+
+// runLive subscribes, replays the blocks missed since the last connection and then
+// handles events until the subscription ends.
+func (r *Runner) runLive(ctx context.Context) error {
+	sub, err := r.RPC.Subscribe(ctx, liveQueries)
+	if err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+	defer sub.Close()
+	r.live.Store(true)
+	log.Printf("live subscription: connected to %s", r.RPC.BaseURL)
+
+	if err := r.catchUpLive(ctx); err != nil {
+		log.Printf("live subscription: catch-up error: %v", err)
+	}
+	for ev := range sub.Events {
+		r.handleLiveEvent(ctx, ev)
+	}
+	if err := sub.Err(); err != nil {
+		return err
+	}
+	return errors.New("subscription closed")
+}
+
+// catchUpLive replays the action transactions and block events of the heights after
+// the last one seen live, up to liveCatchUpMaxBlocks blocks. On first connect there is
+// nothing to replay: the polling loops cover everything before it.
+func (r *Runner) catchUpLive(ctx context.Context) error {
+	latest, err := r.RPC.LatestHeight(ctx)
+	if err != nil {
+		return err
+	}
+	from := r.liveHeight + 1
+	if r.liveHeight == 0 || from > latest {
+		r.liveHeight = max(r.liveHeight, latest)
+		return nil
+	}
+	if latest-from+1 > liveCatchUpMaxBlocks {
+		log.Printf("live subscription: missed heights %d-%d, leaving them to polling", from, latest)
+		r.liveHeight = latest
+		return nil
+	}
+
+	var hashes []string
+	seen := make(map[string]bool)
+	for _, eventType := range []string{"action_registered", "action_finalized", "action_approved"} {
+		query := fmt.Sprintf("%s.action_id EXISTS AND tx.height>=%d AND tx.height<=%d", eventType, from, latest)
+		txs, err := r.RPC.TxSearch(ctx, query, 0)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			if !seen[tx.Hash] {
+				seen[tx.Hash] = true
+				hashes = append(hashes, tx.Hash)
+			}
+		}
+	}
+	for _, h := range hashes {
+		r.storeLiveTx(ctx, h)
+	}
+
+	for h := from; h <= latest; h++ {
+		res, err := r.RPC.BlockResults(ctx, h)
+		if err != nil {
+			return err
+		}
+		for _, id := range blockEventActionIDs(res.BlockEvents) {
+			r.refreshAction(ctx, id)
+		}
+		r.liveHeight = h
+	}
+	log.Printf("live subscription: replayed heights %d-%d (%d action txs)", from, latest, len(hashes))
+	return nil
+}
+
+// handleLiveEvent stores the action transactions of a Tx event, or refreshes the
+// actions named by a block's events.
+func (r *Runner) handleLiveEvent(ctx context.Context, ev lclient.RPCEvent) {
+	if ev.Height > r.liveHeight {
+		r.liveHeight = ev.Height
+	}
+	if hashes := ev.Events["tx.hash"]; len(hashes) > 0 {
+		for _, h := range hashes {
+			r.storeLiveTx(ctx, h)
+		}
+		return
+	}
+	for _, id := range liveEventActionIDs(ev.Events) {
+		r.refreshAction(ctx, id)
+	}
+}
+
+// storeLiveTx stores the action transactions of a transaction, refreshing their
+// actions first so a new action exists before its transactions.
+func (r *Runner) storeLiveTx(ctx context.Context, hash string) {
+	var (
+		txs []*db.ActionTransaction
+		err error
+	)
+	for attempt := 1; attempt <= liveTxAttempts; attempt++ {
+		if txs, err = r.Lumera.GetTxActionTransactions(ctx, hash); !errors.Is(err, db.ErrNotFound) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+	if err != nil {
+		log.Printf("live subscription: fetch tx %s: %v", hash, err)
+		return
+	}
+
+	refreshed := make(map[uint64]bool)
+	for _, tx := range txs {
+		if !refreshed[tx.ActionID] {
+			refreshed[tx.ActionID] = true
+			r.refreshAction(ctx, tx.ActionID)
+		}
+	}
+	for _, tx := range txs {
+		if err := r.storeActionTransaction(ctx, tx); err != nil {
+			log.Printf("live subscription: error persisting tx %s for action %d type %s: %v", hash, tx.ActionID, tx.TxType, err)
+		}
+	}
+}
+
+// refreshAction fetches an action from the chain and stores it. It does not take
+// actionsMu: a full sync holds it for hours, and blocking here would stall the
+// subscription reader until the server drops it. A single-row upsert is atomic, and a
+// sync page read before this refresh can't write its older state back: the upsert
+// never moves an action backwards in its lifecycle, nor records an event for it.
+func (r *Runner) refreshAction(ctx context.Context, id uint64) {
+	a, err := r.Lumera.GetAction(ctx, strconv.FormatUint(id, 10))
+	if err != nil {
+		if !errors.Is(err, lclient.ErrNotFound) {
+			log.Printf("live subscription: refresh action %d: %v", id, err)
+		}
+		return
+	}
+	r.upsertChainAction(ctx, *a)
+}
+
+// liveEventActionIDs returns the action IDs in the "action_*.action_id" entries of a
+// subscription event map, in ascending order.
+func liveEventActionIDs(events map[string][]string) []uint64 {
+	seen := make(map[uint64]bool)
+	for key, values := range events {
+		typ, attr, ok := strings.Cut(key, ".")
+		if !ok || attr != "action_id" || !strings.HasPrefix(typ, "action_") {
+			continue
+		}
+		for _, v := range values {
+			if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+				seen[id] = true
+			}
+		}
+	}
+	return sortedIDs(seen)
+}
+
+// blockEventActionIDs returns the action IDs named by "action_*" block events.
+func blockEventActionIDs(events []lclient.Event) []uint64 {
+	seen := make(map[uint64]bool)
+	for _, e := range events {
+		if !strings.HasPrefix(e.Type, "action_") {
+			continue
+		}
+		for _, a := range e.Attributes {
+			if a.Key != "action_id" {
+				continue
+			}
+			if id, err := strconv.ParseUint(a.Value, 10, 64); err == nil {
+				seen[id] = true
+			}
+		}
+	}
+	return sortedIDs(seen)
+}
+
+func sortedIDs(set map[uint64]bool) []uint64 {
+	ids := make([]uint64, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
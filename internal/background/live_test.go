@@ -0,0 +1,32 @@
+package background
+
+import (
+	"fmt"
+	"testing"
+
+	lclient "lumescope/internal/lumera"
+)
+
+// TestLiveEventActionIDs verifies only action_* action_id entries are collected, once each
+func TestLiveEventActionIDs(t *testing.T) {
+	events := map[string][]string{
+		"tm.event":                      {"NewBlockEvents"},
+		"action_expired.action_id":      {"9", "3"},
+		"action_registered.action_id":   {"3"},
+		"action_registered.creator":     {"lumera1creator"},
+		"supernode_penalized.action_id": {"11"},
+		"action_failed.action_id":       {"bogus"},
+	}
+	if got := fmt.Sprint(liveEventActionIDs(events)); got != "[3 9]" {
+		t.Errorf("liveEventActionIDs = %s, want [3 9]", got)
+	}
+
+	blockEvents := []lclient.Event{
+		{Type: "action_expired", Attributes: []lclient.Attribute{{Key: "action_id", Value: "12"}, {Key: "msg_index", Value: "0"}}},
+		{Type: "transfer", Attributes: []lclient.Attribute{{Key: "action_id", Value: "13"}}},
+		{Type: "action_expired", Attributes: []lclient.Attribute{{Key: "action_id", Value: "4"}}},
+	}
+	if got := fmt.Sprint(blockEventActionIDs(blockEvents)); got != "[4 12]" {
+		t.Errorf("blockEventActionIDs = %s, want [4 12]", got)
+	}
+}
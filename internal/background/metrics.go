@@ -0,0 +1,66 @@
+package background
+
+import (
+	"time"
+
+	"lumescope/internal/metrics"
+)
+
+// loopBuckets cover background passes from sub-second syncs to hour-long full reconciliations.
+var loopBuckets = []float64{.1, .5, 1, 5, 15, 30, 60, 300, 900, 1800, 3600}
+
+var (
+	loopDuration = metrics.NewHistogramVec(
+		"lumescope_loop_duration_seconds",
+		"Duration of background loop passes.",
+		loopBuckets, "network", "loop")
+	loopErrorsTotal = metrics.NewCounterVec(
+		"lumescope_loop_errors_total",
+		"Background loop passes that returned an error.",
+		"network", "loop")
+	loopLastSuccess = metrics.NewGaugeVec(
+		"lumescope_loop_last_success_timestamp_seconds",
+		"Unix time of the last successful pass of each background loop.",
+		"network", "loop")
+	enricherBacklog = metrics.NewGaugeVec(
+		"lumescope_enricher_backlog",
+		"Actions still waiting for transaction enrichment at the start of the last enricher run.",
+		"network")
+	blockIndexerHeight = metrics.NewGaugeVec(
+		"lumescope_block_indexer_height",
+		"Last block height searched for action transactions by the block indexer.",
+		"network")
+	supernodesProbed = metrics.NewGaugeVec(
+		"lumescope_supernodes_probed",
+		"Supernodes with a valid address probed in the last pass.",
+		"network")
+	supernodesAvailable = metrics.NewGaugeVec(
+		"lumescope_supernodes_available",
+		"Supernodes whose status API responded in the last probe pass.",
+		"network")
+)
+
+// observeLoop records the outcome of one background pass in metrics and in the
+// Runner's last-success table used by the readiness probe.
+func (r *Runner) observeLoop(loop string, start time.Time, err error) {
+	loopDuration.Observe(time.Since(start).Seconds(), r.Cfg.Network, loop)
+	if err != nil {
+		loopErrorsTotal.Inc(r.Cfg.Network, loop)
+		return
+	}
+	now := time.Now()
+	loopLastSuccess.Set(float64(now.Unix()), r.Cfg.Network, loop)
+
+	r.statusMu.Lock()
+	r.lastSuccess[loop] = now
+	r.statusMu.Unlock()
+}
+
+// LastSuccess returns when the named loop last completed without error.
+// The second return value is false if it has not succeeded since startup.
+func (r *Runner) LastSuccess(loop string) (time.Time, bool) {
+	r.statusMu.RLock()
+	defer r.statusMu.RUnlock()
+	t, ok := r.lastSuccess[loop]
+	return t, ok
+}
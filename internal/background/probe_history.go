@@ -0,0 +1,73 @@
+package background
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// probeSample converts a probe result into a history sample. Metrics are only
+// recorded when the status API answered.
+func probeSample(account string, at time.Time, status statusSummary, port1Open, p2pOpen bool) db.ProbeSample {
+	s := db.ProbeSample{
+		SupernodeAccount:   account,
+		SampledAt:          at,
+		StatusAPIAvailable: status.Available,
+		Port1Open:          port1Open,
+		P2POpen:            p2pOpen,
+		FullyAvailable:     port1Open && p2pOpen && status.Available,
+	}
+	if !status.Available {
+		return s
+	}
+	s.CPUUsagePercent = ptrF64(status.CPUUsagePercent)
+	s.MemoryUsagePercent = ptrF64(status.MemoryUsagePercent)
+	s.MemoryUsedGb = ptrF64(status.MemoryUsedGb)
+	s.StorageUsagePercent = ptrF64(status.StorageUsagePercent)
+	s.StorageUsedBytes = ptrI64(status.StorageUsedBytes)
+	s.PeersCount = ptrI32(status.PeersCount)
+	s.P2PDbSizeMb = ptrF64(status.P2PDbSizeMb)
+	s.P2PRecords = ptrI64(status.P2PRecords)
+	s.UptimeSeconds = ptrI64(status.UptimeSeconds)
+	return s
+}
+
+// loopProbeRollups downsamples probe samples into 5m and 1h rollups, refreshes the
+// materialized availability ratios and prunes each tier past its retention.
+func (r *Runner) loopProbeRollups(ctx context.Context) {
+	t := time.NewTicker(r.Cfg.ProbeRollupInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		start := time.Now()
+		err := r.rollupProbeSamples(ctx)
+		r.observeLoop(LoopProbeRollup, start, err)
+		if err != nil {
+			log.Printf("probe rollup error: %v", err)
+		}
+	}
+}
+
+// rollupProbeSamples recomputes rollups from the newest 5m bucket on, so samples stored
+// after that bucket was rolled up and buckets missed while the loop was down are all
+// folded in. Without any rollup yet, every raw sample is rolled up.
+func (r *Runner) rollupProbeSamples(ctx context.Context) error {
+	now := time.Now().UTC()
+	since, err := db.LatestProbeRollup(ctx, r.DB, db.ProbeSource5m)
+	if err != nil {
+		return err
+	}
+	if err := db.RollupProbeSamples(ctx, r.DB, since); err != nil {
+		return err
+	}
+	if err := db.RefreshSupernodeAvailability(ctx, r.DB, now); err != nil {
+		return err
+	}
+	return db.PruneProbeSamples(ctx, r.DB, r.Cfg.ProbeSamplesRetention, r.Cfg.ProbeRollup5mRetention, r.Cfg.ProbeRollup1hRetention)
+}
@@ -0,0 +1,33 @@
+package background
+
+import (
+	"testing"
+	"time"
+)
+
+// TestProbeSample verifies per-port outcomes and that metrics are only kept when the status API answered
+func TestProbeSample(t *testing.T) {
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	up := statusSummary{Available: true, CPUUsagePercent: 12.5, PeersCount: 7}
+	s := probeSample("lumera1sn", at, up, true, true)
+	if !s.FullyAvailable || !s.StatusAPIAvailable || !s.Port1Open || !s.P2POpen {
+		t.Errorf("all checks up: got %+v", s)
+	}
+	if s.CPUUsagePercent == nil || *s.CPUUsagePercent != 12.5 || s.PeersCount == nil || *s.PeersCount != 7 {
+		t.Errorf("metrics not recorded: %+v", s)
+	}
+
+	s = probeSample("lumera1sn", at, up, true, false)
+	if s.FullyAvailable || !s.Port1Open || s.P2POpen {
+		t.Errorf("p2p down: got %+v", s)
+	}
+
+	s = probeSample("lumera1sn", at, statusSummary{}, true, true)
+	if s.FullyAvailable || s.StatusAPIAvailable {
+		t.Errorf("status API down: got %+v", s)
+	}
+	if s.CPUUsagePercent != nil || s.UptimeSeconds != nil {
+		t.Errorf("metrics recorded for unavailable status API: %+v", s)
+	}
+}
@@ -3,7 +3,10 @@ package background
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"mime"
 	"net"
 	"net/http"
@@ -11,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"lumescope/internal/config"
@@ -23,34 +27,88 @@ import (
 type Runner struct {
 	Cfg    config.Config
 	DB     *db.Pool
-	Lumera *lclient.Client
+	Lumera lclient.Chain
+	RPC    *lclient.RPCClient // CometBFT RPC for live events; nil when not configured
 
 	validatorMonikers map[string]string
 	syncRunning       bool
 	syncMu            sync.Mutex
+	actionsMu         sync.Mutex  // serializes incremental and full actions syncs
+	supernodesMu      sync.Mutex  // serializes supernode syncs, which diff against stored snapshots
+	probesMu          sync.Mutex  // serializes probe passes, which diff against stored snapshots
+	flowBackfillAfter string      // last tx hash visited by backfillTxFlows
+	live              atomic.Bool // whether the live subscription is connected
+	liveHeight        int64       // last height seen by the live subscription
+
+	// eventsFromHeight is the chain height the first actions backfill reached, kept in
+	// the actions checkpoint: action transactions at or below it are history and record
+	// no tx_attached event. It is math.MaxInt64 until the backfill completed or its
+	// height was loaded, and no action events are recorded meanwhile.
+	eventsFromHeight atomic.Int64
+
+	statusMu    sync.RWMutex
+	lastSuccess map[string]time.Time // loop name -> last successful pass
 }
 
-func NewRunner(cfg config.Config, pool *db.Pool, lumera *lclient.Client) *Runner {
-	return &Runner{Cfg: cfg, DB: pool, Lumera: lumera}
+// Loop names, used as the "loop" metrics label and for readiness checks.
+const (
+	LoopValidators  = "validators"
+	LoopSupernodes  = "supernodes"
+	LoopActions     = "actions"
+	LoopActionsFull = "actions_full"
+	LoopProbes      = "probes"
+	LoopTxEnricher  = "tx_enricher"
+	LoopProbeRollup = "probe_rollup"
+	LoopTxBlocks    = "tx_blocks"
+)
+
+func NewRunner(cfg config.Config, pool *db.Pool, lumera lclient.Chain) *Runner {
+	r := &Runner{Cfg: cfg, DB: pool, Lumera: lumera, lastSuccess: make(map[string]time.Time)}
+	r.eventsFromHeight.Store(math.MaxInt64)
+	if cfg.LumeraRPCBase != "" {
+		r.RPC = lclient.NewRPCClient(cfg.LumeraRPCBase, cfg.HTTPTimeout)
+	}
+	return r
 }
 
 func (r *Runner) Start(ctx context.Context) {
+	// Load the backfill height before any loop can record action events
+	if cp, err := db.GetSyncCheckpoint(ctx, r.DB, db.CheckpointActions); err == nil {
+		r.loadEventsFromHeight(&cp)
+	} else if !errors.Is(err, db.ErrNotFound) {
+		log.Printf("actions checkpoint: %v", err)
+	}
+
 	// Run initial validator sync to populate monikers before starting other loops
-	if err := r.syncValidators(ctx); err != nil {
+	start := time.Now()
+	err := r.syncValidators(ctx)
+	r.observeLoop(LoopValidators, start, err)
+	if err != nil {
 		log.Printf("initial validators sync error: %v", err)
 	}
 	go r.loopValidators(ctx)
 	go r.loopSupernodes(ctx)
 	go r.loopActions(ctx)
+	go r.loopActionsReconcile(ctx)
+	if r.RPC != nil {
+		go r.loopLive(ctx)
+	}
 	go r.loopProbes(ctx)
 	go r.loopActionTxEnricher(ctx)
+	if r.Cfg.ActionTxIndexer == config.TxIndexerBlocks {
+		go r.loopBlockIndexer(ctx)
+	}
+	go r.loopProbeRollups(ctx)
 }
 
 func (r *Runner) loopValidators(ctx context.Context) {
 	t := time.NewTicker(r.Cfg.ValidatorsSyncInterval)
 	defer t.Stop()
 	for {
-		if err := r.syncValidators(ctx); err != nil {
+		start := time.Now()
+		err := r.syncValidators(ctx)
+		r.observeLoop(LoopValidators, start, err)
+		if err != nil {
 			log.Printf("validators sync error: %v", err)
 		}
 		select {
@@ -65,7 +123,10 @@ func (r *Runner) loopSupernodes(ctx context.Context) {
 	t := time.NewTicker(r.Cfg.SupernodesSyncInterval)
 	defer t.Stop()
 	for {
-		if err := r.syncSupernodes(ctx); err != nil {
+		start := time.Now()
+		err := r.syncSupernodes(ctx)
+		r.observeLoop(LoopSupernodes, start, err)
+		if err != nil {
 			log.Printf("supernodes sync error: %v", err)
 		}
 		select {
@@ -79,9 +140,17 @@ func (r *Runner) loopSupernodes(ctx context.Context) {
 func (r *Runner) loopActions(ctx context.Context) {
 	t := time.NewTicker(r.Cfg.ActionsSyncInterval)
 	defer t.Stop()
+	var last time.Time
 	for {
-		if err := r.syncActions(ctx); err != nil {
-			log.Printf("actions sync error: %v", err)
+		// While the live subscription delivers new actions, polling is only a safety net
+		if !r.live.Load() || time.Since(last) >= r.Cfg.ActionsSyncIntervalLive {
+			start := time.Now()
+			last = start
+			err := r.syncActions(ctx)
+			r.observeLoop(LoopActions, start, err)
+			if err != nil {
+				log.Printf("actions sync error: %v", err)
+			}
 		}
 		select {
 		case <-ctx.Done():
@@ -91,11 +160,37 @@ func (r *Runner) loopActions(ctx context.Context) {
 	}
 }
 
+// loopActionsReconcile periodically runs a full list_actions pass to reconcile
+// anything the incremental sync may have missed. The first pass runs after one
+// full interval; on a fresh database the incremental loop bootstraps instead. Each
+// pass also prunes the event logs.
+func (r *Runner) loopActionsReconcile(ctx context.Context) {
+	t := time.NewTicker(r.Cfg.ActionsFullSyncInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		start := time.Now()
+		err := r.syncActionsFull(ctx)
+		r.observeLoop(LoopActionsFull, start, err)
+		if err != nil {
+			log.Printf("actions full sync error: %v", err)
+		}
+		r.pruneEvents(ctx)
+	}
+}
+
 func (r *Runner) loopProbes(ctx context.Context) {
 	t := time.NewTicker(r.Cfg.ProbeInterval)
 	defer t.Stop()
 	for {
-		if err := r.probeSupernodes(ctx); err != nil {
+		start := time.Now()
+		err := r.probeSupernodes(ctx)
+		r.observeLoop(LoopProbes, start, err)
+		if err != nil {
 			log.Printf("probe error: %v", err)
 		}
 		select {
@@ -115,7 +210,10 @@ func (r *Runner) loopActionTxEnricher(ctx context.Context) {
 	t := time.NewTicker(r.Cfg.ActionTxEnricherInterval)
 	defer t.Stop()
 	for {
-		if err := r.runActionTxEnricher(ctx); err != nil {
+		start := time.Now()
+		err := r.runActionTxEnricher(ctx)
+		r.observeLoop(LoopTxEnricher, start, err)
+		if err != nil {
 			log.Printf("action tx enricher error: %v", err)
 		}
 
@@ -133,6 +231,30 @@ func (r *Runner) loopActionTxEnricher(ctx context.Context) {
 	}
 }
 
+// loopBlockIndexer runs the block indexer on a configurable interval. Each pass
+// catches up from the checkpoint to the latest block.
+func (r *Runner) loopBlockIndexer(ctx context.Context) {
+	// Wait a bit before starting to let the initial actions sync record heights
+	time.Sleep(30 * time.Second)
+
+	t := time.NewTicker(r.Cfg.BlockIndexerInterval)
+	defer t.Stop()
+	for {
+		start := time.Now()
+		err := r.runBlockIndexer(ctx)
+		r.observeLoop(LoopTxBlocks, start, err)
+		if err != nil {
+			log.Printf("block indexer error: %v", err)
+		}
+		drainTicker(t)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
 // drainTicker removes any pending ticks from the ticker channel without blocking.
 func drainTicker(t *time.Ticker) {
 	for {
@@ -149,13 +271,33 @@ func drainTicker(t *time.Ticker) {
 // runActionTxEnricher iterates through unenriched actions and enriches them with transaction data.
 // It uses GetUnenrichedActions which only returns actions without a 'register' transaction,
 // making the enricher much more efficient by skipping already-processed actions at the DB level.
+// With the block indexer enabled it only fills gaps: actions registered above the
+// indexer's checkpoint are left for the indexer.
 func (r *Runner) runActionTxEnricher(ctx context.Context) error {
 	const batchSize = 50
 	// Initialize minID based on ActionEnricherStartID config.
 	minID := r.Cfg.ActionEnricherStartID
 	var totalProcessed, totalEnriched, totalNotFound int
 
-	log.Printf("action tx enricher: starting run (minID=%d)", minID)
+	var maxHeight int64
+	if r.Cfg.ActionTxIndexer == config.TxIndexerBlocks {
+		cp, err := db.GetSyncCheckpoint(ctx, r.DB, db.CheckpointBlockIndexer)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			log.Printf("action tx enricher: block indexer has not run yet, skipping")
+			return nil
+		case err != nil:
+			return err
+		}
+		maxHeight = cp.LastHeight
+	}
+
+	log.Printf("action tx enricher: starting run (minID=%d, maxHeight=%d)", minID, maxHeight)
+	if backlog, err := db.CountUnenrichedActions(ctx, r.DB, minID, maxHeight); err != nil {
+		log.Printf("action tx enricher: CountUnenrichedActions error: %v", err)
+	} else {
+		enricherBacklog.Set(float64(backlog), r.Cfg.Network)
+	}
 	startTime := time.Now()
 
 	batchNum := 0
@@ -164,7 +306,7 @@ func (r *Runner) runActionTxEnricher(ctx context.Context) error {
 		log.Printf("action tx enricher: fetching batch %d with minID=%d, batchSize=%d", batchNum, minID, batchSize)
 
 		// Fetch only unenriched actions (no 'register' transaction yet)
-		actions, err := db.GetUnenrichedActions(ctx, r.DB, minID, batchSize)
+		actions, err := db.GetUnenrichedActions(ctx, r.DB, minID, maxHeight, batchSize)
 		if err != nil {
 			log.Printf("action tx enricher: GetUnenrichedActions error: %v", err)
 			return err
@@ -209,7 +351,7 @@ func (r *Runner) runActionTxEnricher(ctx context.Context) error {
 					Height:    0,
 					BlockTime: action.CreatedAt,
 				}
-				if err := db.UpsertActionTransaction(ctx, r.DB, placeholder); err != nil {
+				if _, err := db.UpsertActionTransaction(ctx, r.DB, placeholder); err != nil {
 					log.Printf("action tx enricher: error persisting placeholder for action %d: %v", action.ActionID, err)
 				} else {
 					log.Printf("action tx enricher: persisted placeholder for action %d", action.ActionID)
@@ -219,13 +361,13 @@ func (r *Runner) runActionTxEnricher(ctx context.Context) error {
 
 			// Persist transaction records
 			for _, tx := range txs {
-				if err := db.UpsertActionTransaction(ctx, r.DB, tx); err != nil {
+				if err := r.storeActionTransaction(ctx, tx); err != nil {
 					log.Printf("action tx enricher: error persisting tx for action %d type %s: %v",
 						action.ActionID, tx.TxType, err)
-				} else {
-					log.Printf("action tx enricher: persisted tx for action %d type %s", action.ActionID, tx.TxType)
-					totalEnriched++
+					continue
 				}
+				log.Printf("action tx enricher: persisted tx for action %d type %s", action.ActionID, tx.TxType)
+				totalEnriched++
 			}
 		}
 
@@ -240,6 +382,8 @@ func (r *Runner) runActionTxEnricher(ctx context.Context) error {
 		time.Sleep(100 * time.Millisecond)
 	}
 
+	r.backfillTxFlows(ctx)
+
 	elapsed := time.Since(startTime)
 	log.Printf("action tx enricher: completed run - processed %d unenriched actions, enriched %d txs, %d not found on chain, in %v",
 		totalProcessed, totalEnriched, totalNotFound, elapsed)
@@ -247,6 +391,115 @@ func (r *Runner) runActionTxEnricher(ctx context.Context) error {
 	return nil
 }
 
+// storeActionTransaction persists an action transaction and the flows of its tx, and
+// records a tx_attached event when the row is new or changed, unless the tx predates
+// the first actions backfill.
+func (r *Runner) storeActionTransaction(ctx context.Context, tx *db.ActionTransaction) error {
+	store := db.UpsertActionTransaction
+	if tx.Height > r.eventsFromHeight.Load() {
+		store = db.UpsertActionTransactionWithEvent
+	}
+	if _, err := store(ctx, r.DB, tx); err != nil {
+		return err
+	}
+	if err := db.ReplaceTxFlows(ctx, r.DB, tx.TxHash, tx.Flows); err != nil {
+		log.Printf("error persisting flows of tx %s: %v", tx.TxHash, err)
+	}
+	return nil
+}
+
+// runBlockIndexer follows the chain from the block indexer checkpoint to the latest
+// block, BlockIndexerRange heights at a time, storing the lifecycle transactions of
+// every action found in each range. The checkpoint advances after each range, so a
+// failed range is retried on the next pass. Without a checkpoint it starts at
+// BlockIndexerStartHeight, or at the lowest indexed action height.
+func (r *Runner) runBlockIndexer(ctx context.Context) error {
+	latest, err := r.Lumera.GetLatestHeight(ctx)
+	if err != nil {
+		return fmt.Errorf("latest height: %w", err)
+	}
+
+	var from int64
+	cp, err := db.GetSyncCheckpoint(ctx, r.DB, db.CheckpointBlockIndexer)
+	switch {
+	case err == nil:
+		from = cp.LastHeight + 1
+	case !errors.Is(err, db.ErrNotFound):
+		return err
+	case r.Cfg.BlockIndexerStartHeight > 0:
+		from = r.Cfg.BlockIndexerStartHeight
+	default:
+		if from, err = db.MinActionBlockHeight(ctx, r.DB); err != nil {
+			return err
+		}
+		if from == 0 {
+			log.Printf("block indexer: no action heights indexed yet, waiting for the actions sync")
+			return nil
+		}
+	}
+
+	rangeSize := int64(max(r.Cfg.BlockIndexerRange, 1))
+	var ranges, stored int
+	startTime := time.Now()
+	for from <= latest {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		to := min(from+rangeSize-1, latest)
+		txs, err := r.Lumera.GetActionTransactionsInRange(ctx, from, to)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			if err := r.storeActionTransaction(ctx, tx); err != nil {
+				return fmt.Errorf("store tx %s for action %d: %w", tx.TxHash, tx.ActionID, err)
+			}
+		}
+		if err := db.SaveSyncCheckpoint(ctx, r.DB, db.SyncCheckpoint{Name: db.CheckpointBlockIndexer, LastHeight: to}); err != nil {
+			return err
+		}
+		blockIndexerHeight.Set(float64(to), r.Cfg.Network)
+		ranges++
+		stored += len(txs)
+		from = to + 1
+	}
+	if ranges > 0 {
+		log.Printf("block indexer: indexed %d ranges up to height %d, stored %d action txs, in %v",
+			ranges, latest, stored, time.Since(startTime))
+	}
+	return nil
+}
+
+// backfillTxFlows records the flows of action transactions indexed before flows were
+// stored, a batch per enricher run, by caching them through the chain tx lookup. Hashes
+// are visited in order, wrapping around, so txs the chain can't return don't block the rest.
+func (r *Runner) backfillTxFlows(ctx context.Context) {
+	const batchSize = 50
+	hashes, err := db.GetTxHashesWithoutFlows(ctx, r.DB, r.flowBackfillAfter, batchSize)
+	if err != nil {
+		log.Printf("action tx enricher: GetTxHashesWithoutFlows error: %v", err)
+		return
+	}
+	if len(hashes) < batchSize {
+		r.flowBackfillAfter = ""
+	} else {
+		r.flowBackfillAfter = hashes[len(hashes)-1]
+	}
+	for _, hash := range hashes {
+		tx, err := r.Lumera.GetTx(ctx, hash)
+		if err != nil {
+			log.Printf("action tx enricher: error fetching tx %s for flows: %v", hash, err)
+			continue
+		}
+		if err := db.UpsertChainTx(ctx, r.DB, tx); err != nil {
+			log.Printf("action tx enricher: error caching tx %s: %v", hash, err)
+		}
+	}
+	if len(hashes) > 0 {
+		log.Printf("action tx enricher: backfilled flows of %d txs", len(hashes))
+	}
+}
+
 // syncValidators returns a map of valoper -> moniker to be used in supernode join.
 func (r *Runner) syncValidators(ctx context.Context) error {
 	var (
@@ -281,7 +534,16 @@ func (r *Runner) getMonikerFor(valoper string) string {
 	return r.validatorMonikers[valoper]
 }
 
+// syncSupernodes stores every supernode on chain and records the changes since the
+// stored snapshots. Passes are serialized, so a manual trigger overlapping a loop pass
+// doesn't record the same change twice.
 func (r *Runner) syncSupernodes(ctx context.Context) error {
+	r.supernodesMu.Lock()
+	defer r.supernodesMu.Unlock()
+	snapshots, err := db.ListSupernodeSnapshots(ctx, r.DB)
+	if err != nil {
+		return err
+	}
 	var next string
 	limit := 200
 	for {
@@ -310,6 +572,10 @@ func (r *Runner) syncSupernodes(ctx context.Context) error {
 			}
 			if err := db.UpsertSupernode(ctx, r.DB, rec); err != nil {
 				log.Printf("upsert supernode %s: %v", sn.SupernodeAccount, err)
+				continue
+			}
+			if prev, ok := snapshots[sn.SupernodeAccount]; ok {
+				r.recordSupernodeEvents(ctx, supernodeChainEvents(prev, sn, state, height, ip))
 			}
 		}
 		if n == "" {
@@ -320,72 +586,167 @@ func (r *Runner) syncSupernodes(ctx context.Context) error {
 	return nil
 }
 
+// syncActions performs an incremental actions sync driven by the persisted checkpoint.
+// New actions are discovered by walking action IDs forward from the last one seen, and
+// a bounded hot window of non-terminal actions is refreshed to pick up state changes.
+// If no checkpoint exists yet, a full reconciliation pass is run instead. While a
+// periodic full sync is running the pass is skipped and counts as a success: the full
+// sync already stores every action, and blocking on it for hours would fail readiness.
 func (r *Runner) syncActions(ctx context.Context) error {
+	if !r.actionsMu.TryLock() {
+		return nil
+	}
+	defer r.actionsMu.Unlock()
+
+	cp, err := db.GetSyncCheckpoint(ctx, r.DB, db.CheckpointActions)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			log.Printf("actions sync: no checkpoint yet, running full reconciliation")
+			return r.backfillActions(ctx)
+		}
+		return err
+	}
+	r.loadEventsFromHeight(&cp)
+
+	// Discover new actions. Action IDs are assigned sequentially on chain, so we
+	// probe forward until we hit a few consecutive misses.
+	const maxNewPerRun = 1000
+	const lookahead = 3
+	seen := make(map[uint64]bool)
+	nextID := cp.LastActionID + 1
+	misses := 0
+	for misses < lookahead && len(seen) < maxNewPerRun {
+		a, err := r.Lumera.GetAction(ctx, strconv.FormatUint(nextID, 10))
+		if err != nil {
+			if !errors.Is(err, lclient.ErrNotFound) {
+				return err
+			}
+			misses++
+			nextID++
+			continue
+		}
+		misses = 0
+		id, height, ok := r.upsertChainAction(ctx, *a)
+		if ok {
+			seen[id] = true
+			if id > cp.LastActionID {
+				cp.LastActionID = id
+			}
+			if height > cp.LastHeight {
+				cp.LastHeight = height
+			}
+		}
+		nextID++
+	}
+
+	// Refresh the hot window of actions that may still change state.
+	hot, err := db.ListHotActionIDs(ctx, r.DB, r.Cfg.ActionsHotWindow, r.Cfg.ActionsHotWindowDone)
+	if err != nil {
+		return err
+	}
+	refreshed := 0
+	for _, id := range hot {
+		if seen[id] {
+			continue
+		}
+		a, err := r.Lumera.GetAction(ctx, strconv.FormatUint(id, 10))
+		if err != nil {
+			if !errors.Is(err, lclient.ErrNotFound) {
+				log.Printf("actions sync: refresh action %d: %v", id, err)
+			}
+			continue
+		}
+		if _, _, ok := r.upsertChainAction(ctx, *a); ok {
+			refreshed++
+		}
+	}
+
+	cp.LastFullSyncAt = nil // preserve the stored value
+	if err := db.SaveSyncCheckpoint(ctx, r.DB, cp); err != nil {
+		return err
+	}
+	if len(seen) > 0 || refreshed > 0 {
+		log.Printf("actions sync: %d new, %d refreshed (lastActionID=%d lastHeight=%d)", len(seen), refreshed, cp.LastActionID, cp.LastHeight)
+	}
+	return nil
+}
+
+// syncActionsFull walks the entire list_actions pagination and upserts every action.
+// It reconciles anything the incremental sync may have missed and resets the checkpoint.
+func (r *Runner) syncActionsFull(ctx context.Context) error {
+	r.actionsMu.Lock()
+	defer r.actionsMu.Unlock()
+	return r.syncActionsFullLocked(ctx)
+}
+
+// backfillActions runs the first full sync, which stores every action on chain. Its
+// inserts and transitions are history, not news, so no action events are recorded
+// until it completes. The height it reached is saved in the actions checkpoint.
+func (r *Runner) backfillActions(ctx context.Context) error {
+	prev := r.eventsFromHeight.Swap(math.MaxInt64)
+	if err := r.syncActionsFullLocked(ctx); err != nil {
+		r.eventsFromHeight.Store(prev)
+		return err
+	}
+	cp, err := db.GetSyncCheckpoint(ctx, r.DB, db.CheckpointActions)
+	if err != nil {
+		r.eventsFromHeight.Store(prev)
+		return err
+	}
+	cp.BackfillHeight = cp.LastHeight
+	if height, err := r.Lumera.GetLatestHeight(ctx); err != nil {
+		log.Printf("actions backfill: latest height: %v", err)
+	} else if height > cp.BackfillHeight {
+		cp.BackfillHeight = height
+	}
+	cp.LastFullSyncAt = nil // preserve the stored value
+	if err := db.SaveSyncCheckpoint(ctx, r.DB, cp); err != nil {
+		r.eventsFromHeight.Store(prev)
+		return err
+	}
+	r.eventsFromHeight.Store(cp.BackfillHeight)
+	return nil
+}
+
+// loadEventsFromHeight sets eventsFromHeight from the actions checkpoint, if not known
+// yet. A checkpoint saved before backfill heights were recorded gets one, saved with
+// it next: the height in use, or its last height, as everything up to it was indexed
+// before any event was.
+func (r *Runner) loadEventsFromHeight(cp *db.SyncCheckpoint) {
+	known := r.eventsFromHeight.Load()
+	if cp.BackfillHeight == 0 {
+		cp.BackfillHeight = cp.LastHeight
+		if known != math.MaxInt64 {
+			cp.BackfillHeight = known
+		}
+	}
+	if known == math.MaxInt64 {
+		r.eventsFromHeight.Store(cp.BackfillHeight)
+	}
+}
+
+func (r *Runner) syncActionsFullLocked(ctx context.Context) error {
 	var next string
 	limit := 100
+	startTime := time.Now()
+	cp := db.SyncCheckpoint{Name: db.CheckpointActions}
+	total := 0
 	for {
 		actions, n, err := r.Lumera.GetActions(ctx, "ACTION_TYPE_UNSPECIFIED", "ACTION_STATE_UNSPECIFIED", next, limit)
 		if err != nil {
 			return err
 		}
 		for _, a := range actions {
-			raw, decoded, derr := decoder.DecodeActionMetadata(a.ActionType, a.MetadataB64)
-			if derr != nil {
-				log.Printf("decode action %s: %v", a.ActionID, derr)
-			}
-			var bh int64
-			if a.BlockHeight != "" {
-				if v, err := strconv.ParseInt(a.BlockHeight, 10, 64); err == nil {
-					bh = v
-				}
-			}
-			var exp int64
-			if a.ExpirationTime != "" {
-				if v, err := strconv.ParseInt(a.ExpirationTime, 10, 64); err == nil {
-					exp = v
-				}
-			}
-			// Ensure SuperNodes is never nil to avoid null in DB
-			superNodes := a.SuperNodes
-			if superNodes == nil {
-				superNodes = []string{}
-			}
-
-			// Extract mimeType from file_name extension in metadataJSON (for Cascade actions)
-			mimeType := extractMimeType(decoded)
-
-			// Parse ActionID from string (API response) to uint64 (DB model)
-			actionID, err := strconv.ParseUint(a.ActionID, 10, 64)
-			if err != nil {
-				log.Printf("parse action ID %s: %v", a.ActionID, err)
+			id, height, ok := r.upsertChainAction(ctx, a)
+			if !ok {
 				continue
 			}
-
-			// Parse FileSizeKbs from API response and convert to bytes
-			var sizeBytes int64
-			if a.FileSizeKbs != "" {
-				if kbs, err := strconv.ParseInt(a.FileSizeKbs, 10, 64); err == nil {
-					sizeBytes = kbs * 1024 // Convert KB to bytes
-				}
-			}
-
-			rec := db.ActionDB{
-				ActionID:       actionID,
-				Creator:        a.Creator,
-				ActionType:     a.ActionType,
-				State:          a.State,
-				BlockHeight:    bh,
-				PriceDenom:     a.Price.Denom,
-				PriceAmount:    a.Price.Amount,
-				ExpirationTime: exp,
-				MetadataRaw:    raw,
-				MetadataJSON:   toJSONB(decoded),
-				SuperNodes:     toJSONB(superNodes),
-				MimeType:       mimeType,
-				Size:           sizeBytes, // File size in bytes from API's fileSizeKbs
+			total++
+			if id > cp.LastActionID {
+				cp.LastActionID = id
 			}
-			if err := db.UpsertAction(ctx, r.DB, rec); err != nil {
-				log.Printf("upsert action %d: %v", actionID, err)
+			if height > cp.LastHeight {
+				cp.LastHeight = height
 			}
 		}
 		if n == "" {
@@ -393,9 +754,110 @@ func (r *Runner) syncActions(ctx context.Context) error {
 		}
 		next = n
 	}
+	now := time.Now().UTC()
+	cp.LastFullSyncAt = &now
+	if err := db.SaveSyncCheckpoint(ctx, r.DB, cp); err != nil {
+		return err
+	}
+	log.Printf("actions full sync: upserted %d actions in %v (lastActionID=%d lastHeight=%d)", total, time.Since(startTime), cp.LastActionID, cp.LastHeight)
 	return nil
 }
 
+// pruneEvents drops action and supernode events older than their configured retention.
+func (r *Runner) pruneEvents(ctx context.Context) {
+	logs := []struct {
+		name      string
+		retention time.Duration
+		prune     func(context.Context, *db.Pool, time.Time) (int64, error)
+	}{
+		{"action", r.Cfg.ActionEventsRetention, db.PruneActionEvents},
+		{"supernode", r.Cfg.SupernodeEventsRetention, db.PruneSupernodeEvents},
+	}
+	for _, l := range logs {
+		if l.retention <= 0 {
+			continue
+		}
+		n, err := l.prune(ctx, r.DB, time.Now().UTC().Add(-l.retention))
+		if err != nil {
+			log.Printf("prune %s events: %v", l.name, err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("pruned %d %s events older than %v", n, l.name, l.retention)
+		}
+	}
+}
+
+// upsertChainAction decodes a chain action and stores it, with the event its insert or
+// state change caused unless the first actions backfill is running.
+// Returns the parsed action ID and block height, and false if the action could not be stored.
+func (r *Runner) upsertChainAction(ctx context.Context, a lclient.Action) (uint64, int64, bool) {
+	raw, decoded, derr := decoder.DecodeActionMetadata(a.ActionType, a.MetadataB64)
+	if derr != nil {
+		log.Printf("decode action %s: %v", a.ActionID, derr)
+	}
+	var bh int64
+	if a.BlockHeight != "" {
+		if v, err := strconv.ParseInt(a.BlockHeight, 10, 64); err == nil {
+			bh = v
+		}
+	}
+	var exp int64
+	if a.ExpirationTime != "" {
+		if v, err := strconv.ParseInt(a.ExpirationTime, 10, 64); err == nil {
+			exp = v
+		}
+	}
+	// Ensure SuperNodes is never nil to avoid null in DB
+	superNodes := a.SuperNodes
+	if superNodes == nil {
+		superNodes = []string{}
+	}
+
+	// Extract mimeType from file_name extension in metadataJSON (for Cascade actions)
+	mimeType := extractMimeType(decoded)
+
+	// Parse ActionID from string (API response) to uint64 (DB model)
+	actionID, err := strconv.ParseUint(a.ActionID, 10, 64)
+	if err != nil {
+		log.Printf("parse action ID %s: %v", a.ActionID, err)
+		return 0, 0, false
+	}
+
+	// Parse FileSizeKbs from API response and convert to bytes
+	var sizeBytes int64
+	if a.FileSizeKbs != "" {
+		if kbs, err := strconv.ParseInt(a.FileSizeKbs, 10, 64); err == nil {
+			sizeBytes = kbs * 1024 // Convert KB to bytes
+		}
+	}
+
+	rec := db.ActionDB{
+		ActionID:       actionID,
+		Creator:        a.Creator,
+		ActionType:     a.ActionType,
+		State:          a.State,
+		BlockHeight:    bh,
+		PriceDenom:     a.Price.Denom,
+		PriceAmount:    a.Price.Amount,
+		ExpirationTime: exp,
+		MetadataRaw:    raw,
+		MetadataJSON:   toJSONB(decoded),
+		SuperNodes:     toJSONB(superNodes),
+		MimeType:       mimeType,
+		Size:           sizeBytes, // File size in bytes from API's fileSizeKbs
+	}
+	upsert := db.UpsertActionWithEvent
+	if r.eventsFromHeight.Load() == math.MaxInt64 {
+		upsert = db.UpsertAction
+	}
+	if _, err := upsert(ctx, r.DB, rec); err != nil {
+		log.Printf("upsert action %d: %v", actionID, err)
+		return actionID, bh, false
+	}
+	return actionID, bh, true
+}
+
 // TriggerSyncAndProbe manually triggers a sync+probe run if not already in progress.
 // Returns true if the run was started, false if already running.
 func (r *Runner) TriggerSyncAndProbe(ctx context.Context) bool {
@@ -426,11 +888,20 @@ func (r *Runner) TriggerSyncAndProbe(ctx context.Context) bool {
 	return true
 }
 
+// probeSupernodes probes every known supernode and records the changes since the
+// stored snapshots. Like supernode syncs, passes are serialized.
 func (r *Runner) probeSupernodes(ctx context.Context) error {
+	r.probesMu.Lock()
+	defer r.probesMu.Unlock()
 	targets, err := db.ListKnownSupernodes(ctx, r.DB)
 	if err != nil {
 		return err
 	}
+	snapshots, err := db.ListSupernodeSnapshots(ctx, r.DB)
+	if err != nil {
+		return err
+	}
+	var probed, available int
 	for _, t := range targets {
 		// ipAddress MUST have host:port format, otherwise it's a bad supernode
 		if t.IPAddress == "" {
@@ -475,8 +946,12 @@ func (r *Runner) probeSupernodes(ctx context.Context) error {
 		}
 		openP2P := tcpOpen(ctx, host, int(p2pPort), r.Cfg.DialTimeout)
 
-		// Status check: use host and port 8002
+		// Status check: use host and the status API port (8002)
 		status := fetchStatus(ctx, host)
+		probed++
+		if status.Available {
+			available++
+		}
 
 		// Update DB with probe results (merge into metricsReport and status fields)
 		now := time.Now().UTC()
@@ -513,8 +988,23 @@ func (r *Runner) probeSupernodes(ctx context.Context) error {
 		}
 		if err := db.UpdateSupernodeProbeData(ctx, r.DB, sn); err != nil {
 			log.Printf("probe update %s: %v", t.SupernodeAccount, err)
+			continue
+		}
+
+		// Change detection: full availability means both ports open and the status API answering
+		fullyAvailable := openPort1 && openP2P && status.Available
+		if prev, ok := snapshots[t.SupernodeAccount]; ok {
+			r.recordSupernodeEvents(ctx, supernodeProbeEvents(prev, status.Version, fullyAvailable, report))
+		}
+		if err := db.SetSupernodeFullyAvailable(ctx, r.DB, t.SupernodeAccount, fullyAvailable); err != nil {
+			log.Printf("probe availability update %s: %v", t.SupernodeAccount, err)
+		}
+		if err := db.InsertProbeSample(ctx, r.DB, probeSample(t.SupernodeAccount, now, status, openPort1, openP2P)); err != nil {
+			log.Printf("probe sample %s: %v", t.SupernodeAccount, err)
 		}
 	}
+	supernodesProbed.Set(float64(probed), r.Cfg.Network)
+	supernodesAvailable.Set(float64(available), r.Cfg.Network)
 	return nil
 }
 
@@ -746,9 +1236,12 @@ type statusSummary struct {
 	P2PRecords          int64
 }
 
+// statusPort is the port of the supernode status API; tests point it at a local server.
+var statusPort = "8002"
+
 func fetchStatus(ctx context.Context, host string) statusSummary {
 	client := &http.Client{Timeout: 6 * time.Second}
-	url := "http://" + net.JoinHostPort(host, "8002") + "/api/v1/status?includeP2pMetrics=true"
+	url := "http://" + net.JoinHostPort(host, statusPort) + "/api/v1/status?includeP2pMetrics=true"
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return statusSummary{Available: false}
@@ -1,6 +1,11 @@
 package background
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	"lumescope/internal/config"
+)
 
 func TestExtractMimeType(t *testing.T) {
 	tests := []struct {
@@ -172,3 +177,14 @@ func TestIsValidHost(t *testing.T) {
 		})
 	}
 }
+
+// TestSyncActionsSkipsDuringFullSync verifies an incremental pass returns at once, as
+// a success, while a full sync holds the actions lock
+func TestSyncActionsSkipsDuringFullSync(t *testing.T) {
+	r := NewRunner(config.Config{}, nil, nil) // a pass that ran would dereference these
+	r.actionsMu.Lock()
+	defer r.actionsMu.Unlock()
+	if err := r.syncActions(context.Background()); err != nil {
+		t.Fatalf("syncActions = %v, want nil", err)
+	}
+}
@@ -0,0 +1,86 @@
+package background
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"lumescope/internal/db"
+	lclient "lumescope/internal/lumera"
+)
+
+// supernodeChainEvents compares a freshly synced chain record against the stored
+// snapshot and returns state, IP and evidence changes. Fields that were unknown
+// before (first sync) produce no events.
+func supernodeChainEvents(prev db.SupernodeSnapshot, sn lclient.Supernode, state, stateHeight, ip string) []db.SupernodeEvent {
+	var events []db.SupernodeEvent
+	base := db.SupernodeEvent{SupernodeAccount: sn.SupernodeAccount, ValidatorAddress: sn.ValidatorAddress}
+
+	if prev.CurrentState != "" && state != "" && prev.CurrentState != state {
+		e := base
+		e.EventType = db.SupernodeEventStateChanged
+		e.OldValue, e.NewValue = prev.CurrentState, state
+		if h, err := strconv.ParseInt(stateHeight, 10, 64); err == nil {
+			e.Height = &h
+		}
+		events = append(events, e)
+	}
+
+	if prev.IPAddress != "" && ip != "" && prev.IPAddress != ip {
+		e := base
+		e.EventType = db.SupernodeEventIPChanged
+		e.OldValue, e.NewValue = prev.IPAddress, ip
+		events = append(events, e)
+	}
+
+	// Evidence is append-only on chain, so anything past the stored count is new.
+	for i := prev.EvidenceCount; i < len(sn.Evidence); i++ {
+		ev := sn.Evidence[i]
+		e := base
+		e.EventType = db.SupernodeEventEvidenceAdded
+		e.NewValue = ev.EvidenceType
+		h := int64(ev.Height)
+		e.Height = &h
+		e.Details = toJSONB(ev)
+		events = append(events, e)
+	}
+	return events
+}
+
+// supernodeProbeEvents compares probe results against the stored snapshot and returns
+// version changes and transitions into or out of full availability.
+func supernodeProbeEvents(prev db.SupernodeSnapshot, version string, fullyAvailable bool, details map[string]any) []db.SupernodeEvent {
+	var events []db.SupernodeEvent
+	base := db.SupernodeEvent{SupernodeAccount: prev.SupernodeAccount, ValidatorAddress: prev.ValidatorAddress}
+
+	if prev.ActualVersion != "" && version != "" && prev.ActualVersion != version {
+		e := base
+		e.EventType = db.SupernodeEventVersionChanged
+		e.OldValue, e.NewValue = prev.ActualVersion, version
+		events = append(events, e)
+	}
+
+	if prev.FullyAvailable != nil && *prev.FullyAvailable != fullyAvailable {
+		e := base
+		e.EventType = db.SupernodeEventPortsDown
+		e.OldValue, e.NewValue = "available", "unavailable"
+		if fullyAvailable {
+			e.EventType = db.SupernodeEventPortsUp
+			e.OldValue, e.NewValue = "unavailable", "available"
+		}
+		e.Details = toJSONB(details)
+		events = append(events, e)
+	}
+	return events
+}
+
+// recordSupernodeEvents persists detected events. Failures are logged and do not fail the sync.
+func (r *Runner) recordSupernodeEvents(ctx context.Context, events []db.SupernodeEvent) {
+	for _, e := range events {
+		if err := db.InsertSupernodeEvent(ctx, r.DB, e); err != nil {
+			log.Printf("record %s event for supernode %s: %v", e.EventType, e.SupernodeAccount, err)
+			continue
+		}
+		log.Printf("supernode %s: %s %q -> %q", e.SupernodeAccount, e.EventType, e.OldValue, e.NewValue)
+	}
+}
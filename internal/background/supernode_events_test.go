@@ -0,0 +1,68 @@
+package background
+
+import (
+	"testing"
+
+	"lumescope/internal/db"
+	lclient "lumescope/internal/lumera"
+)
+
+// TestSupernodeChainEvents verifies state, IP and evidence change detection
+func TestSupernodeChainEvents(t *testing.T) {
+	prev := db.SupernodeSnapshot{
+		SupernodeAccount: "lumera1sn",
+		CurrentState:     "SUPERNODE_STATE_ACTIVE",
+		IPAddress:        "1.2.3.4:4444",
+		EvidenceCount:    1,
+	}
+	sn := lclient.Supernode{
+		SupernodeAccount: "lumera1sn",
+		ValidatorAddress: "lumeravaloper1v",
+		Evidence: []lclient.Evidence{
+			{EvidenceType: "old", Height: 10},
+			{EvidenceType: "missed_report", Height: 20},
+		},
+	}
+
+	events := supernodeChainEvents(prev, sn, "SUPERNODE_STATE_PENALIZED", "150", "5.6.7.8:4444")
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+
+	if e := events[0]; e.EventType != db.SupernodeEventStateChanged ||
+		e.OldValue != "SUPERNODE_STATE_ACTIVE" || e.NewValue != "SUPERNODE_STATE_PENALIZED" ||
+		e.Height == nil || *e.Height != 150 || e.ValidatorAddress != "lumeravaloper1v" {
+		t.Errorf("state event = %+v", e)
+	}
+	if e := events[1]; e.EventType != db.SupernodeEventIPChanged || e.NewValue != "5.6.7.8:4444" {
+		t.Errorf("ip event = %+v", e)
+	}
+	if e := events[2]; e.EventType != db.SupernodeEventEvidenceAdded || e.NewValue != "missed_report" || *e.Height != 20 {
+		t.Errorf("evidence event = %+v", e)
+	}
+
+	// Unchanged or previously unknown fields produce no events
+	if got := supernodeChainEvents(db.SupernodeSnapshot{EvidenceCount: 2}, sn, "SUPERNODE_STATE_ACTIVE", "1", "1.2.3.4:4444"); len(got) != 0 {
+		t.Errorf("expected no events for first sync, got %+v", got)
+	}
+}
+
+// TestSupernodeProbeEvents verifies version and availability transition detection
+func TestSupernodeProbeEvents(t *testing.T) {
+	up, down := true, false
+
+	events := supernodeProbeEvents(db.SupernodeSnapshot{SupernodeAccount: "a", ActualVersion: "v1.0.0", FullyAvailable: &up}, "v1.1.0", false, nil)
+	if len(events) != 2 || events[0].EventType != db.SupernodeEventVersionChanged || events[1].EventType != db.SupernodeEventPortsDown {
+		t.Errorf("available -> unavailable with upgrade: got %+v", events)
+	}
+
+	events = supernodeProbeEvents(db.SupernodeSnapshot{SupernodeAccount: "a", FullyAvailable: &down}, "", true, nil)
+	if len(events) != 1 || events[0].EventType != db.SupernodeEventPortsUp {
+		t.Errorf("unavailable -> available: got %+v", events)
+	}
+
+	// No prior probe: nothing to compare against
+	if events = supernodeProbeEvents(db.SupernodeSnapshot{SupernodeAccount: "a"}, "v1.0.0", false, nil); len(events) != 0 {
+		t.Errorf("expected no events without prior probe, got %+v", events)
+	}
+}
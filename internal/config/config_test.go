@@ -0,0 +1,28 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRollup5mRetentionEnv verifies retentions shorter than the longest availability
+// window are raised to it, while longer ones and zero are kept
+func TestRollup5mRetentionEnv(t *testing.T) {
+	const key = "PROBE_ROLLUP_5M_RETENTION"
+	month := 30 * 24 * time.Hour
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", month},
+		{"24h", month},
+		{"1440h", 1440 * time.Hour},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		t.Setenv(key, tt.env)
+		if got := rollup5mRetentionEnv(key, month); got != tt.want {
+			t.Errorf("%s=%q: %s, want %s", key, tt.env, got, tt.want)
+		}
+	}
+}
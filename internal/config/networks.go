@@ -0,0 +1,120 @@
+package config
+
+import (
+	"log"
+	"strings"
+)
+
+// DefaultNetworkName names the single network indexed when NETWORKS is unset.
+const DefaultNetworkName = "default"
+
+// loadNetworks builds one Config per network listed in NETWORKS (comma-separated),
+// default network first. Each starts as a copy of base and applies the
+// NETWORK_<NAME>_* overrides. Without NETWORKS, base is the only network and keeps
+// its tables in the public schema.
+//
+// The default network is DEFAULT_NETWORK if set, otherwise the first listed. It
+// serves the unprefixed /v1 routes and, unless overridden, also uses the public
+// schema so an existing single-network database keeps working. Other networks
+// default to schema "lumescope_<name>".
+func loadNetworks(base Config) []Config {
+	base.Networks = nil
+	names := splitNetworkNames(getenv("NETWORKS", ""))
+	if len(names) == 0 {
+		base.Network = DefaultNetworkName
+		base.ChainID = getenv("CHAIN_ID", "")
+		base.DBSchema = "public"
+		return []Config{base}
+	}
+
+	def := strings.ToLower(strings.TrimSpace(getenv("DEFAULT_NETWORK", names[0])))
+	defIdx := -1
+	for i, n := range names {
+		if n == def {
+			defIdx = i
+		}
+	}
+	if defIdx < 0 {
+		log.Printf("DEFAULT_NETWORK %q is not listed in NETWORKS, using %q", def, names[0])
+		defIdx = 0
+	}
+	names[0], names[defIdx] = names[defIdx], names[0]
+
+	out := make([]Config, 0, len(names))
+	for i, name := range names {
+		out = append(out, networkConfig(base, name, i == 0))
+	}
+	return out
+}
+
+// networkConfig applies NETWORK_<NAME>_* overrides to a copy of base.
+func networkConfig(base Config, name string, isDefault bool) Config {
+	p := networkEnvPrefix(name)
+	c := base
+	c.Network = name
+	c.ChainID = getenv(p+"CHAIN_ID", "")
+	schema := "lumescope_" + strings.ReplaceAll(name, "-", "_")
+	if isDefault {
+		schema = "public"
+	}
+	c.DBSchema = getenv(p+"DB_SCHEMA", schema)
+	c.DB_DSN = getenv(p+"DB_DSN", base.DB_DSN)
+	c.LumeraAPIBases = listEnv(p+"LUMERA_API_BASE", strings.Join(base.LumeraAPIBases, ","))
+	c.LumeraAPIRetries = intEnv(p+"LUMERA_API_RETRIES", base.LumeraAPIRetries)
+	c.LumeraAPIRetryBackoff = durationEnv(p+"LUMERA_API_RETRY_BACKOFF", base.LumeraAPIRetryBackoff)
+	c.LumeraRPCBase = getenv(p+"LUMERA_RPC_BASE", base.LumeraRPCBase)
+	c.ChainClient = choiceEnv(p+"CHAIN_CLIENT", base.ChainClient, ChainClientLCD, ChainClientGRPC)
+	c.LumeraGRPCAddr = getenv(p+"LUMERA_GRPC_ADDR", base.LumeraGRPCAddr)
+	c.LumeraGRPCTLS = boolEnv(p+"LUMERA_GRPC_TLS", base.LumeraGRPCTLS)
+
+	c.ValidatorsSyncInterval = durationEnv(p+"VALIDATORS_SYNC_INTERVAL", base.ValidatorsSyncInterval)
+	c.SupernodesSyncInterval = durationEnv(p+"SUPERNODES_SYNC_INTERVAL", base.SupernodesSyncInterval)
+	c.ActionsSyncInterval = durationEnv(p+"ACTIONS_SYNC_INTERVAL", base.ActionsSyncInterval)
+	c.ActionsSyncIntervalLive = durationEnv(p+"ACTIONS_SYNC_INTERVAL_LIVE", base.ActionsSyncIntervalLive)
+	c.ActionsFullSyncInterval = durationEnv(p+"ACTIONS_FULL_SYNC_INTERVAL", base.ActionsFullSyncInterval)
+	c.ProbeInterval = durationEnv(p+"PROBE_INTERVAL", base.ProbeInterval)
+	c.ActionTxEnricherInterval = durationEnv(p+"ACTION_TX_ENRICHER_INTERVAL", base.ActionTxEnricherInterval)
+	c.ActionEnricherStartID = uint64Env(p+"ACTION_ENRICHER_START_ID", base.ActionEnricherStartID)
+	c.ActionTxIndexer = choiceEnv(p+"ACTION_TX_INDEXER", base.ActionTxIndexer, TxIndexerActions, TxIndexerBlocks)
+	c.BlockIndexerInterval = durationEnv(p+"BLOCK_INDEXER_INTERVAL", base.BlockIndexerInterval)
+	c.BlockIndexerRange = intEnv(p+"BLOCK_INDEXER_RANGE", base.BlockIndexerRange)
+	c.BlockIndexerStartHeight = int64Env(p+"BLOCK_INDEXER_START_HEIGHT", base.BlockIndexerStartHeight)
+	return c
+}
+
+// networkEnvPrefix returns the env prefix for a network, e.g. "NETWORK_TESTNET_2_" for "testnet-2".
+func networkEnvPrefix(name string) string {
+	return "NETWORK_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+// splitNetworkNames parses NETWORKS, dropping duplicates and names that are not
+// usable as a URL segment and schema suffix ([a-z0-9][a-z0-9_-]*).
+func splitNetworkNames(s string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, n := range strings.Split(s, ",") {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if n == "" || seen[n] {
+			continue
+		}
+		if !validNetworkName(n) {
+			log.Printf("ignoring invalid network name %q in NETWORKS", n)
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	return out
+}
+
+func validNetworkName(n string) bool {
+	for i, r := range n {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case (r == '-' || r == '_') && i > 0:
+		default:
+			return false
+		}
+	}
+	return n != ""
+}
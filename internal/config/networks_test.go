@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestLoadNetworksSingle verifies the unprefixed single-network default
+func TestLoadNetworksSingle(t *testing.T) {
+	t.Setenv("NETWORKS", "")
+	nets := loadNetworks(Config{LumeraAPIBases: []string{"http://lcd"}})
+	if len(nets) != 1 || nets[0].Network != DefaultNetworkName || nets[0].DBSchema != "public" {
+		t.Fatalf("networks = %+v, want single default network in public schema", nets)
+	}
+}
+
+// TestLoadNetworksMulti verifies per-network overrides, default ordering and schemas
+func TestLoadNetworksMulti(t *testing.T) {
+	t.Setenv("NETWORKS", "testnet, Mainnet,bad name,testnet")
+	t.Setenv("DEFAULT_NETWORK", "mainnet")
+	t.Setenv("NETWORK_MAINNET_CHAIN_ID", "lumera-mainnet-1")
+	t.Setenv("NETWORK_TESTNET_LUMERA_API_BASE", "http://testnet-lcd, http://testnet-lcd-2 ,")
+	t.Setenv("NETWORK_TESTNET_PROBE_INTERVAL", "5m")
+	t.Setenv("NETWORK_TESTNET_ACTION_TX_INDEXER", "Blocks")
+	t.Setenv("NETWORK_MAINNET_ACTION_TX_INDEXER", "bogus")
+	t.Setenv("NETWORK_TESTNET_CHAIN_CLIENT", "grpc")
+	t.Setenv("NETWORK_TESTNET_LUMERA_GRPC_ADDR", "testnet-grpc:9090")
+
+	base := Config{LumeraAPIBases: []string{"http://lcd"}, ProbeInterval: time.Minute, ActionTxIndexer: TxIndexerActions, ChainClient: ChainClientLCD, LumeraGRPCAddr: "localhost:9090"}
+	nets := loadNetworks(base)
+	if len(nets) != 2 {
+		t.Fatalf("got %d networks, want 2: %+v", len(nets), nets)
+	}
+
+	mn, tn := nets[0], nets[1]
+	if mn.Network != "mainnet" || mn.ChainID != "lumera-mainnet-1" || mn.DBSchema != "public" || fmt.Sprint(mn.LumeraAPIBases) != "[http://lcd]" {
+		t.Errorf("mainnet = %+v", mn)
+	}
+	if tn.Network != "testnet" || tn.DBSchema != "lumescope_testnet" || fmt.Sprint(tn.LumeraAPIBases) != "[http://testnet-lcd http://testnet-lcd-2]" {
+		t.Errorf("testnet = %+v", tn)
+	}
+	if mn.ProbeInterval != time.Minute || tn.ProbeInterval != 5*time.Minute {
+		t.Errorf("probe intervals = %v / %v, want 1m / 5m override", mn.ProbeInterval, tn.ProbeInterval)
+	}
+	if mn.ActionTxIndexer != TxIndexerActions || tn.ActionTxIndexer != TxIndexerBlocks {
+		t.Errorf("tx indexers = %q / %q, want invalid value ignored and blocks override", mn.ActionTxIndexer, tn.ActionTxIndexer)
+	}
+	if mn.ChainClient != ChainClientLCD || tn.ChainClient != ChainClientGRPC || tn.LumeraGRPCAddr != "testnet-grpc:9090" {
+		t.Errorf("chain clients = %q / %q (%s), want lcd / grpc override", mn.ChainClient, tn.ChainClient, tn.LumeraGRPCAddr)
+	}
+}
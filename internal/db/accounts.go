@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AccountActionSummary aggregates the actions an address created.
+type AccountActionSummary struct {
+	Total          int64
+	TotalBytes     int64
+	ByType         []StateCount // State holds the action type
+	ByState        []StateCount
+	FirstHeight    *int64
+	LastHeight     *int64
+	Spent          []PaymentStat // action prices and fees of register transactions, per denom
+	FirstActivity  *time.Time    // earliest register transaction
+	LatestActivity *time.Time    // latest register transaction
+}
+
+// GetAccountActionSummary summarizes the actions created by address. Spending is taken
+// from the register transactions of those actions, grouped by action price denom;
+// fees in another denom are reported under their own denom.
+func GetAccountActionSummary(ctx context.Context, pool *pgxpool.Pool, address string) (AccountActionSummary, error) {
+	var s AccountActionSummary
+
+	err := pool.QueryRow(ctx, `SELECT COUNT(*), COALESCE(SUM("size"), 0)::BIGINT, MIN("blockHeight"), MAX("blockHeight")
+		FROM actions WHERE "creator" = $1`, address).Scan(&s.Total, &s.TotalBytes, &s.FirstHeight, &s.LastHeight)
+	if err != nil {
+		return s, fmt.Errorf("query account actions: %w", err)
+	}
+
+	rows, err := pool.Query(ctx, `SELECT 'type', COALESCE("actionType", ''), COUNT(*) FROM actions WHERE "creator" = $1 GROUP BY 2
+		UNION ALL
+		SELECT 'state', COALESCE("state", ''), COUNT(*) FROM actions WHERE "creator" = $1 GROUP BY 2
+		ORDER BY 1, 2`, address)
+	if err != nil {
+		return s, fmt.Errorf("query account action counts: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			kind string
+			sc   StateCount
+		)
+		if err := rows.Scan(&kind, &sc.State, &sc.Count); err != nil {
+			return s, fmt.Errorf("scan account action counts: %w", err)
+		}
+		if kind == "type" {
+			s.ByType = append(s.ByType, sc)
+		} else {
+			s.ByState = append(s.ByState, sc)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return s, fmt.Errorf("iterate account action counts: %w", err)
+	}
+
+	spentRows, err := pool.Query(ctx, `SELECT denom, COALESCE(SUM(price), 0)::TEXT, COALESCE(SUM(fee), 0)::TEXT FROM (
+			SELECT COALESCE(t."actionPriceDenom", '') AS denom, t."actionPrice"::numeric AS price, NULL::numeric AS fee
+			FROM action_transactions t JOIN actions a ON a."actionID" = t."actionID"
+			WHERE a."creator" = $1 AND t."txType" = 'register' AND t."txHash" <> '_NO_TX_FOUND_' AND t."actionPrice" IS NOT NULL
+			UNION ALL
+			SELECT COALESCE(t."txFeeDenom", ''), NULL, t."txFee"::numeric
+			FROM action_transactions t JOIN actions a ON a."actionID" = t."actionID"
+			WHERE a."creator" = $1 AND t."txType" = 'register' AND t."txHash" <> '_NO_TX_FOUND_' AND t."txFee" IS NOT NULL
+		) u GROUP BY denom ORDER BY denom`, address)
+	if err != nil {
+		return s, fmt.Errorf("query account spending: %w", err)
+	}
+	defer spentRows.Close()
+	for spentRows.Next() {
+		var p PaymentStat
+		if err := spentRows.Scan(&p.Denom, &p.TotalActionPrice, &p.TotalTxFee); err != nil {
+			return s, fmt.Errorf("scan account spending: %w", err)
+		}
+		s.Spent = append(s.Spent, p)
+	}
+	if err := spentRows.Err(); err != nil {
+		return s, fmt.Errorf("iterate account spending: %w", err)
+	}
+
+	err = pool.QueryRow(ctx, `SELECT MIN(t."blockTime"), MAX(t."blockTime")
+		FROM action_transactions t JOIN actions a ON a."actionID" = t."actionID"
+		WHERE a."creator" = $1 AND t."txType" = 'register' AND t."txHash" <> '_NO_TX_FOUND_'`, address).Scan(&s.FirstActivity, &s.LatestActivity)
+	if err != nil {
+		return s, fmt.Errorf("query account activity: %w", err)
+	}
+
+	return s, nil
+}
+
+// AccountSupernode identifies the supernode an address operates, by supernode account or
+// validator operator address.
+type AccountSupernode struct {
+	SupernodeAccount string
+	ValidatorAddress string
+	ValidatorMoniker string
+	CurrentState     string
+}
+
+// GetAccountSupernode returns the supernode whose supernode account or validator
+// operator address is address, or ErrNotFound.
+func GetAccountSupernode(ctx context.Context, pool *pgxpool.Pool, address string) (AccountSupernode, error) {
+	var sn AccountSupernode
+	err := pool.QueryRow(ctx, `SELECT "supernodeAccount", COALESCE("validatorAddress", ''), COALESCE("validatorMoniker", ''), "currentState"
+		FROM supernodes WHERE "supernodeAccount" = $1 OR "validatorAddress" = $1
+		ORDER BY ("supernodeAccount" = $1) DESC LIMIT 1`, address).
+		Scan(&sn.SupernodeAccount, &sn.ValidatorAddress, &sn.ValidatorMoniker, &sn.CurrentState)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sn, ErrNotFound
+		}
+		return sn, err
+	}
+	return sn, nil
+}
+
+// Transfer directions relative to an account.
+const (
+	TransferIn  = "in"  // account is the flow payee
+	TransferOut = "out" // account is the flow payer
+	TransferAny = "any"
+)
+
+// TransferCursor is the keyset position of an account transfer list.
+type TransferCursor struct {
+	Height   int64
+	ActionID uint64
+	TxType   string
+	TxHash   string
+	MsgIndex int
+}
+
+// AccountTransfersFilter selects a page of ListAccountTransfers.
+type AccountTransfersFilter struct {
+	Address   string
+	Direction string // Transfer*
+	TxType    *string
+	Limit     int
+	Cursor    *TransferCursor
+}
+
+// ListAccountTransfers returns action transactions that moved funds from or to an
+// address, newest first, and whether more follow.
+func ListAccountTransfers(ctx context.Context, pool *pgxpool.Pool, f AccountTransfersFilter) ([]ActionTransaction, bool, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 1
+	}
+
+	args := []any{f.Address}
+	var where string
+	switch f.Direction {
+	case TransferIn:
+		where = `"flowPayee" = $1`
+	case TransferOut:
+		where = `"flowPayer" = $1`
+	default:
+		where = `("flowPayer" = $1 OR "flowPayee" = $1)`
+	}
+	where += ` AND "txHash" <> '_NO_TX_FOUND_'`
+	if f.TxType != nil {
+		args = append(args, *f.TxType)
+		where += fmt.Sprintf(` AND "txType" = $%d`, len(args))
+	}
+	if f.Cursor != nil {
+		args = append(args, f.Cursor.Height, f.Cursor.ActionID, f.Cursor.TxType, f.Cursor.TxHash, f.Cursor.MsgIndex)
+		where += fmt.Sprintf(` AND ("height", "actionID", "txType", "txHash", "msgIndex") < ($%d, $%d, $%d, $%d, $%d)`,
+			len(args)-4, len(args)-3, len(args)-2, len(args)-1, len(args))
+	}
+	args = append(args, limit+1)
+
+	query := fmt.Sprintf(`SELECT "actionID","txType","txHash","height","blockTime","gasWanted","gasUsed","actionPrice","actionPriceDenom","flowPayer","flowPayee","txFee","txFeeDenom","createdAt","msgIndex"
+		FROM action_transactions
+		WHERE %s
+		ORDER BY "height" DESC, "actionID" DESC, "txType" DESC, "txHash" DESC, "msgIndex" DESC
+		LIMIT $%d`, where, len(args))
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	txs := make([]ActionTransaction, 0, limit+1)
+	for rows.Next() {
+		var t ActionTransaction
+		if err := rows.Scan(
+			&t.ActionID,
+			&t.TxType,
+			&t.TxHash,
+			&t.Height,
+			&t.BlockTime,
+			&t.GasWanted,
+			&t.GasUsed,
+			&t.ActionPrice,
+			&t.ActionPriceDenom,
+			&t.FlowPayer,
+			&t.FlowPayee,
+			&t.TxFee,
+			&t.TxFeeDenom,
+			&t.CreatedAt,
+			&t.MsgIndex,
+		); err != nil {
+			return nil, false, err
+		}
+		txs = append(txs, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(txs) > limit
+	if hasMore {
+		txs = txs[:limit]
+	}
+	return txs, hasMore, nil
+}
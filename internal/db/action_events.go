@@ -0,0 +1,231 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Advisory lock keys serializing inserts into the event logs. A BIGSERIAL id is drawn
+// when a row is inserted but only becomes visible on commit, so two concurrent
+// inserters can commit out of id order and a reader resuming from "id > cursor" would
+// skip the late one. Holding a transaction-level lock from before the insert until
+// commit makes ids visible strictly in order.
+const (
+	actionEventsLockKey    int32 = 0x4c53_0001
+	supernodeEventsLockKey int32 = 0x4c53_0002
+)
+
+// lockEventLog takes the transaction-level advisory lock key, released on commit or
+// rollback. The lock is scoped to the current schema, so the event logs of networks
+// sharing a database don't wait on each other.
+func lockEventLog(ctx context.Context, tx pgx.Tx, key int32) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, hashtext(current_schema()))`, key)
+	return err
+}
+
+// Action event types recorded in action_events and emitted on /v1/stream/actions.
+const (
+	ActionEventCreated      = "action_created"
+	ActionEventStateChanged = "action_state_changed"
+	ActionEventTxAttached   = "action_tx_attached"
+)
+
+// ActionEvent is one persisted change to an action. Inserts are serialized, so IDs
+// become visible in increasing order and double as SSE event IDs for Last-Event-ID resume.
+type ActionEvent struct {
+	ID         int64
+	EventType  string
+	ActionID   uint64
+	ActionType string
+	Creator    string
+	SuperNodes any
+	State      string
+	PrevState  *string
+	TxType     *string
+	TxHash     *string
+	Height     *int64
+	CreatedAt  time.Time
+}
+
+// ActionEventsFilter mirrors the ListActions filters that apply to a stream.
+type ActionEventsFilter struct {
+	Type      *string
+	Creator   *string
+	Supernode *string
+}
+
+// ActionChange describes what UpsertAction did to the stored row.
+type ActionChange struct {
+	Inserted  bool
+	PrevState string // Only set when the row already existed
+	Stale     bool   // The stored state is further along the lifecycle; nothing was written
+}
+
+// StateChanged reports whether an existing action moved to a different state.
+func (c ActionChange) StateChanged(newState string) bool {
+	return !c.Inserted && !c.Stale && c.PrevState != newState
+}
+
+// RecordActionEvent appends an event for e.ActionID. Only EventType, PrevState and the
+// Tx* fields are taken from e; action attributes (type, creator, supernodes, current
+// state) are copied from the actions table so streams can be filtered without a join.
+func RecordActionEvent(ctx context.Context, pool *pgxpool.Pool, e ActionEvent) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := lockEventLog(ctx, tx, actionEventsLockKey); err != nil {
+		return err
+	}
+	if err := insertActionEvent(ctx, tx, e); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insertActionEvent appends e. The caller holds the action event log lock.
+func insertActionEvent(ctx context.Context, tx pgx.Tx, e ActionEvent) error {
+	_, err := tx.Exec(ctx, `INSERT INTO action_events
+		("eventType","actionID","actionType","creator","superNodes","state","prevState","txType","txHash","height","createdAt")
+	SELECT $1, a."actionID", a."actionType", a."creator", a."superNodes", a."state", $3, $4, $5, $6, now()
+	FROM actions a
+	WHERE a."actionID" = $2`, e.EventType, e.ActionID, e.PrevState, e.TxType, e.TxHash, e.Height)
+	return err
+}
+
+// UpsertActionWithEvent upserts a like UpsertAction and, in the same transaction,
+// records the action_created or action_state_changed event the upsert caused. The
+// event log lock is taken before the upsert, so concurrent writers of the same action
+// see each other's committed row and the change is only reported once.
+func UpsertActionWithEvent(ctx context.Context, pool *pgxpool.Pool, a ActionDB) (ActionChange, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return ActionChange{}, err
+	}
+	defer tx.Rollback(ctx)
+	if err := lockEventLog(ctx, tx, actionEventsLockKey); err != nil {
+		return ActionChange{}, err
+	}
+	change, err := upsertAction(ctx, tx, a)
+	if err != nil {
+		return ActionChange{}, err
+	}
+	e := ActionEvent{ActionID: a.ActionID}
+	switch {
+	case change.Inserted:
+		e.EventType = ActionEventCreated
+	case change.StateChanged(a.State):
+		prev := change.PrevState
+		e.EventType, e.PrevState = ActionEventStateChanged, &prev
+	}
+	if e.EventType != "" {
+		if err := insertActionEvent(ctx, tx, e); err != nil {
+			return ActionChange{}, fmt.Errorf("record %s event: %w", e.EventType, err)
+		}
+	}
+	return change, tx.Commit(ctx)
+}
+
+// UpsertActionTransactionWithEvent upserts t like UpsertActionTransaction and, in the
+// same transaction, records an action_tx_attached event when the row is new. Like
+// UpsertActionWithEvent it holds the event log lock across the upsert.
+func UpsertActionTransactionWithEvent(ctx context.Context, pool *pgxpool.Pool, t *ActionTransaction) (bool, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+	if err := lockEventLog(ctx, tx, actionEventsLockKey); err != nil {
+		return false, err
+	}
+	changed, err := upsertActionTransaction(ctx, tx, t)
+	if err != nil {
+		return false, err
+	}
+	if changed {
+		e := ActionEvent{
+			EventType: ActionEventTxAttached,
+			ActionID:  t.ActionID,
+			TxType:    &t.TxType,
+			TxHash:    &t.TxHash,
+			Height:    &t.Height,
+		}
+		if err := insertActionEvent(ctx, tx, e); err != nil {
+			return false, fmt.Errorf("record %s event: %w", e.EventType, err)
+		}
+	}
+	return changed, tx.Commit(ctx)
+}
+
+// ListActionEventsAfter returns up to limit events with ID > afterID matching f, oldest first.
+func ListActionEventsAfter(ctx context.Context, pool *pgxpool.Pool, afterID int64, f ActionEventsFilter, limit int) ([]ActionEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var (
+		sb         strings.Builder
+		conditions = []string{`"id" > $1`}
+		args       = []any{afterID}
+		argPos     = 2
+	)
+	sb.WriteString(`SELECT "id","eventType","actionID","actionType","creator","superNodes","state","prevState","txType","txHash","height","createdAt"
+		FROM action_events`)
+	if f.Type != nil {
+		conditions = append(conditions, fmt.Sprintf(`"actionType" = $%d`, argPos))
+		args = append(args, *f.Type)
+		argPos++
+	}
+	if f.Creator != nil {
+		conditions = append(conditions, fmt.Sprintf(`"creator" = $%d`, argPos))
+		args = append(args, *f.Creator)
+		argPos++
+	}
+	if f.Supernode != nil {
+		conditions = append(conditions, fmt.Sprintf(`"superNodes" @> jsonb_build_array($%d::text)`, argPos))
+		args = append(args, *f.Supernode)
+		argPos++
+	}
+	sb.WriteString(" WHERE ")
+	sb.WriteString(strings.Join(conditions, " AND "))
+	sb.WriteString(fmt.Sprintf(` ORDER BY "id" ASC LIMIT $%d`, argPos))
+	args = append(args, limit)
+
+	rows, err := pool.Query(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ActionEvent
+	for rows.Next() {
+		var e ActionEvent
+		if err := rows.Scan(&e.ID, &e.EventType, &e.ActionID, &e.ActionType, &e.Creator, &e.SuperNodes,
+			&e.State, &e.PrevState, &e.TxType, &e.TxHash, &e.Height, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// LatestActionEventID returns the highest event ID, or 0 if no events exist.
+func LatestActionEventID(ctx context.Context, pool *pgxpool.Pool) (int64, error) {
+	var id int64
+	err := pool.QueryRow(ctx, `SELECT COALESCE(MAX("id"),0) FROM action_events`).Scan(&id)
+	return id, err
+}
+
+// PruneActionEvents deletes events created before the cutoff and returns how many were removed.
+func PruneActionEvents(ctx context.Context, pool *pgxpool.Pool, before time.Time) (int64, error) {
+	tag, err := pool.Exec(ctx, `DELETE FROM action_events WHERE "createdAt" < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Action lifecycle stages measured by GetActionLatency. Each is the time between two
+// transactions of the same action.
+const (
+	LatencyFinalize = "finalize" // register → finalize
+	LatencyApprove  = "approve"  // finalize → approve
+)
+
+// latencyStages maps each stage to the transaction types it starts and ends with.
+var latencyStages = map[string][2]string{
+	LatencyFinalize: {"register", "finalize"},
+	LatencyApprove:  {"finalize", "approve"},
+}
+
+// Latency groupings.
+const (
+	LatencyGroupType      = "type"
+	LatencyGroupSupernode = "supernode"
+	LatencyGroupNone      = "none"
+)
+
+// LatencyStats summarizes a stage's durations, in seconds.
+type LatencyStats struct {
+	Key   string // action type or supernode account; "" when ungrouped
+	Count int64
+	P50   float64
+	P90   float64
+	P99   float64
+	Avg   float64
+	Max   float64
+}
+
+// ActionLatencyFilter selects the actions measured by GetActionLatency.
+type ActionLatencyFilter struct {
+	Stage      string // Latency*
+	GroupBy    string // LatencyGroup*
+	From       time.Time
+	To         time.Time
+	ActionType *string
+	Supernode  *string
+}
+
+// GetActionLatency computes latency percentiles for one lifecycle stage, over actions
+// whose stage-ending transaction has a block time in [From, To). Grouping by supernode
+// counts an action once for every supernode in its superNodes list. Groups are
+// ordered by key.
+func GetActionLatency(ctx context.Context, pool *pgxpool.Pool, f ActionLatencyFilter) ([]LatencyStats, error) {
+	stage, ok := latencyStages[f.Stage]
+	if !ok {
+		return nil, fmt.Errorf("unknown latency stage %q", f.Stage)
+	}
+
+	var key, join string
+	switch f.GroupBy {
+	case LatencyGroupType:
+		key = `COALESCE(a."actionType", '')`
+	case LatencyGroupSupernode:
+		key = `sn.account`
+		join = ` CROSS JOIN LATERAL jsonb_array_elements_text(
+			CASE WHEN jsonb_typeof(a."superNodes") = 'array' THEN a."superNodes" ELSE '[]'::jsonb END) AS sn(account)`
+	case LatencyGroupNone:
+		key = `''`
+	default:
+		return nil, fmt.Errorf("unknown latency grouping %q", f.GroupBy)
+	}
+
+	args := []any{stage[0], stage[1], f.From, f.To}
+	where := `e."blockTime" >= $3 AND e."blockTime" < $4`
+	if f.ActionType != nil {
+		args = append(args, *f.ActionType)
+		where += fmt.Sprintf(` AND a."actionType" = $%d`, len(args))
+	}
+	if f.Supernode != nil {
+		args = append(args, *f.Supernode)
+		where += fmt.Sprintf(` AND a."superNodes" @> jsonb_build_array($%d::text)`, len(args))
+	}
+
+	query := fmt.Sprintf(`SELECT grp, COUNT(*),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY secs),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY secs),
+			percentile_cont(0.99) WITHIN GROUP (ORDER BY secs),
+			AVG(secs), MAX(secs)
+		FROM (
+			SELECT %s AS grp, EXTRACT(EPOCH FROM (e."blockTime" - s."blockTime"))::DOUBLE PRECISION AS secs
+			FROM action_transactions e
+			JOIN action_transactions s ON s."actionID" = e."actionID" AND s."txType" = $1 AND s."txHash" <> '_NO_TX_FOUND_' AND %s
+			JOIN actions a ON a."actionID" = e."actionID"%s
+			WHERE e."txType" = $2 AND e."txHash" <> '_NO_TX_FOUND_' AND %s AND %s
+		) d
+		GROUP BY grp ORDER BY grp`, key, firstOfType("s"), join, firstOfType("e"), where)
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query action latency: %w", err)
+	}
+	defer rows.Close()
+
+	var out []LatencyStats
+	for rows.Next() {
+		var s LatencyStats
+		if err := rows.Scan(&s.Key, &s.Count, &s.P50, &s.P90, &s.P99, &s.Avg, &s.Max); err != nil {
+			return nil, fmt.Errorf("scan action latency: %w", err)
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate action latency: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Action timeseries bucket sizes; the values are PostgreSQL date_trunc fields.
+const (
+	ActionBucketHour = "hour"
+	ActionBucketDay  = "day"
+	ActionBucketWeek = "week" // starts on Monday
+)
+
+// DenomAmount is a token amount summed per denomination. Amount is a decimal string so
+// large totals keep full precision.
+type DenomAmount struct {
+	Denom  string
+	Amount string
+}
+
+// ActionTimeseriesPoint aggregates action activity in one bucket.
+type ActionTimeseriesPoint struct {
+	BucketStart time.Time
+	Registered  int64
+	Finalized   int64
+	Approved    int64
+	Failed      int64 // registered in the bucket, now ACTION_STATE_FAILED
+	Expired     int64 // registered in the bucket, now ACTION_STATE_EXPIRED
+	BytesStored int64 // size of actions finalized in the bucket
+	Prices      []DenomAmount
+	Fees        []DenomAmount
+}
+
+// ActionTimeseriesFilter selects the buckets of GetActionTimeseries.
+type ActionTimeseriesFilter struct {
+	Bucket     string    // ActionBucket*
+	From       time.Time // inclusive, UTC
+	To         time.Time // exclusive, UTC
+	ActionType *string
+}
+
+// GetActionTimeseries buckets action transactions by block time. Registered, finalized
+// and approved count actions by their first transaction of that type; failed and
+// expired count actions by their register transaction; prices are the action prices
+// paid at registration and fees the transaction fees of all lifecycle transactions,
+// retries included, so a bucket holding only retries has fees but zero counts.
+// Buckets without transactions are omitted; points are ordered by bucket start.
+func GetActionTimeseries(ctx context.Context, pool *pgxpool.Pool, f ActionTimeseriesFilter) ([]ActionTimeseriesPoint, error) {
+	args := []any{f.Bucket, f.From, f.To}
+	where := `t."txHash" <> '_NO_TX_FOUND_' AND t."blockTime" >= $2 AND t."blockTime" < $3`
+	if f.ActionType != nil {
+		where += ` AND a."actionType" = $4`
+		args = append(args, *f.ActionType)
+	}
+	from := `action_transactions t JOIN actions a ON a."actionID" = t."actionID"`
+
+	countsQuery := fmt.Sprintf(`SELECT date_trunc($1, t."blockTime") AS bucket,
+			COUNT(*) FILTER (WHERE t."txType" = 'register'),
+			COUNT(*) FILTER (WHERE t."txType" = 'finalize'),
+			COUNT(*) FILTER (WHERE t."txType" = 'approve'),
+			COUNT(*) FILTER (WHERE t."txType" = 'register' AND a."state" = 'ACTION_STATE_FAILED'),
+			COUNT(*) FILTER (WHERE t."txType" = 'register' AND a."state" = 'ACTION_STATE_EXPIRED'),
+			COALESCE(SUM(a."size") FILTER (WHERE t."txType" = 'finalize'), 0)::BIGINT
+		FROM %s WHERE %s AND %s
+		GROUP BY bucket ORDER BY bucket`, from, where, firstOfType("t"))
+
+	rows, err := pool.Query(ctx, countsQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query action timeseries: %w", err)
+	}
+	defer rows.Close()
+
+	var points []ActionTimeseriesPoint
+	index := make(map[time.Time]int)
+	for rows.Next() {
+		var p ActionTimeseriesPoint
+		if err := rows.Scan(&p.BucketStart, &p.Registered, &p.Finalized, &p.Approved, &p.Failed, &p.Expired, &p.BytesStored); err != nil {
+			return nil, fmt.Errorf("scan action timeseries: %w", err)
+		}
+		index[p.BucketStart] = len(points)
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate action timeseries: %w", err)
+	}
+
+	amountsQuery := fmt.Sprintf(`SELECT bucket, kind, denom, SUM(amount)::TEXT FROM (
+			SELECT date_trunc($1, t."blockTime") AS bucket, 'price' AS kind, COALESCE(t."actionPriceDenom", '') AS denom, t."actionPrice"::numeric AS amount
+			FROM %[1]s WHERE %[2]s AND t."txType" = 'register' AND t."actionPrice" IS NOT NULL
+			UNION ALL
+			SELECT date_trunc($1, t."blockTime"), 'fee', COALESCE(t."txFeeDenom", ''), t."txFee"::numeric
+			FROM %[1]s WHERE %[2]s AND t."txFee" IS NOT NULL
+		) u GROUP BY bucket, kind, denom ORDER BY bucket, kind, denom`, from, where)
+
+	// A bucket holding only retries has fee rows but no counts row; add it here
+	sorted := true
+	amountRows, err := pool.Query(ctx, amountsQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query action timeseries amounts: %w", err)
+	}
+	defer amountRows.Close()
+
+	for amountRows.Next() {
+		var (
+			bucket     time.Time
+			kind       string
+			denomTotal DenomAmount
+		)
+		if err := amountRows.Scan(&bucket, &kind, &denomTotal.Denom, &denomTotal.Amount); err != nil {
+			return nil, fmt.Errorf("scan action timeseries amounts: %w", err)
+		}
+		i, ok := index[bucket]
+		if !ok {
+			i = len(points)
+			index[bucket] = i
+			points = append(points, ActionTimeseriesPoint{BucketStart: bucket})
+			sorted = false
+		}
+		if kind == "price" {
+			points[i].Prices = append(points[i].Prices, denomTotal)
+		} else {
+			points[i].Fees = append(points[i].Fees, denomTotal)
+		}
+	}
+	if err := amountRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate action timeseries amounts: %w", err)
+	}
+	if !sorted {
+		sort.Slice(points, func(i, j int) bool { return points[i].BucketStart.Before(points[j].BucketStart) })
+	}
+
+	return points, nil
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// APIKey is an issued API key. Only the SHA-256 hash of the key is stored; KeyPrefix
+// is kept so operators can recognise a key without being able to use it.
+type APIKey struct {
+	ID        int64
+	Name      string
+	KeyPrefix string
+	Tier      string
+	// Per-key overrides of the tier's rate limit; nil uses the tier value
+	RateLimitPerMinute *float64
+	RateLimitBurst     *int32
+	CreatedAt          time.Time
+	RevokedAt          *time.Time
+	LastUsedAt         *time.Time
+}
+
+const apiKeyColumns = `"id","name","keyPrefix","tier","rateLimitPerMinute","rateLimitBurst","createdAt","revokedAt","lastUsedAt"`
+
+func scanAPIKey(row pgx.Row) (APIKey, error) {
+	var k APIKey
+	err := row.Scan(&k.ID, &k.Name, &k.KeyPrefix, &k.Tier, &k.RateLimitPerMinute, &k.RateLimitBurst, &k.CreatedAt, &k.RevokedAt, &k.LastUsedAt)
+	return k, err
+}
+
+// CreateAPIKey stores a new key by hash and returns it with ID and timestamps set.
+func CreateAPIKey(ctx context.Context, pool *pgxpool.Pool, k APIKey, keyHash string) (APIKey, error) {
+	return scanAPIKey(pool.QueryRow(ctx, `INSERT INTO api_keys
+		("name","keyPrefix","keyHash","tier","rateLimitPerMinute","rateLimitBurst","createdAt")
+	VALUES ($1,$2,$3,$4,$5,$6,now())
+	RETURNING `+apiKeyColumns,
+		k.Name, k.KeyPrefix, keyHash, k.Tier, k.RateLimitPerMinute, k.RateLimitBurst))
+}
+
+// GetActiveAPIKeyByHash loads a non-revoked key by hash. Returns ErrNotFound if there is none.
+func GetActiveAPIKeyByHash(ctx context.Context, pool *pgxpool.Pool, keyHash string) (APIKey, error) {
+	k, err := scanAPIKey(pool.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE "keyHash"=$1 AND "revokedAt" IS NULL`, keyHash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+	return k, err
+}
+
+// RevokeAPIKey marks a key revoked. Returns ErrNotFound if it doesn't exist or is already revoked.
+func RevokeAPIKey(ctx context.Context, pool *pgxpool.Pool, id int64) error {
+	tag, err := pool.Exec(ctx, `UPDATE api_keys SET "revokedAt"=now() WHERE "id"=$1 AND "revokedAt" IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey records that a key was used at t.
+func TouchAPIKey(ctx context.Context, pool *pgxpool.Pool, id int64, t time.Time) error {
+	_, err := pool.Exec(ctx, `UPDATE api_keys SET "lastUsedAt"=$2 WHERE "id"=$1`, id, t)
+	return err
+}
+
+// ListAPIKeys returns keys ordered by ID, optionally including revoked ones.
+func ListAPIKeys(ctx context.Context, pool *pgxpool.Pool, includeRevoked bool) ([]APIKey, error) {
+	sql := `SELECT ` + apiKeyColumns + ` FROM api_keys`
+	if !includeRevoked {
+		sql += ` WHERE "revokedAt" IS NULL`
+	}
+	sql += ` ORDER BY "id"`
+	rows, err := pool.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
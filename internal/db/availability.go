@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AvailabilityWindow is a rolling window over which probe outcomes are aggregated.
+type AvailabilityWindow struct {
+	Name     string // "24h", "7d", "30d"; used in API field names
+	Duration time.Duration
+	column   string // materialized overall ratio on supernodes
+}
+
+// AvailabilityWindows are the windows exposed by the API and materialized on supernodes.
+var AvailabilityWindows = []AvailabilityWindow{
+	{Name: "24h", Duration: 24 * time.Hour, column: "availability24h"},
+	{Name: "7d", Duration: 7 * 24 * time.Hour, column: "availability7d"},
+	{Name: "30d", Duration: 30 * 24 * time.Hour, column: "availability30d"},
+}
+
+// MaxAvailabilityWindow returns the longest of AvailabilityWindows. Windows read closed
+// 5m rollups, so those must be kept at least this long.
+func MaxAvailabilityWindow() time.Duration {
+	var max time.Duration
+	for _, w := range AvailabilityWindows {
+		if w.Duration > max {
+			max = w.Duration
+		}
+	}
+	return max
+}
+
+// AvailabilityWindowByName returns the window with the given name.
+func AvailabilityWindowByName(name string) (AvailabilityWindow, bool) {
+	for _, w := range AvailabilityWindows {
+		if w.Name == name {
+			return w, true
+		}
+	}
+	return AvailabilityWindow{}, false
+}
+
+// AvailabilityStats are the fractions of probes in a window where each check passed.
+// Ratios are nil when there were no samples.
+type AvailabilityStats struct {
+	Samples   int64
+	Port1     *float64
+	P2P       *float64
+	StatusAPI *float64
+	Overall   *float64 // port1, P2P and status API all up
+}
+
+// availabilityBoundary splits a window between 5m rollups (before) and raw samples
+// (after). Rollups for buckets older than an hour are guaranteed to be complete.
+func availabilityBoundary(now time.Time) time.Time {
+	return now.UTC().Truncate(5 * time.Minute).Add(-time.Hour)
+}
+
+// availabilityQuery sums probe outcomes per supernode since $1, reading closed 5m
+// rollups before $2 and raw samples after it. extra is appended to both WHERE clauses.
+func availabilityQuery(extra string) string {
+	return fmt.Sprintf(`SELECT "supernodeAccount", SUM(n) AS n, SUM(p1) AS p1, SUM(p2p) AS p2p, SUM(api) AS api, SUM(fa) AS fa FROM (
+		SELECT "supernodeAccount", "sampleCount" AS n, "port1OpenCount" AS p1, "p2pOpenCount" AS p2p,
+			"availableCount" AS api, "fullyAvailableCount" AS fa
+		FROM supernode_probe_rollups
+		WHERE "resolution" = '5m' AND "bucketStart" >= $1 AND "bucketStart" < $2 %[1]s
+		UNION ALL
+		SELECT "supernodeAccount", 1, COALESCE("port1Open"::int, 0), COALESCE("p2pOpen"::int, 0),
+			"statusApiAvailable"::int, "fullyAvailable"::int
+		FROM supernode_probe_samples
+		WHERE "sampledAt" >= GREATEST($1::timestamp, $2::timestamp) %[1]s
+	) u GROUP BY "supernodeAccount"`, extra)
+}
+
+func ratio(num, den int64) *float64 {
+	if den == 0 {
+		return nil
+	}
+	v := float64(num) / float64(den)
+	return &v
+}
+
+// GetSupernodeAvailability computes availability for one supernode over [since, now].
+func GetSupernodeAvailability(ctx context.Context, pool *pgxpool.Pool, account string, since, now time.Time) (AvailabilityStats, error) {
+	var (
+		acc                 string
+		n, p1, p2p, api, fa int64
+		stats               AvailabilityStats
+	)
+	err := pool.QueryRow(ctx, availabilityQuery(`AND "supernodeAccount" = $3`),
+		since.UTC(), availabilityBoundary(now), account).Scan(&acc, &n, &p1, &p2p, &api, &fa)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return stats, nil
+		}
+		return stats, err
+	}
+	stats.Samples = n
+	stats.Port1 = ratio(p1, n)
+	stats.P2P = ratio(p2p, n)
+	stats.StatusAPI = ratio(api, n)
+	stats.Overall = ratio(fa, n)
+	return stats, nil
+}
+
+// RefreshSupernodeAvailability recomputes the materialized overall availability of every
+// supernode for each window. Supernodes without samples in a window are set to NULL.
+func RefreshSupernodeAvailability(ctx context.Context, pool *pgxpool.Pool, now time.Time) error {
+	boundary := availabilityBoundary(now)
+	for _, w := range AvailabilityWindows {
+		q := fmt.Sprintf(`WITH agg AS (%s)
+		UPDATE supernodes s SET "%s" = agg.fa::double precision / NULLIF(agg.n, 0)
+		FROM supernodes s2 LEFT JOIN agg ON agg."supernodeAccount" = s2."supernodeAccount"
+		WHERE s."supernodeAccount" = s2."supernodeAccount"`, availabilityQuery(""), w.column)
+		if _, err := pool.Exec(ctx, q, now.UTC().Add(-w.Duration), boundary); err != nil {
+			return fmt.Errorf("refresh availability %s: %w", w.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TxEvent is an ABCI event emitted by a transaction.
+type TxEvent struct {
+	Type       string        `json:"type"`
+	Attributes []TxAttribute `json:"attributes"`
+}
+
+// TxAttribute is a key/value pair of a TxEvent.
+type TxAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ChainTxAction is an action lifecycle message carried by a ChainTx, with the funds
+// it moved.
+type ChainTxAction struct {
+	ActionID         uint64  `json:"action_id,string"`
+	TxType           string  `json:"tx_type"`
+	MsgIndex         int     `json:"msg_index"`
+	ActionPrice      *string `json:"action_price,omitempty"`
+	ActionPriceDenom *string `json:"action_price_denom,omitempty"`
+	FlowPayer        *string `json:"flow_payer,omitempty"`
+	FlowPayee        *string `json:"flow_payee,omitempty"`
+}
+
+// ChainTx is a transaction fetched from the chain by hash. Transactions are immutable
+// once included in a block, so they are cached in chain_txs indefinitely.
+type ChainTx struct {
+	TxHash       string
+	Height       int64
+	BlockTime    time.Time
+	Code         uint32
+	GasWanted    *int64
+	GasUsed      *int64
+	Memo         string
+	Signer       string
+	Fee          []DenomAmount
+	MessageTypes []string
+	Events       []TxEvent
+	Actions      []ChainTxAction
+	Flows        []TxFlow
+	FetchedAt    time.Time
+}
+
+type denomAmountJSON struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+// UpsertChainTx stores a fetched transaction and replaces its flows.
+func UpsertChainTx(ctx context.Context, pool *pgxpool.Pool, tx *ChainTx) error {
+	fee := make([]denomAmountJSON, 0, len(tx.Fee))
+	for _, c := range tx.Fee {
+		fee = append(fee, denomAmountJSON{Denom: c.Denom, Amount: c.Amount})
+	}
+	feeJSON, err := json.Marshal(fee)
+	if err != nil {
+		return fmt.Errorf("marshal fee: %w", err)
+	}
+	msgJSON, err := json.Marshal(nonNilStrings(tx.MessageTypes))
+	if err != nil {
+		return fmt.Errorf("marshal message types: %w", err)
+	}
+	events := tx.Events
+	if events == nil {
+		events = []TxEvent{}
+	}
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("marshal events: %w", err)
+	}
+	actions := tx.Actions
+	if actions == nil {
+		actions = []ChainTxAction{}
+	}
+	actionsJSON, err := json.Marshal(actions)
+	if err != nil {
+		return fmt.Errorf("marshal actions: %w", err)
+	}
+
+	dbtx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer dbtx.Rollback(ctx)
+
+	_, err = dbtx.Exec(ctx, `INSERT INTO chain_txs ("txHash","height","blockTime","code","gasWanted","gasUsed","memo","signer","fee","messageTypes","events","actions","fetchedAt")
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,now())
+		ON CONFLICT ("txHash") DO UPDATE SET
+			"height"=EXCLUDED."height", "blockTime"=EXCLUDED."blockTime", "code"=EXCLUDED."code",
+			"gasWanted"=EXCLUDED."gasWanted", "gasUsed"=EXCLUDED."gasUsed", "memo"=EXCLUDED."memo", "signer"=EXCLUDED."signer",
+			"fee"=EXCLUDED."fee", "messageTypes"=EXCLUDED."messageTypes", "events"=EXCLUDED."events", "actions"=EXCLUDED."actions",
+			"fetchedAt"=now()`,
+		tx.TxHash, tx.Height, tx.BlockTime, int64(tx.Code), tx.GasWanted, tx.GasUsed, tx.Memo, tx.Signer,
+		string(feeJSON), string(msgJSON), string(eventsJSON), string(actionsJSON))
+	if err != nil {
+		return fmt.Errorf("upsert chain tx: %w", err)
+	}
+	if err := replaceTxFlows(ctx, dbtx, tx.TxHash, tx.Flows); err != nil {
+		return err
+	}
+	return dbtx.Commit(ctx)
+}
+
+// GetChainTx returns the cached transaction with the given hash, or ErrNotFound.
+func GetChainTx(ctx context.Context, pool *pgxpool.Pool, hash string) (*ChainTx, error) {
+	var (
+		tx                          ChainTx
+		code                        int64
+		memo, signer                *string
+		feeJSON, msgJSON, eventJSON []byte
+		actionsJSON                 []byte
+	)
+	err := pool.QueryRow(ctx, `SELECT "txHash","height","blockTime","code","gasWanted","gasUsed","memo","signer","fee","messageTypes","events","actions","fetchedAt"
+		FROM chain_txs WHERE "txHash" = $1`, hash).Scan(
+		&tx.TxHash, &tx.Height, &tx.BlockTime, &code, &tx.GasWanted, &tx.GasUsed, &memo, &signer,
+		&feeJSON, &msgJSON, &eventJSON, &actionsJSON, &tx.FetchedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	tx.Code = uint32(code)
+	if memo != nil {
+		tx.Memo = *memo
+	}
+	if signer != nil {
+		tx.Signer = *signer
+	}
+
+	var fee []denomAmountJSON
+	if len(feeJSON) > 0 {
+		if err := json.Unmarshal(feeJSON, &fee); err != nil {
+			return nil, fmt.Errorf("decode fee: %w", err)
+		}
+	}
+	for _, c := range fee {
+		tx.Fee = append(tx.Fee, DenomAmount{Denom: c.Denom, Amount: c.Amount})
+	}
+	if len(msgJSON) > 0 {
+		if err := json.Unmarshal(msgJSON, &tx.MessageTypes); err != nil {
+			return nil, fmt.Errorf("decode message types: %w", err)
+		}
+	}
+	if len(eventJSON) > 0 {
+		if err := json.Unmarshal(eventJSON, &tx.Events); err != nil {
+			return nil, fmt.Errorf("decode events: %w", err)
+		}
+	}
+	if len(actionsJSON) > 0 {
+		if err := json.Unmarshal(actionsJSON, &tx.Actions); err != nil {
+			return nil, fmt.Errorf("decode actions: %w", err)
+		}
+	}
+	return &tx, nil
+}
+
+// GetActionTransactionsByHash returns the indexed action transactions with the given
+// hash; one transaction can carry lifecycle messages for several actions.
+func GetActionTransactionsByHash(ctx context.Context, pool *pgxpool.Pool, hash string) ([]ActionTransaction, error) {
+	rows, err := pool.Query(ctx, `SELECT "actionID","txType","txHash","height","blockTime","gasWanted","gasUsed","actionPrice","actionPriceDenom","flowPayer","flowPayee","txFee","txFeeDenom","createdAt","msgIndex"
+		FROM action_transactions
+		WHERE "txHash" = $1
+		ORDER BY "actionID", "txType", "msgIndex"`, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []ActionTransaction
+	for rows.Next() {
+		var t ActionTransaction
+		if err := rows.Scan(
+			&t.ActionID,
+			&t.TxType,
+			&t.TxHash,
+			&t.Height,
+			&t.BlockTime,
+			&t.GasWanted,
+			&t.GasUsed,
+			&t.ActionPrice,
+			&t.ActionPriceDenom,
+			&t.FlowPayer,
+			&t.FlowPayee,
+			&t.TxFee,
+			&t.TxFeeDenom,
+			&t.CreatedAt,
+			&t.MsgIndex,
+		); err != nil {
+			return nil, err
+		}
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
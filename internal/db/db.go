@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -19,6 +20,13 @@ type Pool = pgxpool.Pool
 
 // Connect opens a connection pool to Postgres using pgxpool.
 func Connect(ctx context.Context, dsn string, maxConns int32) (*pgxpool.Pool, error) {
+	return ConnectSchema(ctx, dsn, maxConns, "")
+}
+
+// ConnectSchema is Connect with every connection's search_path set to schema, which
+// is created if missing. Each network keeps its tables in its own schema; an empty
+// schema or "public" leaves the server default in place.
+func ConnectSchema(ctx context.Context, dsn string, maxConns int32, schema string) (*pgxpool.Pool, error) {
 	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("parse dsn: %w", err)
@@ -26,6 +34,10 @@ func Connect(ctx context.Context, dsn string, maxConns int32) (*pgxpool.Pool, er
 	if maxConns > 0 {
 		cfg.MaxConns = maxConns
 	}
+	custom := schema != "" && schema != "public"
+	if custom {
+		cfg.ConnConfig.RuntimeParams["search_path"] = pgx.Identifier{schema}.Sanitize()
+	}
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("connect db: %w", err)
@@ -36,6 +48,12 @@ func Connect(ctx context.Context, dsn string, maxConns int32) (*pgxpool.Pool, er
 		pool.Close()
 		return nil, fmt.Errorf("db ping: %w", err)
 	}
+	if custom {
+		if _, err := pool.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{schema}.Sanitize()); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("create schema %s: %w", schema, err)
+		}
+	}
 	return pool, nil
 }
 
@@ -145,7 +163,7 @@ func Bootstrap(ctx context.Context, pool *pgxpool.Pool) error {
 				"txFee"       TEXT,
 				"txFeeDenom"  TEXT,
 				"createdAt"   TIMESTAMP NOT NULL DEFAULT now(),
-				UNIQUE("actionID", "txType")
+				"msgIndex"    INTEGER NOT NULL DEFAULT 0
 			)`,
 		// Migration: Convert action_transactions.actionID from VARCHAR to BIGINT if needed
 		`DO $$ BEGIN
@@ -170,6 +188,215 @@ func Bootstrap(ctx context.Context, pool *pgxpool.Pool) error {
 		END $$`,
 		`ALTER TABLE action_transactions ADD COLUMN IF NOT EXISTS "txFee" TEXT`,
 		`ALTER TABLE action_transactions ADD COLUMN IF NOT EXISTS "txFeeDenom" TEXT`,
+		// An action can have several transactions of a type (retried finalizations, approvals
+		// by several supernodes, several messages in one tx): replace UNIQUE("actionID", "txType")
+		`ALTER TABLE action_transactions ADD COLUMN IF NOT EXISTS "msgIndex" INTEGER NOT NULL DEFAULT 0`,
+		`DO $$ DECLARE c TEXT; BEGIN
+			SELECT conname INTO c FROM pg_constraint
+			WHERE conrelid = 'action_transactions'::regclass AND contype = 'u' AND array_length(conkey, 1) = 2;
+			IF c IS NOT NULL THEN
+				EXECUTE format('ALTER TABLE action_transactions DROP CONSTRAINT %I', c);
+			END IF;
+		END $$`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_action_transactions_unique ON action_transactions ("actionID", "txType", "txHash", "msgIndex")`,
+		// Rows indexed before message indexes were recorded have msgIndex 0; drop those the
+		// same tx was indexed again for with its real index
+		`DELETE FROM action_transactions legacy
+			USING action_transactions t
+			WHERE legacy."actionID" = t."actionID" AND legacy."txType" = t."txType" AND legacy."txHash" = t."txHash"
+				AND legacy."msgIndex" = 0 AND t."msgIndex" <> 0`,
+		// Sync checkpoints let background loops resume incrementally instead of rescanning the chain
+		`CREATE TABLE IF NOT EXISTS sync_checkpoints (
+				"name"           TEXT PRIMARY KEY,
+				"lastHeight"     BIGINT NOT NULL DEFAULT 0,
+				"lastActionID"   BIGINT NOT NULL DEFAULT 0,
+				"lastFullSyncAt" TIMESTAMP,
+				"updatedAt"      TIMESTAMP NOT NULL DEFAULT now()
+			)`,
+		`CREATE INDEX IF NOT EXISTS idx_actions_state ON actions ("state")`,
+		// Append-only log of action changes backing /v1/stream/actions
+		`CREATE TABLE IF NOT EXISTS action_events (
+				"id"         BIGSERIAL PRIMARY KEY,
+				"eventType"  TEXT NOT NULL,
+				"actionID"   BIGINT NOT NULL,
+				"actionType" TEXT,
+				"creator"    TEXT,
+				"superNodes" JSONB,
+				"state"      TEXT,
+				"prevState"  TEXT,
+				"txType"     TEXT,
+				"txHash"     TEXT,
+				"height"     BIGINT,
+				"createdAt"  TIMESTAMP NOT NULL DEFAULT now()
+			)`,
+		`CREATE INDEX IF NOT EXISTS idx_action_events_created_at ON action_events ("createdAt")`,
+		// Supernode change detection: last probe availability plus a log of discrete changes
+		`ALTER TABLE supernodes ADD COLUMN IF NOT EXISTS "isFullyAvailable" BOOLEAN`,
+		`CREATE TABLE IF NOT EXISTS supernode_events (
+				"id"               BIGSERIAL PRIMARY KEY,
+				"eventType"        TEXT NOT NULL,
+				"supernodeAccount" TEXT NOT NULL,
+				"validatorAddress" TEXT NOT NULL DEFAULT '',
+				"oldValue"         TEXT NOT NULL DEFAULT '',
+				"newValue"         TEXT NOT NULL DEFAULT '',
+				"height"           BIGINT,
+				"details"          JSONB,
+				"createdAt"        TIMESTAMP NOT NULL DEFAULT now()
+			)`,
+		`CREATE INDEX IF NOT EXISTS idx_supernode_events_account ON supernode_events ("supernodeAccount", "id")`,
+		`CREATE INDEX IF NOT EXISTS idx_supernode_events_validator ON supernode_events ("validatorAddress", "id")`,
+		`CREATE INDEX IF NOT EXISTS idx_supernode_events_created_at ON supernode_events ("createdAt")`,
+		`ALTER TABLE sync_checkpoints ADD COLUMN IF NOT EXISTS "lastEventID" BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE sync_checkpoints ADD COLUMN IF NOT EXISTS "backfillHeight" BIGINT NOT NULL DEFAULT 0`,
+		// Outbound webhooks: subscriptions, per-event deliveries and exhausted deliveries
+		`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+				"id"          BIGSERIAL PRIMARY KEY,
+				"url"         TEXT NOT NULL,
+				"secret"      TEXT NOT NULL,
+				"eventTypes"  TEXT[] NOT NULL,
+				"filters"     JSONB NOT NULL DEFAULT '{}'::jsonb,
+				"active"      BOOLEAN NOT NULL DEFAULT true,
+				"description" TEXT NOT NULL DEFAULT '',
+				"createdAt"   TIMESTAMP NOT NULL DEFAULT now(),
+				"updatedAt"   TIMESTAMP NOT NULL DEFAULT now()
+			)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+				"id"             BIGSERIAL PRIMARY KEY,
+				"subscriptionID" BIGINT NOT NULL REFERENCES webhook_subscriptions("id") ON DELETE CASCADE,
+				"eventKey"       TEXT NOT NULL,
+				"eventType"      TEXT NOT NULL,
+				"payload"        JSONB NOT NULL,
+				"status"         TEXT NOT NULL DEFAULT 'pending',
+				"attempts"       INTEGER NOT NULL DEFAULT 0,
+				"nextAttemptAt"  TIMESTAMP NOT NULL DEFAULT now(),
+				"lastStatusCode" INTEGER,
+				"lastError"      TEXT,
+				"createdAt"      TIMESTAMP NOT NULL DEFAULT now(),
+				"updatedAt"      TIMESTAMP NOT NULL DEFAULT now(),
+				"deliveredAt"    TIMESTAMP,
+				UNIQUE ("subscriptionID", "eventKey")
+			)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries ("nextAttemptAt") WHERE "status" = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_done ON webhook_deliveries ("updatedAt") WHERE "status" <> 'pending'`,
+		`CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+				"id"             BIGSERIAL PRIMARY KEY,
+				"deliveryID"     BIGINT NOT NULL,
+				"subscriptionID" BIGINT NOT NULL REFERENCES webhook_subscriptions("id") ON DELETE CASCADE,
+				"eventType"      TEXT NOT NULL,
+				"payload"        JSONB NOT NULL,
+				"attempts"       INTEGER NOT NULL,
+				"lastError"      TEXT NOT NULL DEFAULT '',
+				"createdAt"      TIMESTAMP NOT NULL DEFAULT now()
+			)`,
+		// Probe history: raw samples plus 5m/1h rollups (averages weighted by availableCount)
+		`CREATE TABLE IF NOT EXISTS supernode_probe_samples (
+				"supernodeAccount"    TEXT NOT NULL,
+				"sampledAt"           TIMESTAMP NOT NULL,
+				"statusApiAvailable"  BOOLEAN NOT NULL,
+				"fullyAvailable"      BOOLEAN NOT NULL,
+				"cpuUsagePercent"     DOUBLE PRECISION,
+				"memoryUsagePercent"  DOUBLE PRECISION,
+				"memoryUsedGb"        DOUBLE PRECISION,
+				"storageUsagePercent" DOUBLE PRECISION,
+				"storageUsedBytes"    BIGINT,
+				"peersCount"          INTEGER,
+				"p2pDbSizeMb"         DOUBLE PRECISION,
+				"p2pRecords"          BIGINT,
+				"uptimeSeconds"       BIGINT,
+				PRIMARY KEY ("supernodeAccount", "sampledAt")
+			)`,
+		`CREATE INDEX IF NOT EXISTS idx_probe_samples_sampled_at ON supernode_probe_samples ("sampledAt")`,
+		`CREATE TABLE IF NOT EXISTS supernode_probe_rollups (
+				"supernodeAccount"    TEXT NOT NULL,
+				"resolution"          TEXT NOT NULL,
+				"bucketStart"         TIMESTAMP NOT NULL,
+				"sampleCount"         INTEGER NOT NULL,
+				"availableCount"      INTEGER NOT NULL,
+				"fullyAvailableCount" INTEGER NOT NULL,
+				"cpuUsagePercent"     DOUBLE PRECISION,
+				"memoryUsagePercent"  DOUBLE PRECISION,
+				"memoryUsedGb"        DOUBLE PRECISION,
+				"storageUsagePercent" DOUBLE PRECISION,
+				"storageUsedBytes"    DOUBLE PRECISION,
+				"peersCount"          DOUBLE PRECISION,
+				"p2pDbSizeMb"         DOUBLE PRECISION,
+				"p2pRecords"          DOUBLE PRECISION,
+				"uptimeSeconds"       DOUBLE PRECISION,
+				PRIMARY KEY ("supernodeAccount", "resolution", "bucketStart")
+			)`,
+		`CREATE INDEX IF NOT EXISTS idx_probe_rollups_bucket ON supernode_probe_rollups ("resolution", "bucketStart")`,
+		// Availability (SLA): per-port probe outcomes plus materialized rolling-window ratios
+		`ALTER TABLE supernode_probe_samples ADD COLUMN IF NOT EXISTS "port1Open" BOOLEAN`,
+		`ALTER TABLE supernode_probe_samples ADD COLUMN IF NOT EXISTS "p2pOpen" BOOLEAN`,
+		`ALTER TABLE supernode_probe_rollups ADD COLUMN IF NOT EXISTS "port1OpenCount" INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE supernode_probe_rollups ADD COLUMN IF NOT EXISTS "p2pOpenCount" INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE supernodes ADD COLUMN IF NOT EXISTS "availability24h" DOUBLE PRECISION`,
+		`ALTER TABLE supernodes ADD COLUMN IF NOT EXISTS "availability7d" DOUBLE PRECISION`,
+		`ALTER TABLE supernodes ADD COLUMN IF NOT EXISTS "availability30d" DOUBLE PRECISION`,
+		// API keys (stored as SHA-256 hashes) for authentication and per-key rate limits
+		`CREATE TABLE IF NOT EXISTS api_keys (
+				"id"                 BIGSERIAL PRIMARY KEY,
+				"name"               TEXT NOT NULL,
+				"keyPrefix"          TEXT NOT NULL,
+				"keyHash"            TEXT NOT NULL UNIQUE,
+				"tier"               TEXT NOT NULL,
+				"rateLimitPerMinute" DOUBLE PRECISION,
+				"rateLimitBurst"     INTEGER,
+				"createdAt"          TIMESTAMP NOT NULL DEFAULT now(),
+				"revokedAt"          TIMESTAMP,
+				"lastUsedAt"         TIMESTAMP
+			)`,
+		// Action timeseries buckets transactions by block time
+		`CREATE INDEX IF NOT EXISTS idx_action_transactions_block_time ON action_transactions ("blockTime")`,
+		// Account profiles look up actions by creator and transfers by payer/payee
+		`CREATE INDEX IF NOT EXISTS idx_actions_creator ON actions ("creator")`,
+		`CREATE INDEX IF NOT EXISTS idx_action_transactions_flow_payer ON action_transactions ("flowPayer")`,
+		`CREATE INDEX IF NOT EXISTS idx_action_transactions_flow_payee ON action_transactions ("flowPayee")`,
+		// Search: prefix lookups on hashes and addresses, file name words, metadata filters,
+		// and trigram indexes for fuzzy matching when pg_trgm can be installed
+		`CREATE INDEX IF NOT EXISTS idx_action_transactions_tx_hash_pattern ON action_transactions ("txHash" text_pattern_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_actions_creator_pattern ON actions ("creator" varchar_pattern_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_actions_file_name_tsv ON actions USING GIN (` + fileNameTSVector + `)`,
+		`CREATE INDEX IF NOT EXISTS idx_actions_metadata ON actions USING GIN ("metadataJSON" jsonb_path_ops)`,
+		`DO $$ BEGIN
+			CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
+		EXCEPTION WHEN OTHERS THEN
+			RAISE NOTICE 'pg_trgm unavailable, fuzzy search disabled: %', SQLERRM;
+		END $$`,
+		`DO $$ BEGIN
+			IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm' AND extnamespace = 'public'::regnamespace) THEN
+				CREATE INDEX IF NOT EXISTS idx_actions_file_name_trgm ON actions USING GIN (("metadataJSON"->>'file_name') public.gin_trgm_ops);
+			END IF;
+		END $$`,
+		// Transactions fetched from the LCD by /v1/txs/{hash}
+		`CREATE TABLE IF NOT EXISTS chain_txs (
+				"txHash"       TEXT PRIMARY KEY,
+				"height"       BIGINT NOT NULL,
+				"blockTime"    TIMESTAMP NOT NULL,
+				"code"         BIGINT NOT NULL DEFAULT 0,
+				"gasWanted"    BIGINT,
+				"gasUsed"      BIGINT,
+				"memo"         TEXT,
+				"signer"       TEXT,
+				"fee"          JSONB,
+				"messageTypes" JSONB,
+				"events"       JSONB,
+				"actions"      JSONB,
+				"fetchedAt"    TIMESTAMP NOT NULL DEFAULT now()
+			)`,
+		// Every transfer, coin_spent, coin_received and burn coin of a transaction
+		`CREATE TABLE IF NOT EXISTS tx_flows (
+				"txHash"     TEXT NOT NULL,
+				"eventIndex" INT NOT NULL,
+				"coinIndex"  INT NOT NULL,
+				"msgIndex"   INT,
+				"eventType"  TEXT NOT NULL,
+				"sender"     TEXT,
+				"recipient"  TEXT,
+				"amount"     TEXT NOT NULL,
+				"denom"      TEXT NOT NULL,
+				PRIMARY KEY ("txHash", "eventIndex", "coinIndex")
+			)`,
 	}
 	for _, s := range stmts {
 		if _, err := pool.Exec(ctx, s); err != nil {
@@ -365,9 +592,20 @@ func UpdateSupernodeProbeData(ctx context.Context, pool *pgxpool.Pool, sn Supern
 	return nil
 }
 
-// UpsertAction inserts/updates an action record.
-func UpsertAction(ctx context.Context, pool *pgxpool.Pool, a ActionDB) error {
-	sql := `INSERT INTO actions ("actionID","creator","actionType","state","blockHeight","priceDenom","priceAmount","expirationTime","metadataRaw","metadataJSON","superNodes","mimeType","size","createdAt","updatedAt")
+// UpsertAction inserts/updates an action record and reports whether it was new
+// and, if not, the state it had before the update.
+func UpsertAction(ctx context.Context, pool *pgxpool.Pool, a ActionDB) (ActionChange, error) {
+	return upsertAction(ctx, pool, a)
+}
+
+func upsertAction(ctx context.Context, q querier, a ActionDB) (ActionChange, error) {
+	// The prev CTE reads the row as it was before this statement, so the caller can
+	// tell inserts and state transitions apart from no-op refreshes. A zero size
+	// keeps the stored one: the gRPC client can't read the LCD's fileSizeKbs. A
+	// snapshot behind the stored row in the lifecycle, read before a concurrent
+	// writer stored the newer one, is not written at all.
+	sql := `WITH prev AS (SELECT "state" FROM actions WHERE "actionID" = $1)
+	INSERT INTO actions ("actionID","creator","actionType","state","blockHeight","priceDenom","priceAmount","expirationTime","metadataRaw","metadataJSON","superNodes","mimeType","size","createdAt","updatedAt")
 	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10::jsonb,$11::jsonb,$12,$13,now(),now())
 	ON CONFLICT ("actionID") DO UPDATE SET
 		"creator"=EXCLUDED."creator",
@@ -381,12 +619,36 @@ func UpsertAction(ctx context.Context, pool *pgxpool.Pool, a ActionDB) error {
 		"metadataJSON"=EXCLUDED."metadataJSON",
 		"superNodes"=EXCLUDED."superNodes",
 		"mimeType"=EXCLUDED."mimeType",
-		"size"=EXCLUDED."size",
-		"updatedAt"=now()`
-	_, err := pool.Exec(ctx, sql,
+		"size"=CASE WHEN EXCLUDED."size" > 0 THEN EXCLUDED."size" ELSE actions."size" END,
+		"updatedAt"=now()
+	WHERE ` + actionStateRank(`EXCLUDED."state"`) + ` >= ` + actionStateRank(`actions."state"`) + `
+	RETURNING (SELECT "state" FROM prev), (SELECT COUNT(*) FROM prev) = 0`
+	var prevState *string
+	var change ActionChange
+	err := q.QueryRow(ctx, sql,
 		a.ActionID, a.Creator, a.ActionType, a.State, a.BlockHeight, a.PriceDenom, a.PriceAmount, a.ExpirationTime, a.MetadataRaw, a.MetadataJSON, a.SuperNodes, a.MimeType, a.Size,
-	)
-	return err
+	).Scan(&prevState, &change.Inserted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ActionChange{Stale: true}, nil
+	}
+	if err != nil {
+		return ActionChange{}, err
+	}
+	if prevState != nil {
+		change.PrevState = *prevState
+	}
+	return change, nil
+}
+
+// actionStateRank returns an SQL expression ordering the action state expression state
+// along the lifecycle: pending, processing, done, then the terminal states. Unknown
+// states rank lowest, so any known state replaces them.
+func actionStateRank(state string) string {
+	return fmt.Sprintf(`CASE WHEN %[1]s = 'ACTION_STATE_PENDING' THEN 1
+		WHEN %[1]s = 'ACTION_STATE_PROCESSING' THEN 2
+		WHEN %[1]s = '%[2]s' THEN 3
+		WHEN %[1]s IN ('%[3]s') THEN 4
+		ELSE 0 END`, state, actionStateDone, strings.Join(terminalActionStates, "','"))
 }
 
 // ListKnownSupernodes returns supernode accounts and last known IP/port to probe.
@@ -424,7 +686,7 @@ func listSupernodeMetricsFiltered(ctx context.Context, pool *pgxpool.Pool, f Sup
 		argPos     = 1
 	)
 
-	sb.WriteString(`SELECT "supernodeAccount","validatorAddress","validatorMoniker","currentState","currentStateHeight","ipAddress","p2pPort","protocolVersion","actualVersion","cpuUsagePercent","cpuCores","memoryTotalGb","memoryUsedGb","memoryUsagePercent","storageTotalBytes","storageUsedBytes","storageUsagePercent","hardwareSummary","peersCount","uptimeSeconds",rank,"registeredServices","runningTasks","stateHistory",evidence,"prevIpAddresses","lastStatusCheck","isStatusApiAvailable","metricsReport","lastSuccessfulProbe","failedProbeCounter",COALESCE("lastKnownActualVersion",''),"p2pDbSizeMb","p2pRecords"
+	sb.WriteString(`SELECT "supernodeAccount","validatorAddress","validatorMoniker","currentState","currentStateHeight","ipAddress","p2pPort","protocolVersion","actualVersion","cpuUsagePercent","cpuCores","memoryTotalGb","memoryUsedGb","memoryUsagePercent","storageTotalBytes","storageUsedBytes","storageUsagePercent","hardwareSummary","peersCount","uptimeSeconds",rank,"registeredServices","runningTasks","stateHistory",evidence,"prevIpAddresses","lastStatusCheck","isStatusApiAvailable","metricsReport","lastSuccessfulProbe","failedProbeCounter",COALESCE("lastKnownActualVersion",''),"p2pDbSizeMb","p2pRecords","availability24h","availability7d","availability30d"
 		FROM supernodes`)
 
 	// Legacy CurrentState filter for "running"/"stopped"/"any"
@@ -469,10 +731,34 @@ func listSupernodeMetricsFiltered(ctx context.Context, pool *pgxpool.Pool, f Sup
 		argPos++
 	}
 
+	for _, w := range AvailabilityWindows {
+		if minRatio, ok := f.MinAvailability[w.Name]; ok {
+			conditions = append(conditions, fmt.Sprintf(`"%s" >= $%d`, w.column, argPos))
+			args = append(args, minRatio)
+			argPos++
+		}
+	}
+
+	// Keyset pagination: by account, or by an availability ratio (NULLs sort as -1) then account
+	sortKey := ""
+	if w, ok := AvailabilityWindowByName(f.SortWindow); ok {
+		sortKey = fmt.Sprintf(`COALESCE("%s", -1)`, w.column)
+	}
 	if f.CursorAccount != nil {
-		conditions = append(conditions, fmt.Sprintf(`"supernodeAccount" > $%d`, argPos))
-		args = append(args, *f.CursorAccount)
-		argPos++
+		if sortKey != "" && f.CursorValue != nil {
+			cmp := ">"
+			if f.SortDesc {
+				cmp = "<"
+			}
+			conditions = append(conditions, fmt.Sprintf(`(%s %s $%d OR (%s = $%d AND "supernodeAccount" > $%d))`,
+				sortKey, cmp, argPos, sortKey, argPos, argPos+1))
+			args = append(args, *f.CursorValue, *f.CursorAccount)
+			argPos += 2
+		} else {
+			conditions = append(conditions, fmt.Sprintf(`"supernodeAccount" > $%d`, argPos))
+			args = append(args, *f.CursorAccount)
+			argPos++
+		}
 	}
 
 	if len(conditions) > 0 {
@@ -480,7 +766,15 @@ func listSupernodeMetricsFiltered(ctx context.Context, pool *pgxpool.Pool, f Sup
 		sb.WriteString(strings.Join(conditions, " AND "))
 	}
 
-	sb.WriteString(` ORDER BY "supernodeAccount" ASC`)
+	if sortKey != "" {
+		dir := "ASC"
+		if f.SortDesc {
+			dir = "DESC"
+		}
+		sb.WriteString(fmt.Sprintf(` ORDER BY %s %s, "supernodeAccount" ASC`, sortKey, dir))
+	} else {
+		sb.WriteString(` ORDER BY "supernodeAccount" ASC`)
+	}
 	sb.WriteString(fmt.Sprintf(" LIMIT $%d", argPos))
 	args = append(args, limit+1)
 
@@ -536,6 +830,9 @@ func listSupernodeMetricsFiltered(ctx context.Context, pool *pgxpool.Pool, f Sup
 			&sn.LastKnownActualVersion,
 			&sn.P2PDbSizeMb,
 			&sn.P2PRecords,
+			&sn.Availability24h,
+			&sn.Availability7d,
+			&sn.Availability30d,
 		); err != nil {
 			return nil, false, err
 		}
@@ -658,6 +955,9 @@ type SupernodeDB struct {
 	LastKnownActualVersion string
 	P2PDbSizeMb            *float64
 	P2PRecords             *int64
+	Availability24h        *float64
+	Availability7d         *float64
+	Availability30d        *float64
 }
 
 type SupernodeMetricsFilter struct {
@@ -668,6 +968,11 @@ type SupernodeMetricsFilter struct {
 	MinFailed     int
 	Limit         int
 	CursorAccount *string
+
+	MinAvailability map[string]float64 // window name ("24h", "7d", "30d") -> minimum overall ratio
+	SortWindow      string             // availability window to sort by; empty sorts by account
+	SortDesc        bool
+	CursorValue     *float64 // sort key of the cursor row when SortWindow is set
 }
 
 type ActionDB struct {
@@ -694,6 +999,7 @@ type ActionsFilter struct {
 	Supernode  *string
 	FromHeight *int64
 	ToHeight   *int64
+	Metadata   []MetadataFilter // all must match
 	Limit      int
 	CursorTS   *time.Time
 	CursorID   *uint64
@@ -745,6 +1051,8 @@ type ActionTransaction struct {
 	TxFee            *string
 	TxFeeDenom       *string
 	CreatedAt        time.Time
+	MsgIndex         int      // index of the message in the tx that emitted the action event
+	Flows            []TxFlow // every bank event of the tx; not stored by UpsertActionTransaction
 }
 
 // ListAllActions fetches all actions from the database ordered by block height descending
@@ -833,6 +1141,12 @@ func ListActionsFiltered(ctx context.Context, pool *pgxpool.Pool, f ActionsFilte
 		args = append(args, *f.ToHeight)
 		argPos++
 	}
+	for _, m := range f.Metadata {
+		cond, condArgs := metadataCondition(m, argPos)
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
+		argPos += len(condArgs)
+	}
 	if f.CursorID != nil {
 		// Cast actionID to BIGINT for proper numerical comparison (handles legacy TEXT columns)
 		conditions = append(conditions, fmt.Sprintf(`"actionID"::BIGINT < $%d`, argPos))
@@ -937,7 +1251,8 @@ func GetSupernodeByID(ctx context.Context, pool *pgxpool.Pool, supernodeAccount
 			"lastStatusCheck","isStatusApiAvailable",
 			"metricsReport",
 			"lastSuccessfulProbe","failedProbeCounter",COALESCE("lastKnownActualVersion",''),
-			"p2pDbSizeMb","p2pRecords"
+			"p2pDbSizeMb","p2pRecords",
+			"availability24h","availability7d","availability30d"
 		FROM supernodes
 		WHERE "supernodeAccount" = $1`
 
@@ -977,6 +1292,9 @@ func GetSupernodeByID(ctx context.Context, pool *pgxpool.Pool, supernodeAccount
 		&sn.LastKnownActualVersion,
 		&sn.P2PDbSizeMb,
 		&sn.P2PRecords,
+		&sn.Availability24h,
+		&sn.Availability7d,
+		&sn.Availability30d,
 	)
 	if err != nil {
 		if err.Error() == "no rows in result set" {
@@ -1224,7 +1542,7 @@ func GetActionStatsExtended(ctx context.Context, pool *pgxpool.Pool, filter Acti
 	var fromClause string
 	if needsJoin {
 		fromClause = `FROM actions a
-			INNER JOIN action_transactions at ON a."actionID" = at."actionID" AND at."txType" = 'register'`
+			INNER JOIN action_transactions at ON a."actionID" = at."actionID" AND at."txType" = 'register' AND ` + firstOfType("at")
 	} else {
 		fromClause = `FROM actions a`
 	}
@@ -1338,15 +1656,30 @@ func GetAggregatedHardwareStats(ctx context.Context, pool *pgxpool.Pool) (*Hardw
 	return &stats, nil
 }
 
-// UpsertActionTransaction inserts or updates an action transaction record.
-// The unique constraint on (actionID, txType) ensures only one transaction per type per action.
-func UpsertActionTransaction(ctx context.Context, pool *pgxpool.Pool, tx *ActionTransaction) error {
-	sql := `INSERT INTO action_transactions (
-		"actionID","txType","txHash","height","blockTime","gasWanted","gasUsed","actionPrice","actionPriceDenom","flowPayer","flowPayee","txFee","txFeeDenom","createdAt"
+// UpsertActionTransaction inserts or updates an action transaction record, keyed by
+// (actionID, txType, txHash, msgIndex): an action can have several transactions of a
+// type. Recording a real transaction drops the action's "not found" placeholder. A tx
+// acts on an action once per type, so a row of the same tx under another message
+// index, stored before message indexes were recorded, is replaced.
+// Returns true if the row is new.
+func UpsertActionTransaction(ctx context.Context, pool *pgxpool.Pool, tx *ActionTransaction) (bool, error) {
+	return upsertActionTransaction(ctx, pool, tx)
+}
+
+func upsertActionTransaction(ctx context.Context, q querier, tx *ActionTransaction) (bool, error) {
+	sql := `WITH cleared AS (
+		DELETE FROM action_transactions
+		WHERE "actionID" = $1 AND "txHash" = '_NO_TX_FOUND_' AND $3 <> '_NO_TX_FOUND_'
+	), replaced AS (
+		DELETE FROM action_transactions
+		WHERE "actionID" = $1 AND "txType" = $2 AND "txHash" = $3 AND "msgIndex" <> $14
+		RETURNING 1
+	)
+	INSERT INTO action_transactions (
+		"actionID","txType","txHash","height","blockTime","gasWanted","gasUsed","actionPrice","actionPriceDenom","flowPayer","flowPayee","txFee","txFeeDenom","msgIndex","createdAt"
 	) VALUES (
-		$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,now()
-	) ON CONFLICT ("actionID", "txType") DO UPDATE SET
-		"txHash"=EXCLUDED."txHash",
+		$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,now()
+	) ON CONFLICT ("actionID", "txType", "txHash", "msgIndex") DO UPDATE SET
 		"height"=EXCLUDED."height",
 		"blockTime"=EXCLUDED."blockTime",
 		"gasWanted"=EXCLUDED."gasWanted",
@@ -1356,23 +1689,34 @@ func UpsertActionTransaction(ctx context.Context, pool *pgxpool.Pool, tx *Action
 		"flowPayer"=EXCLUDED."flowPayer",
 		"flowPayee"=EXCLUDED."flowPayee",
 		"txFee"=EXCLUDED."txFee",
-		"txFeeDenom"=EXCLUDED."txFeeDenom"`
-	_, err := pool.Exec(ctx, sql,
+		"txFeeDenom"=EXCLUDED."txFeeDenom"
+	RETURNING (xmax = 0) AND NOT EXISTS (SELECT 1 FROM replaced)`
+	var inserted bool
+	err := q.QueryRow(ctx, sql,
 		tx.ActionID, tx.TxType, tx.TxHash, tx.Height, tx.BlockTime,
 		tx.GasWanted, tx.GasUsed,
 		tx.ActionPrice, tx.ActionPriceDenom, tx.FlowPayer, tx.FlowPayee,
-		tx.TxFee, tx.TxFeeDenom,
-	)
-	return err
+		tx.TxFee, tx.TxFeeDenom, tx.MsgIndex,
+	).Scan(&inserted)
+	return inserted, err
+}
+
+// firstOfType is a condition keeping only the earliest indexed transaction of its
+// (action, type) for the action_transactions alias. Lifecycle milestones use the first
+// transaction; later ones are retries or other supernodes' finalizations and approvals.
+func firstOfType(alias string) string {
+	return fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM action_transactions prior
+		WHERE prior."actionID" = %[1]s."actionID" AND prior."txType" = %[1]s."txType" AND prior."txHash" <> '_NO_TX_FOUND_'
+			AND (prior."height", prior."msgIndex", prior."txHash") < (%[1]s."height", %[1]s."msgIndex", %[1]s."txHash"))`, alias)
 }
 
 // GetActionTransactions fetches all transactions for a given action ID.
 // Returns transactions ordered by height ascending.
 func GetActionTransactions(ctx context.Context, pool *pgxpool.Pool, actionID uint64) ([]ActionTransaction, error) {
-	query := `SELECT "actionID","txType","txHash","height","blockTime","gasWanted","gasUsed","actionPrice","actionPriceDenom","flowPayer","flowPayee","txFee","txFeeDenom","createdAt"
+	query := `SELECT "actionID","txType","txHash","height","blockTime","gasWanted","gasUsed","actionPrice","actionPriceDenom","flowPayer","flowPayee","txFee","txFeeDenom","createdAt","msgIndex"
 		FROM action_transactions
 		WHERE "actionID" = $1
-		ORDER BY "height" ASC`
+		ORDER BY "height" ASC, "msgIndex" ASC`
 
 	rows, err := pool.Query(ctx, query, actionID)
 	if err != nil {
@@ -1398,6 +1742,7 @@ func GetActionTransactions(ctx context.Context, pool *pgxpool.Pool, actionID uin
 			&t.TxFee,
 			&t.TxFeeDenom,
 			&t.CreatedAt,
+			&t.MsgIndex,
 		); err != nil {
 			return nil, err
 		}
@@ -1509,29 +1854,48 @@ func GetActionsAfterCursor(ctx context.Context, pool *pgxpool.Pool, cursorAction
 	return actions, rows.Err()
 }
 
+// CountUnenrichedActions returns how many actions with ID >= minID still lack a 'register' transaction.
+// A maxHeight above 0 only counts actions registered at or below it (see GetUnenrichedActions).
+func CountUnenrichedActions(ctx context.Context, pool *pgxpool.Pool, minID uint64, maxHeight int64) (int64, error) {
+	var n int64
+	err := pool.QueryRow(ctx, `SELECT COUNT(*)
+	FROM actions a
+	WHERE a."actionID" >= $1
+	  AND ($2::BIGINT = 0 OR a."blockHeight" IS NULL OR a."blockHeight" <= $2)
+	  AND NOT EXISTS (
+	    SELECT 1 FROM action_transactions at
+	    WHERE at."actionID" = a."actionID" AND at."txType" = 'register'
+	  )`, minID, maxHeight).Scan(&n)
+	return n, err
+}
+
 // GetUnenrichedActions retrieves actions that don't have a 'register' transaction yet.
 // This allows the enricher to process only actions needing enrichment instead of all actions.
 // Pass minID=0 to start from the beginning. Returns up to `limit` actions sorted numerically.
-func GetUnenrichedActions(ctx context.Context, pool *pgxpool.Pool, minID uint64, limit int) ([]Action, error) {
+// A maxHeight above 0 skips actions registered above it, which the block indexer has yet
+// to reach; actions with an unknown height are always returned.
+func GetUnenrichedActions(ctx context.Context, pool *pgxpool.Pool, minID uint64, maxHeight int64, limit int) ([]Action, error) {
 	if limit <= 0 {
 		limit = 100
 	}
 
 	// Select actions where:
 	// 1. actionID >= minID (actionID is now BIGINT)
-	// 2. No entry exists in action_transactions with txType='register' for this action
+	// 2. blockHeight <= maxHeight, unless maxHeight is 0
+	// 3. No entry exists in action_transactions with txType='register' for this action
 	query := `SELECT
 		a."actionID", a."creator", a."actionType", a."state", a."superNodes", a."createdAt"
 	FROM actions a
 	WHERE a."actionID" >= $1
+	  AND ($2::BIGINT = 0 OR a."blockHeight" IS NULL OR a."blockHeight" <= $2)
 	  AND NOT EXISTS (
 	    SELECT 1 FROM action_transactions at
 	    WHERE at."actionID" = a."actionID" AND at."txType" = 'register'
 	  )
 	ORDER BY a."actionID" ASC
-	LIMIT $2`
+	LIMIT $3`
 
-	rows, err := pool.Query(ctx, query, minID, limit)
+	rows, err := pool.Query(ctx, query, minID, maxHeight, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -1564,29 +1928,37 @@ func GetUnenrichedActions(ctx context.Context, pool *pgxpool.Pool, minID uint64,
 
 // extractFirstSupernode extracts the first supernode account from a JSONB array.
 func extractFirstSupernode(superNodes any) string {
+	if accounts := SupernodeAccounts(superNodes); len(accounts) > 0 {
+		return accounts[0]
+	}
+	return ""
+}
+
+// SupernodeAccounts returns the supernode accounts of an action's superNodes JSONB array.
+func SupernodeAccounts(superNodes any) []string {
 	switch v := superNodes.(type) {
 	case []byte:
 		var arr []string
-		if err := json.Unmarshal(v, &arr); err == nil && len(arr) > 0 {
-			return arr[0]
+		if err := json.Unmarshal(v, &arr); err == nil {
+			return arr
 		}
 	case string:
 		var arr []string
-		if err := json.Unmarshal([]byte(v), &arr); err == nil && len(arr) > 0 {
-			return arr[0]
+		if err := json.Unmarshal([]byte(v), &arr); err == nil {
+			return arr
 		}
 	case []any:
-		if len(v) > 0 {
-			if s, ok := v[0].(string); ok {
-				return s
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
 			}
 		}
+		return out
 	case []string:
-		if len(v) > 0 {
-			return v[0]
-		}
+		return v
 	}
-	return ""
+	return nil
 }
 
 // HasActionTransaction checks if a transaction of the given type already exists for an action.
@@ -1608,10 +1980,10 @@ func GetActionTransactionsByActionIDs(ctx context.Context, pool *pgxpool.Pool, a
 
 	// Build the query with IN clause
 	var sb strings.Builder
-	sb.WriteString(`SELECT "actionID","txType","txHash","height","blockTime","gasWanted","gasUsed","actionPrice","actionPriceDenom","flowPayer","flowPayee","txFee","txFeeDenom","createdAt"
+	sb.WriteString(`SELECT "actionID","txType","txHash","height","blockTime","gasWanted","gasUsed","actionPrice","actionPriceDenom","flowPayer","flowPayee","txFee","txFeeDenom","createdAt","msgIndex"
 		FROM action_transactions
 		WHERE "actionID" = ANY($1)
-		ORDER BY "actionID", "height" ASC`)
+		ORDER BY "actionID", "height" ASC, "msgIndex" ASC`)
 
 	rows, err := pool.Query(ctx, sb.String(), actionIDs)
 	if err != nil {
@@ -1637,6 +2009,7 @@ func GetActionTransactionsByActionIDs(ctx context.Context, pool *pgxpool.Pool, a
 			&t.TxFee,
 			&t.TxFeeDenom,
 			&t.CreatedAt,
+			&t.MsgIndex,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,383 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExportType is the type of an export column. Row values are nil or, by type: string,
+// int64, float64, bool, time.Time (UTC) and, for ExportJSON, the JSON text as a string.
+type ExportType int
+
+const (
+	ExportString ExportType = iota
+	ExportInt64
+	ExportFloat64
+	ExportBool
+	ExportTime
+	ExportJSON
+)
+
+// ExportColumn is one column of an export dataset.
+type ExportColumn struct {
+	Name string
+	Type ExportType
+	expr string // SQL select expression, cast so it scans as Type
+}
+
+// Export filter names, as used in query parameters and CLI flags.
+const (
+	ExportFilterType         = "type"
+	ExportFilterCreator      = "creator"
+	ExportFilterState        = "state"
+	ExportFilterSupernode    = "supernode"
+	ExportFilterFromHeight   = "from"
+	ExportFilterToHeight     = "to"
+	ExportFilterFromTime     = "fromTime"
+	ExportFilterToTime       = "toTime"
+	ExportFilterTxType       = "txType"
+	ExportFilterCurrentState = "currentState"
+	ExportFilterVersion      = "version"
+)
+
+// ExportFilter narrows an export. Action filters match ActionsFilter; the time range
+// applies to the register transaction's block time for actions and to the block time
+// of each transaction for action-transactions.
+type ExportFilter struct {
+	Type         *string
+	Creator      *string
+	State        *string
+	Supernode    *string
+	FromHeight   *int64
+	ToHeight     *int64
+	FromTime     *time.Time
+	ToTime       *time.Time
+	TxType       *string
+	CurrentState *string
+	Version      *string
+}
+
+// set returns the names of the filters that are set.
+func (f ExportFilter) set() []string {
+	var names []string
+	for _, c := range []struct {
+		name string
+		set  bool
+	}{
+		{ExportFilterType, f.Type != nil},
+		{ExportFilterCreator, f.Creator != nil},
+		{ExportFilterState, f.State != nil},
+		{ExportFilterSupernode, f.Supernode != nil},
+		{ExportFilterFromHeight, f.FromHeight != nil},
+		{ExportFilterToHeight, f.ToHeight != nil},
+		{ExportFilterFromTime, f.FromTime != nil},
+		{ExportFilterToTime, f.ToTime != nil},
+		{ExportFilterTxType, f.TxType != nil},
+		{ExportFilterCurrentState, f.CurrentState != nil},
+		{ExportFilterVersion, f.Version != nil},
+	} {
+		if c.set {
+			names = append(names, c.name)
+		}
+	}
+	return names
+}
+
+// exportWhere collects WHERE conditions with positional arguments.
+type exportWhere struct {
+	conds []string
+	args  []any
+}
+
+// add appends cond, whose %d verb is replaced by the argument's position.
+func (w *exportWhere) add(cond string, arg any) {
+	w.args = append(w.args, arg)
+	w.conds = append(w.conds, fmt.Sprintf(cond, len(w.args)))
+}
+
+// ExportDataset is a table or view that can be exported in bulk.
+type ExportDataset struct {
+	Name    string
+	Columns []ExportColumn
+	// Filters lists the supported filter names (ExportFilter* constants).
+	Filters []string
+
+	from  string
+	where func(w *exportWhere, f ExportFilter)
+	order string
+}
+
+// Validate reports an error if f sets a filter the dataset doesn't support.
+func (d ExportDataset) Validate(f ExportFilter) error {
+	for _, name := range f.set() {
+		if !slices.Contains(d.Filters, name) {
+			return fmt.Errorf("filter %q is not supported by the %s export", name, d.Name)
+		}
+	}
+	return nil
+}
+
+// query builds the export SELECT for f.
+func (d ExportDataset) query(f ExportFilter) (string, []any, error) {
+	if err := d.Validate(f); err != nil {
+		return "", nil, err
+	}
+	exprs := make([]string, 0, len(d.Columns))
+	for _, c := range d.Columns {
+		exprs = append(exprs, c.expr)
+	}
+	var w exportWhere
+	d.where(&w, f)
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	sb.WriteString(strings.Join(exprs, ", "))
+	sb.WriteString(" FROM ")
+	sb.WriteString(d.from)
+	if len(w.conds) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(w.conds, " AND "))
+	}
+	sb.WriteString(" ORDER BY ")
+	sb.WriteString(d.order)
+	return sb.String(), w.args, nil
+}
+
+// whereActions applies the ListActionsFiltered filters to the actions alias a.
+func whereActions(w *exportWhere, f ExportFilter) {
+	if f.Type != nil {
+		w.add(`a."actionType" = $%d`, *f.Type)
+	}
+	if f.Creator != nil {
+		w.add(`a."creator" = $%d`, *f.Creator)
+	}
+	if f.State != nil {
+		w.add(`a."state" = $%d`, *f.State)
+	}
+	if f.Supernode != nil {
+		w.add(`a."superNodes" @> jsonb_build_array($%d::text)`, *f.Supernode)
+	}
+}
+
+// ExportActions exports one row per action with its register and finalize transactions
+// flattened, ordered by action ID.
+var ExportActions = ExportDataset{
+	Name: "actions",
+	Columns: []ExportColumn{
+		{"id", ExportInt64, `a."actionID"::BIGINT`},
+		{"type", ExportString, `a."actionType"`},
+		{"creator", ExportString, `a."creator"::TEXT`},
+		{"state", ExportString, `a."state"`},
+		{"block_height", ExportInt64, `a."blockHeight"`},
+		{"price_amount", ExportString, `a."priceAmount"`},
+		{"price_denom", ExportString, `a."priceDenom"`},
+		{"expiration_time", ExportInt64, `a."expirationTime"`},
+		{"mime_type", ExportString, `a."mimeType"`},
+		{"size", ExportInt64, `a."size"`},
+		{"supernodes", ExportJSON, `a."superNodes"::TEXT`},
+		{"register_tx_id", ExportString, `NULLIF(r."txHash", '_NO_TX_FOUND_')`},
+		{"register_tx_time", ExportTime, `CASE WHEN r."txHash" <> '_NO_TX_FOUND_' THEN r."blockTime" END`},
+		{"finalize_tx_id", ExportString, `fz."txHash"`},
+		{"finalize_tx_time", ExportTime, `fz."blockTime"`},
+		{"created_at", ExportTime, `a."createdAt"`},
+		{"metadata", ExportJSON, `a."metadataJSON"::TEXT`},
+	},
+	Filters: []string{
+		ExportFilterType, ExportFilterCreator, ExportFilterState, ExportFilterSupernode,
+		ExportFilterFromHeight, ExportFilterToHeight, ExportFilterFromTime, ExportFilterToTime,
+	},
+	from: `actions a
+		LEFT JOIN action_transactions r ON r."actionID" = a."actionID" AND r."txType" = 'register' AND ` + firstOfType("r") + `
+		LEFT JOIN action_transactions fz ON fz."actionID" = a."actionID" AND fz."txType" = 'finalize' AND ` + firstOfType("fz"),
+	where: func(w *exportWhere, f ExportFilter) {
+		whereActions(w, f)
+		if f.FromHeight != nil {
+			w.add(`a."blockHeight" >= $%d`, *f.FromHeight)
+		}
+		if f.ToHeight != nil {
+			w.add(`a."blockHeight" <= $%d`, *f.ToHeight)
+		}
+		// Same time semantics as GetActionStatsExtended: the register transaction's block time
+		if f.FromTime != nil {
+			w.add(`r."blockTime" >= $%d`, *f.FromTime)
+		}
+		if f.ToTime != nil {
+			w.add(`r."blockTime" <= $%d`, *f.ToTime)
+		}
+	},
+	order: `a."actionID"::BIGINT ASC`,
+}
+
+// ExportActionTransactions exports one row per action transaction, skipping the
+// enricher's "not found" placeholders, ordered by action ID, transaction type, height and
+// message index.
+var ExportActionTransactions = ExportDataset{
+	Name: "action-transactions",
+	Columns: []ExportColumn{
+		{"action_id", ExportInt64, `t."actionID"::BIGINT`},
+		{"action_type", ExportString, `a."actionType"`},
+		{"tx_type", ExportString, `t."txType"`},
+		{"tx_hash", ExportString, `t."txHash"`},
+		{"height", ExportInt64, `t."height"`},
+		{"block_time", ExportTime, `t."blockTime"`},
+		{"gas_wanted", ExportInt64, `t."gasWanted"`},
+		{"gas_used", ExportInt64, `t."gasUsed"`},
+		{"action_price", ExportString, `t."actionPrice"`},
+		{"action_price_denom", ExportString, `t."actionPriceDenom"`},
+		{"flow_payer", ExportString, `t."flowPayer"`},
+		{"flow_payee", ExportString, `t."flowPayee"`},
+		{"tx_fee", ExportString, `t."txFee"`},
+		{"tx_fee_denom", ExportString, `t."txFeeDenom"`},
+		{"msg_index", ExportInt64, `t."msgIndex"::BIGINT`},
+	},
+	Filters: []string{
+		ExportFilterType, ExportFilterCreator, ExportFilterState, ExportFilterSupernode,
+		ExportFilterFromHeight, ExportFilterToHeight, ExportFilterFromTime, ExportFilterToTime,
+		ExportFilterTxType,
+	},
+	from: `action_transactions t
+		JOIN actions a ON a."actionID" = t."actionID"`,
+	where: func(w *exportWhere, f ExportFilter) {
+		w.conds = append(w.conds, `t."txHash" <> '_NO_TX_FOUND_'`)
+		whereActions(w, f)
+		if f.TxType != nil {
+			w.add(`t."txType" = $%d`, *f.TxType)
+		}
+		if f.FromHeight != nil {
+			w.add(`t."height" >= $%d`, *f.FromHeight)
+		}
+		if f.ToHeight != nil {
+			w.add(`t."height" <= $%d`, *f.ToHeight)
+		}
+		if f.FromTime != nil {
+			w.add(`t."blockTime" >= $%d`, *f.FromTime)
+		}
+		if f.ToTime != nil {
+			w.add(`t."blockTime" <= $%d`, *f.ToTime)
+		}
+	},
+	order: `t."actionID"::BIGINT ASC, t."txType" ASC, t."height" ASC, t."msgIndex" ASC`,
+}
+
+// ExportSupernodes exports the current state and last probe metrics of every
+// supernode, ordered by account.
+var ExportSupernodes = ExportDataset{
+	Name: "supernodes",
+	Columns: []ExportColumn{
+		{"supernode_account", ExportString, `"supernodeAccount"::TEXT`},
+		{"validator_address", ExportString, `"validatorAddress"::TEXT`},
+		{"validator_moniker", ExportString, `"validatorMoniker"::TEXT`},
+		{"current_state", ExportString, `"currentState"`},
+		{"current_state_height", ExportString, `"currentStateHeight"::TEXT`},
+		{"ip_address", ExportString, `"ipAddress"::TEXT`},
+		{"p2p_port", ExportInt64, `"p2pPort"::BIGINT`},
+		{"protocol_version", ExportString, `"protocolVersion"::TEXT`},
+		{"actual_version", ExportString, `COALESCE(NULLIF("lastKnownActualVersion", ''), NULLIF("actualVersion", ''))::TEXT`},
+		{"is_status_api_available", ExportBool, `"isStatusApiAvailable"`},
+		{"is_fully_available", ExportBool, `"isFullyAvailable"`},
+		{"failed_probe_counter", ExportInt64, `"failedProbeCounter"::BIGINT`},
+		{"last_status_check", ExportTime, `"lastStatusCheck"`},
+		{"last_successful_probe", ExportTime, `"lastSuccessfulProbe"`},
+		{"cpu_usage_percent", ExportFloat64, `"cpuUsagePercent"`},
+		{"cpu_cores", ExportInt64, `"cpuCores"::BIGINT`},
+		{"memory_total_gb", ExportFloat64, `"memoryTotalGb"`},
+		{"memory_used_gb", ExportFloat64, `"memoryUsedGb"`},
+		{"memory_usage_percent", ExportFloat64, `"memoryUsagePercent"`},
+		{"storage_total_bytes", ExportInt64, `"storageTotalBytes"`},
+		{"storage_used_bytes", ExportInt64, `"storageUsedBytes"`},
+		{"storage_usage_percent", ExportFloat64, `"storageUsagePercent"`},
+		{"peers_count", ExportInt64, `"peersCount"::BIGINT`},
+		{"uptime_seconds", ExportInt64, `"uptimeSeconds"`},
+		{"rank", ExportInt64, `rank::BIGINT`},
+		{"p2p_db_size_mb", ExportFloat64, `"p2pDbSizeMb"`},
+		{"p2p_records", ExportInt64, `"p2pRecords"`},
+		{"availability_24h", ExportFloat64, `"availability24h"`},
+		{"availability_7d", ExportFloat64, `"availability7d"`},
+		{"availability_30d", ExportFloat64, `"availability30d"`},
+		{"updated_at", ExportTime, `"updatedAt"`},
+	},
+	Filters: []string{ExportFilterCurrentState, ExportFilterVersion},
+	from:    `supernodes`,
+	where: func(w *exportWhere, f ExportFilter) {
+		if f.CurrentState != nil {
+			w.add(`"currentState" = $%d`, *f.CurrentState)
+		}
+		if f.Version != nil {
+			w.add(`COALESCE(NULLIF("lastKnownActualVersion", ''), NULLIF("actualVersion", '')) = $%d`, *f.Version)
+		}
+	},
+	order: `"supernodeAccount" ASC`,
+}
+
+// ExportDatasets lists every dataset in the order they are documented.
+var ExportDatasets = []ExportDataset{ExportActions, ExportActionTransactions, ExportSupernodes}
+
+// ExportDatasetByName returns the dataset with the given name.
+func ExportDatasetByName(name string) (ExportDataset, bool) {
+	for _, d := range ExportDatasets {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return ExportDataset{}, false
+}
+
+// ExportFetchSize is the number of rows StreamExport reads from the cursor per round
+// trip; callers may flush their output at this granularity.
+const ExportFetchSize = 1000
+
+// StreamExport runs the dataset query through a server-side cursor inside a read-only
+// transaction and calls fn for every row in order, so memory use does not grow with
+// the size of the export. It returns the number of rows passed to fn.
+func StreamExport(ctx context.Context, pool *pgxpool.Pool, d ExportDataset, f ExportFilter, fn func(row []any) error) (int64, error) {
+	query, args, err := d.query(f)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return 0, fmt.Errorf("begin export: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DECLARE lumescope_export NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return 0, fmt.Errorf("declare export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM lumescope_export", ExportFetchSize)
+	var total int64
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return total, fmt.Errorf("fetch export rows: %w", err)
+		}
+		n := 0
+		for rows.Next() {
+			vals, err := rows.Values()
+			if err != nil {
+				rows.Close()
+				return total, fmt.Errorf("read export row: %w", err)
+			}
+			if err := fn(vals); err != nil {
+				rows.Close()
+				return total, err
+			}
+			n++
+			total++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, fmt.Errorf("fetch export rows: %w", err)
+		}
+		if n < ExportFetchSize {
+			return total, nil
+		}
+	}
+}
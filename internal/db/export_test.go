@@ -0,0 +1,46 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExportQuery verifies filters become positional conditions in declaration order
+func TestExportQuery(t *testing.T) {
+	typ, txType := "ACTION_TYPE_CASCADE", "finalize"
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var toHeight int64 = 500
+	query, args, err := ExportActionTransactions.query(ExportFilter{Type: &typ, TxType: &txType, ToHeight: &toHeight, FromTime: &from})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ` WHERE t."txHash" <> '_NO_TX_FOUND_' AND a."actionType" = $1 AND t."txType" = $2 AND t."height" <= $3 AND t."blockTime" >= $4 ORDER BY t."actionID"::BIGINT ASC, t."txType" ASC, t."height" ASC, t."msgIndex" ASC`
+	if !strings.HasSuffix(query, want) {
+		t.Fatalf("query = %s", query)
+	}
+	if len(args) != 4 || args[0] != typ || args[1] != txType || args[2] != toHeight || args[3] != from {
+		t.Fatalf("args = %v", args)
+	}
+
+	query, args, err = ExportSupernodes.query(ExportFilter{})
+	if err != nil || strings.Contains(query, "WHERE") || len(args) != 0 {
+		t.Fatalf("unfiltered query = %s %v %v", query, args, err)
+	}
+}
+
+// TestExportValidate verifies filters a dataset doesn't support are rejected
+func TestExportValidate(t *testing.T) {
+	state := "SUPERNODE_STATE_ACTIVE"
+	if err := ExportActions.Validate(ExportFilter{CurrentState: &state}); err == nil || !strings.Contains(err.Error(), `"currentState"`) {
+		t.Fatalf("err = %v", err)
+	}
+	if err := ExportSupernodes.Validate(ExportFilter{CurrentState: &state}); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range ExportDatasets {
+		if got, ok := ExportDatasetByName(d.Name); !ok || got.Name != d.Name {
+			t.Errorf("ExportDatasetByName(%q) = %v", d.Name, ok)
+		}
+	}
+}
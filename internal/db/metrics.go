@@ -0,0 +1,33 @@
+package db
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"lumescope/internal/metrics"
+)
+
+var (
+	poolTotalConns = metrics.NewGaugeFuncVec("lumescope_db_pool_total_conns",
+		"Total connections currently in the pool.", "network")
+	poolAcquiredConns = metrics.NewGaugeFuncVec("lumescope_db_pool_acquired_conns",
+		"Connections currently checked out of the pool.", "network")
+	poolIdleConns = metrics.NewGaugeFuncVec("lumescope_db_pool_idle_conns",
+		"Idle connections in the pool.", "network")
+	poolMaxConns = metrics.NewGaugeFuncVec("lumescope_db_pool_max_conns",
+		"Maximum size of the pool.", "network")
+	poolEmptyAcquire = metrics.NewGaugeFuncVec("lumescope_db_pool_empty_acquire_total",
+		"Acquires that had to wait for a connection.", "network")
+)
+
+// RegisterPoolMetrics exposes the pgx connection pool statistics of a network as
+// scrape-time gauges labelled with its name, so each pool can be watched on its own.
+func RegisterPoolMetrics(network string, pool *pgxpool.Pool) {
+	stat := func(f func(*pgxpool.Stat) float64) func() float64 {
+		return func() float64 { return f(pool.Stat()) }
+	}
+	poolTotalConns.Set(stat(func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }), network)
+	poolAcquiredConns.Set(stat(func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }), network)
+	poolIdleConns.Set(stat(func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }), network)
+	poolMaxConns.Set(stat(func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }), network)
+	poolEmptyAcquire.Set(stat(func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }), network)
+}
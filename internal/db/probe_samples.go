@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Probe history sources, from finest to coarsest.
+const (
+	ProbeSourceRaw = "raw"
+	ProbeSource5m  = "5m"
+	ProbeSource1h  = "1h"
+)
+
+// ProbeSample is one probe result for a supernode. Metric fields are nil when the
+// status API did not answer.
+type ProbeSample struct {
+	SupernodeAccount    string
+	SampledAt           time.Time
+	StatusAPIAvailable  bool
+	Port1Open           bool
+	P2POpen             bool
+	FullyAvailable      bool
+	CPUUsagePercent     *float64
+	MemoryUsagePercent  *float64
+	MemoryUsedGb        *float64
+	StorageUsagePercent *float64
+	StorageUsedBytes    *int64
+	PeersCount          *int32
+	P2PDbSizeMb         *float64
+	P2PRecords          *int64
+	UptimeSeconds       *int64
+}
+
+// probeMetricColumns are the numeric columns shared by samples and rollups, paired
+// with the field names used by the history API.
+var probeMetricColumns = []struct {
+	Column string
+	Field  string
+}{
+	{"cpuUsagePercent", "cpu_usage_percent"},
+	{"memoryUsagePercent", "memory_usage_percent"},
+	{"memoryUsedGb", "memory_used_gb"},
+	{"storageUsagePercent", "storage_usage_percent"},
+	{"storageUsedBytes", "storage_used_bytes"},
+	{"peersCount", "peers_count"},
+	{"p2pDbSizeMb", "p2p_db_size_mb"},
+	{"p2pRecords", "p2p_records"},
+	{"uptimeSeconds", "uptime_seconds"},
+}
+
+// Availability ratio fields reported alongside the metric columns.
+const (
+	ProbeFieldStatusAPIAvailable = "status_api_available"
+	ProbeFieldFullyAvailable     = "fully_available"
+)
+
+// ProbeHistoryFields lists every field the history API can return.
+func ProbeHistoryFields() []string {
+	out := []string{ProbeFieldStatusAPIAvailable, ProbeFieldFullyAvailable}
+	for _, c := range probeMetricColumns {
+		out = append(out, c.Field)
+	}
+	return out
+}
+
+// InsertProbeSample appends a probe result to supernode_probe_samples.
+func InsertProbeSample(ctx context.Context, pool *pgxpool.Pool, s ProbeSample) error {
+	_, err := pool.Exec(ctx, `INSERT INTO supernode_probe_samples (
+		"supernodeAccount","sampledAt","statusApiAvailable","port1Open","p2pOpen","fullyAvailable",
+		"cpuUsagePercent","memoryUsagePercent","memoryUsedGb","storageUsagePercent","storageUsedBytes",
+		"peersCount","p2pDbSizeMb","p2pRecords","uptimeSeconds"
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
+	ON CONFLICT ("supernodeAccount","sampledAt") DO NOTHING`,
+		s.SupernodeAccount, s.SampledAt, s.StatusAPIAvailable, s.Port1Open, s.P2POpen, s.FullyAvailable,
+		s.CPUUsagePercent, s.MemoryUsagePercent, s.MemoryUsedGb, s.StorageUsagePercent, s.StorageUsedBytes,
+		s.PeersCount, s.P2PDbSizeMb, s.P2PRecords, s.UptimeSeconds)
+	return err
+}
+
+// bucketExpr truncates a timestamp column to a multiple of seconds since the epoch.
+func bucketExpr(column string, seconds int64) string {
+	return fmt.Sprintf(`to_timestamp(floor(extract(epoch from %s) / %d) * %d) AT TIME ZONE 'UTC'`, column, seconds, seconds)
+}
+
+// RollupProbeSamples (re)computes 5m rollups from raw samples and 1h rollups from 5m
+// rollups for closed buckets starting at or after since. Recomputing is idempotent, so
+// the caller can pass a window that overlaps earlier runs.
+func RollupProbeSamples(ctx context.Context, pool *pgxpool.Pool, since time.Time) error {
+	var rawAvgs, rollupAvgs, cols, updates []string
+	for _, c := range probeMetricColumns {
+		q := `"` + c.Column + `"`
+		cols = append(cols, q)
+		rawAvgs = append(rawAvgs, fmt.Sprintf(`AVG(%s)::double precision`, q))
+		rollupAvgs = append(rollupAvgs, fmt.Sprintf(`SUM(%s * "availableCount") / NULLIF(SUM("availableCount"),0)`, q))
+		updates = append(updates, fmt.Sprintf(`%s=EXCLUDED.%s`, q, q))
+	}
+	insertCols := `"supernodeAccount","resolution","bucketStart","sampleCount","availableCount","fullyAvailableCount","port1OpenCount","p2pOpenCount",` + strings.Join(cols, ",")
+	onConflict := `ON CONFLICT ("supernodeAccount","resolution","bucketStart") DO UPDATE SET
+		"sampleCount"=EXCLUDED."sampleCount","availableCount"=EXCLUDED."availableCount","fullyAvailableCount"=EXCLUDED."fullyAvailableCount",
+		"port1OpenCount"=EXCLUDED."port1OpenCount","p2pOpenCount"=EXCLUDED."p2pOpenCount",` +
+		strings.Join(updates, ",")
+
+	to5m := fmt.Sprintf(`INSERT INTO supernode_probe_rollups (%s)
+	SELECT "supernodeAccount", '5m', %s AS b, COUNT(*),
+		COUNT(*) FILTER (WHERE "statusApiAvailable"), COUNT(*) FILTER (WHERE "fullyAvailable"),
+		COUNT(*) FILTER (WHERE "port1Open"), COUNT(*) FILTER (WHERE "p2pOpen"), %s
+	FROM supernode_probe_samples
+	WHERE "sampledAt" >= %s AND "sampledAt" < %s
+	GROUP BY "supernodeAccount", b
+	%s`, insertCols, bucketExpr(`"sampledAt"`, 300), strings.Join(rawAvgs, ","),
+		bucketExpr("$1::timestamp", 300), bucketExpr("now() AT TIME ZONE 'UTC'", 300), onConflict)
+	if _, err := pool.Exec(ctx, to5m, since); err != nil {
+		return fmt.Errorf("rollup 5m: %w", err)
+	}
+
+	to1h := fmt.Sprintf(`INSERT INTO supernode_probe_rollups (%s)
+	SELECT "supernodeAccount", '1h', %s AS b, SUM("sampleCount"),
+		SUM("availableCount"), SUM("fullyAvailableCount"), SUM("port1OpenCount"), SUM("p2pOpenCount"), %s
+	FROM supernode_probe_rollups
+	WHERE "resolution" = '5m' AND "bucketStart" >= %s AND "bucketStart" < %s
+	GROUP BY "supernodeAccount", b
+	%s`, insertCols, bucketExpr(`"bucketStart"`, 3600), strings.Join(rollupAvgs, ","),
+		bucketExpr("$1::timestamp", 3600), bucketExpr("now() AT TIME ZONE 'UTC'", 3600), onConflict)
+	if _, err := pool.Exec(ctx, to1h, since); err != nil {
+		return fmt.Errorf("rollup 1h: %w", err)
+	}
+	return nil
+}
+
+// LatestProbeRollup returns the start of the newest rollup bucket of resolution
+// (ProbeSource5m or ProbeSource1h), or the zero time when there is none.
+func LatestProbeRollup(ctx context.Context, pool *pgxpool.Pool, resolution string) (time.Time, error) {
+	var latest *time.Time
+	if err := pool.QueryRow(ctx, `SELECT MAX("bucketStart") FROM supernode_probe_rollups WHERE "resolution" = $1`, resolution).Scan(&latest); err != nil {
+		return time.Time{}, err
+	}
+	if latest == nil {
+		return time.Time{}, nil
+	}
+	return latest.UTC(), nil
+}
+
+// PruneProbeSamples deletes raw samples and rollups older than their retention.
+// A zero retention keeps that tier forever.
+func PruneProbeSamples(ctx context.Context, pool *pgxpool.Pool, raw, r5m, r1h time.Duration) error {
+	now := time.Now().UTC()
+	if raw > 0 {
+		if _, err := pool.Exec(ctx, `DELETE FROM supernode_probe_samples WHERE "sampledAt" < $1`, now.Add(-raw)); err != nil {
+			return err
+		}
+	}
+	for _, t := range []struct {
+		res string
+		ret time.Duration
+	}{{ProbeSource5m, r5m}, {ProbeSource1h, r1h}} {
+		if t.ret <= 0 {
+			continue
+		}
+		if _, err := pool.Exec(ctx, `DELETE FROM supernode_probe_rollups WHERE "resolution"=$1 AND "bucketStart" < $2`, t.res, now.Add(-t.ret)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProbeHistoryPoint is one time bucket of probe history. Values maps field name to the
+// bucket average (availability fields are ratios in [0,1]); nil means no data.
+type ProbeHistoryPoint struct {
+	BucketStart time.Time
+	Samples     int64
+	Values      map[string]*float64
+}
+
+// GetProbeHistory buckets a supernode's probe data in [from, to) into step-sized buckets,
+// reading from the given source (ProbeSourceRaw, ProbeSource5m or ProbeSource1h).
+// step must be a whole number of seconds and at least the source resolution.
+func GetProbeHistory(ctx context.Context, pool *pgxpool.Pool, account, source string, from, to time.Time, step time.Duration) ([]ProbeHistoryPoint, error) {
+	secs := int64(step / time.Second)
+	if secs <= 0 {
+		return nil, fmt.Errorf("invalid step %v", step)
+	}
+
+	var sb strings.Builder
+	var args []any
+	switch source {
+	case ProbeSourceRaw:
+		sb.WriteString(fmt.Sprintf(`SELECT %s AS b, COUNT(*),
+			AVG("statusApiAvailable"::int)::double precision, AVG("fullyAvailable"::int)::double precision`, bucketExpr(`"sampledAt"`, secs)))
+		for _, c := range probeMetricColumns {
+			sb.WriteString(fmt.Sprintf(`, AVG("%s")::double precision`, c.Column))
+		}
+		sb.WriteString(` FROM supernode_probe_samples WHERE "supernodeAccount"=$1 AND "sampledAt" >= $2 AND "sampledAt" < $3`)
+		args = []any{account, from, to}
+	case ProbeSource5m, ProbeSource1h:
+		sb.WriteString(fmt.Sprintf(`SELECT %s AS b, SUM("sampleCount"),
+			SUM("availableCount")::double precision / NULLIF(SUM("sampleCount"),0),
+			SUM("fullyAvailableCount")::double precision / NULLIF(SUM("sampleCount"),0)`, bucketExpr(`"bucketStart"`, secs)))
+		for _, c := range probeMetricColumns {
+			sb.WriteString(fmt.Sprintf(`, SUM("%s" * "availableCount") / NULLIF(SUM("availableCount"),0)`, c.Column))
+		}
+		sb.WriteString(` FROM supernode_probe_rollups WHERE "supernodeAccount"=$1 AND "resolution"=$4 AND "bucketStart" >= $2 AND "bucketStart" < $3`)
+		args = []any{account, from, to, source}
+	default:
+		return nil, fmt.Errorf("unknown probe history source %q", source)
+	}
+	sb.WriteString(` GROUP BY b ORDER BY b`)
+
+	rows, err := pool.Query(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ProbeHistoryPoint
+	for rows.Next() {
+		vals := make([]*float64, 2+len(probeMetricColumns))
+		var p ProbeHistoryPoint
+		dest := []any{&p.BucketStart, &p.Samples}
+		for i := range vals {
+			dest = append(dest, &vals[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		p.Values = map[string]*float64{
+			ProbeFieldStatusAPIAvailable: vals[0],
+			ProbeFieldFullyAvailable:     vals[1],
+		}
+		for i, c := range probeMetricColumns {
+			p.Values[c.Field] = vals[2+i]
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
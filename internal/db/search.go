@@ -0,0 +1,270 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Search hit types.
+const (
+	SearchAction      = "action"
+	SearchTransaction = "transaction"
+	SearchAccount     = "account"
+	SearchSupernode   = "supernode"
+	SearchFile        = "file"
+)
+
+// SearchTypes lists every hit type, in the order ties are listed.
+var SearchTypes = []string{SearchAction, SearchTransaction, SearchAccount, SearchSupernode, SearchFile}
+
+// How a hit matched the query.
+const (
+	MatchExact  = "exact"
+	MatchPrefix = "prefix"
+	MatchFuzzy  = "fuzzy"
+)
+
+// Scores of exact and prefix matches; fuzzy matches score their trigram similarity,
+// capped below prefix matches.
+const (
+	scoreExact  = 1.0
+	scorePrefix = 0.8
+)
+
+// SearchHit is one search result. ID is the action ID, tx hash, address or supernode
+// account; ActionID is set for hits belonging to an action.
+type SearchHit struct {
+	Type     string
+	ID       string
+	Label    string // file name, validator moniker or transaction type
+	ActionID string
+	Match    string
+	Score    float64
+}
+
+// SearchOptions restricts a search to some hit types and caps the results.
+type SearchOptions struct {
+	Types map[string]bool // nil means all types
+	Limit int
+}
+
+func (o SearchOptions) wants(t string) bool {
+	return o.Types == nil || o.Types[t]
+}
+
+var hexQuery = regexp.MustCompile(`^[0-9A-Fa-f]{6,64}$`)
+
+// searchTSQuery turns q into a tsquery that prefix-matches every word of q, or "" when
+// q has no words. Words split on anything but letters and digits, as the file name
+// index does.
+func searchTSQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// likePrefix escapes LIKE wildcards in s and appends %.
+func likePrefix(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s) + "%"
+}
+
+// trigramAvailable reports whether pg_trgm is installed in public, where Bootstrap
+// creates it. Network schemas don't have public on their search_path, so trigram
+// functions are always schema-qualified.
+func trigramAvailable(ctx context.Context, pool *pgxpool.Pool) (bool, error) {
+	var ok bool
+	err := pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm' AND extnamespace = 'public'::regnamespace)`).Scan(&ok)
+	return ok, err
+}
+
+// Search finds actions by ID, transactions by hash, creator and supernode addresses by
+// prefix, supernodes by validator moniker and Cascade actions by file name. File names
+// and monikers also match fuzzily when pg_trgm is available. Hits are ordered by
+// score, then type.
+func Search(ctx context.Context, pool *pgxpool.Pool, q string, opts SearchOptions) ([]SearchHit, error) {
+	q = strings.TrimSpace(q)
+	limit := opts.Limit
+	if q == "" || limit <= 0 {
+		return nil, nil
+	}
+	fuzzy, err := trigramAvailable(ctx, pool)
+	if err != nil {
+		return nil, fmt.Errorf("check pg_trgm: %w", err)
+	}
+
+	var hits []SearchHit
+	collect := func(kind, query string, args ...any) error {
+		rows, err := pool.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("search %s: %w", kind, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			h := SearchHit{Type: kind}
+			if err := rows.Scan(&h.ID, &h.Label, &h.ActionID, &h.Score); err != nil {
+				return fmt.Errorf("scan %s hit: %w", kind, err)
+			}
+			switch {
+			case h.Score >= scoreExact:
+				h.Match = MatchExact
+			case h.Score >= scorePrefix:
+				h.Match = MatchPrefix
+			default:
+				h.Match = MatchFuzzy
+			}
+			hits = append(hits, h)
+		}
+		return rows.Err()
+	}
+
+	if id, err := strconv.ParseUint(q, 10, 64); err == nil && opts.wants(SearchAction) {
+		if err := collect(SearchAction, `SELECT "actionID"::TEXT, COALESCE("metadataJSON"->>'file_name', ''), "actionID"::TEXT, 1.0::DOUBLE PRECISION
+			FROM actions WHERE "actionID" = $1`, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if hexQuery.MatchString(q) && opts.wants(SearchTransaction) {
+		hash := strings.ToUpper(q)
+		if err := collect(SearchTransaction, `SELECT "txHash", "txType", "actionID"::TEXT,
+				CASE WHEN "txHash" = $1 THEN 1.0 ELSE 0.8 END::DOUBLE PRECISION
+			FROM action_transactions WHERE "txHash" LIKE $2
+			ORDER BY 4 DESC, "height" DESC LIMIT $3`, hash, likePrefix(hash), limit); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.wants(SearchAccount) {
+		if err := collect(SearchAccount, `SELECT "creator", '', '',
+				CASE WHEN "creator" = $1 THEN 1.0 ELSE 0.8 END::DOUBLE PRECISION
+			FROM actions WHERE "creator" LIKE $2
+			GROUP BY "creator" ORDER BY 4 DESC, "creator" LIMIT $3`, q, likePrefix(q), limit); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.wants(SearchSupernode) {
+		fuzzyCond, fuzzyScore := `FALSE`, `0.5`
+		if fuzzy {
+			fuzzyCond = `"validatorMoniker" OPERATOR(public.%) $1`
+			fuzzyScore = `LEAST(public.similarity("validatorMoniker", $1), 0.79)`
+		}
+		query := fmt.Sprintf(`SELECT "supernodeAccount", COALESCE("validatorMoniker", ''), '',
+				CASE
+					WHEN "supernodeAccount" = $1 OR "validatorAddress" = $1 OR lower("validatorMoniker") = lower($1) THEN 1.0
+					WHEN "supernodeAccount" LIKE $2 OR "validatorAddress" LIKE $2 OR lower("validatorMoniker") LIKE lower($2) THEN 0.8
+					ELSE %s
+				END::DOUBLE PRECISION AS score
+			FROM supernodes
+			WHERE "supernodeAccount" LIKE $2 OR "validatorAddress" LIKE $2
+				OR "validatorMoniker" ILIKE '%%' || $3 || '%%' OR %s
+			ORDER BY score DESC, "supernodeAccount" LIMIT $4`, fuzzyScore, fuzzyCond)
+		if err := collect(SearchSupernode, query, q, likePrefix(q), strings.TrimSuffix(likePrefix(q), "%"), limit); err != nil {
+			return nil, err
+		}
+	}
+
+	if tsq := searchTSQuery(q); opts.wants(SearchFile) && (tsq != "" || fuzzy) {
+		fuzzyCond, fuzzyScore := `FALSE`, `0.5`
+		if fuzzy {
+			fuzzyCond = `"metadataJSON"->>'file_name' OPERATOR(public.%) $1`
+			fuzzyScore = `LEAST(public.similarity("metadataJSON"->>'file_name', $1), 0.79)`
+		}
+		tsCond, args := `FALSE`, []any{q, limit}
+		if tsq != "" {
+			tsCond = fileNameTSVector + ` @@ to_tsquery('simple', $3)`
+			args = append(args, tsq)
+		}
+		query := fmt.Sprintf(`SELECT "actionID"::TEXT, "metadataJSON"->>'file_name', "actionID"::TEXT,
+				CASE
+					WHEN lower("metadataJSON"->>'file_name') = lower($1) THEN 1.0
+					WHEN %[1]s THEN 0.8
+					ELSE %[2]s
+				END::DOUBLE PRECISION AS score
+			FROM actions
+			WHERE "metadataJSON" ? 'file_name' AND (%[1]s OR %[3]s)
+			ORDER BY score DESC, "actionID" DESC LIMIT $2`, tsCond, fuzzyScore, fuzzyCond)
+		if err := collect(SearchFile, query, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	rank := make(map[string]int, len(SearchTypes))
+	for i, t := range SearchTypes {
+		rank[t] = i
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return rank[hits[i].Type] < rank[hits[j].Type]
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// fileNameTSVector is the indexed word vector of Cascade file names; it must match
+// idx_actions_file_name_tsv.
+const fileNameTSVector = `to_tsvector('simple', regexp_replace(COALESCE("metadataJSON"->>'file_name', ''), '[^[:alnum:]]+', ' ', 'g'))`
+
+// MetadataFilter matches actions whose decoded metadata has Field equal to Value.
+type MetadataFilter struct {
+	Field string
+	Value string
+}
+
+var metadataField = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// ValidMetadataField reports whether name can be used as a MetadataFilter field.
+func ValidMetadataField(name string) bool {
+	return metadataField.MatchString(name)
+}
+
+// metadataCondition builds a WHERE condition for f with placeholders from argPos on.
+// Value matches string fields, elements of string arrays (RQ and fingerprint IDs),
+// and booleans or numbers written the same way. Metadata is decoded with omitempty,
+// so false, 0 and "" also match actions that have metadata without the field. The
+// containment tests are served by idx_actions_metadata.
+func metadataCondition(f MetadataFilter, argPos int) (string, []any) {
+	candidates := []any{f.Value, []string{f.Value}}
+	switch f.Value {
+	case "true":
+		candidates = append(candidates, true)
+	case "false":
+		candidates = append(candidates, false)
+	}
+	if _, err := strconv.ParseFloat(f.Value, 64); err == nil && json.Valid([]byte(f.Value)) {
+		candidates = append(candidates, json.Number(f.Value))
+	}
+
+	var (
+		parts []string
+		args  []any
+	)
+	for _, c := range candidates {
+		doc, _ := json.Marshal(map[string]any{f.Field: c})
+		parts = append(parts, fmt.Sprintf(`"metadataJSON" @> $%d::jsonb`, argPos+len(args)))
+		args = append(args, string(doc))
+	}
+	switch f.Value {
+	case "false", "0", "":
+		parts = append(parts, fmt.Sprintf(`("metadataJSON" IS NOT NULL AND NOT "metadataJSON" ? $%d)`, argPos+len(args)))
+		args = append(args, f.Field)
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
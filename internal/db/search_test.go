@@ -0,0 +1,75 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSearchTSQuery verifies queries become prefix matches on their words only
+func TestSearchTSQuery(t *testing.T) {
+	tests := map[string]string{
+		"holiday":                "holiday:*",
+		"Holiday Photo-2024.JPG": "holiday:* & photo:* & 2024:* & jpg:*",
+		"a'b & c:*":              "a:* & b:* & c:*",
+		"--- !!":                 "",
+	}
+	for q, want := range tests {
+		if got := searchTSQuery(q); got != want {
+			t.Errorf("searchTSQuery(%q) = %q, want %q", q, got, want)
+		}
+	}
+}
+
+// TestLikePrefix verifies LIKE wildcards in user input are matched literally
+func TestLikePrefix(t *testing.T) {
+	if got := likePrefix(`lumera1_a%\`); got != `lumera1\_a\%\\%` {
+		t.Errorf("likePrefix = %q", got)
+	}
+}
+
+// TestMetadataCondition verifies the JSON documents tried for each kind of value
+func TestMetadataCondition(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantArgs  []any
+		wantParts int
+	}{
+		{"photo.jpg", []any{`{"file_name":"photo.jpg"}`, `{"file_name":["photo.jpg"]}`}, 2},
+		{"true", []any{`{"file_name":"true"}`, `{"file_name":["true"]}`, `{"file_name":true}`}, 3},
+		{"false", []any{`{"file_name":"false"}`, `{"file_name":["false"]}`, `{"file_name":false}`, "file_name"}, 4},
+		{"12", []any{`{"file_name":"12"}`, `{"file_name":["12"]}`, `{"file_name":12}`}, 3},
+		{"0", []any{`{"file_name":"0"}`, `{"file_name":["0"]}`, `{"file_name":0}`, "file_name"}, 4},
+		{"NaN", []any{`{"file_name":"NaN"}`, `{"file_name":["NaN"]}`}, 2},
+	}
+	for _, tt := range tests {
+		cond, args := metadataCondition(MetadataFilter{Field: "file_name", Value: tt.value}, 3)
+		if len(args) != len(tt.wantArgs) {
+			t.Fatalf("%q: args = %v, want %v", tt.value, args, tt.wantArgs)
+		}
+		for i := range args {
+			if args[i] != tt.wantArgs[i] {
+				t.Errorf("%q: arg %d = %v, want %v", tt.value, i, args[i], tt.wantArgs[i])
+			}
+		}
+		if n := strings.Count(cond, " OR ") + 1; n != tt.wantParts {
+			t.Errorf("%q: %d branches in %s", tt.value, n, cond)
+		}
+		if !strings.Contains(cond, "$3") || strings.Contains(cond, "$2") {
+			t.Errorf("%q: placeholders not numbered from 3: %s", tt.value, cond)
+		}
+	}
+}
+
+// TestValidMetadataField verifies field names cannot inject JSON or SQL
+func TestValidMetadataField(t *testing.T) {
+	for _, ok := range []string{"file_name", "rq_ids_ids", "public"} {
+		if !ValidMetadataField(ok) {
+			t.Errorf("%q rejected", ok)
+		}
+	}
+	for _, bad := range []string{"", "File", "a.b", `x"`, "1st", strings.Repeat("a", 65)} {
+		if ValidMetadataField(bad) {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
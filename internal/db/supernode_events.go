@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Supernode event types recorded by the supernode sync and probe loops.
+const (
+	SupernodeEventStateChanged   = "state_changed"
+	SupernodeEventIPChanged      = "ip_changed"
+	SupernodeEventVersionChanged = "version_changed"
+	SupernodeEventPortsDown      = "ports_down"
+	SupernodeEventPortsUp        = "ports_up"
+	SupernodeEventEvidenceAdded  = "evidence_added"
+)
+
+// SupernodeEventTypes lists every supernode event type.
+var SupernodeEventTypes = []string{
+	SupernodeEventStateChanged,
+	SupernodeEventIPChanged,
+	SupernodeEventVersionChanged,
+	SupernodeEventPortsDown,
+	SupernodeEventPortsUp,
+	SupernodeEventEvidenceAdded,
+}
+
+// SupernodeEvent is one discrete change detected for a supernode.
+type SupernodeEvent struct {
+	ID               int64
+	EventType        string
+	SupernodeAccount string
+	ValidatorAddress string
+	OldValue         string
+	NewValue         string
+	Height           *int64
+	Details          any
+	CreatedAt        time.Time
+}
+
+// SupernodeEventsFilter selects supernode events for listing and streaming.
+type SupernodeEventsFilter struct {
+	Supernode *string
+	Validator *string
+	EventType *string
+	Limit     int
+	CursorID  *int64 // List: return events with id < CursorID (newest first)
+}
+
+// SupernodeSnapshot is the stored state change detection compares against.
+type SupernodeSnapshot struct {
+	SupernodeAccount string
+	ValidatorAddress string
+	CurrentState     string
+	IPAddress        string
+	ActualVersion    string
+	EvidenceCount    int
+	FullyAvailable   *bool // nil until the first probe has run
+}
+
+// ListSupernodeSnapshots returns the change-detection snapshot of every known supernode.
+func ListSupernodeSnapshots(ctx context.Context, pool *pgxpool.Pool) (map[string]SupernodeSnapshot, error) {
+	rows, err := pool.Query(ctx, `SELECT
+		"supernodeAccount",
+		COALESCE("validatorAddress",''),
+		COALESCE("currentState",''),
+		COALESCE("ipAddress",''),
+		COALESCE("actualVersion",''),
+		CASE WHEN jsonb_typeof(evidence) = 'array' THEN jsonb_array_length(evidence) ELSE 0 END,
+		"isFullyAvailable"
+	FROM supernodes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]SupernodeSnapshot)
+	for rows.Next() {
+		var s SupernodeSnapshot
+		if err := rows.Scan(&s.SupernodeAccount, &s.ValidatorAddress, &s.CurrentState, &s.IPAddress,
+			&s.ActualVersion, &s.EvidenceCount, &s.FullyAvailable); err != nil {
+			return nil, err
+		}
+		out[s.SupernodeAccount] = s
+	}
+	return out, rows.Err()
+}
+
+// SetSupernodeFullyAvailable records whether the last probe found all ports open and
+// the status API responding.
+func SetSupernodeFullyAvailable(ctx context.Context, pool *pgxpool.Pool, supernodeAccount string, available bool) error {
+	_, err := pool.Exec(ctx, `UPDATE supernodes SET "isFullyAvailable"=$2 WHERE "supernodeAccount"=$1`, supernodeAccount, available)
+	return err
+}
+
+// InsertSupernodeEvent appends an event to supernode_events. Inserts are serialized
+// like action events, so ListSupernodeEventsAfter cursors never skip a late commit.
+func InsertSupernodeEvent(ctx context.Context, pool *pgxpool.Pool, e SupernodeEvent) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := lockEventLog(ctx, tx, supernodeEventsLockKey); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO supernode_events
+		("eventType","supernodeAccount","validatorAddress","oldValue","newValue","height","details","createdAt")
+	VALUES ($1,$2,$3,$4,$5,$6,$7::jsonb,now())`,
+		e.EventType, e.SupernodeAccount, e.ValidatorAddress, e.OldValue, e.NewValue, e.Height, e.Details); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListSupernodeEvents returns events newest first with keyset pagination on id.
+func ListSupernodeEvents(ctx context.Context, pool *pgxpool.Pool, f SupernodeEventsFilter) ([]SupernodeEvent, bool, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 1
+	}
+	conditions, args := supernodeEventConditions(f)
+	if f.CursorID != nil {
+		args = append(args, *f.CursorID)
+		conditions = append(conditions, fmt.Sprintf(`"id" < $%d`, len(args)))
+	}
+	args = append(args, limit+1)
+	sql := supernodeEventsSelect(conditions) + fmt.Sprintf(` ORDER BY "id" DESC LIMIT $%d`, len(args))
+
+	events, err := querySupernodeEvents(ctx, pool, sql, args)
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := false
+	if len(events) > limit {
+		hasMore = true
+		events = events[:limit]
+	}
+	return events, hasMore, nil
+}
+
+// ListSupernodeEventsAfter returns up to limit events with id > afterID, oldest first.
+// Used by the live stream; f.Limit and f.CursorID are ignored.
+func ListSupernodeEventsAfter(ctx context.Context, pool *pgxpool.Pool, afterID int64, f SupernodeEventsFilter, limit int) ([]SupernodeEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	conditions, args := supernodeEventConditions(f)
+	args = append(args, afterID)
+	conditions = append(conditions, fmt.Sprintf(`"id" > $%d`, len(args)))
+	args = append(args, limit)
+	sql := supernodeEventsSelect(conditions) + fmt.Sprintf(` ORDER BY "id" ASC LIMIT $%d`, len(args))
+	return querySupernodeEvents(ctx, pool, sql, args)
+}
+
+// LatestSupernodeEventID returns the highest event ID, or 0 if no events exist.
+func LatestSupernodeEventID(ctx context.Context, pool *pgxpool.Pool) (int64, error) {
+	var id int64
+	err := pool.QueryRow(ctx, `SELECT COALESCE(MAX("id"),0) FROM supernode_events`).Scan(&id)
+	return id, err
+}
+
+func supernodeEventConditions(f SupernodeEventsFilter) ([]string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	if f.Supernode != nil {
+		args = append(args, *f.Supernode)
+		conditions = append(conditions, fmt.Sprintf(`"supernodeAccount" = $%d`, len(args)))
+	}
+	if f.Validator != nil {
+		args = append(args, *f.Validator)
+		conditions = append(conditions, fmt.Sprintf(`"validatorAddress" = $%d`, len(args)))
+	}
+	if f.EventType != nil {
+		args = append(args, *f.EventType)
+		conditions = append(conditions, fmt.Sprintf(`"eventType" = $%d`, len(args)))
+	}
+	return conditions, args
+}
+
+func supernodeEventsSelect(conditions []string) string {
+	var sb strings.Builder
+	sb.WriteString(`SELECT "id","eventType","supernodeAccount","validatorAddress","oldValue","newValue","height","details","createdAt"
+		FROM supernode_events`)
+	if len(conditions) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(conditions, " AND "))
+	}
+	return sb.String()
+}
+
+func querySupernodeEvents(ctx context.Context, pool *pgxpool.Pool, sql string, args []any) ([]SupernodeEvent, error) {
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SupernodeEvent
+	for rows.Next() {
+		var e SupernodeEvent
+		if err := rows.Scan(&e.ID, &e.EventType, &e.SupernodeAccount, &e.ValidatorAddress,
+			&e.OldValue, &e.NewValue, &e.Height, &e.Details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// PruneSupernodeEvents deletes events created before the cutoff and returns how many were removed.
+func PruneSupernodeEvents(ctx context.Context, pool *pgxpool.Pool, before time.Time) (int64, error) {
+	tag, err := pool.Exec(ctx, `DELETE FROM supernode_events WHERE "createdAt" < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
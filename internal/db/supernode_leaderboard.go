@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Leaderboard metrics supernodes can be ranked by.
+const (
+	LeaderboardEarnings        = "earnings"
+	LeaderboardFinalized       = "finalized"
+	LeaderboardFailureRate     = "failure_rate"
+	LeaderboardAvailability    = "availability"
+	LeaderboardFinalizeLatency = "finalize_latency"
+)
+
+// LeaderboardMetric is a rankable column and the order in which it ranks best first.
+type LeaderboardMetric struct {
+	Name        string
+	DefaultDesc bool
+	column      string
+}
+
+// LeaderboardMetrics lists the metrics accepted by GetSupernodeLeaderboard.
+var LeaderboardMetrics = []LeaderboardMetric{
+	{Name: LeaderboardEarnings, DefaultDesc: true, column: "earnings"},
+	{Name: LeaderboardFinalized, DefaultDesc: true, column: "finalized"},
+	{Name: LeaderboardFailureRate, DefaultDesc: false, column: "failure_rate"},
+	{Name: LeaderboardAvailability, DefaultDesc: true, column: "availability"},
+	{Name: LeaderboardFinalizeLatency, DefaultDesc: false, column: "median_latency"},
+}
+
+// LeaderboardMetricByName returns the metric with the given name.
+func LeaderboardMetricByName(name string) (LeaderboardMetric, bool) {
+	for _, m := range LeaderboardMetrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return LeaderboardMetric{}, false
+}
+
+// LeaderboardEntry is one supernode's totals over the leaderboard window.
+type LeaderboardEntry struct {
+	Rank             int64
+	SupernodeAccount string
+	ValidatorAddress string
+	ValidatorMoniker string
+	CurrentState     string
+	Earnings         string // decimal sum of action prices in the filter's denom
+	Finalized        int64
+	Assigned         int64
+	Failed           int64
+	FailureRate      *float64 // Failed / Assigned; nil without assigned actions
+	Availability     *float64 // fraction of probes with all checks up; nil without samples
+	ProbeSamples     int64
+	MedianLatency    *float64 // register → finalize seconds
+}
+
+// LeaderboardFilter selects the window, ranking and page of GetSupernodeLeaderboard.
+type LeaderboardFilter struct {
+	Metric    LeaderboardMetric
+	Desc      bool
+	Denom     string
+	From      time.Time // inclusive, UTC
+	To        time.Time // exclusive, UTC
+	AfterRank int64     // return entries ranked after this one
+	Limit     int
+}
+
+// GetSupernodeLeaderboard ranks every known supernode over [From, To). Earnings sum
+// the action prices of finalize transactions paying the supernode, as in
+// GetSupernodePaymentStats. Finalized counts and latencies use the first finalize
+// transaction, and failure rates the first register transaction, of actions listing the
+// supernode in superNodes. Availability reads probe rollups and samples. Missing values rank last
+// and ties are broken by account. It returns up to Limit entries and whether more follow.
+func GetSupernodeLeaderboard(ctx context.Context, pool *pgxpool.Pool, f LeaderboardFilter, now time.Time) ([]LeaderboardEntry, bool, error) {
+	if f.Metric.column == "" {
+		return nil, false, fmt.Errorf("unknown leaderboard metric %q", f.Metric.Name)
+	}
+	dir := "ASC"
+	if f.Desc {
+		dir = "DESC"
+	}
+
+	query := fmt.Sprintf(`WITH earn AS (
+			SELECT "flowPayee" AS account, SUM("actionPrice"::numeric) AS amount
+			FROM action_transactions
+			WHERE "txType" = 'finalize' AND "txHash" <> '_NO_TX_FOUND_' AND "actionPrice" IS NOT NULL
+				AND COALESCE("actionPriceDenom", '') = $3 AND "blockTime" >= $1 AND "blockTime" < $2
+			GROUP BY "flowPayee"
+		), fin AS (
+			SELECT sn.account, COUNT(*) AS finalized,
+				percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM (f."blockTime" - r."blockTime"))::DOUBLE PRECISION)
+					FILTER (WHERE r."blockTime" IS NOT NULL) AS median_latency
+			FROM action_transactions f
+			JOIN actions a ON a."actionID" = f."actionID"
+			CROSS JOIN LATERAL jsonb_array_elements_text(
+				CASE WHEN jsonb_typeof(a."superNodes") = 'array' THEN a."superNodes" ELSE '[]'::jsonb END) AS sn(account)
+			LEFT JOIN action_transactions r ON r."actionID" = f."actionID" AND r."txType" = 'register' AND r."txHash" <> '_NO_TX_FOUND_' AND %[3]s
+			WHERE f."txType" = 'finalize' AND f."txHash" <> '_NO_TX_FOUND_' AND f."blockTime" >= $1 AND f."blockTime" < $2 AND %[4]s
+			GROUP BY sn.account
+		), outcome AS (
+			SELECT sn.account, COUNT(*) AS assigned, COUNT(*) FILTER (WHERE a."state" = 'ACTION_STATE_FAILED') AS failed
+			FROM action_transactions r
+			JOIN actions a ON a."actionID" = r."actionID"
+			CROSS JOIN LATERAL jsonb_array_elements_text(
+				CASE WHEN jsonb_typeof(a."superNodes") = 'array' THEN a."superNodes" ELSE '[]'::jsonb END) AS sn(account)
+			WHERE r."txType" = 'register' AND r."txHash" <> '_NO_TX_FOUND_' AND r."blockTime" >= $1 AND r."blockTime" < $2 AND %[3]s
+			GROUP BY sn.account
+		), avail AS (
+			SELECT "supernodeAccount" AS account, SUM(n) AS n, SUM(fa) AS fa FROM (
+				SELECT "supernodeAccount", "sampleCount" AS n, "fullyAvailableCount" AS fa
+				FROM supernode_probe_rollups
+				WHERE "resolution" = '5m' AND "bucketStart" >= $1 AND "bucketStart" < LEAST($2::timestamp, $4::timestamp)
+				UNION ALL
+				SELECT "supernodeAccount", 1, "fullyAvailable"::int
+				FROM supernode_probe_samples
+				WHERE "sampledAt" >= GREATEST($1::timestamp, $4::timestamp) AND "sampledAt" < $2
+			) u GROUP BY "supernodeAccount"
+		), board AS (
+			SELECT s."supernodeAccount" AS account, COALESCE(s."validatorAddress", '') AS validator,
+				COALESCE(s."validatorMoniker", '') AS moniker, s."currentState" AS state,
+				COALESCE(earn.amount, 0) AS earnings,
+				COALESCE(fin.finalized, 0) AS finalized, fin.median_latency,
+				COALESCE(outcome.assigned, 0) AS assigned, COALESCE(outcome.failed, 0) AS failed,
+				outcome.failed::DOUBLE PRECISION / NULLIF(outcome.assigned, 0) AS failure_rate,
+				avail.fa::DOUBLE PRECISION / NULLIF(avail.n, 0) AS availability,
+				COALESCE(avail.n, 0)::BIGINT AS samples
+			FROM supernodes s
+			LEFT JOIN earn ON earn.account = s."supernodeAccount"
+			LEFT JOIN fin ON fin.account = s."supernodeAccount"
+			LEFT JOIN outcome ON outcome.account = s."supernodeAccount"
+			LEFT JOIN avail ON avail.account = s."supernodeAccount"
+		), ranked AS (
+			SELECT *, ROW_NUMBER() OVER (ORDER BY %[1]s %[2]s NULLS LAST, account) AS rank FROM board
+		)
+		SELECT rank, account, validator, moniker, state, earnings::TEXT, finalized, assigned, failed,
+			failure_rate, availability, samples, median_latency
+		FROM ranked WHERE rank > $5 ORDER BY rank LIMIT $6`, f.Metric.column, dir, firstOfType("r"), firstOfType("f"))
+
+	rows, err := pool.Query(ctx, query, f.From, f.To, f.Denom, availabilityBoundary(now), f.AfterRank, f.Limit+1)
+	if err != nil {
+		return nil, false, fmt.Errorf("query supernode leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []LeaderboardEntry
+	for rows.Next() {
+		var e LeaderboardEntry
+		if err := rows.Scan(&e.Rank, &e.SupernodeAccount, &e.ValidatorAddress, &e.ValidatorMoniker, &e.CurrentState,
+			&e.Earnings, &e.Finalized, &e.Assigned, &e.Failed, &e.FailureRate, &e.Availability, &e.ProbeSamples, &e.MedianLatency); err != nil {
+			return nil, false, fmt.Errorf("scan supernode leaderboard: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("iterate supernode leaderboard: %w", err)
+	}
+
+	hasMore := len(entries) > f.Limit
+	if hasMore {
+		entries = entries[:f.Limit]
+	}
+	return entries, hasMore, nil
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Checkpoint names used by background ingestion loops.
+const (
+	CheckpointActions           = "actions"
+	CheckpointWebhookActions    = "webhook_action_events"
+	CheckpointWebhookSupernodes = "webhook_supernode_events"
+	CheckpointBlockIndexer      = "action_tx_blocks"
+)
+
+// SyncCheckpoint records how far a background ingestion loop has progressed,
+// so steady-state runs only need to look at new or still-changing data.
+type SyncCheckpoint struct {
+	Name           string
+	LastHeight     int64      // Highest block height observed
+	LastActionID   uint64     // Highest action ID observed
+	LastEventID    int64      // Highest event log ID consumed
+	BackfillHeight int64      // Chain height the first full sync reached; 0 if not recorded
+	LastFullSyncAt *time.Time // Completion time of the last full reconciliation pass
+	UpdatedAt      time.Time
+}
+
+// terminalActionStates lists action states that no longer change on chain.
+// Actions in any other state are kept in the hot window and refreshed.
+// ACTION_STATE_DONE is not terminal, as a done action can still be approved.
+var terminalActionStates = []string{
+	"ACTION_STATE_APPROVED",
+	"ACTION_STATE_REJECTED",
+	"ACTION_STATE_FAILED",
+	"ACTION_STATE_EXPIRED",
+}
+
+// IsTerminalActionState reports whether an action in the given state can still change.
+func IsTerminalActionState(state string) bool {
+	for _, s := range terminalActionStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSyncCheckpoint loads a checkpoint by name. Returns ErrNotFound if none has been saved yet.
+func GetSyncCheckpoint(ctx context.Context, pool *pgxpool.Pool, name string) (SyncCheckpoint, error) {
+	var cp SyncCheckpoint
+	err := pool.QueryRow(ctx, `SELECT "name","lastHeight","lastActionID","lastEventID","backfillHeight","lastFullSyncAt","updatedAt"
+		FROM sync_checkpoints
+		WHERE "name" = $1`, name).Scan(
+		&cp.Name,
+		&cp.LastHeight,
+		&cp.LastActionID,
+		&cp.LastEventID,
+		&cp.BackfillHeight,
+		&cp.LastFullSyncAt,
+		&cp.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return SyncCheckpoint{}, ErrNotFound
+		}
+		return SyncCheckpoint{}, err
+	}
+	return cp, nil
+}
+
+// SaveSyncCheckpoint stores a checkpoint. Heights, action ID and event ID never move backwards,
+// and lastFullSyncAt is only replaced when a new value is provided.
+func SaveSyncCheckpoint(ctx context.Context, pool *pgxpool.Pool, cp SyncCheckpoint) error {
+	sql := `INSERT INTO sync_checkpoints ("name","lastHeight","lastActionID","lastEventID","backfillHeight","lastFullSyncAt","updatedAt")
+	VALUES ($1,$2,$3,$4,$5,$6,now())
+	ON CONFLICT ("name") DO UPDATE SET
+		"lastHeight"=GREATEST(sync_checkpoints."lastHeight",EXCLUDED."lastHeight"),
+		"lastActionID"=GREATEST(sync_checkpoints."lastActionID",EXCLUDED."lastActionID"),
+		"lastEventID"=GREATEST(sync_checkpoints."lastEventID",EXCLUDED."lastEventID"),
+		"backfillHeight"=GREATEST(sync_checkpoints."backfillHeight",EXCLUDED."backfillHeight"),
+		"lastFullSyncAt"=COALESCE(EXCLUDED."lastFullSyncAt",sync_checkpoints."lastFullSyncAt"),
+		"updatedAt"=now()`
+	_, err := pool.Exec(ctx, sql, cp.Name, cp.LastHeight, cp.LastActionID, cp.LastEventID, cp.BackfillHeight, cp.LastFullSyncAt)
+	return err
+}
+
+// MinActionBlockHeight returns the lowest block height of an indexed action, or 0 if
+// no action with a known height is indexed yet.
+func MinActionBlockHeight(ctx context.Context, pool *pgxpool.Pool) (int64, error) {
+	var h *int64
+	if err := pool.QueryRow(ctx, `SELECT MIN("blockHeight") FROM actions WHERE "blockHeight" > 0`).Scan(&h); err != nil {
+		return 0, err
+	}
+	if h == nil {
+		return 0, nil
+	}
+	return *h, nil
+}
+
+// actionStateDone is the state of a finalized action awaiting approval. Done actions
+// pile up faster than they change, so the hot window caps them separately.
+const actionStateDone = "ACTION_STATE_DONE"
+
+// ListHotActionIDs returns the most recent action IDs whose state is not terminal:
+// up to limit pending or processing actions, then up to doneLimit done ones. These
+// actions may still transition on chain and are refreshed on every incremental sync.
+func ListHotActionIDs(ctx context.Context, pool *pgxpool.Pool, limit, doneLimit int) ([]uint64, error) {
+	limit, doneLimit = max(limit, 0), max(doneLimit, 0)
+	if limit == 0 && doneLimit == 0 {
+		return nil, nil
+	}
+	rows, err := pool.Query(ctx, `(SELECT "actionID"
+		FROM actions
+		WHERE ("state" IS NULL OR NOT ("state" = ANY($1))) AND "state" IS DISTINCT FROM $2
+		ORDER BY "actionID" DESC
+		LIMIT $3)
+	UNION ALL
+	(SELECT "actionID"
+		FROM actions
+		WHERE "state" = $2
+		ORDER BY "actionID" DESC
+		LIMIT $4)`, terminalActionStates, actionStateDone, limit, doneLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
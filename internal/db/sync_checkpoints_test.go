@@ -0,0 +1,28 @@
+package db
+
+import "testing"
+
+// TestIsTerminalActionState verifies which states are excluded from the hot window
+func TestIsTerminalActionState(t *testing.T) {
+	tests := []struct {
+		state    string
+		terminal bool
+	}{
+		{"ACTION_STATE_PENDING", false},
+		{"ACTION_STATE_PROCESSING", false},
+		{"ACTION_STATE_DONE", false},
+		{"ACTION_STATE_APPROVED", true},
+		{"ACTION_STATE_REJECTED", true},
+		{"ACTION_STATE_FAILED", true},
+		{"ACTION_STATE_EXPIRED", true},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			if got := IsTerminalActionState(tt.state); got != tt.terminal {
+				t.Errorf("IsTerminalActionState(%q) = %v, want %v", tt.state, got, tt.terminal)
+			}
+		})
+	}
+}
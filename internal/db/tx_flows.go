@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Bank event types recorded as flows.
+const (
+	FlowTransfer     = "transfer"
+	FlowCoinSpent    = "coin_spent"
+	FlowCoinReceived = "coin_received"
+	FlowBurn         = "burn"
+)
+
+// TxFlow is one coin moved by a bank event of a transaction. Sender is the transfer
+// sender, coin_spent spender or burn burner; Recipient is the transfer recipient or
+// coin_received receiver. MsgIndex is nil for events emitted outside any message,
+// such as fee deduction.
+type TxFlow struct {
+	TxHash     string
+	EventIndex int // position among the transaction's events
+	CoinIndex  int // position in the event's comma-separated amount
+	MsgIndex   *int
+	EventType  string
+	Sender     *string
+	Recipient  *string
+	Amount     string
+	Denom      string
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	execer
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// ReplaceTxFlows replaces the stored flows of a transaction.
+func ReplaceTxFlows(ctx context.Context, pool *pgxpool.Pool, hash string, flows []TxFlow) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := replaceTxFlows(ctx, tx, hash, flows); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func replaceTxFlows(ctx context.Context, q execer, hash string, flows []TxFlow) error {
+	if _, err := q.Exec(ctx, `DELETE FROM tx_flows WHERE "txHash" = $1`, hash); err != nil {
+		return fmt.Errorf("delete tx flows: %w", err)
+	}
+	for _, f := range flows {
+		if _, err := q.Exec(ctx, `INSERT INTO tx_flows ("txHash","eventIndex","coinIndex","msgIndex","eventType","sender","recipient","amount","denom")
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
+			hash, f.EventIndex, f.CoinIndex, f.MsgIndex, f.EventType, f.Sender, f.Recipient, f.Amount, f.Denom); err != nil {
+			return fmt.Errorf("insert tx flow: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetTxFlows returns the flows of the given transactions by hash, in event order.
+func GetTxFlows(ctx context.Context, pool *pgxpool.Pool, hashes []string) (map[string][]TxFlow, error) {
+	out := make(map[string][]TxFlow, len(hashes))
+	if len(hashes) == 0 {
+		return out, nil
+	}
+	rows, err := pool.Query(ctx, `SELECT "txHash","eventIndex","coinIndex","msgIndex","eventType","sender","recipient","amount","denom"
+		FROM tx_flows
+		WHERE "txHash" = ANY($1)
+		ORDER BY "txHash", "eventIndex", "coinIndex"`, hashes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f TxFlow
+		if err := rows.Scan(&f.TxHash, &f.EventIndex, &f.CoinIndex, &f.MsgIndex, &f.EventType, &f.Sender, &f.Recipient, &f.Amount, &f.Denom); err != nil {
+			return nil, err
+		}
+		out[f.TxHash] = append(out[f.TxHash], f)
+	}
+	return out, rows.Err()
+}
+
+// GetTxHashesWithoutFlows returns hashes greater than after of indexed action
+// transactions that have neither flows nor a chain_txs entry, so their flows were
+// never recorded. Fetching them into chain_txs records their flows, even when there
+// are none.
+func GetTxHashesWithoutFlows(ctx context.Context, pool *pgxpool.Pool, after string, limit int) ([]string, error) {
+	rows, err := pool.Query(ctx, `SELECT DISTINCT at."txHash"
+		FROM action_transactions at
+		WHERE at."txHash" <> '_NO_TX_FOUND_' AND at."txHash" > $1
+			AND NOT EXISTS (SELECT 1 FROM tx_flows f WHERE f."txHash" = at."txHash")
+			AND NOT EXISTS (SELECT 1 FROM chain_txs c WHERE c."txHash" = at."txHash")
+		ORDER BY at."txHash"
+		LIMIT $2`, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
@@ -0,0 +1,310 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// WebhookFilters narrows which events a subscription receives. Empty fields match everything.
+type WebhookFilters struct {
+	ActionType string `json:"action_type,omitempty"`
+	Creator    string `json:"creator,omitempty"`
+	Supernode  string `json:"supernode,omitempty"`
+	Validator  string `json:"validator,omitempty"`
+}
+
+// WebhookSubscription is a registered webhook endpoint.
+type WebhookSubscription struct {
+	ID          int64
+	URL         string
+	Secret      string
+	EventTypes  []string
+	Filters     WebhookFilters
+	Active      bool
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// WebhookDelivery is one attempt-tracked delivery of an event to a subscription.
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventKey       string
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      *string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// WebhookDeadLetter is a delivery that exhausted its retries.
+type WebhookDeadLetter struct {
+	ID             int64
+	DeliveryID     int64
+	SubscriptionID int64
+	EventType      string
+	Payload        []byte
+	Attempts       int
+	LastError      string
+	CreatedAt      time.Time
+}
+
+const webhookSubscriptionColumns = `"id","url","secret","eventTypes","filters","active","description","createdAt","updatedAt"`
+
+func scanWebhookSubscription(row pgx.Row) (WebhookSubscription, error) {
+	var s WebhookSubscription
+	err := row.Scan(&s.ID, &s.URL, &s.Secret, &s.EventTypes, &s.Filters, &s.Active, &s.Description, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+// CreateWebhookSubscription inserts a subscription and returns it with ID and timestamps set.
+func CreateWebhookSubscription(ctx context.Context, pool *pgxpool.Pool, s WebhookSubscription) (WebhookSubscription, error) {
+	return scanWebhookSubscription(pool.QueryRow(ctx, `INSERT INTO webhook_subscriptions
+		("url","secret","eventTypes","filters","active","description","createdAt","updatedAt")
+	VALUES ($1,$2,$3,$4,$5,$6,now(),now())
+	RETURNING `+webhookSubscriptionColumns,
+		s.URL, s.Secret, s.EventTypes, s.Filters, s.Active, s.Description))
+}
+
+// UpdateWebhookSubscription replaces the mutable fields of a subscription. Returns ErrNotFound if it doesn't exist.
+func UpdateWebhookSubscription(ctx context.Context, pool *pgxpool.Pool, s WebhookSubscription) (WebhookSubscription, error) {
+	out, err := scanWebhookSubscription(pool.QueryRow(ctx, `UPDATE webhook_subscriptions SET
+		"url"=$2,"secret"=$3,"eventTypes"=$4,"filters"=$5,"active"=$6,"description"=$7,"updatedAt"=now()
+	WHERE "id"=$1
+	RETURNING `+webhookSubscriptionColumns,
+		s.ID, s.URL, s.Secret, s.EventTypes, s.Filters, s.Active, s.Description))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WebhookSubscription{}, ErrNotFound
+	}
+	return out, err
+}
+
+// GetWebhookSubscription loads a subscription by ID. Returns ErrNotFound if it doesn't exist.
+func GetWebhookSubscription(ctx context.Context, pool *pgxpool.Pool, id int64) (WebhookSubscription, error) {
+	s, err := scanWebhookSubscription(pool.QueryRow(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE "id"=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WebhookSubscription{}, ErrNotFound
+	}
+	return s, err
+}
+
+// DeleteWebhookSubscription removes a subscription and its delivery history. Returns ErrNotFound if it doesn't exist.
+func DeleteWebhookSubscription(ctx context.Context, pool *pgxpool.Pool, id int64) error {
+	tag, err := pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE "id"=$1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListWebhookSubscriptions returns all subscriptions, optionally only active ones, ordered by ID.
+func ListWebhookSubscriptions(ctx context.Context, pool *pgxpool.Pool, activeOnly bool) ([]WebhookSubscription, error) {
+	sql := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions`
+	if activeOnly {
+		sql += ` WHERE "active"`
+	}
+	sql += ` ORDER BY "id"`
+	rows, err := pool.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WebhookSubscription
+	for rows.Next() {
+		s, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// EnqueueWebhookDelivery schedules an event for delivery. Enqueuing the same event key for
+// the same subscription twice is a no-op, so fan-out can safely be retried.
+func EnqueueWebhookDelivery(ctx context.Context, pool *pgxpool.Pool, subscriptionID int64, eventKey, eventType string, payload []byte) error {
+	_, err := pool.Exec(ctx, `INSERT INTO webhook_deliveries
+		("subscriptionID","eventKey","eventType","payload","status","attempts","nextAttemptAt","createdAt","updatedAt")
+	VALUES ($1,$2,$3,$4,'pending',0,now(),now(),now())
+	ON CONFLICT ("subscriptionID","eventKey") DO NOTHING`,
+		subscriptionID, eventKey, eventType, string(payload))
+	return err
+}
+
+// ClaimDueWebhookDeliveries leases up to limit pending deliveries whose next attempt is due.
+// Claimed rows have nextAttemptAt pushed out by lease so other workers (or a restarted
+// process) only pick them up again if this worker never records a result.
+func ClaimDueWebhookDeliveries(ctx context.Context, pool *pgxpool.Pool, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	rows, err := pool.Query(ctx, `UPDATE webhook_deliveries d SET
+		"nextAttemptAt"=now() + $2 * interval '1 millisecond',
+		"updatedAt"=now()
+	WHERE d."id" IN (
+		SELECT "id" FROM webhook_deliveries
+		WHERE "status"='pending' AND "nextAttemptAt" <= now()
+		ORDER BY "nextAttemptAt"
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING d."id",d."subscriptionID",d."eventKey",d."eventType",d."payload"::text,d."status",d."attempts",d."nextAttemptAt",d."lastStatusCode",d."lastError",d."createdAt",d."deliveredAt"`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// MarkWebhookDeliverySucceeded records a successful attempt.
+func MarkWebhookDeliverySucceeded(ctx context.Context, pool *pgxpool.Pool, id int64, statusCode int) error {
+	_, err := pool.Exec(ctx, `UPDATE webhook_deliveries SET
+		"status"='succeeded',"attempts"="attempts"+1,"lastStatusCode"=$2,"lastError"=NULL,
+		"deliveredAt"=now(),"updatedAt"=now()
+	WHERE "id"=$1`, id, statusCode)
+	return err
+}
+
+// MarkWebhookDeliveryRetry records a failed attempt and schedules the next one.
+func MarkWebhookDeliveryRetry(ctx context.Context, pool *pgxpool.Pool, id int64, statusCode *int, lastError string, next time.Time) error {
+	_, err := pool.Exec(ctx, `UPDATE webhook_deliveries SET
+		"attempts"="attempts"+1,"lastStatusCode"=$2,"lastError"=$3,"nextAttemptAt"=$4,"updatedAt"=now()
+	WHERE "id"=$1`, id, statusCode, lastError, next)
+	return err
+}
+
+// MarkWebhookDeliveryDead records the final failed attempt and copies the delivery to the dead-letter table.
+func MarkWebhookDeliveryDead(ctx context.Context, pool *pgxpool.Pool, id int64, statusCode *int, lastError string) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE webhook_deliveries SET
+		"status"='dead',"attempts"="attempts"+1,"lastStatusCode"=$2,"lastError"=$3,"updatedAt"=now()
+	WHERE "id"=$1`, id, statusCode, lastError); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO webhook_dead_letters
+		("deliveryID","subscriptionID","eventType","payload","attempts","lastError","createdAt")
+	SELECT "id","subscriptionID","eventType","payload","attempts",$2,now()
+	FROM webhook_deliveries WHERE "id"=$1`, id, lastError); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// PruneWebhookDeliveries deletes succeeded and dead deliveries last attempted before the
+// cutoff and returns how many were removed. Dead letters keep their own copy.
+func PruneWebhookDeliveries(ctx context.Context, pool *pgxpool.Pool, before time.Time) (int64, error) {
+	tag, err := pool.Exec(ctx, `DELETE FROM webhook_deliveries WHERE "status" <> 'pending' AND "updatedAt" < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// WebhookDeliveriesFilter selects deliveries for the delivery log.
+type WebhookDeliveriesFilter struct {
+	SubscriptionID int64
+	Status         *string
+	Limit          int
+	CursorID       *int64 // Return deliveries with id < CursorID (newest first)
+}
+
+// ListWebhookDeliveries returns a subscription's deliveries, newest first, with keyset pagination on id.
+func ListWebhookDeliveries(ctx context.Context, pool *pgxpool.Pool, f WebhookDeliveriesFilter) ([]WebhookDelivery, bool, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 1
+	}
+	args := []any{f.SubscriptionID}
+	sql := `SELECT "id","subscriptionID","eventKey","eventType","payload"::text,"status","attempts","nextAttemptAt","lastStatusCode","lastError","createdAt","deliveredAt"
+		FROM webhook_deliveries WHERE "subscriptionID"=$1`
+	if f.Status != nil {
+		args = append(args, *f.Status)
+		sql += fmt.Sprintf(` AND "status"=$%d`, len(args))
+	}
+	if f.CursorID != nil {
+		args = append(args, *f.CursorID)
+		sql += fmt.Sprintf(` AND "id"<$%d`, len(args))
+	}
+	args = append(args, limit+1)
+	sql += fmt.Sprintf(` ORDER BY "id" DESC LIMIT $%d`, len(args))
+
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	out, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := false
+	if len(out) > limit {
+		hasMore = true
+		out = out[:limit]
+	}
+	return out, hasMore, nil
+}
+
+// ListWebhookDeadLetters returns a subscription's dead letters, newest first.
+func ListWebhookDeadLetters(ctx context.Context, pool *pgxpool.Pool, subscriptionID int64, limit int) ([]WebhookDeadLetter, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := pool.Query(ctx, `SELECT "id","deliveryID","subscriptionID","eventType","payload"::text,"attempts","lastError","createdAt"
+		FROM webhook_dead_letters WHERE "subscriptionID"=$1
+		ORDER BY "id" DESC LIMIT $2`, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WebhookDeadLetter
+	for rows.Next() {
+		var d WebhookDeadLetter
+		var payload string
+		if err := rows.Scan(&d.ID, &d.DeliveryID, &d.SubscriptionID, &d.EventType, &payload, &d.Attempts, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Payload = []byte(payload)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func scanWebhookDeliveries(rows pgx.Rows) ([]WebhookDelivery, error) {
+	defer rows.Close()
+	var out []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var payload string
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventKey, &d.EventType, &payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, err
+		}
+		d.Payload = []byte(payload)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,100 @@
+// Package export serializes export datasets (see db.ExportDataset) as CSV, NDJSON or
+// Parquet while rows stream from the database.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"lumescope/internal/db"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	CSV     Format = "csv"
+	NDJSON  Format = "ndjson"
+	Parquet Format = "parquet"
+)
+
+// ParseFormat parses a format name; empty means CSV.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return CSV, nil
+	case CSV, NDJSON, Parquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %q: must be 'csv', 'ndjson' or 'parquet'", s)
+	}
+}
+
+// ContentType is the MIME type served for the format.
+func (f Format) ContentType() string {
+	switch f {
+	case NDJSON:
+		return "application/x-ndjson"
+	case Parquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Extension is the file extension for the format, without the dot.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// RowWriter encodes rows of one dataset.
+type RowWriter interface {
+	// WriteRow encodes one row; values follow db.ExportType.
+	WriteRow(row []any) error
+	// Flush writes buffered rows to the underlying writer where the format allows it.
+	Flush() error
+	// Close writes any trailer and flushes. It doesn't close the underlying writer.
+	Close() error
+}
+
+// NewWriter returns a RowWriter for cols in format f. Nothing is written to w until
+// the first Flush or Close, so callers can still report errors that occur before any
+// row was read.
+func NewWriter(f Format, w io.Writer, cols []db.ExportColumn) (RowWriter, error) {
+	switch f {
+	case CSV:
+		return newCSVWriter(w, cols), nil
+	case NDJSON:
+		return newNDJSONWriter(w, cols), nil
+	case Parquet:
+		return newParquetWriter(w, cols), nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", f)
+	}
+}
+
+// Run streams dataset d filtered by filter to w in format f, flushing after every
+// cursor batch, and returns the number of rows written.
+func Run(ctx context.Context, pool *db.Pool, d db.ExportDataset, filter db.ExportFilter, f Format, w io.Writer) (int64, error) {
+	rw, err := NewWriter(f, w, d.Columns)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	n, err := db.StreamExport(ctx, pool, d, filter, func(row []any) error {
+		if err := rw.WriteRow(row); err != nil {
+			return err
+		}
+		written++
+		if written%db.ExportFetchSize == 0 {
+			return rw.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, rw.Close()
+}
@@ -0,0 +1,230 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+var testColumns = []db.ExportColumn{
+	{Name: "id", Type: db.ExportInt64},
+	{Name: "name", Type: db.ExportString},
+	{Name: "score", Type: db.ExportFloat64},
+	{Name: "ok", Type: db.ExportBool},
+	{Name: "at", Type: db.ExportTime},
+	{Name: "meta", Type: db.ExportJSON},
+}
+
+var testRows = [][]any{
+	{int64(1), "a,b", 1.5, true, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), `{"k": [1, 2]}`},
+	{int64(2), nil, nil, false, nil, nil},
+}
+
+func writeAll(t *testing.T, f Format, rows [][]any) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(f, &buf, testColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(rows) < parquetRowGroupSize && buf.Len() != 0 {
+		t.Fatalf("%s: %d bytes written before flush", f, buf.Len())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": CSV, "CSV": CSV, "ndjson": NDJSON, " parquet ": Parquet} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("xlsx"); err == nil {
+		t.Error("expected error for xlsx")
+	}
+}
+
+func TestCSV(t *testing.T) {
+	want := "id,name,score,ok,at,meta\n" +
+		"1,\"a,b\",1.5,true,2025-01-02T03:04:05Z,\"{\"\"k\"\": [1, 2]}\"\n" +
+		"2,,,false,,\n"
+	if got := string(writeAll(t, CSV, testRows)); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := string(writeAll(t, CSV, nil)); got != "id,name,score,ok,at,meta\n" {
+		t.Fatalf("empty export = %q", got)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	want := `{"id":1,"name":"a,b","score":1.5,"ok":true,"at":"2025-01-02T03:04:05Z","meta":{"k": [1, 2]}}` + "\n" +
+		`{"id":2,"name":null,"score":null,"ok":false,"at":null,"meta":null}` + "\n"
+	if got := string(writeAll(t, NDJSON, testRows)); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestParquet decodes the footer and one column chunk of a two row group file
+func TestParquet(t *testing.T) {
+	rows := make([][]any, 0, parquetRowGroupSize+3)
+	for i := 0; i < parquetRowGroupSize+3; i++ {
+		rows = append(rows, testRows[i%2])
+	}
+	file := writeAll(t, Parquet, rows)
+
+	if !bytes.HasPrefix(file, parquetMagic) || !bytes.HasSuffix(file, parquetMagic) {
+		t.Fatal("missing magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := file[len(file)-8-footerLen : len(file)-8]
+	r := &compactReader{b: footer}
+	meta := r.readStruct()
+	if r.pos != len(footer) {
+		t.Fatalf("footer decoded %d of %d bytes", r.pos, len(footer))
+	}
+
+	if got := meta[3].(int64); got != int64(len(rows)) {
+		t.Fatalf("num_rows = %d", got)
+	}
+	schema := meta[2].([]any)
+	if len(schema) != len(testColumns)+1 {
+		t.Fatalf("schema has %d elements", len(schema))
+	}
+	for i, c := range testColumns {
+		if name := string(schema[i+1].(map[int16]any)[4].([]byte)); name != c.Name {
+			t.Errorf("schema[%d] = %q, want %q", i+1, name, c.Name)
+		}
+	}
+	if ts := schema[5].(map[int16]any); ts[1] != int64(pqInt64) || ts[6] != int64(pqConvertedTimestampMillis) {
+		t.Errorf("timestamp schema = %v", ts)
+	}
+
+	groups := meta[4].([]any)
+	if len(groups) != 2 || groups[0].(map[int16]any)[3] != int64(parquetRowGroupSize) || groups[1].(map[int16]any)[3] != int64(3) {
+		t.Fatalf("row groups = %v", groups)
+	}
+
+	// Decode the "score" column of the last row group: 1.5, null, 1.5
+	chunk := groups[1].(map[int16]any)[1].([]any)[2].(map[int16]any)[3].(map[int16]any)
+	offset := chunk[9].(int64)
+	r = &compactReader{b: file[offset:]}
+	page := r.readStruct()
+	data := file[offset+int64(r.pos):][:page[3].(int64)]
+	if dp := page[5].(map[int16]any); dp[1] != int64(3) {
+		t.Fatalf("page num_values = %v", dp[1])
+	}
+	levelsLen := binary.LittleEndian.Uint32(data)
+	levels := data[4 : 4+levelsLen]
+	// One bit-packed group (header 0b11) with levels 1,0,1
+	if !bytes.Equal(levels, []byte{0x03, 0x05}) {
+		t.Fatalf("levels = %x", levels)
+	}
+	values := data[4+levelsLen:]
+	if len(values) != 16 || math.Float64frombits(binary.LittleEndian.Uint64(values)) != 1.5 {
+		t.Fatalf("values = %x", values)
+	}
+}
+
+func TestParquetEmpty(t *testing.T) {
+	file := writeAll(t, Parquet, nil)
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	if 4+footerLen+8 != len(file) {
+		t.Fatalf("file is %d bytes with a %d byte footer", len(file), footerLen)
+	}
+	meta := (&compactReader{b: file[4 : 4+footerLen]}).readStruct()
+	if meta[3] != int64(0) || len(meta[4].([]any)) != 0 {
+		t.Fatalf("meta = %v", meta)
+	}
+}
+
+func TestWriterRejectsBadValues(t *testing.T) {
+	for _, f := range []Format{CSV, NDJSON, Parquet} {
+		w, _ := NewWriter(f, &bytes.Buffer{}, testColumns)
+		if err := w.WriteRow([]any{struct{}{}, nil, nil, nil, nil, nil}); err == nil {
+			t.Errorf("%s: expected error for unsupported value", f)
+		}
+	}
+	w, _ := NewWriter(NDJSON, &bytes.Buffer{}, testColumns)
+	if err := w.WriteRow([]any{int64(1), nil, nil, nil, nil, "{"}); err == nil || !strings.Contains(err.Error(), "meta") {
+		t.Errorf("invalid JSON: err = %v", err)
+	}
+}
+
+// compactReader decodes Thrift compact structs into field id → value maps, enough to
+// check what parquetWriter produces.
+type compactReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *compactReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) varint() int64 {
+	v, n := binary.Varint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) readStruct() map[int16]any {
+	out := map[int16]any{}
+	var last int16
+	for {
+		h := r.b[r.pos]
+		r.pos++
+		if h == 0 {
+			return out
+		}
+		typ := h & 0x0F
+		if delta := int16(h >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(r.varint())
+		}
+		out[last] = r.readValue(typ)
+	}
+}
+
+func (r *compactReader) readValue(typ byte) any {
+	switch typ {
+	case ctI32, ctI64:
+		return r.varint()
+	case ctBinary:
+		n := int(r.uvarint())
+		v := r.b[r.pos : r.pos+n]
+		r.pos += n
+		return v
+	case ctList:
+		h := r.b[r.pos]
+		r.pos++
+		n, elem := int(h>>4), h&0x0F
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		out := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			out = append(out, r.readValue(elem))
+		}
+		return out
+	case ctStruct:
+		return r.readStruct()
+	default:
+		panic("unexpected compact type")
+	}
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// ParseFilter builds an export filter from named values (query parameters or CLI
+// flags) using the db.ExportFilter* names; get returns "" for unset names. Heights are
+// integers and times RFC3339, matching /v1/actions and /v1/actions/stats.
+func ParseFilter(get func(name string) string) (db.ExportFilter, error) {
+	var f db.ExportFilter
+	str := func(name string) *string {
+		if v := strings.TrimSpace(get(name)); v != "" {
+			return &v
+		}
+		return nil
+	}
+	f.Type = str(db.ExportFilterType)
+	f.Creator = str(db.ExportFilterCreator)
+	f.State = str(db.ExportFilterState)
+	f.Supernode = str(db.ExportFilterSupernode)
+	f.TxType = str(db.ExportFilterTxType)
+	f.CurrentState = str(db.ExportFilterCurrentState)
+	f.Version = str(db.ExportFilterVersion)
+
+	for _, h := range []struct {
+		name string
+		dst  **int64
+	}{{db.ExportFilterFromHeight, &f.FromHeight}, {db.ExportFilterToHeight, &f.ToHeight}} {
+		if v := str(h.name); v != nil {
+			n, err := strconv.ParseInt(*v, 10, 64)
+			if err != nil {
+				return f, fmt.Errorf("invalid %s parameter: must be a block height", h.name)
+			}
+			*h.dst = &n
+		}
+	}
+	for _, tm := range []struct {
+		name string
+		dst  **time.Time
+	}{{db.ExportFilterFromTime, &f.FromTime}, {db.ExportFilterToTime, &f.ToTime}} {
+		if v := str(tm.name); v != nil {
+			t, err := time.Parse(time.RFC3339, *v)
+			if err != nil {
+				return f, fmt.Errorf("invalid %s parameter: must be RFC3339 format", tm.name)
+			}
+			*tm.dst = &t
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,431 @@
+package export
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// A minimal Parquet writer: flat schema of optional columns, one uncompressed PLAIN
+// data page (v1) per column chunk, and row groups of parquetRowGroupSize rows so memory
+// stays bounded. Metadata is Thrift compact protocol, as required by the format.
+// See https://github.com/apache/parquet-format.
+
+const parquetRowGroupSize = 8192
+
+var parquetMagic = []byte("PAR1")
+
+// Parquet physical types, converted types, encodings and enums used here.
+const (
+	pqBoolean   = 0
+	pqInt64     = 2
+	pqDouble    = 5
+	pqByteArray = 6
+
+	pqConvertedUTF8            = 0
+	pqConvertedTimestampMillis = 9
+	pqConvertedJSON            = 19
+
+	pqRepetitionOptional = 1
+	pqEncodingPlain      = 0
+	pqEncodingRLE        = 3
+	pqCodecUncompressed  = 0
+	pqPageData           = 0
+)
+
+// parquetColumn buffers one column chunk of the current row group.
+type parquetColumn struct {
+	name      string
+	typ       db.ExportType
+	defined   []bool // definition level per row: false means null
+	values    []byte // PLAIN-encoded non-null values (except booleans)
+	bools     []bool // non-null boolean values, bit-packed on flush
+	physical  int32
+	converted int32 // -1 for none
+}
+
+type parquetRowGroup struct {
+	columns   []parquetChunkMeta
+	numRows   int64
+	byteSize  int64
+	firstByte int64
+}
+
+type parquetChunkMeta struct {
+	offset int64 // data page offset
+	size   int64 // page header plus page data
+	values int64
+}
+
+type parquetWriter struct {
+	w         io.Writer
+	columns   []*parquetColumn
+	rows      int // rows in the current row group
+	groups    []parquetRowGroup
+	offset    int64
+	totalRows int64
+	started   bool
+}
+
+func newParquetWriter(w io.Writer, cols []db.ExportColumn) *parquetWriter {
+	pw := &parquetWriter{w: w}
+	for _, c := range cols {
+		pc := &parquetColumn{name: c.Name, typ: c.Type, converted: -1}
+		switch c.Type {
+		case db.ExportInt64:
+			pc.physical = pqInt64
+		case db.ExportFloat64:
+			pc.physical = pqDouble
+		case db.ExportBool:
+			pc.physical = pqBoolean
+		case db.ExportTime:
+			pc.physical, pc.converted = pqInt64, pqConvertedTimestampMillis
+		case db.ExportJSON:
+			pc.physical, pc.converted = pqByteArray, pqConvertedJSON
+		default:
+			pc.physical, pc.converted = pqByteArray, pqConvertedUTF8
+		}
+		pw.columns = append(pw.columns, pc)
+	}
+	return pw
+}
+
+func (w *parquetWriter) WriteRow(row []any) error {
+	for i, v := range row {
+		if err := w.columns[i].append(v); err != nil {
+			return fmt.Errorf("column %s: %w", w.columns[i].name, err)
+		}
+	}
+	w.rows++
+	if w.rows >= parquetRowGroupSize {
+		return w.flushRowGroup()
+	}
+	return nil
+}
+
+func (c *parquetColumn) append(v any) error {
+	if v == nil {
+		c.defined = append(c.defined, false)
+		return nil
+	}
+	switch c.typ {
+	case db.ExportInt64:
+		n, ok := asInt64(v)
+		if !ok {
+			return fmt.Errorf("unsupported value %T", v)
+		}
+		c.values = binary.LittleEndian.AppendUint64(c.values, uint64(n))
+	case db.ExportFloat64:
+		f, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("unsupported value %T", v)
+		}
+		c.values = binary.LittleEndian.AppendUint64(c.values, math.Float64bits(f))
+	case db.ExportBool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("unsupported value %T", v)
+		}
+		c.bools = append(c.bools, b)
+	case db.ExportTime:
+		t, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("unsupported value %T", v)
+		}
+		c.values = binary.LittleEndian.AppendUint64(c.values, uint64(t.UnixMilli()))
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("unsupported value %T", v)
+		}
+		c.values = binary.LittleEndian.AppendUint32(c.values, uint32(len(s)))
+		c.values = append(c.values, s...)
+	}
+	c.defined = append(c.defined, true)
+	return nil
+}
+
+func asInt64(v any) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// start writes the leading magic number; output is deferred until there is a row
+// group or footer to write.
+func (w *parquetWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.write(parquetMagic)
+}
+
+func (w *parquetWriter) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+// flushRowGroup writes the buffered rows as one row group.
+func (w *parquetWriter) flushRowGroup() error {
+	if w.rows == 0 {
+		return nil
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	rg := parquetRowGroup{numRows: int64(w.rows), firstByte: w.offset}
+	for _, c := range w.columns {
+		data := c.pageData()
+		var hdr compactWriter
+		hdr.begin()
+		hdr.i32(1, pqPageData)
+		hdr.i32(2, int32(len(data)))
+		hdr.i32(3, int32(len(data)))
+		hdr.beginStruct(5) // DataPageHeader
+		hdr.i32(1, int32(len(c.defined)))
+		hdr.i32(2, pqEncodingPlain)
+		hdr.i32(3, pqEncodingRLE)
+		hdr.i32(4, pqEncodingRLE)
+		hdr.end()
+		hdr.end()
+
+		meta := parquetChunkMeta{offset: w.offset, size: int64(len(hdr.b) + len(data)), values: int64(len(c.defined))}
+		if err := w.write(hdr.b); err != nil {
+			return err
+		}
+		if err := w.write(data); err != nil {
+			return err
+		}
+		rg.columns = append(rg.columns, meta)
+		rg.byteSize += meta.size
+		c.reset()
+	}
+	w.groups = append(w.groups, rg)
+	w.totalRows += int64(w.rows)
+	w.rows = 0
+	return nil
+}
+
+// pageData encodes definition levels (RLE/bit-packed hybrid, length-prefixed) followed
+// by the PLAIN values.
+func (c *parquetColumn) pageData() []byte {
+	levels := encodeLevels(c.defined)
+	data := make([]byte, 0, 4+len(levels)+len(c.values)+len(c.bools)/8+1)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(levels)))
+	data = append(data, levels...)
+	if c.physical == pqBoolean {
+		return append(data, packBits(c.bools)...)
+	}
+	return append(data, c.values...)
+}
+
+func (c *parquetColumn) reset() {
+	c.defined = c.defined[:0]
+	c.values = c.values[:0]
+	c.bools = c.bools[:0]
+}
+
+// encodeLevels encodes 1-bit definition levels: a single RLE run when all rows agree,
+// otherwise one bit-packed run.
+func encodeLevels(defined []bool) []byte {
+	n := len(defined)
+	uniform := true
+	for _, d := range defined {
+		if d != defined[0] {
+			uniform = false
+			break
+		}
+	}
+	if uniform {
+		var v byte
+		if n > 0 && defined[0] {
+			v = 1
+		}
+		return append(binary.AppendUvarint(nil, uint64(n)<<1), v)
+	}
+	groups := (n + 7) / 8
+	return append(binary.AppendUvarint(nil, uint64(groups)<<1|1), packBits(defined)...)
+}
+
+// packBits packs booleans LSB first, padding the last byte with zeros.
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
+
+// Flush is a no-op: rows are written a row group at a time.
+func (w *parquetWriter) Flush() error {
+	return nil
+}
+
+// Close writes the last row group and the file footer.
+func (w *parquetWriter) Close() error {
+	if err := w.flushRowGroup(); err != nil {
+		return err
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	footer := w.fileMetaData()
+	if err := w.write(footer); err != nil {
+		return err
+	}
+	tail := binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))
+	if err := w.write(append(tail, parquetMagic...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// fileMetaData encodes the FileMetaData footer.
+func (w *parquetWriter) fileMetaData() []byte {
+	var m compactWriter
+	m.begin()
+	m.i32(1, 1) // version
+
+	m.list(2, ctStruct, len(w.columns)+1) // schema
+	m.begin()
+	m.binary(4, "schema")
+	m.i32(5, int32(len(w.columns)))
+	m.end()
+	for _, c := range w.columns {
+		m.begin()
+		m.i32(1, c.physical)
+		m.i32(3, pqRepetitionOptional)
+		m.binary(4, c.name)
+		if c.converted >= 0 {
+			m.i32(6, c.converted)
+		}
+		m.end()
+	}
+
+	m.i64(3, w.totalRows)
+
+	m.list(4, ctStruct, len(w.groups)) // row_groups
+	for _, rg := range w.groups {
+		m.begin()
+		m.list(1, ctStruct, len(rg.columns))
+		for i, cm := range rg.columns {
+			c := w.columns[i]
+			m.begin() // ColumnChunk
+			m.i64(2, cm.offset)
+			m.beginStruct(3) // ColumnMetaData
+			m.i32(1, c.physical)
+			m.list(2, ctI32, 2)
+			m.listI32(pqEncodingPlain)
+			m.listI32(pqEncodingRLE)
+			m.list(3, ctBinary, 1)
+			m.listBinary(c.name)
+			m.i32(4, pqCodecUncompressed)
+			m.i64(5, cm.values)
+			m.i64(6, cm.size)
+			m.i64(7, cm.size)
+			m.i64(9, cm.offset)
+			m.end()
+			m.end()
+		}
+		m.i64(2, rg.byteSize)
+		m.i64(3, rg.numRows)
+		m.i64(5, rg.firstByte) // file_offset
+		m.i64(6, rg.byteSize)  // total_compressed_size
+		m.end()
+	}
+
+	m.binary(6, "lumescope")
+	m.end()
+	return m.b
+}
+
+// Thrift compact protocol field types.
+const (
+	ctI32    = 5
+	ctI64    = 6
+	ctBinary = 8
+	ctList   = 9
+	ctStruct = 12
+)
+
+// compactWriter encodes Thrift compact protocol structs. begin/end bracket a struct,
+// either the top-level one or a struct element of a list; beginStruct opens a struct
+// field.
+type compactWriter struct {
+	b    []byte
+	last []int16 // last field id of each open struct
+}
+
+func (w *compactWriter) begin() {
+	w.last = append(w.last, 0)
+}
+
+func (w *compactWriter) end() {
+	w.b = append(w.b, 0) // stop field
+	w.last = w.last[:len(w.last)-1]
+}
+
+func (w *compactWriter) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.b = append(w.b, byte(delta)<<4|typ)
+	} else {
+		w.b = append(w.b, typ)
+		w.b = binary.AppendVarint(w.b, int64(id))
+	}
+	*last = id
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.field(id, ctI32)
+	w.b = binary.AppendVarint(w.b, int64(v))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.field(id, ctI64)
+	w.b = binary.AppendVarint(w.b, v)
+}
+
+func (w *compactWriter) binary(id int16, s string) {
+	w.field(id, ctBinary)
+	w.listBinary(s)
+}
+
+func (w *compactWriter) beginStruct(id int16) {
+	w.field(id, ctStruct)
+	w.begin()
+}
+
+// list writes a list field header; the n elements follow.
+func (w *compactWriter) list(id int16, elem byte, n int) {
+	w.field(id, ctList)
+	if n < 15 {
+		w.b = append(w.b, byte(n)<<4|elem)
+		return
+	}
+	w.b = append(w.b, 0xF0|elem)
+	w.b = binary.AppendUvarint(w.b, uint64(n))
+}
+
+func (w *compactWriter) listI32(v int32) {
+	w.b = binary.AppendVarint(w.b, int64(v))
+}
+
+func (w *compactWriter) listBinary(s string) {
+	w.b = binary.AppendUvarint(w.b, uint64(len(s)))
+	w.b = append(w.b, s...)
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// textBufferSize is the write buffer of the CSV and NDJSON writers.
+const textBufferSize = 64 << 10
+
+// formatText renders a value for CSV; nil is the empty string and JSON is kept as text.
+func formatText(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	default:
+		return "", fmt.Errorf("unsupported export value %T", v)
+	}
+}
+
+type csvWriter struct {
+	bw      *bufio.Writer
+	cw      *csv.Writer
+	header  []string
+	started bool
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, cols []db.ExportColumn) *csvWriter {
+	bw := bufio.NewWriterSize(w, textBufferSize)
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Name
+	}
+	return &csvWriter{bw: bw, cw: csv.NewWriter(bw), header: header, record: make([]string, len(cols))}
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.cw.Write(w.header)
+}
+
+func (w *csvWriter) WriteRow(row []any) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	for i, v := range row {
+		s, err := formatText(v)
+		if err != nil {
+			return err
+		}
+		w.record[i] = s
+	}
+	return w.cw.Write(w.record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.cw.Flush()
+	if err := w.cw.Error(); err != nil {
+		return err
+	}
+	return w.bw.Flush()
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// ndjsonWriter writes one JSON object per line with keys in column order. JSON columns
+// are embedded as values rather than strings.
+type ndjsonWriter struct {
+	bw   *bufio.Writer
+	cols []db.ExportColumn
+	keys [][]byte // pre-encoded `"name":`
+	buf  []byte
+}
+
+func newNDJSONWriter(w io.Writer, cols []db.ExportColumn) *ndjsonWriter {
+	keys := make([][]byte, len(cols))
+	for i, c := range cols {
+		k, _ := json.Marshal(c.Name)
+		keys[i] = append(k, ':')
+	}
+	return &ndjsonWriter{bw: bufio.NewWriterSize(w, textBufferSize), cols: cols, keys: keys}
+}
+
+func (w *ndjsonWriter) WriteRow(row []any) error {
+	b := append(w.buf[:0], '{')
+	for i, v := range row {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, w.keys[i]...)
+		switch {
+		case v == nil:
+			b = append(b, "null"...)
+		case w.cols[i].Type == db.ExportJSON:
+			s, ok := v.(string)
+			if !ok || !json.Valid([]byte(s)) {
+				return fmt.Errorf("column %s: invalid JSON value", w.cols[i].Name)
+			}
+			b = append(b, s...)
+		default:
+			var err error
+			if b, err = appendJSONValue(b, v); err != nil {
+				return fmt.Errorf("column %s: %w", w.cols[i].Name, err)
+			}
+		}
+	}
+	b = append(b, '}', '\n')
+	w.buf = b
+	_, err := w.bw.Write(b)
+	return err
+}
+
+// appendJSONValue appends a non-nil scalar export value as JSON.
+func appendJSONValue(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		enc, err := json.Marshal(v)
+		return append(b, enc...), err
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+	case int32:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case float64:
+		enc, err := json.Marshal(v)
+		return append(b, enc...), err
+	case bool:
+		return strconv.AppendBool(b, v), nil
+	case time.Time:
+		b = append(b, '"')
+		b = v.UTC().AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"'), nil
+	default:
+		return b, fmt.Errorf("unsupported export value %T", v)
+	}
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return w.bw.Flush()
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.bw.Flush()
+}
@@ -0,0 +1,737 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Request is a GraphQL request as posted by clients.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+
+	// Root is passed as the Source of root field resolvers.
+	Root any `json:"-"`
+}
+
+// Response is a GraphQL response. Data is nil when the request failed before
+// execution started (syntax, validation or limit errors).
+type Response struct {
+	Data   *OrderedMap `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a GraphQL error.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Location is a 1-based position in the query document.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// OrderedMap is a JSON object that keeps fields in selection order.
+type OrderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *OrderedMap { return &OrderedMap{values: make(map[string]any)} }
+
+func (m *OrderedMap) set(key string, v any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// Get returns the value of key.
+func (m *OrderedMap) Get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Keys returns the keys in order.
+func (m *OrderedMap) Keys() []string { return m.keys }
+
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Execute parses, validates and runs a query against the schema. Fields are
+// resolved sequentially, so resolvers may share request-scoped state without locking.
+func Execute(ctx context.Context, s *Schema, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	op, gerr := selectOperation(doc, req.OperationName)
+	if gerr != nil {
+		return &Response{Errors: []*Error{gerr}}
+	}
+	if op.kind != "query" {
+		return &Response{Errors: []*Error{{
+			Message:   fmt.Sprintf("%s operations are not supported", op.kind),
+			Locations: []Location{location(req.Query, op.pos)},
+		}}}
+	}
+
+	e := &executor{src: req.Query, schema: s, doc: doc}
+	if e.vars, gerr = e.coerceVariables(op, req.Variables); gerr != nil {
+		return &Response{Errors: []*Error{gerr}}
+	}
+	if errs := e.validate(op); len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+
+	data := e.executeSelections(ctx, s.Query, req.Root, op.selections, nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func asError(err error) *Error {
+	if ge, ok := err.(*Error); ok {
+		return ge
+	}
+	return &Error{Message: err.Error()}
+}
+
+func selectOperation(doc *document, name string) (*operation, *Error) {
+	if name == "" {
+		if len(doc.operations) != 1 {
+			return nil, &Error{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+type executor struct {
+	src    string
+	schema *Schema
+	doc    *document
+	vars   map[string]any
+	errors []*Error
+}
+
+func (e *executor) errorAt(pos int, format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{location(e.src, pos)}}
+}
+
+func (e *executor) coerceVariables(op *operation, input map[string]any) (map[string]any, *Error) {
+	vars := make(map[string]any, len(op.variables))
+	for _, def := range op.variables {
+		raw, ok := input[def.name]
+		if !ok && def.defaultValue != nil {
+			v, err := e.literal(def.defaultValue)
+			if err != nil {
+				return nil, err
+			}
+			raw, ok = v, true
+		}
+		if !ok {
+			if isNonNull(def.typ) {
+				return nil, e.errorAt(def.pos, "Variable \"$%s\" of required type \"%s\" was not provided.", def.name, def.typ)
+			}
+			continue
+		}
+		v, err := coerce(def.typ, raw)
+		if err != nil {
+			return nil, e.errorAt(def.pos, "Variable \"$%s\" got invalid value: %v", def.name, err)
+		}
+		vars[def.name] = v
+	}
+	return vars, nil
+}
+
+// value converts a value literal to its Go form, substituting variables. The
+// second result is false for variables that were not provided.
+func (e *executor) value(v *value) (any, bool, *Error) {
+	switch v.kind {
+	case valVariable:
+		x, ok := e.vars[v.raw]
+		return x, ok, nil
+	case valInt:
+		n, err := strconv.ParseInt(v.raw, 10, 64)
+		if err != nil {
+			return nil, false, e.errorAt(v.pos, "Int cannot represent value %s", v.raw)
+		}
+		return n, true, nil
+	case valFloat:
+		f, err := strconv.ParseFloat(v.raw, 64)
+		if err != nil {
+			return nil, false, e.errorAt(v.pos, "Float cannot represent value %s", v.raw)
+		}
+		return f, true, nil
+	case valString, valEnum:
+		return v.raw, true, nil
+	case valBoolean:
+		return v.raw == "true", true, nil
+	case valNull:
+		return nil, true, nil
+	case valList:
+		out := make([]any, 0, len(v.list))
+		for _, item := range v.list {
+			x, _, err := e.value(item)
+			if err != nil {
+				return nil, false, err
+			}
+			out = append(out, x)
+		}
+		return out, true, nil
+	case valObject:
+		out := make(map[string]any, len(v.fields))
+		for _, f := range v.fields {
+			x, ok, err := e.value(f.value)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				out[f.name] = x
+			}
+		}
+		return out, true, nil
+	}
+	return nil, false, e.errorAt(v.pos, "invalid value")
+}
+
+func (e *executor) literal(v *value) (any, *Error) {
+	x, _, err := e.value(v)
+	return x, err
+}
+
+// coerce converts an input value to the Go form of the given SDL type.
+func coerce(typ string, v any) (any, error) {
+	nonNull := isNonNull(typ)
+	typ = strings.TrimSuffix(typ, "!")
+	if v == nil {
+		if nonNull {
+			return nil, fmt.Errorf("expected non-null %s", typ+"!")
+		}
+		return nil, nil
+	}
+	if isListType(typ) {
+		inner := typ[1 : len(typ)-1]
+		items, ok := v.([]any)
+		if !ok {
+			items = []any{v}
+		}
+		out := make([]any, 0, len(items))
+		for _, item := range items {
+			x, err := coerce(inner, item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, x)
+		}
+		return out, nil
+	}
+	switch typ {
+	case "Int":
+		n, ok := toInt64(v)
+		if !ok || n > math.MaxInt32 || n < math.MinInt32 {
+			return nil, fmt.Errorf("Int cannot represent %v", describeValue(v))
+		}
+		return int(n), nil
+	case "Float":
+		switch x := v.(type) {
+		case float64:
+			return x, nil
+		case int64:
+			return float64(x), nil
+		case int:
+			return float64(x), nil
+		}
+		return nil, fmt.Errorf("Float cannot represent %v", describeValue(v))
+	case "String":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("String cannot represent %v", describeValue(v))
+	case "ID":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		if n, ok := toInt64(v); ok {
+			return strconv.FormatInt(n, 10), nil
+		}
+		return nil, fmt.Errorf("ID cannot represent %v", describeValue(v))
+	case "Boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("Boolean cannot represent %v", describeValue(v))
+	}
+	// Custom scalars and enums are passed through.
+	return v, nil
+}
+
+func toInt64(v any) (int64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return x, true
+	case int:
+		return int64(x), true
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			return int64(x), true
+		}
+	}
+	return 0, false
+}
+
+func describeValue(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// coerceArgs coerces the field arguments, applying defaults.
+func (e *executor) coerceArgs(def *Field, f *field) (map[string]any, *Error) {
+	args := make(map[string]any, len(def.Args))
+	for _, a := range f.arguments {
+		if argDef(def, a.name) == nil {
+			return nil, e.errorAt(a.pos, "Unknown argument \"%s\" on field \"%s\".", a.name, def.Name)
+		}
+	}
+	for _, ad := range def.Args {
+		var (
+			raw     any
+			present bool
+			pos     = f.pos
+		)
+		for _, a := range f.arguments {
+			if a.name == ad.Name {
+				var err *Error
+				if raw, present, err = e.value(a.value); err != nil {
+					return nil, err
+				}
+				pos = a.pos
+			}
+		}
+		if !present && ad.Default != nil {
+			raw, present = ad.Default, true
+		}
+		if !present {
+			if isNonNull(ad.Type) {
+				return nil, e.errorAt(f.pos, "Field \"%s\" argument \"%s\" of type \"%s\" is required, but it was not provided.", def.Name, ad.Name, ad.Type)
+			}
+			continue
+		}
+		v, err := coerce(ad.Type, raw)
+		if err != nil {
+			return nil, e.errorAt(pos, "Argument \"%s\" has invalid value: %v", ad.Name, err)
+		}
+		args[ad.Name] = v
+	}
+	return args, nil
+}
+
+func argDef(f *Field, name string) *Arg {
+	for i := range f.Args {
+		if f.Args[i].Name == name {
+			return &f.Args[i]
+		}
+	}
+	return nil
+}
+
+// include evaluates @skip and @include.
+func (e *executor) include(dirs []*directive) (bool, *Error) {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			return false, e.errorAt(d.pos, "Unknown directive \"@%s\".", d.name)
+		}
+		var cond, found bool
+		for _, a := range d.arguments {
+			if a.name != "if" {
+				return false, e.errorAt(a.pos, "Unknown argument \"%s\" on directive \"@%s\".", a.name, d.name)
+			}
+			v, _, err := e.value(a.value)
+			if err != nil {
+				return false, err
+			}
+			b, ok := v.(bool)
+			if !ok {
+				return false, e.errorAt(a.pos, "Directive \"@%s\" argument \"if\" must be a Boolean.", d.name)
+			}
+			cond, found = b, true
+		}
+		if !found {
+			return false, e.errorAt(d.pos, "Directive \"@%s\" argument \"if\" is required.", d.name)
+		}
+		if (d.name == "skip" && cond) || (d.name == "include" && !cond) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// collectFields flattens fragments into fields grouped by response key, in order.
+func (e *executor) collectFields(obj *Object, sels []selection, out *fieldGroups, visiting map[string]bool) *Error {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *field:
+			ok, err := e.include(sel.directives)
+			if err != nil {
+				return err
+			}
+			if ok {
+				out.add(sel)
+			}
+		case *inlineFragment:
+			ok, err := e.include(sel.directives)
+			if err != nil {
+				return err
+			}
+			if sel.typeCondition != "" && sel.typeCondition != obj.Name {
+				return e.errorAt(sel.pos, "Fragment cannot be spread here as objects of type \"%s\" can never be of type \"%s\".", obj.Name, sel.typeCondition)
+			}
+			if ok {
+				if err := e.collectFields(obj, sel.selections, out, visiting); err != nil {
+					return err
+				}
+			}
+		case *fragmentSpread:
+			ok, err := e.include(sel.directives)
+			if err != nil {
+				return err
+			}
+			frag := e.doc.fragments[sel.name]
+			if frag == nil {
+				return e.errorAt(sel.pos, "Unknown fragment \"%s\".", sel.name)
+			}
+			if frag.typeCondition != obj.Name {
+				return e.errorAt(sel.pos, "Fragment \"%s\" cannot be spread here as objects of type \"%s\" can never be of type \"%s\".", sel.name, obj.Name, frag.typeCondition)
+			}
+			if visiting[sel.name] {
+				return e.errorAt(sel.pos, "Cannot spread fragment \"%s\" within itself.", sel.name)
+			}
+			if !ok {
+				continue
+			}
+			visiting[sel.name] = true
+			err = e.collectFields(obj, frag.selections, out, visiting)
+			delete(visiting, sel.name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type fieldGroups struct {
+	keys   []string
+	fields map[string][]*field
+}
+
+func (g *fieldGroups) add(f *field) {
+	if g.fields == nil {
+		g.fields = make(map[string][]*field)
+	}
+	key := f.responseKey()
+	if _, ok := g.fields[key]; !ok {
+		g.keys = append(g.keys, key)
+	}
+	g.fields[key] = append(g.fields[key], f)
+}
+
+func (e *executor) groupFields(obj *Object, sels []selection) (*fieldGroups, *Error) {
+	groups := &fieldGroups{}
+	if err := e.collectFields(obj, sels, groups, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// mergedSelections joins the sub-selections of fields sharing a response key.
+func mergedSelections(fields []*field) []selection {
+	if len(fields) == 1 {
+		return fields[0].selections
+	}
+	var sels []selection
+	for _, f := range fields {
+		sels = append(sels, f.selections...)
+	}
+	return sels
+}
+
+// validate checks fields, arguments and fragments against the schema and
+// enforces the depth and complexity limits before anything is resolved.
+func (e *executor) validate(op *operation) []*Error {
+	if _, err := e.include(op.directives); err != nil {
+		return []*Error{err}
+	}
+	if err := e.checkFragmentCycles(); err != nil {
+		return []*Error{err}
+	}
+	var errs []*Error
+	complexity := e.validateSelections(e.schema.Query, op.selections, 1, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	if max := e.schema.MaxComplexity; max > 0 && complexity > max {
+		return []*Error{{Message: fmt.Sprintf("Query complexity %d exceeds the maximum of %d.", complexity, max)}}
+	}
+	return nil
+}
+
+// checkFragmentCycles rejects fragments that spread themselves, directly or through
+// other fragments, at any nesting level.
+func (e *executor) checkFragmentCycles() *Error {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(e.doc.fragments))
+	var visit func(sels []selection) *Error
+	visit = func(sels []selection) *Error {
+		for _, sel := range sels {
+			switch sel := sel.(type) {
+			case *field:
+				if err := visit(sel.selections); err != nil {
+					return err
+				}
+			case *inlineFragment:
+				if err := visit(sel.selections); err != nil {
+					return err
+				}
+			case *fragmentSpread:
+				frag := e.doc.fragments[sel.name]
+				if frag == nil {
+					continue // reported when fields are collected
+				}
+				switch state[sel.name] {
+				case visiting:
+					return e.errorAt(sel.pos, "Cannot spread fragment \"%s\" within itself.", sel.name)
+				case unvisited:
+					state[sel.name] = visiting
+					if err := visit(frag.selections); err != nil {
+						return err
+					}
+					state[sel.name] = done
+				}
+			}
+		}
+		return nil
+	}
+	for _, frag := range e.doc.fragments {
+		if state[frag.name] == unvisited {
+			state[frag.name] = visiting
+			if err := visit(frag.selections); err != nil {
+				return err
+			}
+			state[frag.name] = done
+		}
+	}
+	return nil
+}
+
+func (e *executor) validateSelections(obj *Object, sels []selection, depth int, errs *[]*Error) int {
+	groups, err := e.groupFields(obj, sels)
+	if err != nil {
+		*errs = append(*errs, err)
+		return 0
+	}
+	total := 0
+	for _, key := range groups.keys {
+		fields := groups.fields[key]
+		f := fields[0]
+		for _, other := range fields[1:] {
+			if other.name != f.name {
+				*errs = append(*errs, e.errorAt(other.pos, "Fields \"%s\" conflict because \"%s\" and \"%s\" are different fields.", key, f.name, other.name))
+				return total
+			}
+		}
+		if f.name == "__typename" {
+			if len(f.selections) > 0 || len(f.arguments) > 0 {
+				*errs = append(*errs, e.errorAt(f.pos, "Field \"__typename\" takes no arguments or selections."))
+			}
+			continue
+		}
+		def := obj.Field(f.name)
+		if def == nil {
+			*errs = append(*errs, e.errorAt(f.pos, "Cannot query field \"%s\" on type \"%s\".", f.name, obj.Name))
+			continue
+		}
+		if max := e.schema.MaxDepth; max > 0 && depth > max {
+			*errs = append(*errs, e.errorAt(f.pos, "Query depth exceeds the maximum of %d.", max))
+			return total
+		}
+		args, aerr := e.coerceArgs(def, f)
+		if aerr != nil {
+			*errs = append(*errs, aerr)
+			continue
+		}
+		cost := def.Cost
+		if cost <= 0 {
+			cost = 1
+		}
+		sub := mergedSelections(fields)
+		switch {
+		case def.Object == nil && len(sub) > 0:
+			*errs = append(*errs, e.errorAt(f.pos, "Field \"%s\" must not have a selection since type \"%s\" has no subfields.", f.name, def.Type))
+		case def.Object != nil && len(sub) == 0:
+			*errs = append(*errs, e.errorAt(f.pos, "Field \"%s\" of type \"%s\" must have a selection of subfields.", f.name, def.Type))
+		case def.Object != nil:
+			mult := 1
+			if def.Multiplier != nil {
+				mult = max(def.Multiplier(args), 1)
+			}
+			cost += mult * e.validateSelections(def.Object, sub, depth+1, errs)
+		}
+		total += cost
+	}
+	return total
+}
+
+func (e *executor) executeSelections(ctx context.Context, obj *Object, source any, sels []selection, path []any) *OrderedMap {
+	out := newOrderedMap()
+	groups, err := e.groupFields(obj, sels)
+	if err != nil {
+		e.errors = append(e.errors, err)
+		return out
+	}
+	for _, key := range groups.keys {
+		fields := groups.fields[key]
+		f := fields[0]
+		fieldPath := appendPath(path, key)
+		if f.name == "__typename" {
+			out.set(key, obj.Name)
+			continue
+		}
+		def := obj.Field(f.name)
+		args, aerr := e.coerceArgs(def, f)
+		if aerr != nil {
+			aerr.Path = fieldPath
+			e.errors = append(e.errors, aerr)
+			out.set(key, nil)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			e.fieldError(f, fieldPath, err)
+			out.set(key, nil)
+			continue
+		}
+		v, rerr := resolve(def, ResolveParams{Context: ctx, Source: source, Args: args})
+		if rerr != nil {
+			e.fieldError(f, fieldPath, rerr)
+			out.set(key, nil)
+			continue
+		}
+		out.set(key, e.completeValue(ctx, def, def.Type, f, v, mergedSelections(fields), fieldPath))
+	}
+	return out
+}
+
+func resolve(def *Field, p ResolveParams) (v any, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("internal error resolving %s", def.Name)
+		}
+	}()
+	if def.Resolve != nil {
+		return def.Resolve(p)
+	}
+	if m, ok := p.Source.(map[string]any); ok {
+		return m[def.Name], nil
+	}
+	return nil, fmt.Errorf("no resolver for field %s", def.Name)
+}
+
+func (e *executor) completeValue(ctx context.Context, def *Field, typ string, f *field, v any, sels []selection, path []any) any {
+	if isNil(v) {
+		if isNonNull(typ) {
+			e.fieldError(f, path, fmt.Errorf("cannot return null for non-nullable field %s", def.Name))
+		}
+		return nil
+	}
+	typ = strings.TrimSuffix(typ, "!")
+	if isListType(typ) {
+		items, ok := v.([]any)
+		if !ok {
+			e.fieldError(f, path, fmt.Errorf("field %s: expected a list", def.Name))
+			return nil
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			out[i] = e.completeValue(ctx, def, typ[1:len(typ)-1], f, item, sels, appendPath(path, i))
+		}
+		return out
+	}
+	if def.Object == nil {
+		return v
+	}
+	return e.executeSelections(ctx, def.Object, v, sels, path)
+}
+
+func (e *executor) fieldError(f *field, path []any, err error) {
+	e.errors = append(e.errors, &Error{
+		Message:   err.Error(),
+		Locations: []Location{location(e.src, f.pos)},
+		Path:      path,
+	})
+}
+
+func appendPath(path []any, elem any) []any {
+	out := make([]any, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, elem)
+}
+
+// isNil reports whether v is nil or a nil pointer or map. Nil slices complete
+// as empty lists.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	ID   string
+	Tags []string
+}
+
+func testSchema() *Schema {
+	item := &Object{Name: "Item"}
+	item.Fields = []*Field{
+		{Name: "id", Type: "ID!", Resolve: func(p ResolveParams) (any, error) { return p.Source.(testItem).ID, nil }},
+		{Name: "tags", Type: "[String!]!", Resolve: func(p ResolveParams) (any, error) {
+			out := make([]any, 0)
+			for _, t := range p.Source.(testItem).Tags {
+				out = append(out, t)
+			}
+			return out, nil
+		}},
+		{Name: "related", Type: "[Item!]!", Object: item, Args: []Arg{{Name: "first", Type: "Int", Default: 2}},
+			Multiplier: func(args map[string]any) int { return args["first"].(int) },
+			Resolve: func(p ResolveParams) (any, error) {
+				n, _ := p.Int("first")
+				out := make([]any, 0, n)
+				for i := 0; i < n; i++ {
+					out = append(out, testItem{ID: p.Source.(testItem).ID + "." + string(rune('a'+i))})
+				}
+				return out, nil
+			}},
+		{Name: "broken", Type: "String", Resolve: func(p ResolveParams) (any, error) { return nil, errors.New("boom") }},
+	}
+	return &Schema{
+		Query: &Object{Name: "Query", Fields: []*Field{
+			{Name: "item", Type: "Item", Object: item, Args: []Arg{{Name: "id", Type: "ID!"}},
+				Resolve: func(p ResolveParams) (any, error) {
+					id, _ := p.String("id")
+					if id == "missing" {
+						return nil, nil
+					}
+					return testItem{ID: id, Tags: []string{"x", "y"}}, nil
+				}},
+			{Name: "meta", Type: "Meta!", Object: &Object{Name: "Meta", Fields: []*Field{{Name: "version", Type: "String!"}}},
+				Resolve: func(p ResolveParams) (any, error) { return map[string]any{"version": "v1"}, nil }},
+		}},
+	}
+}
+
+func run(t *testing.T, s *Schema, query string, vars map[string]any) (string, []*Error) {
+	t.Helper()
+	resp := Execute(context.Background(), s, Request{Query: query, Variables: vars})
+	if resp.Data == nil {
+		return "", resp.Errors
+	}
+	b, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b), resp.Errors
+}
+
+func TestExecute(t *testing.T) {
+	s := testSchema()
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+		want  string
+	}{
+		{"shorthand", `{ item(id: "1") { id tags } }`, nil, `{"item":{"id":"1","tags":["x","y"]}}`},
+		{"alias and order", `{ b: item(id: 2) { id } a: meta { version } }`, nil, `{"b":{"id":"2"},"a":{"version":"v1"}}`},
+		{"null object", `{ item(id: "missing") { id } }`, nil, `{"item":null}`},
+		{"nested list", `query { item(id: "1") { related(first: 2) { id } } }`, nil, `{"item":{"related":[{"id":"1.a"},{"id":"1.b"}]}}`},
+		{"variables", `query Q($id: ID!, $n: Int = 1) { item(id: $id) { related(first: $n) { id } } }`, map[string]any{"id": "7"}, `{"item":{"related":[{"id":"7.a"}]}}`},
+		{"fragments", `{ item(id: "1") { ...F ... on Item { tags } } } fragment F on Item { id }`, nil, `{"item":{"id":"1","tags":["x","y"]}}`},
+		{"merged selections", `{ item(id: "1") { id } item(id: "1") { tags } }`, nil, `{"item":{"id":"1","tags":["x","y"]}}`},
+		{"directives", `query($s: Boolean!) { item(id: "1") { id @skip(if: $s) tags @include(if: false) } }`, map[string]any{"s": true}, `{"item":{}}`},
+		{"typename", `{ __typename item(id: "1") { __typename } }`, nil, `{"__typename":"Query","item":{"__typename":"Item"}}`},
+		{"comments and commas", "# q\n{ item(id: \"1\"), { id, } }", nil, `{"item":{"id":"1"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := run(t, s, tt.query, tt.vars)
+			if len(errs) > 0 {
+				t.Fatalf("errors: %v", errs[0])
+			}
+			if got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteFieldError(t *testing.T) {
+	got, errs := run(t, testSchema(), `{ item(id: "1") { id broken } }`, nil)
+	if got != `{"item":{"id":"1","broken":null}}` {
+		t.Fatalf("data = %s", got)
+	}
+	if len(errs) != 1 || errs[0].Message != "boom" {
+		t.Fatalf("errors = %v", errs)
+	}
+	if b, _ := json.Marshal(errs[0].Path); string(b) != `["item","broken"]` {
+		t.Fatalf("path = %s", b)
+	}
+}
+
+func TestExecuteRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+		want  string
+	}{
+		{"syntax", `{ item(id: "1") { id }`, nil, "Syntax Error"},
+		{"unknown field", `{ item(id: "1") { nope } }`, nil, `Cannot query field "nope" on type "Item"`},
+		{"unknown argument", `{ item(id: "1", x: 1) { id } }`, nil, `Unknown argument "x"`},
+		{"missing argument", `{ item { id } }`, nil, `argument "id" of type "ID!" is required`},
+		{"bad argument", `{ item(id: "1") { related(first: "two") { id } } }`, nil, "Int cannot represent"},
+		{"missing variable", `query($id: ID!) { item(id: $id) { id } }`, nil, `Variable "$id" of required type "ID!" was not provided`},
+		{"leaf selection", `{ item(id: "1") { id { x } } }`, nil, "must not have a selection"},
+		{"object without selection", `{ item(id: "1") }`, nil, "must have a selection of subfields"},
+		{"unknown fragment", `{ item(id: "1") { ...F } }`, nil, `Unknown fragment "F"`},
+		{"fragment cycle", `{ item(id: "1") { ...A } } fragment A on Item { related { ...A } }`, nil, "within itself"},
+		{"mutation", `mutation { item(id: "1") { id } }`, nil, "not supported"},
+		{"ambiguous operation", `query A { meta { version } } query B { meta { version } }`, nil, "Must provide operation name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := run(t, testSchema(), tt.query, tt.vars)
+			if got != "" {
+				t.Fatalf("expected no data, got %s", got)
+			}
+			if len(errs) == 0 || !strings.Contains(errs[0].Message, tt.want) {
+				t.Fatalf("errors = %v, want %q", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	deep := `{ item(id: "1") { related { related { related { id } } } } }`
+
+	s := testSchema()
+	s.MaxDepth = 4
+	if _, errs := run(t, s, deep, nil); len(errs) == 0 || !strings.Contains(errs[0].Message, "depth") {
+		t.Fatalf("expected depth error, got %v", errs)
+	}
+	s.MaxDepth = 5
+	if _, errs := run(t, s, deep, nil); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs[0])
+	}
+
+	// item(1) + related(1 + 10*(related(1 + 10*id(1))))
+	wide := `{ item(id: "1") { related(first: 10) { related(first: 10) { id } } } }`
+	s = testSchema()
+	s.MaxComplexity = 111
+	if _, errs := run(t, s, wide, nil); len(errs) == 0 || !strings.Contains(errs[0].Message, "complexity 112") {
+		t.Fatalf("expected complexity error, got %v", errs)
+	}
+	s.MaxComplexity = 112
+	if _, errs := run(t, s, wide, nil); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs[0])
+	}
+}
+
+func TestSDL(t *testing.T) {
+	sdl := testSchema().SDL()
+	for _, want := range []string{
+		"type Query {\n  item(id: ID!): Item\n  meta: Meta!\n}",
+		"related(first: Int = 2): [Item!]!",
+		"type Meta {",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("SDL missing %q:\n%s", want, sdl)
+		}
+	}
+}
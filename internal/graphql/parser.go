@@ -0,0 +1,568 @@
+package graphql
+
+import (
+	"strings"
+)
+
+// Parsed document. Only the executable subset of the language is supported:
+// operations, fragments, variables and the @skip/@include directives.
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // "query", "mutation" or "subscription"
+	name       string
+	variables  []*variableDef
+	directives []*directive
+	selections []selection
+	pos        int
+}
+
+type variableDef struct {
+	name         string
+	typ          string // printed type, e.g. "[String!]!"
+	defaultValue *value
+	pos          int
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	pos           int
+}
+
+type selection interface{ position() int }
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+	pos        int
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	pos        int
+}
+
+type inlineFragment struct {
+	typeCondition string // empty when omitted
+	directives    []*directive
+	selections    []selection
+	pos           int
+}
+
+func (f *field) position() int          { return f.pos }
+func (f *fragmentSpread) position() int { return f.pos }
+func (f *inlineFragment) position() int { return f.pos }
+
+// responseKey is the alias if present, otherwise the field name.
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value *value
+	pos   int
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+	pos       int
+}
+
+type valueKind int
+
+const (
+	valVariable valueKind = iota
+	valInt
+	valFloat
+	valString
+	valBoolean
+	valNull
+	valEnum
+	valList
+	valObject
+)
+
+type value struct {
+	kind   valueKind
+	raw    string // literal text, variable name or enum name
+	list   []*value
+	fields []*objectField
+	pos    int
+}
+
+type objectField struct {
+	name  string
+	value *value
+}
+
+type parser struct {
+	src string
+	lex lexer
+	tok token
+}
+
+// parse parses an executable GraphQL document.
+func parse(src string) (*document, error) {
+	p := &parser{src: src, lex: lexer{src: src}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &document{fragments: make(map[string]*fragment)}
+	if p.tok.kind == tokEOF {
+		return nil, p.errorf("unexpected %s", tokEOF)
+	}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek(tokPunct, "{"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peek(tokName, "query"), p.peek(tokName, "mutation"), p.peek(tokName, "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peek(tokName, "fragment"):
+			frag, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[frag.name]; dup {
+				return nil, &Error{
+					Message:   "There can be only one fragment named \"" + frag.name + "\".",
+					Locations: []Location{location(src, frag.pos)},
+				}
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) peek(kind tokenKind, v string) bool {
+	return p.tok.kind == kind && p.tok.value == v
+}
+
+// skip consumes the token if it matches.
+func (p *parser) skip(kind tokenKind, v string) (bool, error) {
+	if !p.peek(kind, v) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expect(kind tokenKind, v string) error {
+	if !p.peek(kind, v) {
+		return p.errorf("expected %q, found %s", v, p.describe())
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.errorf("expected name, found %s", p.describe())
+	}
+	n := p.tok.value
+	return n, p.advance()
+}
+
+func (p *parser) describe() string {
+	switch p.tok.kind {
+	case tokEOF:
+		return tokEOF.String()
+	case tokString:
+		return "string"
+	}
+	return "\"" + p.tok.value + "\""
+}
+
+func (p *parser) unexpected() error {
+	return p.errorf("unexpected %s", p.describe())
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return syntaxError(p.src, p.tok.pos, format, args...)
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{kind: "query", pos: p.tok.pos}
+	if p.tok.kind == tokName {
+		op.kind = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokName {
+			op.name = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek(tokPunct, "(") {
+			vars, err := p.parseVariableDefs()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = vars
+		}
+		dirs, err := p.parseDirectives(true)
+		if err != nil {
+			return nil, err
+		}
+		op.directives = dirs
+	}
+	sels, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+func (p *parser) parseVariableDefs() ([]*variableDef, error) {
+	if err := p.expect(tokPunct, "("); err != nil {
+		return nil, err
+	}
+	var defs []*variableDef
+	for !p.peek(tokPunct, ")") {
+		def := &variableDef{pos: p.tok.pos}
+		if err := p.expect(tokPunct, "$"); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		def.name = name
+		if err := p.expect(tokPunct, ":"); err != nil {
+			return nil, err
+		}
+		if def.typ, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip(tokPunct, "="); err != nil {
+			return nil, err
+		} else if ok {
+			if def.defaultValue, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.parseDirectives(true); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	if len(defs) == 0 {
+		return nil, p.unexpected()
+	}
+	return defs, p.advance()
+}
+
+func (p *parser) parseType() (string, error) {
+	var t string
+	if ok, err := p.skip(tokPunct, "["); err != nil {
+		return "", err
+	} else if ok {
+		inner, err := p.parseType()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect(tokPunct, "]"); err != nil {
+			return "", err
+		}
+		t = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		t = name
+	}
+	if ok, err := p.skip(tokPunct, "!"); err != nil {
+		return "", err
+	} else if ok {
+		t += "!"
+	}
+	return t, nil
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	frag := &fragment{pos: p.tok.pos}
+	if err := p.advance(); err != nil { // "fragment"
+		return nil, err
+	}
+	if p.peek(tokName, "on") {
+		return nil, p.unexpected()
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	frag.name = name
+	if err := p.expect(tokName, "on"); err != nil {
+		return nil, err
+	}
+	if frag.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.directives, err = p.parseDirectives(false); err != nil {
+		return nil, err
+	}
+	if frag.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if err := p.expect(tokPunct, "{"); err != nil {
+		return nil, err
+	}
+	var sels []selection
+	for !p.peek(tokPunct, "}") {
+		if p.tok.kind == tokEOF {
+			return nil, p.unexpected()
+		}
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, p.unexpected()
+	}
+	return sels, p.advance()
+}
+
+func (p *parser) parseSelection() (selection, error) {
+	pos := p.tok.pos
+	if ok, err := p.skip(tokPunct, "..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokName && p.tok.value != "on" {
+			spread := &fragmentSpread{name: p.tok.value, pos: pos}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			dirs, err := p.parseDirectives(false)
+			if err != nil {
+				return nil, err
+			}
+			spread.directives = dirs
+			return spread, nil
+		}
+		inline := &inlineFragment{pos: pos}
+		if ok, err := p.skip(tokName, "on"); err != nil {
+			return nil, err
+		} else if ok {
+			if inline.typeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if inline.directives, err = p.parseDirectives(false); err != nil {
+			return nil, err
+		}
+		if inline.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+		return inline, nil
+	}
+
+	f := &field{pos: pos}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(tokPunct, ":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	f.name = name
+	if p.peek(tokPunct, "(") {
+		if f.arguments, err = p.parseArguments(false); err != nil {
+			return nil, err
+		}
+	}
+	if f.directives, err = p.parseDirectives(false); err != nil {
+		return nil, err
+	}
+	if p.peek(tokPunct, "{") {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) parseArguments(constant bool) ([]*argument, error) {
+	if err := p.expect(tokPunct, "("); err != nil {
+		return nil, err
+	}
+	var args []*argument
+	for !p.peek(tokPunct, ")") {
+		arg := &argument{pos: p.tok.pos}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arg.name = name
+		if err := p.expect(tokPunct, ":"); err != nil {
+			return nil, err
+		}
+		if arg.value, err = p.parseValue(constant); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return nil, p.unexpected()
+	}
+	return args, p.advance()
+}
+
+func (p *parser) parseDirectives(constant bool) ([]*directive, error) {
+	var dirs []*directive
+	for p.peek(tokPunct, "@") {
+		d := &directive{pos: p.tok.pos}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		d.name = name
+		if p.peek(tokPunct, "(") {
+			if d.arguments, err = p.parseArguments(constant); err != nil {
+				return nil, err
+			}
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// parseValue parses a value literal; variables are rejected when constant is set.
+func (p *parser) parseValue(constant bool) (*value, error) {
+	v := &value{pos: p.tok.pos, raw: p.tok.value}
+	switch p.tok.kind {
+	case tokInt:
+		v.kind = valInt
+	case tokFloat:
+		v.kind = valFloat
+	case tokString:
+		v.kind = valString
+	case tokName:
+		switch p.tok.value {
+		case "true", "false":
+			v.kind = valBoolean
+		case "null":
+			v.kind = valNull
+		default:
+			v.kind = valEnum
+		}
+	case tokPunct:
+		switch p.tok.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected()
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			v.kind, v.raw = valVariable, name
+			return v, nil
+		case "[":
+			v.kind = valList
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			for !p.peek(tokPunct, "]") {
+				if p.tok.kind == tokEOF {
+					return nil, p.unexpected()
+				}
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				v.list = append(v.list, item)
+			}
+			return v, p.advance()
+		case "{":
+			v.kind = valObject
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			for !p.peek(tokPunct, "}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(tokPunct, ":"); err != nil {
+					return nil, err
+				}
+				fv, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				v.fields = append(v.fields, &objectField{name: name, value: fv})
+			}
+			return v, p.advance()
+		default:
+			return nil, p.unexpected()
+		}
+	default:
+		return nil, p.unexpected()
+	}
+	return v, p.advance()
+}
+
+// isNonNull reports whether a printed type is non-null.
+func isNonNull(typ string) bool { return strings.HasSuffix(typ, "!") }
+
+// isListType reports whether a printed type is a list, ignoring non-null.
+func isListType(typ string) bool { return strings.HasPrefix(typ, "[") }
+
+// elemType strips non-null and list wrappers from a printed type: "[Action!]!" -> "Action".
+func elemType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
@@ -0,0 +1,182 @@
+// Package graphql is a small, dependency-free GraphQL query engine: a parser for
+// executable documents, a code-first schema of object types with resolvers, query
+// depth/complexity limits, and a sequential executor.
+//
+// It deliberately supports only what the API needs: query operations (no mutations
+// or subscriptions), object and scalar types, variables, fragments, and the @skip
+// and @include directives. Introspection is limited to __typename; the schema is
+// published as SDL instead (see Schema.SDL).
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema is the root of an executable schema.
+type Schema struct {
+	Query *Object
+
+	// MaxDepth limits field nesting; 0 disables the check.
+	MaxDepth int
+	// MaxComplexity limits the estimated cost of a query (see Field.Cost and
+	// Field.Multiplier); 0 disables the check.
+	MaxComplexity int
+}
+
+// Object is a GraphQL object type.
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+// Field lookup by name; nil when the object has no such field.
+func (o *Object) Field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Field is a field of an object type.
+type Field struct {
+	Name        string
+	Description string
+	// Type is the field type as written in SDL, e.g. "String", "[Action!]!".
+	Type string
+	// Object is the element type of object-valued fields; nil for scalars.
+	Object *Object
+	Args   []Arg
+
+	// Cost of resolving the field once; 0 counts as 1.
+	Cost int
+	// Multiplier estimates how many elements a list field returns given its coerced
+	// arguments; the cost of the child selection is multiplied by it. nil counts as 1.
+	Multiplier func(args map[string]any) int
+
+	// Resolve produces the field value. List fields must return []any. When nil, the
+	// value is looked up by field name in a map[string]any source.
+	Resolve func(p ResolveParams) (any, error)
+}
+
+// Arg is a field argument.
+type Arg struct {
+	Name        string
+	Type        string // SDL type, e.g. "Int", "ID!"
+	Default     any    // used when the argument is omitted; nil for none
+	Description string
+}
+
+// ResolveParams is passed to field resolvers.
+type ResolveParams struct {
+	Context context.Context
+	// Source is the value the parent field resolved to (Request.Root for root fields).
+	Source any
+	// Args holds the coerced arguments: Int as int, Float as float64, String, ID and
+	// enum values as string, Boolean as bool, lists as []any. Omitted arguments
+	// without a default are absent.
+	Args map[string]any
+}
+
+// String returns a string argument and whether it was set.
+func (p ResolveParams) String(name string) (string, bool) {
+	s, ok := p.Args[name].(string)
+	return s, ok
+}
+
+// Int returns an Int argument and whether it was set.
+func (p ResolveParams) Int(name string) (int, bool) {
+	n, ok := p.Args[name].(int)
+	return n, ok
+}
+
+// SDL renders the schema types reachable from Query in schema definition language.
+func (s *Schema) SDL() string {
+	var (
+		sb      strings.Builder
+		order   []*Object
+		seen    = map[string]bool{}
+		scalars = map[string]bool{}
+	)
+	var visit func(o *Object)
+	visit = func(o *Object) {
+		if seen[o.Name] {
+			return
+		}
+		seen[o.Name] = true
+		order = append(order, o)
+		for _, f := range o.Fields {
+			if f.Object != nil {
+				visit(f.Object)
+			} else if t := elemType(f.Type); !builtinScalars[t] {
+				scalars[t] = true
+			}
+			for _, a := range f.Args {
+				if t := elemType(a.Type); !builtinScalars[t] {
+					scalars[t] = true
+				}
+			}
+		}
+	}
+	visit(s.Query)
+
+	names := make([]string, 0, len(scalars))
+	for name := range scalars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&sb, "scalar %s\n\n", name)
+	}
+
+	for i, o := range order {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		writeDescription(&sb, "", o.Description)
+		fmt.Fprintf(&sb, "type %s {\n", o.Name)
+		for _, f := range o.Fields {
+			writeDescription(&sb, "  ", f.Description)
+			sb.WriteString("  " + f.Name)
+			if len(f.Args) > 0 {
+				sb.WriteString("(")
+				for j, a := range f.Args {
+					if j > 0 {
+						sb.WriteString(", ")
+					}
+					sb.WriteString(a.Name + ": " + a.Type)
+					if a.Default != nil {
+						sb.WriteString(" = " + formatLiteral(a.Default))
+					}
+				}
+				sb.WriteString(")")
+			}
+			sb.WriteString(": " + f.Type + "\n")
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+var builtinScalars = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+
+func writeDescription(sb *strings.Builder, indent, desc string) {
+	if desc == "" {
+		return
+	}
+	fmt.Fprintf(sb, "%s%q\n", indent, desc)
+}
+
+func formatLiteral(v any) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
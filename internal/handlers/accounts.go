@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+// AccountActionsSummary summarizes the actions an account created.
+type AccountActionsSummary struct {
+	Total            int64            `json:"total"`
+	TotalBytes       int64            `json:"total_bytes"`
+	ByType           map[string]int   `json:"by_type"`
+	ByState          map[string]int   `json:"by_state"`
+	FirstBlockHeight *int64           `json:"first_block_height,omitempty"`
+	LastBlockHeight  *int64           `json:"last_block_height,omitempty"`
+	FirstActivity    *time.Time       `json:"first_activity,omitempty"`
+	LatestActivity   *time.Time       `json:"latest_activity,omitempty"`
+	Spent            []db.PaymentStat `json:"spent"`
+}
+
+// AccountSupernodeDTO is the supernode an account operates. Role is
+// "supernode_account" or "validator_operator" depending on which address matched.
+type AccountSupernodeDTO struct {
+	SupernodeAccount string `json:"supernode_account"`
+	ValidatorAddress string `json:"validator_address,omitempty"`
+	ValidatorMoniker string `json:"validator_moniker,omitempty"`
+	CurrentState     string `json:"current_state"`
+	Role             string `json:"role"`
+}
+
+// AccountResponse is returned by /v1/accounts/{address}.
+type AccountResponse struct {
+	Address       string                `json:"address"`
+	Actions       AccountActionsSummary `json:"actions"`
+	Supernode     *AccountSupernodeDTO  `json:"supernode,omitempty"`
+	SchemaVersion string                `json:"schema_version"`
+}
+
+// AccountTransferDTO is an action transaction that moved funds from or to an account.
+// Direction is "in", "out" or "self".
+type AccountTransferDTO struct {
+	ActionID  string `json:"action_id"`
+	Direction string `json:"direction"`
+	TransactionDTO
+}
+
+// AccountTransfersResponse is returned by /v1/accounts/{address}/transfers.
+type AccountTransfersResponse struct {
+	Items         []AccountTransferDTO `json:"items"`
+	NextCursor    string               `json:"next_cursor,omitempty"`
+	SchemaVersion string               `json:"schema_version"`
+}
+
+// accountAddressFromPath extracts {address} from /v1/accounts/{address}[/...].
+func accountAddressFromPath(path string) string {
+	const prefix = "/v1/accounts/"
+	if !strings.HasPrefix(path, prefix) {
+		return ""
+	}
+	s := strings.TrimPrefix(path, prefix)
+	if idx := strings.Index(s, "/"); idx != -1 {
+		s = s[:idx]
+	}
+	return s
+}
+
+// GetAccount summarizes an address: the actions it created, what it spent on them, and
+// the supernode it operates, if any.
+func GetAccount(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		address := accountAddressFromPath(r.URL.Path)
+		if address == "" {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid account address")
+			return
+		}
+
+		summary, err := db.GetAccountActionSummary(r.Context(), pool, address)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch account actions")
+			return
+		}
+
+		resp := AccountResponse{
+			Address: address,
+			Actions: AccountActionsSummary{
+				Total:            summary.Total,
+				TotalBytes:       summary.TotalBytes,
+				ByType:           make(map[string]int, len(summary.ByType)),
+				ByState:          make(map[string]int, len(summary.ByState)),
+				FirstBlockHeight: summary.FirstHeight,
+				LastBlockHeight:  summary.LastHeight,
+				FirstActivity:    summary.FirstActivity,
+				LatestActivity:   summary.LatestActivity,
+				Spent:            summary.Spent,
+			},
+			SchemaVersion: "v1.0",
+		}
+		for _, c := range summary.ByType {
+			resp.Actions.ByType[c.State] = c.Count
+		}
+		for _, c := range summary.ByState {
+			resp.Actions.ByState[c.State] = c.Count
+		}
+		if resp.Actions.Spent == nil {
+			resp.Actions.Spent = []db.PaymentStat{}
+		}
+
+		sn, err := db.GetAccountSupernode(r.Context(), pool, address)
+		switch {
+		case err == nil:
+			role := "supernode_account"
+			if sn.SupernodeAccount != address {
+				role = "validator_operator"
+			}
+			resp.Supernode = &AccountSupernodeDTO{
+				SupernodeAccount: sn.SupernodeAccount,
+				ValidatorAddress: sn.ValidatorAddress,
+				ValidatorMoniker: sn.ValidatorMoniker,
+				CurrentState:     sn.CurrentState,
+				Role:             role,
+			}
+		case !errors.Is(err, db.ErrNotFound):
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch account supernode")
+			return
+		}
+
+		lm := time.Now().UTC()
+		if summary.LatestActivity != nil {
+			lm = summary.LatestActivity.UTC()
+		}
+		util.WriteJSON(w, r, http.StatusOK, resp, &lm)
+	}
+}
+
+// ListAccountActions lists the actions created by an address. It accepts the
+// /v1/actions parameters except creator.
+func ListAccountActions(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		address := accountAddressFromPath(r.URL.Path)
+		if address == "" {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid account address")
+			return
+		}
+		listActions(pool, address)(w, r)
+	}
+}
+
+// transferCursor is the keyset pagination cursor of account transfers (base64 JSON).
+// Cursors without tx_hash, issued before an action could have several transactions of
+// a type, resume after every transaction of their (height, action_id, tx_type).
+type transferCursor struct {
+	Height   int64  `json:"height"`
+	ActionID string `json:"action_id"`
+	TxType   string `json:"tx_type"`
+	TxHash   string `json:"tx_hash,omitempty"`
+	MsgIndex int    `json:"msg_index,omitempty"`
+}
+
+func encodeTransferCursor(c db.TransferCursor) (string, error) {
+	buf, err := json.Marshal(transferCursor{
+		Height:   c.Height,
+		ActionID: strconv.FormatUint(c.ActionID, 10),
+		TxType:   c.TxType,
+		TxHash:   c.TxHash,
+		MsgIndex: c.MsgIndex,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+func decodeTransferCursor(s string) (db.TransferCursor, error) {
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return db.TransferCursor{}, errors.New("invalid cursor parameter: must be base64 encoded JSON")
+	}
+	var payload transferCursor
+	if err := json.Unmarshal(decoded, &payload); err != nil || payload.ActionID == "" || payload.TxType == "" {
+		return db.TransferCursor{}, errors.New("invalid cursor parameter: must be base64 encoded JSON with height, action_id and tx_type")
+	}
+	id, err := strconv.ParseUint(payload.ActionID, 10, 64)
+	if err != nil {
+		return db.TransferCursor{}, errors.New("invalid cursor parameter: action_id must be numeric")
+	}
+	return db.TransferCursor{Height: payload.Height, ActionID: id, TxType: payload.TxType, TxHash: payload.TxHash, MsgIndex: payload.MsgIndex}, nil
+}
+
+// transferDirection reports how a transaction moved funds relative to address.
+func transferDirection(tx db.ActionTransaction, address string) string {
+	payer := tx.FlowPayer != nil && *tx.FlowPayer == address
+	payee := tx.FlowPayee != nil && *tx.FlowPayee == address
+	switch {
+	case payer && payee:
+		return "self"
+	case payer:
+		return db.TransferOut
+	default:
+		return db.TransferIn
+	}
+}
+
+// ListAccountTransfers lists action transactions paid by or to an address, newest first:
+// /v1/accounts/{address}/transfers?direction=in|out|any&tx_type=&limit=&cursor=
+func ListAccountTransfers(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		address := accountAddressFromPath(r.URL.Path)
+		if address == "" {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid account address")
+			return
+		}
+		query := r.URL.Query()
+
+		filter := db.AccountTransfersFilter{Address: address, Direction: db.TransferAny, Limit: 50}
+		switch val := query.Get("direction"); val {
+		case "", db.TransferAny:
+		case db.TransferIn, db.TransferOut:
+			filter.Direction = val
+		default:
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid direction parameter: must be 'in', 'out' or 'any'")
+			return
+		}
+
+		if val := query.Get("tx_type"); val != "" {
+			switch val {
+			case "register", "finalize", "approve":
+			default:
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid tx_type parameter: must be 'register', 'finalize' or 'approve'")
+				return
+			}
+			filter.TxType = &val
+		}
+
+		if val := query.Get("limit"); val != "" {
+			parsed, err := strconv.Atoi(val)
+			if err != nil || parsed < 1 || parsed > 200 {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid limit parameter: must be an integer between 1 and 200")
+				return
+			}
+			filter.Limit = parsed
+		}
+
+		if val := query.Get("cursor"); val != "" {
+			cursor, err := decodeTransferCursor(val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			filter.Cursor = &cursor
+		}
+
+		txs, hasMore, err := db.ListAccountTransfers(r.Context(), pool, filter)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch account transfers")
+			return
+		}
+
+		resp := AccountTransfersResponse{
+			Items:         make([]AccountTransferDTO, 0, len(txs)),
+			SchemaVersion: "v1.0",
+		}
+		var lastModified *time.Time
+		for _, tx := range txs {
+			resp.Items = append(resp.Items, AccountTransferDTO{
+				ActionID:       strconv.FormatUint(tx.ActionID, 10),
+				Direction:      transferDirection(tx, address),
+				TransactionDTO: actionTransactionToDTO(tx),
+			})
+			if lastModified == nil || tx.BlockTime.After(*lastModified) {
+				bt := tx.BlockTime.UTC()
+				lastModified = &bt
+			}
+		}
+		if hasMore && len(txs) > 0 {
+			last := txs[len(txs)-1]
+			cursor, err := encodeTransferCursor(db.TransferCursor{Height: last.Height, ActionID: last.ActionID, TxType: last.TxType, TxHash: last.TxHash, MsgIndex: last.MsgIndex})
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to encode pagination cursor")
+				return
+			}
+			resp.NextCursor = cursor
+		}
+
+		if lastModified == nil {
+			now := time.Now().UTC()
+			lastModified = &now
+		}
+		util.WriteJSON(w, r, http.StatusOK, resp, lastModified)
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestAccountAddressFromPath verifies the address is taken from the first path segment
+func TestAccountAddressFromPath(t *testing.T) {
+	tests := map[string]string{
+		"/v1/accounts/lumera1abc":           "lumera1abc",
+		"/v1/accounts/lumera1abc/actions":   "lumera1abc",
+		"/v1/accounts/lumera1abc/transfers": "lumera1abc",
+		"/v1/accounts/":                     "",
+		"/v1/actions/1":                     "",
+	}
+	for path, want := range tests {
+		if got := accountAddressFromPath(path); got != want {
+			t.Errorf("accountAddressFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// TestTransferCursorRoundTrip verifies cursors decode to the position they were made from
+func TestTransferCursorRoundTrip(t *testing.T) {
+	want := db.TransferCursor{Height: 1200, ActionID: 42, TxType: "finalize", TxHash: "ABC123", MsgIndex: 2}
+	s, err := encodeTransferCursor(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeTransferCursor(s)
+	if err != nil || got != want {
+		t.Fatalf("decode = %+v, %v; want %+v", got, err, want)
+	}
+	if _, err := decodeTransferCursor("e30="); err == nil {
+		t.Error("empty cursor decoded")
+	}
+}
+
+// TestTransferDirection verifies direction is relative to the requested address
+func TestTransferDirection(t *testing.T) {
+	a, b := "lumera1a", "lumera1b"
+	tests := []struct {
+		payer, payee *string
+		want         string
+	}{
+		{&a, &b, "out"},
+		{&b, &a, "in"},
+		{&a, &a, "self"},
+		{nil, &a, "in"},
+	}
+	for _, tt := range tests {
+		if got := transferDirection(db.ActionTransaction{FlowPayer: tt.payer, FlowPayee: tt.payee}, a); got != tt.want {
+			t.Errorf("direction = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// TestAccountTransfersRejects verifies unknown directions and tx types, bad limits and
+// undecodable cursors are refused without a pool
+func TestAccountTransfersRejects(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/v1/accounts/lumera1abc/transfers?direction=both", "invalid direction"},
+		{"/v1/accounts/lumera1abc/transfers?tx_type=send", "invalid tx_type"},
+		{"/v1/accounts/lumera1abc/transfers?limit=0", "invalid limit"},
+		{"/v1/accounts/lumera1abc/transfers?cursor=%25%25", "invalid cursor"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ListAccountTransfers(nil)(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: %d %s, want %q", tt.url, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
+
+// TestAccountQueries verifies the summary only counts actions the address created, that
+// spending sums register prices and fees per denom, that the supernode is found by either
+// of its addresses, and that transfers follow the direction and tx type filters and page
+// newest first
+func TestAccountQueries(t *testing.T) {
+	pool := testPool(t)
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	seedAction(t, pool,
+		db.ActionDB{ActionID: 1, Creator: "lumera1a", ActionType: "ACTION_TYPE_CASCADE", State: "ACTION_STATE_DONE", BlockHeight: 10, Size: 100},
+		db.ActionTransaction{TxType: "register", TxHash: "R1", Height: 10, BlockTime: day.Add(time.Hour),
+			ActionPrice: ptr("1000"), ActionPriceDenom: ptr("ulume"), TxFee: ptr("20"), TxFeeDenom: ptr("ulume"), FlowPayer: ptr("lumera1a")},
+		db.ActionTransaction{TxType: "finalize", TxHash: "F1", Height: 12, BlockTime: day.Add(3 * time.Hour),
+			ActionPrice: ptr("1000"), ActionPriceDenom: ptr("ulume"), FlowPayee: ptr("lumera1sn")})
+	seedAction(t, pool,
+		db.ActionDB{ActionID: 2, Creator: "lumera1a", ActionType: "ACTION_TYPE_SENSE", State: "ACTION_STATE_PENDING", BlockHeight: 11, Size: 50},
+		db.ActionTransaction{TxType: "register", TxHash: "R2", Height: 11, BlockTime: day.Add(2 * time.Hour),
+			ActionPrice: ptr("500"), ActionPriceDenom: ptr("ulume"), TxFee: ptr("10"), TxFeeDenom: ptr("ulume"), FlowPayer: ptr("lumera1a")})
+	seedAction(t, pool,
+		db.ActionDB{ActionID: 3, Creator: "lumera1b", ActionType: "ACTION_TYPE_CASCADE", State: "ACTION_STATE_DONE", BlockHeight: 13, Size: 70},
+		db.ActionTransaction{TxType: "register", TxHash: "R3", Height: 13, BlockTime: day.Add(4 * time.Hour),
+			ActionPrice: ptr("700"), ActionPriceDenom: ptr("ulume"), TxFee: ptr("5"), TxFeeDenom: ptr("ulume"), FlowPayer: ptr("lumera1b")},
+		db.ActionTransaction{TxType: "finalize", TxHash: "F3", Height: 14, BlockTime: day.Add(5 * time.Hour),
+			ActionPrice: ptr("700"), ActionPriceDenom: ptr("ulume"), FlowPayee: ptr("lumera1a")})
+	sn := db.SupernodeDB{SupernodeAccount: "lumera1sn", ValidatorAddress: "lumeravaloper1sn", ValidatorMoniker: "node-1", CurrentState: "SUPERNODE_STATE_ACTIVE"}
+	if err := db.UpsertSupernode(context.Background(), pool, sn); err != nil {
+		t.Fatal(err)
+	}
+
+	var account AccountResponse
+	getJSON(t, GetAccount(pool), "/v1/accounts/lumera1a", &account)
+	a := account.Actions
+	if a.Total != 2 || a.TotalBytes != 150 || a.FirstBlockHeight == nil || *a.FirstBlockHeight != 10 || a.LastBlockHeight == nil || *a.LastBlockHeight != 11 {
+		t.Errorf("actions = %+v", a)
+	}
+	if a.ByType["ACTION_TYPE_CASCADE"] != 1 || a.ByType["ACTION_TYPE_SENSE"] != 1 || a.ByState["ACTION_STATE_DONE"] != 1 || a.ByState["ACTION_STATE_PENDING"] != 1 {
+		t.Errorf("by_type = %v, by_state = %v", a.ByType, a.ByState)
+	}
+	if len(a.Spent) != 1 || a.Spent[0] != (db.PaymentStat{Denom: "ulume", TotalActionPrice: "1500", TotalTxFee: "30"}) {
+		t.Errorf("spent = %+v", a.Spent)
+	}
+	if a.FirstActivity == nil || !a.FirstActivity.Equal(day.Add(time.Hour)) || a.LatestActivity == nil || !a.LatestActivity.Equal(day.Add(2*time.Hour)) {
+		t.Errorf("activity = %v - %v", a.FirstActivity, a.LatestActivity)
+	}
+	if account.Supernode != nil {
+		t.Errorf("lumera1a supernode = %+v", account.Supernode)
+	}
+
+	for address, role := range map[string]string{"lumera1sn": "supernode_account", "lumeravaloper1sn": "validator_operator"} {
+		var resp AccountResponse
+		getJSON(t, GetAccount(pool), "/v1/accounts/"+address, &resp)
+		if resp.Actions.Total != 0 || len(resp.Actions.Spent) != 0 {
+			t.Errorf("%s actions = %+v", address, resp.Actions)
+		}
+		if s := resp.Supernode; s == nil || s.SupernodeAccount != "lumera1sn" || s.ValidatorMoniker != "node-1" || s.Role != role {
+			t.Errorf("%s supernode = %+v, want role %s", address, s, role)
+		}
+	}
+
+	h := ListAccountTransfers(pool)
+	transfers := func(query string) ([]string, string) {
+		t.Helper()
+		var resp AccountTransfersResponse
+		getJSON(t, h, "/v1/accounts/lumera1a/transfers?"+query, &resp)
+		var out []string
+		for _, it := range resp.Items {
+			out = append(out, it.ActionID+":"+it.TxHash+":"+it.Direction)
+		}
+		return out, resp.NextCursor
+	}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "3:F3:in 2:R2:out 1:R1:out"},
+		{"direction=in", "3:F3:in"},
+		{"direction=out", "2:R2:out 1:R1:out"},
+		{"tx_type=finalize", "3:F3:in"},
+		{"direction=in&tx_type=register", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := transfers(tt.query); strings.Join(got, " ") != tt.want {
+			t.Errorf("%q = %v, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	var paged []string
+	query := "limit=1"
+	for i := 0; i < 4; i++ {
+		items, next := transfers(query)
+		paged = append(paged, items...)
+		if next == "" {
+			break
+		}
+		query = "limit=1&cursor=" + url.QueryEscape(next)
+	}
+	if got := strings.Join(paged, " "); got != "3:F3:in 2:R2:out 1:R1:out" {
+		t.Errorf("paged = %s", got)
+	}
+}
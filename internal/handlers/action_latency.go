@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+// actionLatencyDefaultRange is the window used when from is omitted.
+const actionLatencyDefaultRange = 7 * 24 * time.Hour
+
+// LatencyStatsDTO summarizes one group's durations in seconds.
+type LatencyStatsDTO struct {
+	Key        string  `json:"key,omitempty"`
+	Count      int64   `json:"count"`
+	P50Seconds float64 `json:"p50_seconds"`
+	P90Seconds float64 `json:"p90_seconds"`
+	P99Seconds float64 `json:"p99_seconds"`
+	AvgSeconds float64 `json:"avg_seconds"`
+	MaxSeconds float64 `json:"max_seconds"`
+}
+
+// ActionLatencyResponse is returned by /v1/actions/latency.
+type ActionLatencyResponse struct {
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	GroupBy       string            `json:"group_by"`
+	Type          string            `json:"type,omitempty"`
+	Supernode     string            `json:"supernode,omitempty"`
+	Finalize      []LatencyStatsDTO `json:"finalize"`
+	Approve       []LatencyStatsDTO `json:"approve"`
+	SchemaVersion string            `json:"schema_version"`
+}
+
+// lifecycleSeconds returns the seconds between two lifecycle transactions, or nil when
+// either is missing.
+func lifecycleSeconds(start, end *time.Time) *float64 {
+	if start == nil || end == nil {
+		return nil
+	}
+	secs := end.Sub(*start).Seconds()
+	return &secs
+}
+
+func toLatencyStatsDTOs(stats []db.LatencyStats) []LatencyStatsDTO {
+	out := make([]LatencyStatsDTO, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, LatencyStatsDTO{
+			Key:        s.Key,
+			Count:      s.Count,
+			P50Seconds: s.P50,
+			P90Seconds: s.P90,
+			P99Seconds: s.P99,
+			AvgSeconds: s.Avg,
+			MaxSeconds: s.Max,
+		})
+	}
+	return out
+}
+
+// GetActionLatency returns finalize (register → finalize) and approve (finalize →
+// approve) latency percentiles:
+// /v1/actions/latency?group_by=type|supernode|none&from=&to=&type=&supernode=
+//
+// An action counts in the window when the transaction ending the stage falls in [from, to).
+func GetActionLatency(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		groupBy := strings.TrimSpace(query.Get("group_by"))
+		switch groupBy {
+		case "":
+			groupBy = db.LatencyGroupType
+		case db.LatencyGroupType, db.LatencyGroupSupernode, db.LatencyGroupNone:
+		default:
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid group_by parameter: must be 'type', 'supernode' or 'none'")
+			return
+		}
+
+		to := time.Now().UTC()
+		if val := strings.TrimSpace(query.Get("to")); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid 'to' parameter: must be RFC3339 format")
+				return
+			}
+			to = t.UTC()
+		}
+		from := to.Add(-actionLatencyDefaultRange)
+		if val := strings.TrimSpace(query.Get("from")); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid 'from' parameter: must be RFC3339 format")
+				return
+			}
+			from = t.UTC()
+		}
+		if !from.Before(to) {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid range: from must be before to")
+			return
+		}
+
+		filter := db.ActionLatencyFilter{GroupBy: groupBy, From: from, To: to}
+		resp := ActionLatencyResponse{From: from, To: to, GroupBy: groupBy, SchemaVersion: "v1.0"}
+		if val := strings.TrimSpace(query.Get("type")); val != "" {
+			resp.Type = val
+			filter.ActionType = &val
+		}
+		if val := strings.TrimSpace(query.Get("supernode")); val != "" {
+			resp.Supernode = val
+			filter.Supernode = &val
+		}
+
+		for _, stage := range []struct {
+			name string
+			dst  *[]LatencyStatsDTO
+		}{{db.LatencyFinalize, &resp.Finalize}, {db.LatencyApprove, &resp.Approve}} {
+			filter.Stage = stage.name
+			stats, err := db.GetActionLatency(r.Context(), pool, filter)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch action latency")
+				return
+			}
+			*stage.dst = toLatencyStatsDTOs(stats)
+		}
+
+		now := time.Now().UTC()
+		util.WriteJSON(w, r, http.StatusOK, resp, &now)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestLifecycleSeconds verifies durations are only derived when both transactions exist
+func TestLifecycleSeconds(t *testing.T) {
+	start := time.Date(2025, 3, 6, 15, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+	if got := lifecycleSeconds(&start, &end); got == nil || *got != 90 {
+		t.Errorf("lifecycleSeconds = %v, want 90", got)
+	}
+	if got := lifecycleSeconds(&start, nil); got != nil {
+		t.Errorf("missing end = %v, want nil", *got)
+	}
+	if got := lifecycleSeconds(nil, &end); got != nil {
+		t.Errorf("missing start = %v, want nil", *got)
+	}
+}
+
+// TestActionLatencyRejects verifies unknown groupings and bad or inverted time ranges
+// are refused without a pool
+func TestActionLatencyRejects(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/v1/actions/latency?group_by=creator", "invalid group_by"},
+		{"/v1/actions/latency?to=now", "invalid 'to'"},
+		{"/v1/actions/latency?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", "from must be before to"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		GetActionLatency(nil)(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: %d %s, want %q", tt.url, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
+
+// TestActionLatencyQuery verifies stage durations run from the first transaction of
+// one type to the first of the next, grouped by type or by each assigned supernode,
+// and that the window and filters select by the stage-ending transaction
+func TestActionLatencyQuery(t *testing.T) {
+	pool := testPool(t)
+	t0 := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	lifecycle := func(id uint64, actionType, superNodes string, finalize time.Duration, more ...db.ActionTransaction) {
+		txs := []db.ActionTransaction{
+			{TxType: "register", TxHash: fmt.Sprintf("R%d", id), Height: 1, BlockTime: t0},
+			{TxType: "finalize", TxHash: fmt.Sprintf("F%d", id), Height: 2, BlockTime: t0.Add(finalize)},
+		}
+		seedAction(t, pool, db.ActionDB{ActionID: id, ActionType: actionType, State: "ACTION_STATE_DONE", SuperNodes: superNodes}, append(txs, more...)...)
+	}
+	lifecycle(1, "ACTION_TYPE_CASCADE", `["lumera1sn1"]`, time.Minute,
+		db.ActionTransaction{TxType: "approve", TxHash: "A1", Height: 3, BlockTime: t0.Add(160 * time.Second)},
+		db.ActionTransaction{TxType: "finalize", TxHash: "F1-RETRY", Height: 4, BlockTime: t0.Add(10 * time.Minute)})
+	lifecycle(2, "ACTION_TYPE_CASCADE", `["lumera1sn1","lumera1sn2"]`, 2*time.Minute)
+	lifecycle(3, "ACTION_TYPE_SENSE", `["lumera1sn2"]`, 30*time.Second)
+	h := GetActionLatency(pool)
+	window := "from=2025-03-01T00:00:00Z&to=2025-03-02T00:00:00Z"
+
+	stats := func(s []LatencyStatsDTO) string {
+		var out []string
+		for _, st := range s {
+			out = append(out, fmt.Sprintf("%s:%d/%g/%g", st.Key, st.Count, st.AvgSeconds, st.MaxSeconds))
+		}
+		return strings.Join(out, " ")
+	}
+	tests := []struct {
+		query             string
+		finalize, approve string
+	}{
+		{"group_by=type&" + window, "ACTION_TYPE_CASCADE:2/90/120 ACTION_TYPE_SENSE:1/30/30", "ACTION_TYPE_CASCADE:1/100/100"},
+		{"group_by=supernode&" + window, "lumera1sn1:2/90/120 lumera1sn2:2/75/120", "lumera1sn1:1/100/100"},
+		{"group_by=none&supernode=lumera1sn2&" + window, ":2/75/120", ""},
+		{"group_by=none&type=ACTION_TYPE_SENSE&" + window, ":1/30/30", ""},
+		{"group_by=none&from=2025-03-01T00:00:00Z&to=2025-03-01T12:01:40Z", ":2/45/60", ""},
+	}
+	for _, tt := range tests {
+		var resp ActionLatencyResponse
+		getJSON(t, h, "/v1/actions/latency?"+tt.query, &resp)
+		if got := stats(resp.Finalize); got != tt.finalize {
+			t.Errorf("%s: finalize = %q, want %q", tt.query, got, tt.finalize)
+		}
+		if got := stats(resp.Approve); got != tt.approve {
+			t.Errorf("%s: approve = %q, want %q", tt.query, got, tt.approve)
+		}
+	}
+}
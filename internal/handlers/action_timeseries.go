@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+// actionTimeseriesMaxBuckets caps the number of buckets in one response.
+const actionTimeseriesMaxBuckets = 1000
+
+// actionTimeseriesDefaultRange is the range used when from is omitted, per bucket size.
+var actionTimeseriesDefaultRange = map[string]time.Duration{
+	db.ActionBucketHour: 48 * time.Hour,
+	db.ActionBucketDay:  30 * 24 * time.Hour,
+	db.ActionBucketWeek: 26 * 7 * 24 * time.Hour,
+}
+
+// DenomAmountDTO is a token amount in a timeseries bucket.
+type DenomAmountDTO struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+// ActionTimeseriesBucket is one bucket of /v1/actions/timeseries.
+type ActionTimeseriesBucket struct {
+	Start       time.Time        `json:"start"`
+	Registered  int64            `json:"registered"`
+	Finalized   int64            `json:"finalized"`
+	Approved    int64            `json:"approved"`
+	Failed      int64            `json:"failed"`
+	Expired     int64            `json:"expired"`
+	BytesStored int64            `json:"bytes_stored"`
+	Prices      []DenomAmountDTO `json:"prices"`
+	Fees        []DenomAmountDTO `json:"fees"`
+}
+
+// ActionTimeseriesResponse lists every bucket in [from, to), including empty ones.
+type ActionTimeseriesResponse struct {
+	Bucket        string                   `json:"bucket"`
+	From          time.Time                `json:"from"`
+	To            time.Time                `json:"to"`
+	Type          string                   `json:"type,omitempty"`
+	Buckets       []ActionTimeseriesBucket `json:"buckets"`
+	SchemaVersion string                   `json:"schema_version"`
+}
+
+// actionBucketStart returns the start of the bucket containing t (UTC), matching
+// PostgreSQL date_trunc.
+func actionBucketStart(bucket string, t time.Time) time.Time {
+	t = t.UTC()
+	switch bucket {
+	case db.ActionBucketHour:
+		return t.Truncate(time.Hour)
+	case db.ActionBucketWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// nextActionBucket returns the start of the bucket after the one starting at start.
+func nextActionBucket(bucket string, start time.Time) time.Time {
+	switch bucket {
+	case db.ActionBucketHour:
+		return start.Add(time.Hour)
+	case db.ActionBucketWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// fillActionBuckets returns one bucket per bucket start in [from, to), taking values
+// from points (ordered by bucket start) where present.
+func fillActionBuckets(bucket string, from, to time.Time, points []db.ActionTimeseriesPoint) []ActionTimeseriesBucket {
+	out := make([]ActionTimeseriesBucket, 0)
+	i := 0
+	for start := from; start.Before(to); start = nextActionBucket(bucket, start) {
+		b := ActionTimeseriesBucket{Start: start, Prices: []DenomAmountDTO{}, Fees: []DenomAmountDTO{}}
+		for i < len(points) && points[i].BucketStart.Before(start) {
+			i++
+		}
+		if i < len(points) && points[i].BucketStart.Equal(start) {
+			p := points[i]
+			b.Registered, b.Finalized, b.Approved = p.Registered, p.Finalized, p.Approved
+			b.Failed, b.Expired, b.BytesStored = p.Failed, p.Expired, p.BytesStored
+			for _, a := range p.Prices {
+				b.Prices = append(b.Prices, DenomAmountDTO{Denom: a.Denom, Amount: a.Amount})
+			}
+			for _, a := range p.Fees {
+				b.Fees = append(b.Fees, DenomAmountDTO{Denom: a.Denom, Amount: a.Amount})
+			}
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// GetActionTimeseries returns per-bucket action counts, stored bytes and amounts paid:
+// /v1/actions/timeseries?bucket=hour|day|week&from=&to=&type=
+//
+// Buckets are derived from action transaction block times; from and to (RFC3339) are
+// widened to whole buckets.
+func GetActionTimeseries(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		bucket := strings.TrimSpace(query.Get("bucket"))
+		if bucket == "" {
+			bucket = db.ActionBucketDay
+		}
+		defaultRange, ok := actionTimeseriesDefaultRange[bucket]
+		if !ok {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid bucket parameter: must be 'hour', 'day' or 'week'")
+			return
+		}
+
+		to := time.Now().UTC()
+		if val := strings.TrimSpace(query.Get("to")); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid 'to' parameter: must be RFC3339 format")
+				return
+			}
+			to = t.UTC()
+		}
+		from := to.Add(-defaultRange)
+		if val := strings.TrimSpace(query.Get("from")); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid 'from' parameter: must be RFC3339 format")
+				return
+			}
+			from = t.UTC()
+		}
+		if !from.Before(to) {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid range: from must be before to")
+			return
+		}
+
+		from = actionBucketStart(bucket, from)
+		if end := actionBucketStart(bucket, to); end.Before(to) {
+			to = nextActionBucket(bucket, end)
+		}
+		n := 0
+		for start := from; start.Before(to); start = nextActionBucket(bucket, start) {
+			if n++; n > actionTimeseriesMaxBuckets {
+				util.WriteJSONError(w, http.StatusBadRequest, fmt.Sprintf("range too large for bucket: at most %d buckets", actionTimeseriesMaxBuckets))
+				return
+			}
+		}
+
+		filter := db.ActionTimeseriesFilter{Bucket: bucket, From: from, To: to}
+		var actionType string
+		if val := strings.TrimSpace(query.Get("type")); val != "" {
+			actionType = val
+			filter.ActionType = &actionType
+		}
+
+		points, err := db.GetActionTimeseries(r.Context(), pool, filter)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch action timeseries")
+			return
+		}
+
+		resp := ActionTimeseriesResponse{
+			Bucket:        bucket,
+			From:          from,
+			To:            to,
+			Type:          actionType,
+			Buckets:       fillActionBuckets(bucket, from, to, points),
+			SchemaVersion: "v1.0",
+		}
+		now := time.Now().UTC()
+		util.WriteJSON(w, r, http.StatusOK, resp, &now)
+	}
+}
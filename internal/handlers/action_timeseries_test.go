@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestActionBucketStart verifies bucket alignment matches date_trunc (weeks start on Monday)
+func TestActionBucketStart(t *testing.T) {
+	ts := time.Date(2025, 3, 6, 15, 42, 10, 0, time.UTC) // Thursday
+	tests := []struct {
+		bucket string
+		want   time.Time
+	}{
+		{db.ActionBucketHour, time.Date(2025, 3, 6, 15, 0, 0, 0, time.UTC)},
+		{db.ActionBucketDay, time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{db.ActionBucketWeek, time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := actionBucketStart(tt.bucket, ts); !got.Equal(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.bucket, got, tt.want)
+		}
+	}
+	sunday := time.Date(2025, 3, 9, 23, 0, 0, 0, time.FixedZone("X", -3*3600)) // Monday 02:00 UTC
+	if got := actionBucketStart(db.ActionBucketWeek, sunday); !got.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("week of %v = %v", sunday, got)
+	}
+}
+
+// TestFillActionBuckets verifies empty buckets are emitted with zero values
+func TestFillActionBuckets(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 3)
+	points := []db.ActionTimeseriesPoint{{
+		BucketStart: from.AddDate(0, 0, 1),
+		Registered:  4,
+		BytesStored: 1024,
+		Prices:      []db.DenomAmount{{Denom: "ulume", Amount: "1000"}},
+	}}
+	got := fillActionBuckets(db.ActionBucketDay, from, to, points)
+	if len(got) != 3 {
+		t.Fatalf("got %d buckets, want 3", len(got))
+	}
+	if got[0].Registered != 0 || got[0].Prices == nil || got[0].Fees == nil {
+		t.Errorf("empty bucket = %+v", got[0])
+	}
+	if got[1].Registered != 4 || got[1].BytesStored != 1024 || len(got[1].Prices) != 1 || got[1].Prices[0].Amount != "1000" {
+		t.Errorf("filled bucket = %+v", got[1])
+	}
+	if !got[2].Start.Equal(from.AddDate(0, 0, 2)) {
+		t.Errorf("last bucket starts %v", got[2].Start)
+	}
+}
+
+// TestActionTimeseriesRejects verifies unknown buckets, bad or inverted ranges and
+// ranges over the bucket cap are refused without a pool
+func TestActionTimeseriesRejects(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/v1/actions/timeseries?bucket=month", "invalid bucket"},
+		{"/v1/actions/timeseries?from=yesterday", "invalid 'from'"},
+		{"/v1/actions/timeseries?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", "from must be before to"},
+		{"/v1/actions/timeseries?bucket=hour&from=2024-01-01T00:00:00Z&to=2025-01-01T00:00:00Z", "at most 1000 buckets"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		GetActionTimeseries(nil)(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: %d %s, want %q", tt.url, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
+
+// ptr returns a pointer to v, for the optional fields of seeded rows.
+func ptr[T any](v T) *T { return &v }
+
+// seedAction stores a and its transactions, failing the test on error.
+func seedAction(t *testing.T, pool *db.Pool, a db.ActionDB, txs ...db.ActionTransaction) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := db.UpsertAction(ctx, pool, a); err != nil {
+		t.Fatalf("action %d: %v", a.ActionID, err)
+	}
+	for i := range txs {
+		txs[i].ActionID = a.ActionID
+		if _, err := db.UpsertActionTransaction(ctx, pool, &txs[i]); err != nil {
+			t.Fatalf("action %d tx %s: %v", a.ActionID, txs[i].TxHash, err)
+		}
+	}
+}
+
+// getJSON serves a GET of target and decodes the 200 response into v.
+func getJSON(t *testing.T, h http.Handler, target string, v any) {
+	t.Helper()
+	rec := serve(h, http.MethodGet, target, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s = %d %s", target, rec.Code, rec.Body)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("%s: %v", target, err)
+	}
+}
+
+// TestActionTimeseriesQuery verifies counts, bytes, prices and fees land in the bucket
+// of their transaction, that retries only add fees and that the type filter applies
+func TestActionTimeseriesQuery(t *testing.T) {
+	pool := testPool(t)
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	seedAction(t, pool,
+		db.ActionDB{ActionID: 1, Creator: "lumera1a", ActionType: "ACTION_TYPE_CASCADE", State: "ACTION_STATE_DONE", Size: 2048},
+		db.ActionTransaction{TxType: "register", TxHash: "R1", Height: 10, BlockTime: day.Add(10 * time.Hour),
+			ActionPrice: ptr("1000"), ActionPriceDenom: ptr("ulume"), TxFee: ptr("50"), TxFeeDenom: ptr("ulume")},
+		db.ActionTransaction{TxType: "finalize", TxHash: "F1", Height: 20, BlockTime: day.AddDate(0, 0, 1),
+			TxFee: ptr("20"), TxFeeDenom: ptr("ulume")},
+		db.ActionTransaction{TxType: "finalize", TxHash: "F1-RETRY", Height: 30, BlockTime: day.AddDate(0, 0, 2),
+			TxFee: ptr("5"), TxFeeDenom: ptr("ulume")})
+	seedAction(t, pool,
+		db.ActionDB{ActionID: 2, Creator: "lumera1b", ActionType: "ACTION_TYPE_SENSE", State: "ACTION_STATE_FAILED"},
+		db.ActionTransaction{TxType: "register", TxHash: "R2", Height: 11, BlockTime: day.Add(11 * time.Hour),
+			ActionPrice: ptr("500"), ActionPriceDenom: ptr("ulume"), TxFee: ptr("10"), TxFeeDenom: ptr("ulume")})
+	h := GetActionTimeseries(pool)
+
+	var resp ActionTimeseriesResponse
+	getJSON(t, h, "/v1/actions/timeseries?bucket=day&from=2025-03-01T00:00:00Z&to=2025-03-04T00:00:00Z", &resp)
+	if len(resp.Buckets) != 3 {
+		t.Fatalf("buckets = %+v, want 3", resp.Buckets)
+	}
+	b := resp.Buckets
+	if b[0].Registered != 2 || b[0].Failed != 1 || fmt.Sprint(b[0].Prices) != "[{ulume 1500}]" || fmt.Sprint(b[0].Fees) != "[{ulume 60}]" {
+		t.Errorf("day 1 = %+v", b[0])
+	}
+	if b[1].Registered != 0 || b[1].Finalized != 1 || b[1].BytesStored != 2048 || fmt.Sprint(b[1].Fees) != "[{ulume 20}]" {
+		t.Errorf("day 2 = %+v", b[1])
+	}
+	if b[2].Finalized != 0 || b[2].BytesStored != 0 || fmt.Sprint(b[2].Fees) != "[{ulume 5}]" {
+		t.Errorf("day 3, retry only = %+v", b[2])
+	}
+
+	getJSON(t, h, "/v1/actions/timeseries?bucket=day&from=2025-03-01T00:00:00Z&to=2025-03-04T00:00:00Z&type=ACTION_TYPE_SENSE", &resp)
+	b = resp.Buckets
+	if b[0].Registered != 1 || b[0].Failed != 1 || fmt.Sprint(b[0].Prices) != "[{ulume 500}]" || b[1].Finalized != 0 || len(b[2].Fees) != 0 {
+		t.Errorf("SENSE buckets = %+v", b)
+	}
+}
@@ -3,7 +3,11 @@ package handlers
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,18 +18,49 @@ import (
 
 // TransactionDTO represents transaction data in API responses
 type TransactionDTO struct {
-	TxType           string     `json:"tx_type"`
-	TxHash           string     `json:"tx_hash"`
-	Height           int64      `json:"height"`
-	BlockTime        time.Time  `json:"block_time"`
-	GasWanted        *int64     `json:"gas_wanted,omitempty"`
-	GasUsed          *int64     `json:"gas_used,omitempty"`
-	ActionPrice      *string    `json:"action_price,omitempty"`
-	ActionPriceDenom *string    `json:"action_price_denom,omitempty"`
-	FlowPayer        *string    `json:"flow_payer,omitempty"`
-	FlowPayee        *string    `json:"flow_payee,omitempty"`
-	TxFee            *string    `json:"tx_fee,omitempty"`
-	TxFeeDenom       *string    `json:"tx_fee_denom,omitempty"`
+	TxType           string      `json:"tx_type"`
+	TxHash           string      `json:"tx_hash"`
+	MsgIndex         int         `json:"msg_index"`
+	Height           int64       `json:"height"`
+	BlockTime        time.Time   `json:"block_time"`
+	GasWanted        *int64      `json:"gas_wanted,omitempty"`
+	GasUsed          *int64      `json:"gas_used,omitempty"`
+	ActionPrice      *string     `json:"action_price,omitempty"`
+	ActionPriceDenom *string     `json:"action_price_denom,omitempty"`
+	FlowPayer        *string     `json:"flow_payer,omitempty"`
+	FlowPayee        *string     `json:"flow_payee,omitempty"`
+	TxFee            *string     `json:"tx_fee,omitempty"`
+	TxFeeDenom       *string     `json:"tx_fee_denom,omitempty"`
+	Flows            []TxFlowDTO `json:"flows,omitempty"`
+}
+
+// TxFlowDTO is one coin moved by a transfer, coin_spent, coin_received or burn event.
+// MsgIndex is omitted for events outside any message, such as fee deduction.
+type TxFlowDTO struct {
+	EventIndex int     `json:"event_index"`
+	MsgIndex   *int    `json:"msg_index,omitempty"`
+	EventType  string  `json:"event_type"`
+	Sender     *string `json:"sender,omitempty"`
+	Recipient  *string `json:"recipient,omitempty"`
+	Amount     string  `json:"amount"`
+	Denom      string  `json:"denom"`
+}
+
+// txFlowsToDTO converts db.TxFlow records to TxFlowDTOs
+func txFlowsToDTO(flows []db.TxFlow) []TxFlowDTO {
+	out := make([]TxFlowDTO, 0, len(flows))
+	for _, f := range flows {
+		out = append(out, TxFlowDTO{
+			EventIndex: f.EventIndex,
+			MsgIndex:   f.MsgIndex,
+			EventType:  f.EventType,
+			Sender:     f.Sender,
+			Recipient:  f.Recipient,
+			Amount:     f.Amount,
+			Denom:      f.Denom,
+		})
+	}
+	return out
 }
 
 // PlaceholderTxHash is used to mark actions that have been checked but have no
@@ -43,6 +78,7 @@ func actionTransactionToDTO(tx db.ActionTransaction) TransactionDTO {
 	return TransactionDTO{
 		TxType:           tx.TxType,
 		TxHash:           tx.TxHash,
+		MsgIndex:         tx.MsgIndex,
 		Height:           tx.Height,
 		BlockTime:        tx.BlockTime,
 		GasWanted:        tx.GasWanted,
@@ -53,6 +89,7 @@ func actionTransactionToDTO(tx db.ActionTransaction) TransactionDTO {
 		FlowPayee:        tx.FlowPayee,
 		TxFee:            tx.TxFee,
 		TxFeeDenom:       tx.TxFeeDenom,
+		Flows:            txFlowsToDTO(tx.Flows),
 	}
 }
 
@@ -74,9 +111,42 @@ type ActionItem struct {
 	FinalizeTxTime   *time.Time `json:"finalize_tx_time,omitempty"`
 	ApproveTxID      *string    `json:"approve_tx_id,omitempty"`
 	ApproveTxTime    *time.Time `json:"approve_tx_time,omitempty"`
+	// Lifecycle durations derived from the transaction times above
+	FinalizeLatencySeconds *float64 `json:"finalize_latency_seconds,omitempty"` // register → finalize
+	ApproveLatencySeconds  *float64 `json:"approve_latency_seconds,omitempty"`  // finalize → approve
 	Transactions     []TransactionDTO `json:"transactions,omitempty"`
 }
 
+// maxMetadataFilters caps the metadata.<field> parameters of one action list request.
+const maxMetadataFilters = 8
+
+// parseMetadataFilters collects metadata.<field>=value parameters, sorted by field.
+func parseMetadataFilters(query url.Values) ([]db.MetadataFilter, error) {
+	var filters []db.MetadataFilter
+	for key, values := range query {
+		field, ok := strings.CutPrefix(key, "metadata.")
+		if !ok {
+			continue
+		}
+		if !db.ValidMetadataField(field) {
+			return nil, fmt.Errorf("invalid %s parameter: metadata fields are lowercase letters, digits and underscores", key)
+		}
+		for _, v := range values {
+			filters = append(filters, db.MetadataFilter{Field: field, Value: v})
+		}
+	}
+	if len(filters) > maxMetadataFilters {
+		return nil, fmt.Errorf("too many metadata filters: at most %d", maxMetadataFilters)
+	}
+	sort.Slice(filters, func(i, j int) bool {
+		if filters[i].Field != filters[j].Field {
+			return filters[i].Field < filters[j].Field
+		}
+		return filters[i].Value < filters[j].Value
+	})
+	return filters, nil
+}
+
 type ActionsListResponse struct {
 	Items         []ActionItem `json:"items"`
 	NextCursor    string       `json:"next_cursor,omitempty"`
@@ -84,6 +154,11 @@ type ActionsListResponse struct {
 }
 
 func ListActions(pool *db.Pool) http.HandlerFunc {
+	return listActions(pool, "")
+}
+
+// listActions serves an action list. A non-empty creator replaces the creator parameter.
+func listActions(pool *db.Pool, creator string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		queryValues := r.URL.Query()
 
@@ -93,7 +168,9 @@ func ListActions(pool *db.Pool) http.HandlerFunc {
 			filterType := typeStr
 			filter.Type = &filterType
 		}
-		if creatorStr := queryValues.Get("creator"); creatorStr != "" {
+		if creator != "" {
+			filter.Creator = &creator
+		} else if creatorStr := queryValues.Get("creator"); creatorStr != "" {
 			filterCreator := creatorStr
 			filter.Creator = &filterCreator
 		}
@@ -106,6 +183,13 @@ func ListActions(pool *db.Pool) http.HandlerFunc {
 			filter.Supernode = &filterSupernode
 		}
 
+		metadata, err := parseMetadataFilters(queryValues)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter.Metadata = metadata
+
 		limit := 50
 		if limitStr := queryValues.Get("limit"); limitStr != "" {
 			parsedLimit, err := strconv.Atoi(limitStr)
@@ -143,30 +227,9 @@ func ListActions(pool *db.Pool) http.HandlerFunc {
 		}
 
 		if cursorStr := queryValues.Get("cursor"); cursorStr != "" {
-			decodedCursor, err := base64.StdEncoding.DecodeString(cursorStr)
+			cursorTime, cursorIDVal, err := decodeActionCursor(cursorStr)
 			if err != nil {
-				util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor encoding")
-				return
-			}
-			var payload struct {
-				TS string `json:"ts"`
-				ID string `json:"id"`
-			}
-			if err := json.Unmarshal(decodedCursor, &payload); err != nil || payload.TS == "" || payload.ID == "" {
-				util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor format")
-				return
-			}
-			parsedCursorTS, err := time.Parse(time.RFC3339, payload.TS)
-			if err != nil {
-				util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor timestamp")
-				return
-			}
-			parsedCursorTS = parsedCursorTS.UTC()
-			cursorTime := parsedCursorTS
-			// Parse cursor ID as uint64
-			cursorIDVal, err := strconv.ParseUint(payload.ID, 10, 64)
-			if err != nil {
-				util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor ID: must be numeric")
+				util.WriteJSONError(w, http.StatusBadRequest, err.Error())
 				return
 			}
 			filter.CursorTS = &cursorTime
@@ -238,21 +301,24 @@ func ListActions(pool *db.Pool) http.HandlerFunc {
 					if includeTransactions {
 						txDTOs = append(txDTOs, actionTransactionToDTO(tx))
 					}
-					// Always populate flattened transaction fields
+					// Always populate flattened transaction fields; transactions come in
+					// height order, so the earliest of each type wins
 					txHash := tx.TxHash
 					txTime := tx.BlockTime
-					switch tx.TxType {
-					case "register":
+					switch {
+					case tx.TxType == "register" && item.RegisterTxID == nil:
 						item.RegisterTxID = &txHash
 						item.RegisterTxTime = &txTime
-					case "finalize":
+					case tx.TxType == "finalize" && item.FinalizeTxID == nil:
 						item.FinalizeTxID = &txHash
 						item.FinalizeTxTime = &txTime
-					case "approve":
+					case tx.TxType == "approve" && item.ApproveTxID == nil:
 						item.ApproveTxID = &txHash
 						item.ApproveTxTime = &txTime
 					}
 				}
+				item.FinalizeLatencySeconds = lifecycleSeconds(item.RegisterTxTime, item.FinalizeTxTime)
+				item.ApproveLatencySeconds = lifecycleSeconds(item.FinalizeTxTime, item.ApproveTxTime)
 				// Only include Transactions array if requested
 				if includeTransactions {
 					item.Transactions = txDTOs
@@ -268,20 +334,12 @@ func ListActions(pool *db.Pool) http.HandlerFunc {
 		}
 
 		if hasMore && len(actions) > 0 {
-			last := actions[len(actions)-1]
-			cursorPayload := struct {
-				TS string `json:"ts"`
-				ID string `json:"id"`
-			}{
-				TS: last.CreatedAt.UTC().Format(time.RFC3339),
-				ID: strconv.FormatUint(last.ActionID, 10),
-			}
-			cursorJSON, err := json.Marshal(cursorPayload)
+			cursor, err := encodeActionCursor(actions[len(actions)-1])
 			if err != nil {
 				util.WriteJSONError(w, http.StatusInternalServerError, "failed to encode cursor")
 				return
 			}
-			resp.NextCursor = base64.StdEncoding.EncodeToString(cursorJSON)
+			resp.NextCursor = cursor
 		}
 
 		lm := time.Now().UTC()
@@ -289,6 +347,43 @@ func ListActions(pool *db.Pool) http.HandlerFunc {
 	}
 }
 
+// actionCursor is the keyset pagination cursor of action lists (base64 JSON).
+type actionCursor struct {
+	TS string `json:"ts"`
+	ID string `json:"id"`
+}
+
+func encodeActionCursor(a db.ActionDB) (string, error) {
+	buf, err := json.Marshal(actionCursor{
+		TS: a.CreatedAt.UTC().Format(time.RFC3339),
+		ID: strconv.FormatUint(a.ActionID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+func decodeActionCursor(s string) (time.Time, uint64, error) {
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid cursor encoding")
+	}
+	var payload actionCursor
+	if err := json.Unmarshal(decoded, &payload); err != nil || payload.TS == "" || payload.ID == "" {
+		return time.Time{}, 0, errors.New("invalid cursor format")
+	}
+	ts, err := time.Parse(time.RFC3339, payload.TS)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid cursor timestamp")
+	}
+	id, err := strconv.ParseUint(payload.ID, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid cursor ID: must be numeric")
+	}
+	return ts.UTC(), id, nil
+}
+
 func GetAction(pool *db.Pool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		idStr := actionIDFromPath(r.URL.Path)
@@ -320,6 +415,20 @@ func GetAction(pool *db.Pool) http.HandlerFunc {
 			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch action transactions")
 			return
 		}
+		hashes := make([]string, 0, len(transactions))
+		for _, tx := range transactions {
+			if !isPlaceholderTransaction(tx) {
+				hashes = append(hashes, tx.TxHash)
+			}
+		}
+		flows, err := db.GetTxFlows(r.Context(), pool, hashes)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch transaction flows")
+			return
+		}
+		for i := range transactions {
+			transactions[i].Flows = flows[transactions[i].TxHash]
+		}
 
 		// Convert transactions to DTOs and extract flattened fields
 		// Filter out placeholder transactions (_NO_TX_FOUND_) from API responses
@@ -334,17 +443,17 @@ func GetAction(pool *db.Pool) http.HandlerFunc {
 					continue
 				}
 				txDTOs = append(txDTOs, actionTransactionToDTO(tx))
-				// Populate flattened transaction fields
+				// Populate flattened transaction fields from the earliest of each type
 				txHash := tx.TxHash
 				txTime := tx.BlockTime
-				switch tx.TxType {
-				case "register":
+				switch {
+				case tx.TxType == "register" && registerTxID == nil:
 					registerTxID = &txHash
 					registerTxTime = &txTime
-				case "finalize":
+				case tx.TxType == "finalize" && finalizeTxID == nil:
 					finalizeTxID = &txHash
 					finalizeTxTime = &txTime
-				case "approve":
+				case tx.TxType == "approve" && approveTxID == nil:
 					approveTxID = &txHash
 					approveTxTime = &txTime
 				}
@@ -370,6 +479,8 @@ func GetAction(pool *db.Pool) http.HandlerFunc {
 			FinalizeTxTime *time.Time       `json:"finalize_tx_time,omitempty"`
 			ApproveTxID    *string          `json:"approve_tx_id,omitempty"`
 			ApproveTxTime  *time.Time       `json:"approve_tx_time,omitempty"`
+			FinalizeLatencySeconds *float64 `json:"finalize_latency_seconds,omitempty"`
+			ApproveLatencySeconds  *float64 `json:"approve_latency_seconds,omitempty"`
 			Transactions   []TransactionDTO `json:"transactions,omitempty"`
 			SchemaVersion  string           `json:"schema_version"`
 		}{
@@ -390,6 +501,8 @@ func GetAction(pool *db.Pool) http.HandlerFunc {
 			FinalizeTxTime: finalizeTxTime,
 			ApproveTxID:    approveTxID,
 			ApproveTxTime:  approveTxTime,
+			FinalizeLatencySeconds: lifecycleSeconds(registerTxTime, finalizeTxTime),
+			ApproveLatencySeconds:  lifecycleSeconds(finalizeTxTime, approveTxTime),
 			Transactions:   txDTOs,
 			SchemaVersion:  "v1.0",
 		}
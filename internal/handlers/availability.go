@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+// AvailabilityDTO is the share of probes in a window where each check passed (0..1).
+// Ratios are omitted when the window has no samples.
+type AvailabilityDTO struct {
+	From      time.Time `json:"from"`
+	Samples   int64     `json:"samples"`
+	Port1     *float64  `json:"port1,omitempty"`
+	P2P       *float64  `json:"p2p,omitempty"`
+	StatusAPI *float64  `json:"status_api,omitempty"`
+	Overall   *float64  `json:"overall,omitempty"`
+}
+
+type SupernodeAvailabilityResponse struct {
+	SupernodeAccount string                     `json:"supernode_account"`
+	Windows          map[string]AvailabilityDTO `json:"windows"`
+	SchemaVersion    string                     `json:"schema_version"`
+}
+
+// availabilityListParams are the availability filters and sort order of /v1/supernodes/metrics.
+type availabilityListParams struct {
+	MinAvailability map[string]float64
+	SortWindow      string
+	SortDesc        bool
+}
+
+// parseAvailabilityListParams reads minAvailability{24h,7d,30d}, sort and order.
+// sort is "supernode_account" (default) or "availability_{24h,7d,30d}"; availability
+// sorts default to descending.
+func parseAvailabilityListParams(query url.Values) (availabilityListParams, error) {
+	var p availabilityListParams
+	for _, w := range db.AvailabilityWindows {
+		name := "minAvailability" + w.Name
+		val := query.Get(name)
+		if val == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(val, 64)
+		if err != nil || v < 0 || v > 1 {
+			return p, fmt.Errorf("invalid %s parameter: must be a number between 0 and 1", name)
+		}
+		if p.MinAvailability == nil {
+			p.MinAvailability = make(map[string]float64)
+		}
+		p.MinAvailability[w.Name] = v
+	}
+
+	switch sort := query.Get("sort"); {
+	case sort == "" || sort == "supernode_account":
+	case strings.HasPrefix(sort, "availability_"):
+		w, ok := db.AvailabilityWindowByName(strings.TrimPrefix(sort, "availability_"))
+		if !ok {
+			return p, fmt.Errorf("invalid sort parameter: must be 'supernode_account', 'availability_24h', 'availability_7d' or 'availability_30d'")
+		}
+		p.SortWindow = w.Name
+		p.SortDesc = true
+	default:
+		return p, fmt.Errorf("invalid sort parameter: must be 'supernode_account', 'availability_24h', 'availability_7d' or 'availability_30d'")
+	}
+
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		p.SortDesc = false
+	case "desc":
+		if p.SortWindow == "" {
+			return p, fmt.Errorf("invalid order parameter: 'desc' requires an availability sort")
+		}
+		p.SortDesc = true
+	default:
+		return p, fmt.Errorf("invalid order parameter: must be 'asc' or 'desc'")
+	}
+	return p, nil
+}
+
+// availabilitySortValue returns the keyset value of a supernode for the given sort
+// window, matching the NULL-as--1 ordering used by the query.
+func availabilitySortValue(sn db.SupernodeDB, window string) float64 {
+	var v *float64
+	switch window {
+	case "24h":
+		v = sn.Availability24h
+	case "7d":
+		v = sn.Availability7d
+	case "30d":
+		v = sn.Availability30d
+	}
+	if v == nil {
+		return -1
+	}
+	return *v
+}
+
+// GetSupernodeAvailability returns port1, P2P, status API and overall availability of a
+// supernode over the rolling windows, computed from recorded probe outcomes.
+func GetSupernodeAvailability(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := supernodeIDFromPath(r.URL.Path)
+		if id == "" {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid supernode ID")
+			return
+		}
+		if _, err := db.GetSupernodeByID(r.Context(), pool, id); err != nil {
+			if err == db.ErrNotFound {
+				util.WriteJSONError(w, http.StatusNotFound, "supernode not found")
+				return
+			}
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch supernode")
+			return
+		}
+
+		now := time.Now().UTC()
+		resp := SupernodeAvailabilityResponse{
+			SupernodeAccount: id,
+			Windows:          make(map[string]AvailabilityDTO, len(db.AvailabilityWindows)),
+			SchemaVersion:    "v1.0",
+		}
+		for _, win := range db.AvailabilityWindows {
+			from := now.Add(-win.Duration)
+			stats, err := db.GetSupernodeAvailability(r.Context(), pool, id, from, now)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to compute availability")
+				return
+			}
+			resp.Windows[win.Name] = AvailabilityDTO{
+				From:      from,
+				Samples:   stats.Samples,
+				Port1:     stats.Port1,
+				P2P:       stats.P2P,
+				StatusAPI: stats.StatusAPI,
+				Overall:   stats.Overall,
+			}
+		}
+		util.WriteJSON(w, r, http.StatusOK, resp, &now)
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+)
+
+// TestParseAvailabilityListParams verifies availability filters and sort parsing for /v1/supernodes/metrics
+func TestParseAvailabilityListParams(t *testing.T) {
+	p, err := parseAvailabilityListParams(url.Values{})
+	if err != nil || p.SortWindow != "" || p.SortDesc || p.MinAvailability != nil {
+		t.Fatalf("defaults = %+v, %v; want account sort without filters", p, err)
+	}
+
+	p, err = parseAvailabilityListParams(url.Values{"minAvailability7d": {"0.99"}, "sort": {"availability_7d"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.MinAvailability["7d"] != 0.99 || p.SortWindow != "7d" || !p.SortDesc {
+		t.Errorf("params = %+v, want minAvailability7d=0.99 sorted by 7d desc", p)
+	}
+
+	p, err = parseAvailabilityListParams(url.Values{"sort": {"availability_30d"}, "order": {"asc"}})
+	if err != nil || p.SortWindow != "30d" || p.SortDesc {
+		t.Errorf("params = %+v, %v; want 30d asc", p, err)
+	}
+
+	invalid := []url.Values{
+		{"minAvailability24h": {"1.5"}},
+		{"minAvailability30d": {"abc"}},
+		{"sort": {"availability_1y"}},
+		{"sort": {"cpu"}},
+		{"order": {"desc"}},
+		{"sort": {"availability_24h"}, "order": {"up"}},
+	}
+	for _, q := range invalid {
+		if _, err := parseAvailabilityListParams(q); err == nil {
+			t.Errorf("expected error for %v", q)
+		}
+	}
+}
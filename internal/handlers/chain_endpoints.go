@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"lumescope/internal/lumera"
+)
+
+// ChainEndpoints reports the health of the chain client's LCD endpoints.
+type ChainEndpoints interface {
+	EndpointHealth() []lumera.EndpointHealth
+}
+
+// ChainEndpointsResponse is the /v1/admin/chain/endpoints body.
+type ChainEndpointsResponse struct {
+	Status    string                  `json:"status"` // "ok", "degraded" (some endpoints down) or "down"
+	Healthy   int                     `json:"healthy"`
+	Endpoints []lumera.EndpointHealth `json:"endpoints"`
+}
+
+// AdminChainEndpoints returns the current health of each LCD endpoint: whether it is
+// in rotation or cooling down after a failure, its average latency and error counts.
+func AdminChainEndpoints(src ChainEndpoints) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET, OPTIONS")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		writeNoStoreJSON(w, http.StatusOK, chainEndpointsResponse(src.EndpointHealth()))
+	}
+}
+
+func chainEndpointsResponse(endpoints []lumera.EndpointHealth) ChainEndpointsResponse {
+	resp := ChainEndpointsResponse{Status: "ok", Endpoints: endpoints}
+	for _, e := range endpoints {
+		if e.Healthy {
+			resp.Healthy++
+		}
+	}
+	switch {
+	case resp.Healthy == 0:
+		resp.Status = "down"
+	case resp.Healthy < len(endpoints):
+		resp.Status = "degraded"
+	}
+	return resp
+}
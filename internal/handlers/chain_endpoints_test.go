@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"testing"
+
+	"lumescope/internal/lumera"
+)
+
+// TestChainEndpointsResponse verifies the overall status summarizes endpoint health
+func TestChainEndpointsResponse(t *testing.T) {
+	up, down := lumera.EndpointHealth{URL: "http://a", Healthy: true}, lumera.EndpointHealth{URL: "http://b"}
+	tests := []struct {
+		endpoints   []lumera.EndpointHealth
+		wantStatus  string
+		wantHealthy int
+	}{
+		{[]lumera.EndpointHealth{up, up}, "ok", 2},
+		{[]lumera.EndpointHealth{up, down}, "degraded", 1},
+		{[]lumera.EndpointHealth{down}, "down", 0},
+	}
+	for _, tt := range tests {
+		got := chainEndpointsResponse(tt.endpoints)
+		if got.Status != tt.wantStatus || got.Healthy != tt.wantHealthy {
+			t.Errorf("status = %s (%d healthy), want %s (%d)", got.Status, got.Healthy, tt.wantStatus, tt.wantHealthy)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/export"
+	"lumescope/internal/util"
+)
+
+// exportRetryAfter is the Retry-After, in seconds, of an export refused for lack of a slot.
+const exportRetryAfter = "30"
+
+// ExportSlots bounds how many exports run at once. Every export holds a database
+// connection until it ends, so one instance is shared by all networks of the process.
+// A nil *ExportSlots is unlimited.
+type ExportSlots struct {
+	ch chan struct{}
+}
+
+// NewExportSlots returns slots for n concurrent exports, or nil (unlimited) if n <= 0.
+func NewExportSlots(n int) *ExportSlots {
+	if n <= 0 {
+		return nil
+	}
+	return &ExportSlots{ch: make(chan struct{}, n)}
+}
+
+// tryAcquire takes a slot without waiting and reports whether one was free.
+func (s *ExportSlots) tryAcquire() bool {
+	if s == nil {
+		return true
+	}
+	select {
+	case s.ch <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *ExportSlots) release() {
+	if s != nil {
+		<-s.ch
+	}
+}
+
+// ExportLimits bounds exports. Zero values don't limit.
+type ExportLimits struct {
+	Slots        *ExportSlots
+	MaxDuration  time.Duration // the export is cut off after this long
+	WriteTimeout time.Duration // deadline of each write, so a client that stops reading releases its connection
+}
+
+// Export streams a dataset as CSV, NDJSON or Parquet:
+// /v1/export/{actions,action-transactions,supernodes}?format=&<filters>
+//
+// Rows are read through a database cursor and written as they arrive, so the response
+// is never held in memory. The server write deadline is replaced by limits.WriteTimeout
+// per write, and requests beyond the free limits.Slots get a 429.
+// Errors before the first byte are reported as JSON; later ones truncate the response.
+func Export(pool *db.Pool, ds db.ExportDataset, limits ExportLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		format, err := export.ParseFormat(query.Get("format"))
+		if err != nil {
+			util.WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter, err := export.ParseFilter(query.Get)
+		if err == nil {
+			err = ds.Validate(filter)
+		}
+		if err != nil {
+			util.WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !limits.Slots.tryAcquire() {
+			w.Header().Set("Retry-After", exportRetryAfter)
+			util.WriteJSONError(w, http.StatusTooManyRequests, "too many exports running, retry later")
+			return
+		}
+		defer limits.Slots.release()
+
+		ctx := r.Context()
+		if limits.MaxDuration > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, limits.MaxDuration)
+			defer cancel()
+		}
+
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to start export")
+			return
+		}
+		h := w.Header()
+		h.Set("Content-Type", format.ContentType())
+		h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, ds.Name, format.Extension()))
+		h.Set("Cache-Control", "no-store")
+		h.Set("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+
+		out := &exportResponseWriter{w: w, rc: rc, timeout: limits.WriteTimeout}
+		if _, err := export.Run(ctx, pool, ds, filter, format, out); err != nil {
+			if !out.started {
+				h.Del("Content-Disposition")
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to export "+ds.Name)
+			}
+			if r.Context().Err() == nil {
+				log.Printf("export %s: %v", ds.Name, err)
+			}
+		}
+	}
+}
+
+// exportResponseWriter flushes every write to the client, each within timeout when
+// set, and records whether the response has started.
+type exportResponseWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+	started bool
+}
+
+func (e *exportResponseWriter) Write(p []byte) (int, error) {
+	e.started = true
+	if e.timeout > 0 {
+		if err := e.rc.SetWriteDeadline(time.Now().Add(e.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return 0, err
+		}
+	}
+	n, err := e.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if err := e.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return n, err
+	}
+	return n, nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lumescope/internal/db"
+)
+
+// TestExportRejects verifies invalid formats and filters fail before the export starts
+func TestExportRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		ds   db.ExportDataset
+		url  string
+		want string
+	}{
+		{"format", db.ExportActions, "/v1/export/actions?format=xlsx", "unknown format"},
+		{"height", db.ExportActions, "/v1/export/actions?from=abc", "invalid from parameter"},
+		{"time", db.ExportActionTransactions, "/v1/export/action-transactions?toTime=yesterday", "invalid toTime parameter"},
+		{"unsupported filter", db.ExportSupernodes, "/v1/export/supernodes?type=ACTION_TYPE_CASCADE", "is not supported by the supernodes export"},
+		{"tx type on actions", db.ExportActions, "/v1/export/actions?txType=register", "txType"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Export(nil, tt.ds, ExportLimits{})(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Fatalf("body = %s, want %q", rec.Body, tt.want)
+			}
+			if rec.Header().Get("Content-Disposition") != "" {
+				t.Fatal("error response must not be an attachment")
+			}
+		})
+	}
+}
+
+// TestExportSlots verifies an export is refused with a 429 while every slot is taken,
+// before the database is touched
+func TestExportSlots(t *testing.T) {
+	slots := NewExportSlots(1)
+	if !slots.tryAcquire() {
+		t.Fatal("first slot not free")
+	}
+	rec := httptest.NewRecorder()
+	Export(nil, db.ExportActions, ExportLimits{Slots: slots})(rec, httptest.NewRequest(http.MethodGet, "/v1/export/actions", nil))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("status = %d, Retry-After = %q: %s", rec.Code, rec.Header().Get("Retry-After"), rec.Body)
+	}
+
+	slots.release()
+	if !slots.tryAcquire() {
+		t.Error("slot not free after release")
+	}
+	if NewExportSlots(0) != nil || !(*ExportSlots)(nil).tryAcquire() {
+		t.Error("zero slots must be unlimited")
+	}
+}
@@ -0,0 +1,618 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"lumescope/internal/db"
+	"lumescope/internal/graphql"
+	"lumescope/internal/util"
+)
+
+// GraphQLLimits bounds the cost of a single GraphQL query (0 disables a limit).
+type GraphQLLimits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+const (
+	graphQLDefaultPageSize = 20
+	graphQLMaxPageSize     = 100
+	// graphQLListEstimate is the assumed length of unpaginated lists (an action's
+	// supernodes and transactions, a supernode's payment denoms) when estimating
+	// query complexity.
+	graphQLListEstimate = 3
+	graphQLMaxBodyBytes = 1 << 20
+)
+
+// GraphQL executes queries against the schema returned by GraphQLSchema. It accepts
+// POST with a JSON body {"query", "variables", "operationName"} and GET with the same
+// fields as query parameters (variables JSON-encoded).
+func GraphQL(pool *db.Pool, limits GraphQLLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req graphql.Request
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			req.Query = query.Get("query")
+			req.OperationName = query.Get("operationName")
+			if v := query.Get("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					util.WriteJSONError(w, http.StatusBadRequest, "invalid variables parameter: must be a JSON object")
+					return
+				}
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, graphQLMaxBodyBytes)).Decode(&req); err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		if strings.TrimSpace(req.Query) == "" {
+			util.WriteJSONError(w, http.StatusBadRequest, "missing query")
+			return
+		}
+
+		schema := graphql.Schema{Query: graphQLQuery, MaxDepth: limits.MaxDepth, MaxComplexity: limits.MaxComplexity}
+		ctx := context.WithValue(r.Context(), gqlLoaderKey{}, &gqlLoader{pool: pool, supernodes: make(map[string]*gqlSupernode)})
+		resp := graphql.Execute(ctx, &schema, req)
+
+		// Requests rejected before execution (syntax, validation, limits) are client errors.
+		status := http.StatusOK
+		if resp.Data == nil {
+			status = http.StatusBadRequest
+		}
+		util.WriteJSON(w, r, status, resp, nil)
+	}
+}
+
+// GraphQLSchema serves the schema in SDL.
+func GraphQLSchema() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(graphQLSDL))
+	}
+}
+
+// gqlLoader holds request-scoped state shared by resolvers. The executor resolves
+// fields sequentially, so it needs no locking.
+type gqlLoader struct {
+	pool       *db.Pool
+	supernodes map[string]*gqlSupernode // by account
+}
+
+type gqlLoaderKey struct{}
+
+func loaderFrom(ctx context.Context) *gqlLoader {
+	return ctx.Value(gqlLoaderKey{}).(*gqlLoader)
+}
+
+// supernode loads a supernode once per request. Accounts that are not indexed yet
+// resolve to a stub carrying only the account.
+func (l *gqlLoader) supernode(ctx context.Context, account string) (*gqlSupernode, error) {
+	if sn, ok := l.supernodes[account]; ok {
+		return sn, nil
+	}
+	row, err := db.GetSupernodeByID(ctx, l.pool, account)
+	var sn *gqlSupernode
+	switch {
+	case err == nil:
+		sn = &gqlSupernode{SupernodeDB: row, indexed: true}
+	case errors.Is(err, db.ErrNotFound):
+		sn = &gqlSupernode{SupernodeDB: db.SupernodeDB{SupernodeAccount: account}}
+	default:
+		return nil, errors.New("failed to fetch supernode")
+	}
+	l.supernodes[account] = sn
+	return sn, nil
+}
+
+type gqlSupernode struct {
+	db.SupernodeDB
+	indexed bool
+}
+
+// gqlAction is an action of one result page. Transactions are loaded for the whole
+// page on first access instead of once per action.
+type gqlAction struct {
+	db.ActionDB
+	page *gqlActionPage
+}
+
+type gqlActionPage struct {
+	ids []uint64
+	txs map[uint64][]db.ActionTransaction
+}
+
+func (p *gqlActionPage) transactions(ctx context.Context, pool *db.Pool, id uint64) ([]db.ActionTransaction, error) {
+	if p.txs == nil {
+		txs, err := db.GetActionTransactionsByActionIDs(ctx, pool, p.ids)
+		if err != nil {
+			return nil, errors.New("failed to fetch action transactions")
+		}
+		p.txs = txs
+	}
+	return p.txs[id], nil
+}
+
+func newActionPage(actions []db.ActionDB) []*gqlAction {
+	page := &gqlActionPage{ids: make([]uint64, len(actions))}
+	out := make([]*gqlAction, len(actions))
+	for i, a := range actions {
+		page.ids[i] = a.ActionID
+		out[i] = &gqlAction{ActionDB: a, page: page}
+	}
+	return out
+}
+
+// actionTransactions returns the action's transactions, without enricher placeholders,
+// that match keep.
+func actionTransactions(p graphql.ResolveParams, keep func(db.ActionTransaction) bool) (any, error) {
+	a := p.Source.(*gqlAction)
+	txs, err := a.page.transactions(p.Context, loaderFrom(p.Context).pool, a.ActionID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, 0, len(txs))
+	for _, tx := range txs {
+		if !isPlaceholderTransaction(tx) && keep(tx) {
+			out = append(out, tx)
+		}
+	}
+	return out, nil
+}
+
+// pageSize validates the "first" argument of connection fields.
+func pageSize(p graphql.ResolveParams) (int, error) {
+	first, _ := p.Int("first")
+	if first < 1 || first > graphQLMaxPageSize {
+		return 0, errors.New("first must be between 1 and " + strconv.Itoa(graphQLMaxPageSize))
+	}
+	return first, nil
+}
+
+func pageMultiplier(args map[string]any) int {
+	first, _ := args["first"].(int)
+	return first
+}
+
+// connection builds a Relay-style connection; cursors use the REST keyset format.
+func connection(nodes []any, cursors []string, hasMore bool) map[string]any {
+	edges := make([]any, len(nodes))
+	for i, n := range nodes {
+		edges[i] = map[string]any{"cursor": cursors[i], "node": n}
+	}
+	var endCursor any
+	if len(cursors) > 0 {
+		endCursor = cursors[len(cursors)-1]
+	}
+	return map[string]any{
+		"edges": edges,
+		"nodes": nodes,
+		"pageInfo": map[string]any{
+			"hasNextPage": hasMore,
+			"endCursor":   endCursor,
+		},
+	}
+}
+
+// resolveActions lists actions matching the filter arguments, paginated by first/after.
+func resolveActions(p graphql.ResolveParams, f db.ActionsFilter) (any, error) {
+	first, err := pageSize(p)
+	if err != nil {
+		return nil, err
+	}
+	f.Limit = first
+	if s, ok := p.String("type"); ok {
+		f.Type = &s
+	}
+	if s, ok := p.String("creator"); ok {
+		f.Creator = &s
+	}
+	if s, ok := p.String("state"); ok {
+		f.State = &s
+	}
+	if s, ok := p.String("supernode"); ok && f.Supernode == nil {
+		f.Supernode = &s
+	}
+	if n, ok := p.Int("fromHeight"); ok {
+		h := int64(n)
+		f.FromHeight = &h
+	}
+	if n, ok := p.Int("toHeight"); ok {
+		h := int64(n)
+		f.ToHeight = &h
+	}
+	if after, ok := p.String("after"); ok {
+		ts, id, err := decodeActionCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		f.CursorTS, f.CursorID = &ts, &id
+	}
+
+	actions, hasMore, err := db.ListActionsFiltered(p.Context, loaderFrom(p.Context).pool, f)
+	if err != nil {
+		return nil, errors.New("failed to fetch actions")
+	}
+	nodes := make([]any, len(actions))
+	cursors := make([]string, len(actions))
+	for i, a := range newActionPage(actions) {
+		nodes[i] = a
+		if cursors[i], err = encodeActionCursor(a.ActionDB); err != nil {
+			return nil, errors.New("failed to encode cursor")
+		}
+	}
+	return connection(nodes, cursors, hasMore), nil
+}
+
+func resolveSupernodes(p graphql.ResolveParams) (any, error) {
+	first, err := pageSize(p)
+	if err != nil {
+		return nil, err
+	}
+	f := db.SupernodeMetricsFilter{CurrentState: "any", Status: "any", Limit: first}
+	if s, ok := p.String("currentState"); ok {
+		if !validChainStates[s] {
+			return nil, errors.New("invalid currentState: must be a SUPERNODE_STATE_* value")
+		}
+		f.ChainState = &s
+	}
+	if s, ok := p.String("status"); ok {
+		switch s {
+		case "available", "unavailable", "any":
+			f.Status = s
+		default:
+			return nil, errors.New("invalid status: must be 'available', 'unavailable', or 'any'")
+		}
+	}
+	if s, ok := p.String("version"); ok {
+		f.Version = &s
+	}
+	if after, ok := p.String("after"); ok {
+		account, _, err := decodeSupernodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		f.CursorAccount = &account
+	}
+
+	l := loaderFrom(p.Context)
+	rows, hasMore, err := db.ListSupernodeMetricsFiltered(p.Context, l.pool, f)
+	if err != nil {
+		return nil, errors.New("failed to fetch supernodes")
+	}
+	nodes := make([]any, len(rows))
+	cursors := make([]string, len(rows))
+	for i, row := range rows {
+		sn := &gqlSupernode{SupernodeDB: row, indexed: true}
+		l.supernodes[row.SupernodeAccount] = sn
+		nodes[i] = sn
+		if cursors[i], err = encodeSupernodeCursor(row.SupernodeAccount, nil); err != nil {
+			return nil, errors.New("failed to encode cursor")
+		}
+	}
+	return connection(nodes, cursors, hasMore), nil
+}
+
+// gqlLeaf is a field read from a source of type T.
+func gqlLeaf[T any](name, typ string, get func(T) any) *graphql.Field {
+	return &graphql.Field{
+		Name: name,
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return get(p.Source.(T)), nil
+		},
+	}
+}
+
+func gqlConnectionType(node, pageInfo *graphql.Object) *graphql.Object {
+	edge := &graphql.Object{
+		Name: node.Name + "Edge",
+		Fields: []*graphql.Field{
+			{Name: "cursor", Type: "String!"},
+			{Name: "node", Type: node.Name + "!", Object: node},
+		},
+	}
+	return &graphql.Object{
+		Name: node.Name + "Connection",
+		Fields: []*graphql.Field{
+			{Name: "edges", Type: "[" + edge.Name + "!]!", Object: edge},
+			{Name: "nodes", Type: "[" + node.Name + "!]!", Object: node},
+			{Name: "pageInfo", Type: "PageInfo!", Object: pageInfo},
+		},
+	}
+}
+
+var (
+	actionPageArgs = []graphql.Arg{
+		{Name: "first", Type: "Int", Default: graphQLDefaultPageSize, Description: "page size, 1-100"},
+		{Name: "after", Type: "String", Description: "endCursor of the previous page"},
+		{Name: "type", Type: "String"},
+		{Name: "creator", Type: "String"},
+		{Name: "state", Type: "String"},
+	}
+	supernodePageArgs = []graphql.Arg{
+		{Name: "first", Type: "Int", Default: graphQLDefaultPageSize, Description: "page size, 1-100"},
+		{Name: "after", Type: "String", Description: "endCursor of the previous page"},
+		{Name: "currentState", Type: "String", Description: "chain state, e.g. SUPERNODE_STATE_ACTIVE"},
+		{Name: "status", Type: "String", Description: "available, unavailable or any"},
+		{Name: "version", Type: "String"},
+	}
+)
+
+// graphQLQuery is the root query type served at /v1/graphql.
+var (
+	graphQLQuery = newGraphQLQuery()
+	graphQLSDL   = (&graphql.Schema{Query: graphQLQuery}).SDL()
+)
+
+func newGraphQLQuery() *graphql.Object {
+	pageInfo := &graphql.Object{
+		Name: "PageInfo",
+		Fields: []*graphql.Field{
+			{Name: "hasNextPage", Type: "Boolean!"},
+			{Name: "endCursor", Type: "String"},
+		},
+	}
+
+	price := &graphql.Object{
+		Name: "Price",
+		Fields: []*graphql.Field{
+			gqlLeaf("denom", "String!", func(a *gqlAction) any { return a.PriceDenom }),
+			gqlLeaf("amount", "String!", func(a *gqlAction) any { return a.PriceAmount }),
+		},
+	}
+
+	transaction := &graphql.Object{
+		Name:        "ActionTransaction",
+		Description: "A transaction of an action's lifecycle (register, finalize, approve).",
+		Fields: []*graphql.Field{
+			gqlLeaf("txType", "String!", func(t db.ActionTransaction) any { return t.TxType }),
+			gqlLeaf("txHash", "String!", func(t db.ActionTransaction) any { return t.TxHash }),
+			gqlLeaf("msgIndex", "Int!", func(t db.ActionTransaction) any { return t.MsgIndex }),
+			gqlLeaf("height", "Long!", func(t db.ActionTransaction) any { return t.Height }),
+			gqlLeaf("blockTime", "Time!", func(t db.ActionTransaction) any { return t.BlockTime }),
+			gqlLeaf("gasWanted", "Long", func(t db.ActionTransaction) any { return t.GasWanted }),
+			gqlLeaf("gasUsed", "Long", func(t db.ActionTransaction) any { return t.GasUsed }),
+			gqlLeaf("actionPrice", "String", func(t db.ActionTransaction) any { return t.ActionPrice }),
+			gqlLeaf("actionPriceDenom", "String", func(t db.ActionTransaction) any { return t.ActionPriceDenom }),
+			gqlLeaf("flowPayer", "String", func(t db.ActionTransaction) any { return t.FlowPayer }),
+			gqlLeaf("flowPayee", "String", func(t db.ActionTransaction) any { return t.FlowPayee }),
+			gqlLeaf("txFee", "String", func(t db.ActionTransaction) any { return t.TxFee }),
+			gqlLeaf("txFeeDenom", "String", func(t db.ActionTransaction) any { return t.TxFeeDenom }),
+		},
+	}
+
+	paymentStat := &graphql.Object{
+		Name:        "PaymentStat",
+		Description: "Totals of finalize transactions paid to a supernode, per denomination.",
+		Fields: []*graphql.Field{
+			gqlLeaf("denom", "String!", func(s db.PaymentStat) any { return s.Denom }),
+			gqlLeaf("totalActionPrice", "String!", func(s db.PaymentStat) any { return s.TotalActionPrice }),
+			gqlLeaf("totalTxFee", "String!", func(s db.PaymentStat) any { return s.TotalTxFee }),
+		},
+	}
+
+	metrics := &graphql.Object{
+		Name:        "SupernodeMetrics",
+		Description: "Latest probe results of a supernode.",
+		Fields: []*graphql.Field{
+			gqlLeaf("cpuUsagePercent", "Float", func(s *gqlSupernode) any { return s.CPUUsagePercent }),
+			gqlLeaf("cpuCores", "Int", func(s *gqlSupernode) any { return s.CPUCores }),
+			gqlLeaf("memoryTotalGb", "Float", func(s *gqlSupernode) any { return s.MemoryTotalGb }),
+			gqlLeaf("memoryUsedGb", "Float", func(s *gqlSupernode) any { return s.MemoryUsedGb }),
+			gqlLeaf("memoryUsagePercent", "Float", func(s *gqlSupernode) any { return s.MemoryUsagePercent }),
+			gqlLeaf("storageTotalBytes", "Long", func(s *gqlSupernode) any { return s.StorageTotalBytes }),
+			gqlLeaf("storageUsedBytes", "Long", func(s *gqlSupernode) any { return s.StorageUsedBytes }),
+			gqlLeaf("storageUsagePercent", "Float", func(s *gqlSupernode) any { return s.StorageUsagePercent }),
+			gqlLeaf("hardwareSummary", "String", func(s *gqlSupernode) any { return s.HardwareSummary }),
+			gqlLeaf("peersCount", "Int", func(s *gqlSupernode) any { return s.PeersCount }),
+			gqlLeaf("uptimeSeconds", "Long", func(s *gqlSupernode) any { return s.UptimeSeconds }),
+			gqlLeaf("rank", "Int", func(s *gqlSupernode) any { return s.Rank }),
+			gqlLeaf("p2pDbSizeMb", "Float", func(s *gqlSupernode) any { return s.P2PDbSizeMb }),
+			gqlLeaf("p2pRecords", "Long", func(s *gqlSupernode) any { return s.P2PRecords }),
+			gqlLeaf("isStatusApiAvailable", "Boolean!", func(s *gqlSupernode) any { return s.IsStatusAPIAvailable }),
+			gqlLeaf("lastStatusCheck", "Time", func(s *gqlSupernode) any { return s.LastStatusCheck }),
+			gqlLeaf("lastSuccessfulProbe", "Time", func(s *gqlSupernode) any { return s.LastSuccessfulProbe }),
+			gqlLeaf("failedProbeCounter", "Int!", func(s *gqlSupernode) any { return s.FailedProbeCounter }),
+			gqlLeaf("report", "JSON", func(s *gqlSupernode) any { return s.MetricsReport }),
+		},
+	}
+
+	action := &graphql.Object{Name: "Action", Description: "A Lumera action (cascade, sense, ...)."}
+	supernode := &graphql.Object{Name: "Supernode"}
+	actionConnection := gqlConnectionType(action, pageInfo)
+	supernodeConnection := gqlConnectionType(supernode, pageInfo)
+
+	action.Fields = []*graphql.Field{
+		gqlLeaf("id", "ID!", func(a *gqlAction) any { return strconv.FormatUint(a.ActionID, 10) }),
+		gqlLeaf("type", "String!", func(a *gqlAction) any { return a.ActionType }),
+		gqlLeaf("creator", "String!", func(a *gqlAction) any { return a.Creator }),
+		gqlLeaf("state", "String!", func(a *gqlAction) any { return a.State }),
+		gqlLeaf("blockHeight", "Long!", func(a *gqlAction) any { return a.BlockHeight }),
+		{Name: "price", Type: "Price!", Object: price, Resolve: func(p graphql.ResolveParams) (any, error) { return p.Source, nil }},
+		gqlLeaf("expirationTime", "Long!", func(a *gqlAction) any { return a.ExpirationTime }),
+		gqlLeaf("mimeType", "String", func(a *gqlAction) any { return a.MimeType }),
+		gqlLeaf("size", "Long!", func(a *gqlAction) any { return a.Size }),
+		gqlLeaf("createdAt", "Time!", func(a *gqlAction) any { return a.CreatedAt }),
+		gqlLeaf("metadata", "JSON", func(a *gqlAction) any { return a.MetadataJSON }),
+		gqlLeaf("supernodeAccounts", "[String!]!", func(a *gqlAction) any {
+			accounts := db.SupernodeAccounts(a.SuperNodes)
+			out := make([]any, len(accounts))
+			for i, acc := range accounts {
+				out[i] = acc
+			}
+			return out
+		}),
+		{
+			Name:        "supernodes",
+			Description: "Supernodes assigned to the action.",
+			Type:        "[Supernode!]!",
+			Object:      supernode,
+			Multiplier:  func(map[string]any) int { return graphQLListEstimate },
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				a := p.Source.(*gqlAction)
+				l := loaderFrom(p.Context)
+				accounts := db.SupernodeAccounts(a.SuperNodes)
+				out := make([]any, 0, len(accounts))
+				for _, acc := range accounts {
+					sn, err := l.supernode(p.Context, acc)
+					if err != nil {
+						return nil, err
+					}
+					out = append(out, sn)
+				}
+				return out, nil
+			},
+		},
+		{
+			Name:       "transactions",
+			Type:       "[ActionTransaction!]!",
+			Object:     transaction,
+			Args:       []graphql.Arg{{Name: "type", Type: "String", Description: "register, finalize or approve"}},
+			Multiplier: func(map[string]any) int { return graphQLListEstimate },
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				txType, filter := p.String("type")
+				return actionTransactions(p, func(tx db.ActionTransaction) bool { return !filter || tx.TxType == txType })
+			},
+		},
+		{
+			Name:        "payments",
+			Description: "Finalize transactions that paid a supernode.",
+			Type:        "[ActionTransaction!]!",
+			Object:      transaction,
+			Multiplier:  func(map[string]any) int { return graphQLListEstimate },
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return actionTransactions(p, func(tx db.ActionTransaction) bool { return tx.TxType == "finalize" && tx.FlowPayee != nil })
+			},
+		},
+	}
+
+	supernode.Fields = []*graphql.Field{
+		gqlLeaf("account", "ID!", func(s *gqlSupernode) any { return s.SupernodeAccount }),
+		gqlLeaf("validatorAddress", "String", func(s *gqlSupernode) any { return s.ValidatorAddress }),
+		gqlLeaf("validatorMoniker", "String", func(s *gqlSupernode) any { return s.ValidatorMoniker }),
+		gqlLeaf("currentState", "String", func(s *gqlSupernode) any { return s.CurrentState }),
+		gqlLeaf("currentStateHeight", "String", func(s *gqlSupernode) any { return s.CurrentStateHeight }),
+		gqlLeaf("ipAddress", "String", func(s *gqlSupernode) any { return s.IPAddress }),
+		gqlLeaf("p2pPort", "Int", func(s *gqlSupernode) any { return s.P2PPort }),
+		gqlLeaf("protocolVersion", "String", func(s *gqlSupernode) any { return s.ProtocolVersion }),
+		gqlLeaf("actualVersion", "String", func(s *gqlSupernode) any { return s.ActualVersion }),
+		gqlLeaf("lastKnownActualVersion", "String", func(s *gqlSupernode) any { return s.LastKnownActualVersion }),
+		gqlLeaf("availability24h", "Float", func(s *gqlSupernode) any { return s.Availability24h }),
+		gqlLeaf("availability7d", "Float", func(s *gqlSupernode) any { return s.Availability7d }),
+		gqlLeaf("availability30d", "Float", func(s *gqlSupernode) any { return s.Availability30d }),
+		{
+			Name:        "metrics",
+			Description: "Null when the supernode is not indexed yet.",
+			Type:        "SupernodeMetrics",
+			Object:      metrics,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				if sn := p.Source.(*gqlSupernode); sn.indexed {
+					return sn, nil
+				}
+				return nil, nil
+			},
+		},
+		{
+			Name:       "payments",
+			Type:       "[PaymentStat!]!",
+			Object:     paymentStat,
+			Multiplier: func(map[string]any) int { return graphQLListEstimate },
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				sn := p.Source.(*gqlSupernode)
+				stats, err := db.GetSupernodePaymentStats(p.Context, loaderFrom(p.Context).pool, sn.SupernodeAccount)
+				if err != nil {
+					return nil, errors.New("failed to fetch payment stats")
+				}
+				out := make([]any, len(stats))
+				for i, s := range stats {
+					out[i] = s
+				}
+				return out, nil
+			},
+		},
+		{
+			Name:        "actions",
+			Description: "Actions the supernode was assigned to, newest first.",
+			Type:        "ActionConnection!",
+			Object:      actionConnection,
+			Args:        actionPageArgs,
+			Multiplier:  pageMultiplier,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				account := p.Source.(*gqlSupernode).SupernodeAccount
+				return resolveActions(p, db.ActionsFilter{Supernode: &account})
+			},
+		},
+	}
+
+	return &graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{
+			{
+				Name:   "action",
+				Type:   "Action",
+				Object: action,
+				Args:   []graphql.Arg{{Name: "id", Type: "ID!"}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					idStr, _ := p.String("id")
+					id, err := strconv.ParseUint(idStr, 10, 64)
+					if err != nil {
+						return nil, errors.New("invalid action ID")
+					}
+					a, err := db.GetActionByID(p.Context, loaderFrom(p.Context).pool, id)
+					if errors.Is(err, db.ErrNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, errors.New("failed to fetch action")
+					}
+					return newActionPage([]db.ActionDB{a})[0], nil
+				},
+			},
+			{
+				Name:   "actions",
+				Type:   "ActionConnection!",
+				Object: actionConnection,
+				Args: append(actionPageArgs[:len(actionPageArgs):len(actionPageArgs)],
+					graphql.Arg{Name: "supernode", Type: "String", Description: "supernode account the action was assigned to"},
+					graphql.Arg{Name: "fromHeight", Type: "Int"},
+					graphql.Arg{Name: "toHeight", Type: "Int"},
+				),
+				Multiplier: pageMultiplier,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return resolveActions(p, db.ActionsFilter{})
+				},
+			},
+			{
+				Name:   "supernode",
+				Type:   "Supernode",
+				Object: supernode,
+				Args:   []graphql.Arg{{Name: "account", Type: "ID!"}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					account, _ := p.String("account")
+					sn, err := loaderFrom(p.Context).supernode(p.Context, account)
+					if err != nil || !sn.indexed {
+						return nil, err
+					}
+					return sn, nil
+				},
+			},
+			{
+				Name:       "supernodes",
+				Type:       "SupernodeConnection!",
+				Object:     supernodeConnection,
+				Args:       supernodePageArgs,
+				Multiplier: pageMultiplier,
+				Resolve:    resolveSupernodes,
+			},
+		},
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestGraphQLSchemaRelations verifies the published SDL exposes the relations the dashboard walks
+func TestGraphQLSchemaRelations(t *testing.T) {
+	for _, want := range []string{
+		"action(id: ID!): Action",
+		"actions(first: Int = 20, after: String, type: String, creator: String, state: String, supernode: String, fromHeight: Int, toHeight: Int): ActionConnection!",
+		"supernodes: [Supernode!]!",
+		"metrics: SupernodeMetrics",
+		"payments: [PaymentStat!]!",
+		"actions(first: Int = 20, after: String, type: String, creator: String, state: String): ActionConnection!",
+		"type ActionConnection {\n  edges: [ActionEdge!]!\n  nodes: [Action!]!\n  pageInfo: PageInfo!\n}",
+		"scalar Long",
+	} {
+		if !strings.Contains(graphQLSDL, want) {
+			t.Errorf("SDL missing %q", want)
+		}
+	}
+}
+
+// TestGraphQLRejects verifies malformed and over-limit queries fail before touching the database
+func TestGraphQLRejects(t *testing.T) {
+	limits := GraphQLLimits{MaxDepth: 10, MaxComplexity: 5000}
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{"missing query", httptest.NewRequest(http.MethodGet, "/v1/graphql", nil), "missing query"},
+		{"bad body", httptest.NewRequest(http.MethodPost, "/v1/graphql", strings.NewReader("{")), "invalid request body"},
+		{"bad variables", httptest.NewRequest(http.MethodGet, "/v1/graphql?query=%7Bactions%7Bnodes%7Bid%7D%7D%7D&variables=x", nil), "invalid variables"},
+		{"syntax", graphQLPost(`{ actions { nodes { id } }`), "Syntax Error"},
+		{"unknown field", graphQLPost(`{ actions { nodes { hash } } }`), "Cannot query field"},
+		{"complexity", graphQLPost(`{ actions(first: 100) { nodes { supernodes { actions(first: 100) { nodes { id } } } } } }`), "complexity"},
+		{"depth", graphQLPost(`{ supernode(account: "a") { actions(first: 1) { nodes { supernodes { actions(first: 1) { nodes { supernodes { actions(first: 1) { nodes { supernodes { account } } } } } } } } } } }`), "depth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			GraphQL(nil, limits)(rec, tt.req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Fatalf("body = %s, want %q", rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+// TestGraphQLDashboardQuery verifies the action page query fits the default limits
+func TestGraphQLDashboardQuery(t *testing.T) {
+	query := `query Page($after: String) {
+		actions(first: 20, after: $after) {
+			pageInfo { hasNextPage endCursor }
+			edges { cursor node {
+				id type state creator blockHeight createdAt price { denom amount }
+				transactions { txType txHash height blockTime }
+				supernodes { account validatorMoniker metrics { cpuUsagePercent memoryUsagePercent isStatusApiAvailable } payments { denom totalActionPrice } }
+			} }
+		}
+	}`
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": map[string]any{"after": "bad"}})
+	rec := httptest.NewRecorder()
+	GraphQL(nil, GraphQLLimits{MaxDepth: 10, MaxComplexity: 5000})(rec, httptest.NewRequest(http.MethodPost, "/v1/graphql", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	// The malformed cursor fails in the resolver, before any query runs.
+	var resp struct {
+		Data   map[string]any `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+			Path    []any  `json:"path"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "invalid cursor encoding" || resp.Data["actions"] != nil {
+		t.Fatalf("response = %s", rec.Body)
+	}
+}
+
+// TestActionCursorRoundTrip verifies GraphQL and REST share the keyset cursor format
+func TestActionCursorRoundTrip(t *testing.T) {
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cursor, err := encodeActionCursor(db.ActionDB{ActionID: 42, CreatedAt: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, id, err := decodeActionCursor(cursor)
+	if err != nil || id != 42 || !ts.Equal(created) {
+		t.Fatalf("decoded %v %d %v", ts, id, err)
+	}
+
+	account, value, err := decodeSupernodeCursor(mustSupernodeCursor(t, "lumera1abc"))
+	if err != nil || account != "lumera1abc" || value != nil {
+		t.Fatalf("decoded %q %v %v", account, value, err)
+	}
+}
+
+func mustSupernodeCursor(t *testing.T, account string) string {
+	t.Helper()
+	c, err := encodeSupernodeCursor(account, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func graphQLPost(query string) *http.Request {
+	body, _ := json.Marshal(map[string]string{"query": query})
+	r := httptest.NewRequest(http.MethodPost, "/v1/graphql", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"time"
+
+	"lumescope/internal/db"
 )
 
 // Healthz is the liveness probe endpoint.
@@ -27,6 +31,44 @@ func Healthz(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("ok"))
 }
 
+// LoopStatus reports when each background loop last completed successfully.
+type LoopStatus interface {
+	LastSuccess(loop string) (time.Time, bool)
+}
+
+// FreshnessCheck requires a background loop to have succeeded within MaxAge.
+type FreshnessCheck struct {
+	Loop   string
+	MaxAge time.Duration
+}
+
+// ComponentStatus is the readiness state of one dependency or loop.
+type ComponentStatus struct {
+	Status        string     `json:"status"` // "ok" or "unhealthy"
+	Error         string     `json:"error,omitempty"`
+	LastSuccess   *time.Time `json:"last_success,omitempty"`
+	AgeSeconds    *float64   `json:"age_seconds,omitempty"`
+	MaxAgeSeconds float64    `json:"max_age_seconds,omitempty"`
+}
+
+// ReadinessTarget is one network's database pool and loops checked by /readyz.
+// Component names are prefixed with Prefix ("" for the default network).
+type ReadinessTarget struct {
+	Prefix string
+	Pool   *db.Pool
+	Loops  LoopStatus
+	Checks []FreshnessCheck
+}
+
+// ReadinessResponse is the /readyz body.
+type ReadinessResponse struct {
+	Status     string                     `json:"status"` // "ready" or "not_ready"
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// dbPingTimeout bounds the readiness DB check so a hung pool cannot stall the probe.
+const dbPingTimeout = 2 * time.Second
+
 // Readyz is the readiness probe endpoint.
 //
 // Purpose:
@@ -35,23 +77,73 @@ func Healthz(w http.ResponseWriter, r *http.Request) {
 //   from the serving pool without restarting it.
 //
 // Behavior:
-// - May check critical dependencies (e.g., DB connectivity, Redis availability,
-//   warm caches, follower/scraper freshness). If any critical dependency is not
-//   ready, this endpoint should return 503 Service Unavailable.
-// - Returns 200 OK when the instance is able to serve user requests in a
-//   meaningful way.
+// - Pings each network's database pool and checks that each configured background loop
+//   has completed successfully within its staleness threshold. A loop that has
+//   not succeeded since startup counts as unhealthy, so a fresh instance is not
+//   ready until its first sync passes complete. Components of non-default
+//   networks are reported as "<network>/<component>".
+// - Returns 200 OK when every component is healthy, otherwise 503 Service
+//   Unavailable. Both carry a per-component JSON breakdown.
 // - Responses are marked as non-cacheable.
-//
-// Current implementation:
-// - In this starter skeleton we assume readiness immediately and return 200.
-//   Replace the stub with real checks as subsystems are added.
-func Readyz(w http.ResponseWriter, r *http.Request) {
-	// In the base skeleton, we consider the service ready immediately.
-	// Later this can check DB/Redis/scraper/indexer readiness
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-	w.Header().Set("Pragma", "no-cache")
-	w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"ready"}`))
+func Readyz(targets ...ReadinessTarget) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		components := make(map[string]ComponentStatus)
+		now := time.Now().UTC()
+
+		for _, t := range targets {
+			ctx, cancel := context.WithTimeout(r.Context(), dbPingTimeout)
+			err := t.Pool.Ping(ctx)
+			cancel()
+			if err != nil {
+				components[t.Prefix+"database"] = ComponentStatus{Status: "unhealthy", Error: err.Error()}
+			} else {
+				components[t.Prefix+"database"] = ComponentStatus{Status: "ok"}
+			}
+
+			for _, c := range t.Checks {
+				var last time.Time
+				var ok bool
+				if t.Loops != nil {
+					last, ok = t.Loops.LastSuccess(c.Loop)
+				}
+				components[t.Prefix+c.Loop] = freshnessStatus(now, last, ok, c.MaxAge)
+			}
+		}
+
+		resp := ReadinessResponse{Status: "ready", Components: components}
+		status := http.StatusOK
+		for _, c := range components {
+			if c.Status != "ok" {
+				resp.Status = "not_ready"
+				status = http.StatusServiceUnavailable
+				break
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
+		w.Header().Set("Pragma", "no-cache")
+		w.Header().Set("Last-Modified", now.Format(http.TimeFormat))
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// freshnessStatus evaluates one loop's last success time against maxAge.
+func freshnessStatus(now, last time.Time, ok bool, maxAge time.Duration) ComponentStatus {
+	cs := ComponentStatus{Status: "ok", MaxAgeSeconds: maxAge.Seconds()}
+	if !ok {
+		cs.Status = "unhealthy"
+		cs.Error = "no successful run since startup"
+		return cs
+	}
+	last = last.UTC()
+	age := now.Sub(last).Seconds()
+	cs.LastSuccess = &last
+	cs.AgeSeconds = &age
+	if now.Sub(last) > maxAge {
+		cs.Status = "unhealthy"
+		cs.Error = "last successful run is older than max age"
+	}
+	return cs
 }
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFreshnessStatus verifies loop staleness evaluation for the readiness probe
+func TestFreshnessStatus(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	maxAge := 5 * time.Minute
+
+	tests := []struct {
+		name       string
+		last       time.Time
+		ok         bool
+		wantStatus string
+	}{
+		{"never succeeded", time.Time{}, false, "unhealthy"},
+		{"fresh", now.Add(-time.Minute), true, "ok"},
+		{"exactly max age", now.Add(-maxAge), true, "ok"},
+		{"stale", now.Add(-10 * time.Minute), true, "unhealthy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := freshnessStatus(now, tt.last, tt.ok, maxAge)
+			if got.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q (error=%q)", got.Status, tt.wantStatus, got.Error)
+			}
+			if got.MaxAgeSeconds != 300 {
+				t.Errorf("MaxAgeSeconds = %v, want 300", got.MaxAgeSeconds)
+			}
+			if tt.ok && (got.AgeSeconds == nil || got.LastSuccess == nil) {
+				t.Errorf("expected age and last success to be set")
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"lumescope/internal/util"
+)
+
+// NetworkDTO describes one indexed network.
+type NetworkDTO struct {
+	Name     string `json:"name"`
+	ChainID  string `json:"chain_id,omitempty"`
+	Default  bool   `json:"default"`
+	BasePath string `json:"base_path"`
+}
+
+type NetworksResponse struct {
+	Networks      []NetworkDTO `json:"networks"`
+	SchemaVersion string       `json:"schema_version"`
+}
+
+// ListNetworks returns the networks this instance indexes. The default network is
+// also served on the unprefixed /v1 routes.
+func ListNetworks(networks []NetworkDTO) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().UTC()
+		util.WriteJSON(w, r, http.StatusOK, NetworksResponse{Networks: networks, SchemaVersion: "v1.0"}, &now)
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+const (
+	// probeHistoryDefaultRange is used when from is omitted.
+	probeHistoryDefaultRange = 24 * time.Hour
+	// probeHistoryMaxPoints caps the number of buckets in one response.
+	probeHistoryMaxPoints = 2000
+	// probeHistoryTargetPoints is what step=auto aims for.
+	probeHistoryTargetPoints = 300
+)
+
+// probeHistorySteps are the candidate bucket sizes for step=auto.
+var probeHistorySteps = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// ProbeHistoryRetention tells the history endpoint how far back each tier reaches,
+// so long ranges are served from rollups even when a fine step is requested.
+type ProbeHistoryRetention struct {
+	Raw      time.Duration
+	Rollup5m time.Duration
+}
+
+// SupernodeMetricsHistoryResponse is a columnar time series: Timestamps[i], Samples[i]
+// and Series[field][i] describe the same bucket. Null values mean no data.
+type SupernodeMetricsHistoryResponse struct {
+	SupernodeAccount string                `json:"supernode_account"`
+	From             time.Time             `json:"from"`
+	To               time.Time             `json:"to"`
+	StepSeconds      int64                 `json:"step_seconds"`
+	Source           string                `json:"source"`
+	Fields           []string              `json:"fields"`
+	Timestamps       []time.Time           `json:"timestamps"`
+	Samples          []int64               `json:"samples"`
+	Series           map[string][]*float64 `json:"series"`
+	SchemaVersion    string                `json:"schema_version"`
+}
+
+// parseProbeFields validates a comma-separated fields list; empty means all fields.
+func parseProbeFields(val string) ([]string, error) {
+	all := db.ProbeHistoryFields()
+	if strings.TrimSpace(val) == "" {
+		return all, nil
+	}
+	var out []string
+	for _, f := range strings.Split(val, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" || slices.Contains(out, f) {
+			continue
+		}
+		if !slices.Contains(all, f) {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		out = append(out, f)
+	}
+	if len(out) == 0 {
+		return all, nil
+	}
+	return out, nil
+}
+
+// autoProbeStep picks the smallest candidate step that keeps the range near the target
+// number of points.
+func autoProbeStep(span time.Duration) time.Duration {
+	for _, s := range probeHistorySteps {
+		if span/s <= probeHistoryTargetPoints {
+			return s
+		}
+	}
+	return probeHistorySteps[len(probeHistorySteps)-1]
+}
+
+// selectProbeSource picks the finest tier that both fits the step and still holds data
+// at from, then rounds step up to a whole multiple of that tier's resolution.
+func selectProbeSource(step time.Duration, from, now time.Time, ret ProbeHistoryRetention) (string, time.Duration) {
+	covers := func(retention time.Duration) bool {
+		return retention <= 0 || !from.Before(now.Add(-retention))
+	}
+	source, res := db.ProbeSource1h, time.Hour
+	switch {
+	case step < 5*time.Minute && covers(ret.Raw):
+		source, res = db.ProbeSourceRaw, time.Second
+	case step < time.Hour && covers(ret.Rollup5m):
+		source, res = db.ProbeSource5m, 5*time.Minute
+	}
+	if rem := step % res; rem != 0 {
+		step += res - rem
+	}
+	if step < res {
+		step = res
+	}
+	return source, step
+}
+
+// GetSupernodeMetricsHistory returns bucketed probe metrics for one supernode:
+// /v1/supernodes/{id}/metrics/history?from=&to=&step=&fields=
+func GetSupernodeMetricsHistory(pool *db.Pool, ret ProbeHistoryRetention) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := supernodeIDFromPath(r.URL.Path)
+		if id == "" {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid supernode ID")
+			return
+		}
+		query := r.URL.Query()
+		now := time.Now().UTC()
+
+		to := now
+		if val := query.Get("to"); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid to parameter: must be RFC3339")
+				return
+			}
+			to = t.UTC()
+		}
+		from := to.Add(-probeHistoryDefaultRange)
+		if val := query.Get("from"); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid from parameter: must be RFC3339")
+				return
+			}
+			from = t.UTC()
+		}
+		if !from.Before(to) {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid range: from must be before to")
+			return
+		}
+
+		var step time.Duration
+		if val := query.Get("step"); val != "" && val != "auto" {
+			d, err := time.ParseDuration(val)
+			if err != nil || d < time.Minute {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid step parameter: must be a duration of at least 1m or 'auto'")
+				return
+			}
+			step = d.Truncate(time.Second)
+		} else {
+			step = autoProbeStep(to.Sub(from))
+		}
+		source, step := selectProbeSource(step, from, now, ret)
+		if to.Sub(from)/step > probeHistoryMaxPoints {
+			util.WriteJSONError(w, http.StatusBadRequest, fmt.Sprintf("range too large for step: at most %d points", probeHistoryMaxPoints))
+			return
+		}
+
+		fields, err := parseProbeFields(query.Get("fields"))
+		if err != nil {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid fields parameter: "+err.Error())
+			return
+		}
+
+		if _, err := db.GetSupernodeByID(r.Context(), pool, id); err != nil {
+			if err == db.ErrNotFound {
+				util.WriteJSONError(w, http.StatusNotFound, "supernode not found")
+				return
+			}
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch supernode")
+			return
+		}
+
+		points, err := db.GetProbeHistory(r.Context(), pool, id, source, from, to, step)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch metrics history")
+			return
+		}
+
+		resp := SupernodeMetricsHistoryResponse{
+			SupernodeAccount: id,
+			From:             from,
+			To:               to,
+			StepSeconds:      int64(step / time.Second),
+			Source:           source,
+			Fields:           fields,
+			Timestamps:       make([]time.Time, 0, len(points)),
+			Samples:          make([]int64, 0, len(points)),
+			Series:           make(map[string][]*float64, len(fields)),
+			SchemaVersion:    "v1.0",
+		}
+		for _, f := range fields {
+			resp.Series[f] = make([]*float64, 0, len(points))
+		}
+		for _, p := range points {
+			resp.Timestamps = append(resp.Timestamps, p.BucketStart.UTC())
+			resp.Samples = append(resp.Samples, p.Samples)
+			for _, f := range fields {
+				resp.Series[f] = append(resp.Series[f], p.Values[f])
+			}
+		}
+
+		lm := now
+		if len(points) > 0 {
+			lm = points[len(points)-1].BucketStart.UTC()
+		}
+		util.WriteJSON(w, r, http.StatusOK, resp, &lm)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestParseProbeFields verifies the fields whitelist for the metrics history endpoint
+func TestParseProbeFields(t *testing.T) {
+	all, err := parseProbeFields("")
+	if err != nil || len(all) != len(db.ProbeHistoryFields()) {
+		t.Fatalf("empty fields = %v, %v; want all fields", all, err)
+	}
+
+	got, err := parseProbeFields("cpu_usage_percent, fully_available,cpu_usage_percent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "cpu_usage_percent" || got[1] != "fully_available" {
+		t.Errorf("fields = %v, want [cpu_usage_percent fully_available]", got)
+	}
+
+	if _, err := parseProbeFields("cpu_usage_percent,bogus"); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+// TestSelectProbeSource verifies tier selection by step and retention
+func TestSelectProbeSource(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ret := ProbeHistoryRetention{Raw: 48 * time.Hour, Rollup5m: 30 * 24 * time.Hour}
+
+	tests := []struct {
+		name       string
+		step       time.Duration
+		from       time.Time
+		wantSource string
+		wantStep   time.Duration
+	}{
+		{"fine step recent range", time.Minute, now.Add(-time.Hour), db.ProbeSourceRaw, time.Minute},
+		{"fine step beyond raw retention", time.Minute, now.Add(-72 * time.Hour), db.ProbeSource5m, 5 * time.Minute},
+		{"step rounded to 5m multiple", 7 * time.Minute, now.Add(-time.Hour), db.ProbeSource5m, 10 * time.Minute},
+		{"hourly step", time.Hour, now.Add(-time.Hour), db.ProbeSource1h, time.Hour},
+		{"beyond 5m retention", 15 * time.Minute, now.Add(-60 * 24 * time.Hour), db.ProbeSource1h, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, step := selectProbeSource(tt.step, tt.from, now, ret)
+			if source != tt.wantSource || step != tt.wantStep {
+				t.Errorf("selectProbeSource = (%q, %v), want (%q, %v)", source, step, tt.wantSource, tt.wantStep)
+			}
+		})
+	}
+}
+
+// TestAutoProbeStep verifies step=auto keeps responses near the target size
+func TestAutoProbeStep(t *testing.T) {
+	if got := autoProbeStep(time.Hour); got != time.Minute {
+		t.Errorf("1h span: step = %v, want 1m", got)
+	}
+	if got := autoProbeStep(24 * time.Hour); got != 5*time.Minute {
+		t.Errorf("24h span: step = %v, want 5m", got)
+	}
+	if got := autoProbeStep(3 * 365 * 24 * time.Hour); got != 24*time.Hour {
+		t.Errorf("3y span: step = %v, want 24h", got)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+// searchMaxQueryLen caps the length of q.
+const searchMaxQueryLen = 256
+
+// SearchHitDTO is one typed search result.
+type SearchHitDTO struct {
+	Type     string  `json:"type"`
+	ID       string  `json:"id"`
+	Label    string  `json:"label,omitempty"`
+	ActionID string  `json:"action_id,omitempty"`
+	Match    string  `json:"match"`
+	Score    float64 `json:"score"`
+}
+
+// SearchResponse is returned by /v1/search.
+type SearchResponse struct {
+	Query         string         `json:"query"`
+	Hits          []SearchHitDTO `json:"hits"`
+	SchemaVersion string         `json:"schema_version"`
+}
+
+// parseSearchTypes parses the comma-separated types parameter; nil means all types.
+func parseSearchTypes(val string) (map[string]bool, bool) {
+	if strings.TrimSpace(val) == "" {
+		return nil, true
+	}
+	known := make(map[string]bool, len(db.SearchTypes))
+	for _, t := range db.SearchTypes {
+		known[t] = true
+	}
+	types := make(map[string]bool)
+	for _, t := range strings.Split(val, ",") {
+		t = strings.TrimSpace(t)
+		if !known[t] {
+			return nil, false
+		}
+		types[t] = true
+	}
+	return types, true
+}
+
+// Search finds actions, transactions, accounts, supernodes and Cascade files:
+// /v1/search?q=&types=action,transaction,account,supernode,file&limit=
+func Search(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		q := strings.TrimSpace(query.Get("q"))
+		if q == "" {
+			util.WriteJSONError(w, http.StatusBadRequest, "missing q parameter")
+			return
+		}
+		if len(q) > searchMaxQueryLen {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid q parameter: at most 256 characters")
+			return
+		}
+
+		types, ok := parseSearchTypes(query.Get("types"))
+		if !ok {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid types parameter: must be a comma-separated list of 'action', 'transaction', 'account', 'supernode' and 'file'")
+			return
+		}
+
+		limit := 20
+		if val := query.Get("limit"); val != "" {
+			parsed, err := strconv.Atoi(val)
+			if err != nil || parsed < 1 || parsed > 100 {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid limit parameter: must be an integer between 1 and 100")
+				return
+			}
+			limit = parsed
+		}
+
+		hits, err := db.Search(r.Context(), pool, q, db.SearchOptions{Types: types, Limit: limit})
+		if err != nil {
+			util.WriteJSONError(w, http.StatusInternalServerError, "search failed")
+			return
+		}
+
+		resp := SearchResponse{
+			Query:         q,
+			Hits:          make([]SearchHitDTO, 0, len(hits)),
+			SchemaVersion: "v1.0",
+		}
+		for _, h := range hits {
+			resp.Hits = append(resp.Hits, SearchHitDTO{
+				Type:     h.Type,
+				ID:       h.ID,
+				Label:    h.Label,
+				ActionID: h.ActionID,
+				Match:    h.Match,
+				Score:    h.Score,
+			})
+		}
+
+		now := time.Now().UTC()
+		util.WriteJSON(w, r, http.StatusOK, resp, &now)
+	}
+}
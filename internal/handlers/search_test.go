@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestSearchRejects verifies a missing or overlong q, unknown types and bad limits are
+// refused without a pool
+func TestSearchRejects(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/v1/search", "missing q"},
+		{"/v1/search?q=" + strings.Repeat("a", 257), "at most 256"},
+		{"/v1/search?q=abc&types=action,block", "invalid types"},
+		{"/v1/search?q=abc&limit=101", "invalid limit"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		Search(nil)(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: %d %s, want %q", tt.url, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
+
+// TestSearchQuery verifies each hit type is found by its identifier, exactly or by
+// prefix, that files match by the words of their name, and that hits are ranked by
+// score then type, filtered by types and capped by limit
+func TestSearchQuery(t *testing.T) {
+	pool := testPool(t)
+	blockTime := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	seedAction(t, pool,
+		db.ActionDB{ActionID: 1, Creator: "lumera1alice", ActionType: "ACTION_TYPE_CASCADE", State: "ACTION_STATE_DONE",
+			MetadataJSON: `{"file_name":"holiday-photo.jpg"}`},
+		db.ActionTransaction{TxType: "register", TxHash: "ABCDEF0123", Height: 10, BlockTime: blockTime})
+	seedAction(t, pool,
+		db.ActionDB{ActionID: 12, Creator: "lumera1bob", ActionType: "ACTION_TYPE_CASCADE", State: "ACTION_STATE_DONE",
+			MetadataJSON: `{"file_name":"tax-report.pdf"}`},
+		db.ActionTransaction{TxType: "register", TxHash: "ABCDEF9999", Height: 20, BlockTime: blockTime})
+	sn := db.SupernodeDB{SupernodeAccount: "lumera1sn", ValidatorAddress: "lumeravaloper1sn", ValidatorMoniker: "Holiday Node", CurrentState: "SUPERNODE_STATE_ACTIVE"}
+	if err := db.UpsertSupernode(context.Background(), pool, sn); err != nil {
+		t.Fatal(err)
+	}
+	h := Search(pool)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"q=12", "action:12:exact"},
+		{"q=abcdef0123", "transaction:ABCDEF0123:exact"},
+		{"q=ABCDEF", "transaction:ABCDEF9999:prefix transaction:ABCDEF0123:prefix"},
+		{"q=lumera1", "account:lumera1alice:prefix account:lumera1bob:prefix supernode:lumera1sn:prefix"},
+		{"q=lumera1&limit=2", "account:lumera1alice:prefix account:lumera1bob:prefix"},
+		{"q=lumera1&types=supernode", "supernode:lumera1sn:prefix"},
+		{"q=lumeravaloper1sn", "supernode:lumera1sn:exact"},
+		{"q=holiday", "supernode:lumera1sn:prefix file:1:prefix"},
+		{"q=tax-report.pdf&types=file", "file:12:exact"},
+		{"q=report&types=file", "file:12:prefix"},
+		{"q=lumera1carol", ""},
+	}
+	for _, tt := range tests {
+		var resp SearchResponse
+		getJSON(t, h, "/v1/search?"+tt.query, &resp)
+		var got []string
+		for _, hit := range resp.Hits {
+			got = append(got, fmt.Sprintf("%s:%s:%s", hit.Type, hit.ID, hit.Match))
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s = %v, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+// TestParseMetadataFilters verifies metadata.<field> parameters are collected in order
+func TestParseMetadataFilters(t *testing.T) {
+	q := url.Values{"metadata.public": {"true"}, "metadata.file_name": {"a.jpg"}, "type": {"ACTION_TYPE_CASCADE"}}
+	filters, err := parseMetadataFilters(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 2 || filters[0].Field != "file_name" || filters[1].Field != "public" || filters[1].Value != "true" {
+		t.Errorf("filters = %+v", filters)
+	}
+	if _, err := parseMetadataFilters(url.Values{"metadata.File-Name": {"x"}}); err == nil {
+		t.Error("invalid field accepted")
+	}
+	many := url.Values{}
+	for i := 0; i <= maxMetadataFilters; i++ {
+		many.Add("metadata.rq_ids_ids", string(rune('a'+i)))
+	}
+	if _, err := parseMetadataFilters(many); err == nil {
+		t.Error("too many filters accepted")
+	}
+}
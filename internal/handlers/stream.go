@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+// streamBatchSize caps how many events are read from the log per poll.
+const streamBatchSize = 500
+
+// streamHeartbeat is how often an idle stream sends a keepalive comment so proxies
+// and load balancers don't close the connection.
+const streamHeartbeat = 15 * time.Second
+
+// ActionEventDTO is the data payload of one /v1/stream/actions event.
+type ActionEventDTO struct {
+	ID         int64     `json:"id"`
+	EventType  string    `json:"event_type"`
+	ActionID   string    `json:"action_id"`
+	ActionType string    `json:"action_type"`
+	Creator    string    `json:"creator"`
+	State      string    `json:"state"`
+	PrevState  *string   `json:"prev_state,omitempty"`
+	Supernodes any       `json:"supernodes,omitempty"`
+	TxType     *string   `json:"tx_type,omitempty"`
+	TxHash     *string   `json:"tx_hash,omitempty"`
+	Height     *int64    `json:"height,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StreamActions serves action events as Server-Sent Events.
+//
+// Events are read from the persisted action event log, so any instance can serve a
+// stream and clients can resume with the Last-Event-ID header (or the lastEventId
+// query parameter, for EventSource polyfills that can't set headers). Without a
+// resume point the stream starts at the current end of the log.
+//
+// Supports the same type, creator and supernode filters as ListActions.
+func StreamActions(pool *db.Pool, pollInterval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queryValues := r.URL.Query()
+
+		filter := db.ActionEventsFilter{}
+		if typeStr := queryValues.Get("type"); typeStr != "" {
+			filter.Type = &typeStr
+		}
+		if creatorStr := queryValues.Get("creator"); creatorStr != "" {
+			filter.Creator = &creatorStr
+		}
+		if supernodeStr := queryValues.Get("supernode"); supernodeStr != "" {
+			filter.Supernode = &supernodeStr
+		}
+
+		lastID, ok, err := parseLastEventID(r)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid Last-Event-ID: must be a non-negative integer")
+			return
+		}
+		if !ok {
+			lastID, err = db.LatestActionEventID(r.Context(), pool)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to read action events")
+				return
+			}
+		}
+
+		serveEventStream(w, r, pollInterval, lastID, func(ctx context.Context, afterID int64) ([]streamEvent, error) {
+			events, err := db.ListActionEventsAfter(ctx, pool, afterID, filter, streamBatchSize)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]streamEvent, 0, len(events))
+			for _, e := range events {
+				out = append(out, streamEvent{ID: e.ID, Event: e.EventType, Data: toActionEventDTO(e)})
+			}
+			return out, nil
+		})
+	}
+}
+
+// streamEvent is one event ready to be written to an SSE stream.
+type streamEvent struct {
+	ID    int64
+	Event string
+	Data  any
+}
+
+// serveEventStream polls fetch for events after lastID and writes them as SSE until the
+// client disconnects. fetch returns events in ascending ID order, at most streamBatchSize.
+func serveEventStream(w http.ResponseWriter, r *http.Request, pollInterval time.Duration, lastID int64,
+	fetch func(ctx context.Context, afterID int64) ([]streamEvent, error)) {
+	sse, err := util.NewSSEWriter(w)
+	if err != nil {
+		log.Printf("stream %s: %v", r.URL.Path, err)
+		return
+	}
+	if err := sse.Retry(pollInterval * 2); err != nil {
+		return
+	}
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	idleSince := time.Now()
+
+	for {
+		events, err := fetch(r.Context(), lastID)
+		if err != nil {
+			if r.Context().Err() == nil {
+				log.Printf("stream %s: fetch events after %d: %v", r.URL.Path, lastID, err)
+			}
+			return
+		}
+		for _, e := range events {
+			if err := sse.Event(strconv.FormatInt(e.ID, 10), e.Event, e.Data); err != nil {
+				return
+			}
+			lastID = e.ID
+		}
+		if len(events) > 0 {
+			idleSince = time.Now()
+		}
+		if len(events) == streamBatchSize {
+			continue // more backlog to drain
+		}
+
+		if time.Since(idleSince) >= streamHeartbeat {
+			if err := sse.Comment("keepalive"); err != nil {
+				return
+			}
+			idleSince = time.Now()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// parseLastEventID reads the resume point from the Last-Event-ID header or lastEventId
+// query parameter. ok is false if neither is present.
+func parseLastEventID(r *http.Request) (id int64, ok bool, err error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("lastEventId")
+	}
+	if v == "" {
+		return 0, false, nil
+	}
+	id, err = strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, strconv.ErrSyntax
+	}
+	return id, true, nil
+}
+
+func toActionEventDTO(e db.ActionEvent) ActionEventDTO {
+	return ActionEventDTO{
+		ID:         e.ID,
+		EventType:  e.EventType,
+		ActionID:   strconv.FormatUint(e.ActionID, 10),
+		ActionType: e.ActionType,
+		Creator:    e.Creator,
+		State:      e.State,
+		PrevState:  e.PrevState,
+		Supernodes: e.SuperNodes,
+		TxType:     e.TxType,
+		TxHash:     e.TxHash,
+		Height:     e.Height,
+		CreatedAt:  e.CreatedAt,
+	}
+}
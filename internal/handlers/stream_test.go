@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// TestParseLastEventID verifies resume point parsing from header and query
+func TestParseLastEventID(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		query   string
+		wantID  int64
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "header", header: "42", wantID: 42, wantOK: true},
+		{name: "query", query: "7", wantID: 7, wantOK: true},
+		{name: "header wins", header: "42", query: "7", wantID: 42, wantOK: true},
+		{name: "invalid", header: "abc", wantErr: true},
+		{name: "negative", query: "-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/v1/stream/actions"
+			if tt.query != "" {
+				target += "?lastEventId=" + tt.query
+			}
+			req := httptest.NewRequest("GET", target, nil)
+			if tt.header != "" {
+				req.Header.Set("Last-Event-ID", tt.header)
+			}
+			id, ok, err := parseLastEventID(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("got (%d, %v), want (%d, %v)", id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+// SupernodeEventDTO is one supernode change event.
+type SupernodeEventDTO struct {
+	ID               int64     `json:"id"`
+	EventType        string    `json:"event_type"`
+	SupernodeAccount string    `json:"supernode_account"`
+	ValidatorAddress string    `json:"validator_address,omitempty"`
+	OldValue         string    `json:"old_value,omitempty"`
+	NewValue         string    `json:"new_value,omitempty"`
+	Height           *int64    `json:"height,omitempty"`
+	Details          any       `json:"details,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type SupernodeEventsListResponse struct {
+	Items         []SupernodeEventDTO `json:"items"`
+	NextCursor    string              `json:"next_cursor,omitempty"`
+	SchemaVersion string              `json:"schema_version"`
+}
+
+// parseSupernodeEventsFilter reads the supernode, validator and type filters shared
+// by the list and stream endpoints. It returns a client-facing error message for an
+// unknown type.
+func parseSupernodeEventsFilter(r *http.Request) (db.SupernodeEventsFilter, string) {
+	query := r.URL.Query()
+	f := db.SupernodeEventsFilter{}
+	if val := query.Get("supernode"); val != "" {
+		f.Supernode = &val
+	}
+	if val := query.Get("validator"); val != "" {
+		f.Validator = &val
+	}
+	if val := query.Get("type"); val != "" {
+		if !slices.Contains(db.SupernodeEventTypes, val) {
+			return f, "invalid type parameter: must be one of " + strings.Join(db.SupernodeEventTypes, ", ")
+		}
+		f.EventType = &val
+	}
+	return f, ""
+}
+
+// ListSupernodeEvents returns supernode change events, newest first, with cursor pagination.
+func ListSupernodeEvents(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter, msg := parseSupernodeEventsFilter(r)
+		if msg != "" {
+			util.WriteJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		limit := 100
+		if val := query.Get("limit"); val != "" {
+			parsed, err := strconv.Atoi(val)
+			if err != nil || parsed < 1 || parsed > 200 {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid limit parameter: must be between 1 and 200")
+				return
+			}
+			limit = parsed
+		}
+		filter.Limit = limit
+
+		if val := query.Get("cursor"); val != "" {
+			decoded, err := base64.StdEncoding.DecodeString(val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor parameter: must be base64 encoded JSON")
+				return
+			}
+			var payload struct {
+				ID int64 `json:"id"`
+			}
+			if err := json.Unmarshal(decoded, &payload); err != nil || payload.ID <= 0 {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor parameter: must be base64 encoded JSON with id")
+				return
+			}
+			filter.CursorID = &payload.ID
+		}
+
+		events, hasMore, err := db.ListSupernodeEvents(r.Context(), pool, filter)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch supernode events")
+			return
+		}
+
+		items := make([]SupernodeEventDTO, 0, len(events))
+		for _, e := range events {
+			items = append(items, toSupernodeEventDTO(e))
+		}
+		response := SupernodeEventsListResponse{Items: items, SchemaVersion: "v1.0"}
+
+		if hasMore && len(events) > 0 {
+			buf, err := json.Marshal(struct {
+				ID int64 `json:"id"`
+			}{ID: events[len(events)-1].ID})
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to encode pagination cursor")
+				return
+			}
+			response.NextCursor = base64.StdEncoding.EncodeToString(buf)
+		}
+
+		lastModified := time.Now().UTC()
+		if len(events) > 0 {
+			lastModified = events[0].CreatedAt.UTC()
+		}
+		util.WriteJSON(w, r, http.StatusOK, response, &lastModified)
+	}
+}
+
+// StreamSupernodeEvents serves supernode change events as Server-Sent Events, with the
+// same filters as ListSupernodeEvents and Last-Event-ID resume.
+func StreamSupernodeEvents(pool *db.Pool, pollInterval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, msg := parseSupernodeEventsFilter(r)
+		if msg != "" {
+			util.WriteJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		lastID, ok, err := parseLastEventID(r)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid Last-Event-ID: must be a non-negative integer")
+			return
+		}
+		if !ok {
+			lastID, err = db.LatestSupernodeEventID(r.Context(), pool)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to read supernode events")
+				return
+			}
+		}
+
+		serveEventStream(w, r, pollInterval, lastID, func(ctx context.Context, afterID int64) ([]streamEvent, error) {
+			events, err := db.ListSupernodeEventsAfter(ctx, pool, afterID, filter, streamBatchSize)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]streamEvent, 0, len(events))
+			for _, e := range events {
+				out = append(out, streamEvent{ID: e.ID, Event: "supernode_" + e.EventType, Data: toSupernodeEventDTO(e)})
+			}
+			return out, nil
+		})
+	}
+}
+
+func toSupernodeEventDTO(e db.SupernodeEvent) SupernodeEventDTO {
+	return SupernodeEventDTO{
+		ID:               e.ID,
+		EventType:        e.EventType,
+		SupernodeAccount: e.SupernodeAccount,
+		ValidatorAddress: e.ValidatorAddress,
+		OldValue:         e.OldValue,
+		NewValue:         e.NewValue,
+		Height:           e.Height,
+		Details:          e.Details,
+		CreatedAt:        e.CreatedAt,
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestSupernodeEventsRejects verifies invalid list and stream parameters fail before
+// the database is touched
+func TestSupernodeEventsRejects(t *testing.T) {
+	list, stream := ListSupernodeEvents(nil), StreamSupernodeEvents(nil, time.Second)
+	tests := []struct {
+		name    string
+		handler http.Handler
+		url     string
+		header  string
+		want    string
+	}{
+		{"limit zero", list, "/v1/supernodes/events?limit=0", "", "invalid limit parameter"},
+		{"limit too high", list, "/v1/supernodes/events?limit=201", "", "invalid limit parameter"},
+		{"limit not a number", list, "/v1/supernodes/events?limit=ten", "", "invalid limit parameter"},
+		{"cursor not base64", list, "/v1/supernodes/events?cursor=not-base64!", "", "invalid cursor parameter"},
+		{"cursor without id", list, "/v1/supernodes/events?cursor=e30=", "", "invalid cursor parameter"},
+		{"list type", list, "/v1/supernodes/events?type=deleted", "", "invalid type parameter"},
+		{"stream type", stream, "/v1/stream/supernodes?type=deleted", "", "invalid type parameter"},
+		{"stream last event id", stream, "/v1/stream/supernodes", "abc", "invalid Last-Event-ID"},
+		{"stream negative last event id", stream, "/v1/stream/supernodes?lastEventId=-1", "", "invalid Last-Event-ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set("Last-Event-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("body = %s, want %q", rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+// insertSupernodeEvents records n events alternating between two supernodes and
+// returns their IDs, oldest first.
+func insertSupernodeEvents(t *testing.T, pool *db.Pool, n int) []int64 {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		e := db.SupernodeEvent{EventType: db.SupernodeEventPortsDown, SupernodeAccount: "lumera1a"}
+		if i%2 == 1 {
+			e = db.SupernodeEvent{EventType: db.SupernodeEventStateChanged, SupernodeAccount: "lumera1b", NewValue: "SUPERNODE_STATE_ACTIVE"}
+		}
+		if err := db.InsertSupernodeEvent(ctx, pool, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, err := db.ListSupernodeEventsAfter(ctx, pool, 0, db.SupernodeEventsFilter{}, n)
+	if err != nil || len(events) != n {
+		t.Fatalf("events = %+v, %v", events, err)
+	}
+	ids := make([]int64, 0, n)
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+// TestListSupernodeEventsCursor verifies events page newest first through next_cursor
+// and that filters apply to every page
+func TestListSupernodeEventsCursor(t *testing.T) {
+	pool := testPool(t)
+	ids := insertSupernodeEvents(t, pool, 5)
+	h := ListSupernodeEvents(pool)
+
+	page := func(url string) SupernodeEventsListResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		var resp SupernodeEventsListResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s = %d %s, %v", url, rec.Code, rec.Body, err)
+		}
+		return resp
+	}
+
+	var got []int64
+	next := ""
+	for {
+		resp := page("/v1/supernodes/events?limit=2&cursor=" + next)
+		for _, e := range resp.Items {
+			got = append(got, e.ID)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		next = resp.NextCursor
+	}
+	want := []int64{ids[4], ids[3], ids[2], ids[1], ids[0]}
+	if len(got) != len(want) {
+		t.Fatalf("ids = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ids = %v, want %v", got, want)
+		}
+	}
+
+	resp := page("/v1/supernodes/events?supernode=lumera1b&type=state_changed&limit=1")
+	if len(resp.Items) != 1 || resp.Items[0].ID != ids[3] || resp.Items[0].NewValue != "SUPERNODE_STATE_ACTIVE" || resp.NextCursor == "" {
+		t.Fatalf("filtered page 1 = %+v", resp)
+	}
+	resp = page("/v1/supernodes/events?supernode=lumera1b&type=state_changed&limit=1&cursor=" + resp.NextCursor)
+	if len(resp.Items) != 1 || resp.Items[0].ID != ids[1] || resp.NextCursor != "" {
+		t.Errorf("filtered page 2 = %+v", resp)
+	}
+}
+
+// TestStreamSupernodeEventsResume verifies a stream resumes after Last-Event-ID,
+// applies its filters and starts at the end of the log without a resume point
+func TestStreamSupernodeEventsResume(t *testing.T) {
+	pool := testPool(t)
+	ids := insertSupernodeEvents(t, pool, 5)
+	h := StreamSupernodeEvents(pool, 10*time.Millisecond)
+
+	stream := func(url, lastEventID string) []string {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		req := httptest.NewRequest(http.MethodGet, url, nil).WithContext(ctx)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var got []string
+		for _, m := range regexp.MustCompile(`(?m)^id: (\d+)$`).FindAllStringSubmatch(rec.Body.String(), -1) {
+			got = append(got, m[1])
+		}
+		return got
+	}
+
+	id := func(i int) string { return strconv.FormatInt(ids[i], 10) }
+	if got := stream("/v1/stream/supernodes", id(1)); strings.Join(got, ",") != strings.Join([]string{id(2), id(3), id(4)}, ",") {
+		t.Errorf("resumed ids = %v", got)
+	}
+	if got := stream("/v1/stream/supernodes?supernode=lumera1a", id(0)); strings.Join(got, ",") != strings.Join([]string{id(2), id(4)}, ",") {
+		t.Errorf("filtered ids = %v", got)
+	}
+	if got := stream("/v1/stream/supernodes", ""); len(got) != 0 {
+		t.Errorf("ids without a resume point = %v, want none", got)
+	}
+}
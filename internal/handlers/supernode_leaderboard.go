@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+const (
+	// leaderboardDefaultRange is the window used when from is omitted.
+	leaderboardDefaultRange = 30 * 24 * time.Hour
+	// leaderboardDefaultDenom is the denom earnings are summed in when denom is omitted.
+	leaderboardDefaultDenom = "ulume"
+)
+
+// LeaderboardEntryDTO is one ranked supernode.
+type LeaderboardEntryDTO struct {
+	Rank                         int64    `json:"rank"`
+	SupernodeAccount             string   `json:"supernode_account"`
+	ValidatorAddress             string   `json:"validator_address,omitempty"`
+	ValidatorMoniker             string   `json:"validator_moniker,omitempty"`
+	CurrentState                 string   `json:"current_state"`
+	Earnings                     string   `json:"earnings"`
+	ActionsFinalized             int64    `json:"actions_finalized"`
+	ActionsAssigned              int64    `json:"actions_assigned"`
+	ActionsFailed                int64    `json:"actions_failed"`
+	FailureRate                  *float64 `json:"failure_rate"`
+	Availability                 *float64 `json:"availability"`
+	ProbeSamples                 int64    `json:"probe_samples"`
+	MedianFinalizeLatencySeconds *float64 `json:"median_finalize_latency_seconds"`
+}
+
+// SupernodeLeaderboardResponse is returned by /v1/supernodes/leaderboard.
+type SupernodeLeaderboardResponse struct {
+	Metric        string                `json:"metric"`
+	Order         string                `json:"order"`
+	Denom         string                `json:"denom"`
+	From          time.Time             `json:"from"`
+	To            time.Time             `json:"to"`
+	Entries       []LeaderboardEntryDTO `json:"entries"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
+	SchemaVersion string                `json:"schema_version"`
+}
+
+// leaderboardCursor is the pagination cursor of the leaderboard (base64 JSON). Ranks
+// are recomputed per request, so pages are consistent as long as the data is.
+type leaderboardCursor struct {
+	Rank int64 `json:"rank"`
+}
+
+func encodeLeaderboardCursor(rank int64) (string, error) {
+	buf, err := json.Marshal(leaderboardCursor{Rank: rank})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+func decodeLeaderboardCursor(s string) (int64, error) {
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return 0, errors.New("invalid cursor parameter: must be base64 encoded JSON")
+	}
+	var payload leaderboardCursor
+	if err := json.Unmarshal(decoded, &payload); err != nil || payload.Rank < 1 {
+		return 0, errors.New("invalid cursor parameter: must be base64 encoded JSON with rank")
+	}
+	return payload.Rank, nil
+}
+
+// GetSupernodeLeaderboard ranks all supernodes by one metric over a time window:
+// /v1/supernodes/leaderboard?metric=&order=asc|desc&denom=&from=&to=&limit=&cursor=
+//
+// Each metric has a best-first default order: earnings, finalized and availability
+// descending; failure_rate and finalize_latency ascending.
+func GetSupernodeLeaderboard(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		metricName := strings.TrimSpace(query.Get("metric"))
+		if metricName == "" {
+			metricName = db.LeaderboardEarnings
+		}
+		metric, ok := db.LeaderboardMetricByName(metricName)
+		if !ok {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid metric parameter: must be 'earnings', 'finalized', 'failure_rate', 'availability' or 'finalize_latency'")
+			return
+		}
+
+		desc := metric.DefaultDesc
+		switch query.Get("order") {
+		case "":
+		case "asc":
+			desc = false
+		case "desc":
+			desc = true
+		default:
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid order parameter: must be 'asc' or 'desc'")
+			return
+		}
+
+		denom := strings.TrimSpace(query.Get("denom"))
+		if denom == "" {
+			denom = leaderboardDefaultDenom
+		}
+
+		to := time.Now().UTC()
+		if val := strings.TrimSpace(query.Get("to")); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid 'to' parameter: must be RFC3339 format")
+				return
+			}
+			to = t.UTC()
+		}
+		from := to.Add(-leaderboardDefaultRange)
+		if val := strings.TrimSpace(query.Get("from")); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid 'from' parameter: must be RFC3339 format")
+				return
+			}
+			from = t.UTC()
+		}
+		if !from.Before(to) {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid range: from must be before to")
+			return
+		}
+
+		limit := 50
+		if val := query.Get("limit"); val != "" {
+			parsed, err := strconv.Atoi(val)
+			if err != nil || parsed < 1 || parsed > 200 {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid limit parameter: must be an integer between 1 and 200")
+				return
+			}
+			limit = parsed
+		}
+
+		var afterRank int64
+		if val := query.Get("cursor"); val != "" {
+			rank, err := decodeLeaderboardCursor(val)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			afterRank = rank
+		}
+
+		now := time.Now().UTC()
+		entries, hasMore, err := db.GetSupernodeLeaderboard(r.Context(), pool, db.LeaderboardFilter{
+			Metric:    metric,
+			Desc:      desc,
+			Denom:     denom,
+			From:      from,
+			To:        to,
+			AfterRank: afterRank,
+			Limit:     limit,
+		}, now)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch supernode leaderboard")
+			return
+		}
+
+		order := "asc"
+		if desc {
+			order = "desc"
+		}
+		resp := SupernodeLeaderboardResponse{
+			Metric:        metric.Name,
+			Order:         order,
+			Denom:         denom,
+			From:          from,
+			To:            to,
+			Entries:       make([]LeaderboardEntryDTO, 0, len(entries)),
+			SchemaVersion: "v1.0",
+		}
+		for _, e := range entries {
+			resp.Entries = append(resp.Entries, LeaderboardEntryDTO{
+				Rank:                         e.Rank,
+				SupernodeAccount:             e.SupernodeAccount,
+				ValidatorAddress:             e.ValidatorAddress,
+				ValidatorMoniker:             e.ValidatorMoniker,
+				CurrentState:                 e.CurrentState,
+				Earnings:                     e.Earnings,
+				ActionsFinalized:             e.Finalized,
+				ActionsAssigned:              e.Assigned,
+				ActionsFailed:                e.Failed,
+				FailureRate:                  e.FailureRate,
+				Availability:                 e.Availability,
+				ProbeSamples:                 e.ProbeSamples,
+				MedianFinalizeLatencySeconds: e.MedianLatency,
+			})
+		}
+		if hasMore && len(entries) > 0 {
+			cursor, err := encodeLeaderboardCursor(entries[len(entries)-1].Rank)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to encode pagination cursor")
+				return
+			}
+			resp.NextCursor = cursor
+		}
+
+		util.WriteJSON(w, r, http.StatusOK, resp, &now)
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestLeaderboardCursorRoundTrip verifies cursors decode to the rank they were made from
+func TestLeaderboardCursorRoundTrip(t *testing.T) {
+	cursor, err := encodeLeaderboardCursor(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rank, err := decodeLeaderboardCursor(cursor)
+	if err != nil || rank != 50 {
+		t.Fatalf("decode = %d, %v; want 50", rank, err)
+	}
+	for _, bad := range []string{"not base64!", "e30=" /* {} */} {
+		if _, err := decodeLeaderboardCursor(bad); err == nil {
+			t.Errorf("decode(%q) succeeded", bad)
+		}
+	}
+}
+
+// TestSupernodeLeaderboardRejects verifies unknown metrics and orders, bad ranges,
+// limits and cursors are refused without a pool
+func TestSupernodeLeaderboardRejects(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/v1/supernodes/leaderboard?metric=uptime", "invalid metric"},
+		{"/v1/supernodes/leaderboard?order=up", "invalid order"},
+		{"/v1/supernodes/leaderboard?from=last-week", "invalid 'from'"},
+		{"/v1/supernodes/leaderboard?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", "from must be before to"},
+		{"/v1/supernodes/leaderboard?limit=500", "invalid limit"},
+		{"/v1/supernodes/leaderboard?cursor=e30=", "invalid cursor"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		GetSupernodeLeaderboard(nil)(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: %d %s, want %q", tt.url, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
+
+// TestSupernodeLeaderboardQuery verifies earnings, finalized counts, latencies,
+// failure rates and availability are totalled per supernode, ranked by the requested
+// metric with missing values last, and paged
+func TestSupernodeLeaderboardQuery(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	t0 := now.Add(-24 * time.Hour).Truncate(time.Second)
+	for _, sn := range []string{"lumera1sn1", "lumera1sn2", "lumera1sn3"} {
+		if err := db.UpsertSupernode(ctx, pool, db.SupernodeDB{SupernodeAccount: sn, CurrentState: "SUPERNODE_STATE_ACTIVE"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, up := range map[string][]bool{"lumera1sn1": {true, true}, "lumera1sn2": {true, false}} {
+		for j, fa := range up {
+			s := db.ProbeSample{SupernodeAccount: i, SampledAt: now.Add(-time.Duration(10-5*j) * time.Minute), FullyAvailable: fa}
+			if err := db.InsertProbeSample(ctx, pool, s); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	action := func(id uint64, sn, state string, finalize time.Duration, price string) {
+		txs := []db.ActionTransaction{{TxType: "register", TxHash: fmt.Sprintf("R%d", id), Height: 1, BlockTime: t0,
+			ActionPrice: ptr(price), ActionPriceDenom: ptr("ulume")}}
+		if finalize > 0 {
+			txs = append(txs, db.ActionTransaction{TxType: "finalize", TxHash: fmt.Sprintf("F%d", id), Height: 2, BlockTime: t0.Add(finalize),
+				ActionPrice: ptr(price), ActionPriceDenom: ptr("ulume"), FlowPayee: ptr(sn)})
+		}
+		seedAction(t, pool, db.ActionDB{ActionID: id, State: state, SuperNodes: `["` + sn + `"]`}, txs...)
+	}
+	action(1, "lumera1sn1", "ACTION_STATE_DONE", time.Minute, "1000")
+	action(2, "lumera1sn1", "ACTION_STATE_DONE", 3*time.Minute, "500")
+	action(3, "lumera1sn2", "ACTION_STATE_FAILED", 0, "300")
+	action(4, "lumera1sn2", "ACTION_STATE_DONE", 30*time.Second, "200")
+	h := GetSupernodeLeaderboard(pool)
+	window := "&from=" + now.Add(-48*time.Hour).Format(time.RFC3339) + "&to=" + now.Add(time.Hour).Format(time.RFC3339)
+
+	board := func(query string) SupernodeLeaderboardResponse {
+		t.Helper()
+		var resp SupernodeLeaderboardResponse
+		getJSON(t, h, "/v1/supernodes/leaderboard?"+query+window, &resp)
+		return resp
+	}
+	accounts := func(resp SupernodeLeaderboardResponse) string {
+		var out []string
+		for _, e := range resp.Entries {
+			out = append(out, fmt.Sprintf("%d:%s", e.Rank, e.SupernodeAccount))
+		}
+		return strings.Join(out, " ")
+	}
+
+	resp := board("metric=earnings")
+	if got := accounts(resp); got != "1:lumera1sn1 2:lumera1sn2 3:lumera1sn3" {
+		t.Fatalf("earnings ranking = %s", got)
+	}
+	sn1, sn2, sn3 := resp.Entries[0], resp.Entries[1], resp.Entries[2]
+	if sn1.Earnings != "1500" || sn1.ActionsFinalized != 2 || sn1.ActionsAssigned != 2 || sn1.ActionsFailed != 0 ||
+		sn1.MedianFinalizeLatencySeconds == nil || *sn1.MedianFinalizeLatencySeconds != 120 ||
+		sn1.Availability == nil || *sn1.Availability != 1 || sn1.ProbeSamples != 2 {
+		t.Errorf("lumera1sn1 = %+v", sn1)
+	}
+	if sn2.Earnings != "200" || sn2.ActionsFinalized != 1 || sn2.ActionsAssigned != 2 || sn2.ActionsFailed != 1 ||
+		sn2.FailureRate == nil || *sn2.FailureRate != 0.5 || sn2.Availability == nil || *sn2.Availability != 0.5 {
+		t.Errorf("lumera1sn2 = %+v", sn2)
+	}
+	if sn3.Earnings != "0" || sn3.FailureRate != nil || sn3.Availability != nil || sn3.MedianFinalizeLatencySeconds != nil {
+		t.Errorf("lumera1sn3 = %+v", sn3)
+	}
+
+	for query, want := range map[string]string{
+		"metric=failure_rate":                "1:lumera1sn1 2:lumera1sn2 3:lumera1sn3",
+		"metric=finalize_latency":            "1:lumera1sn2 2:lumera1sn1 3:lumera1sn3",
+		"metric=availability&order=asc":      "1:lumera1sn2 2:lumera1sn1 3:lumera1sn3",
+		"metric=earnings&denom=uatom":        "1:lumera1sn1 2:lumera1sn2 3:lumera1sn3",
+		"metric=finalized&order=asc&limit=2": "1:lumera1sn3 2:lumera1sn2",
+	} {
+		if got := accounts(board(query)); got != want {
+			t.Errorf("%s = %s, want %s", query, got, want)
+		}
+	}
+
+	page := board("metric=earnings&limit=2")
+	if page.NextCursor == "" {
+		t.Fatal("first page has no next_cursor")
+	}
+	page = board("metric=earnings&limit=2&cursor=" + page.NextCursor)
+	if got := accounts(page); got != "3:lumera1sn3" || page.NextCursor != "" {
+		t.Errorf("second page = %s, next_cursor %q", got, page.NextCursor)
+	}
+}
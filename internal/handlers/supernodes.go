@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -44,6 +45,9 @@ type SingleSupernodeMetricsResponse struct {
 	LastSuccessfulProbe    *time.Time             `json:"last_successful_probe,omitempty"`
 	FailedProbeCounter     int32                  `json:"failed_probe_counter"`
 	LastKnownActualVersion string                 `json:"last_known_actual_version,omitempty"`
+	Availability24h        *float64               `json:"availability_24h,omitempty"`
+	Availability7d         *float64               `json:"availability_7d,omitempty"`
+	Availability30d        *float64               `json:"availability_30d,omitempty"`
 }
 
 type SupernodeMetricsListResponse struct {
@@ -139,21 +143,28 @@ func ListSupernodesMetrics(pool *db.Pool) http.HandlerFunc {
 			limit = parsed
 		}
 
-		var cursorAccount *string
+		avail, err := parseAvailabilityListParams(query)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var (
+			cursorAccount *string
+			cursorValue   *float64
+		)
 		if val := query.Get("cursor"); val != "" {
-			decoded, err := base64.StdEncoding.DecodeString(val)
+			account, value, err := decodeSupernodeCursor(val)
 			if err != nil {
-				util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor parameter: must be base64 encoded JSON")
+				util.WriteJSONError(w, http.StatusBadRequest, err.Error())
 				return
 			}
-			var payload struct {
-				Account string `json:"account"`
-			}
-			if err := json.Unmarshal(decoded, &payload); err != nil || payload.Account == "" {
-				util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor parameter: must be base64 encoded JSON with account")
+			if avail.SortWindow != "" && value == nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor parameter: cursor does not match sort")
 				return
 			}
-			cursorAccount = &payload.Account
+			cursorAccount = &account
+			cursorValue = value
 		}
 
 		filter := db.SupernodeMetricsFilter{
@@ -164,6 +175,11 @@ func ListSupernodesMetrics(pool *db.Pool) http.HandlerFunc {
 			MinFailed:     minFailed,
 			Limit:         limit,
 			CursorAccount: cursorAccount,
+
+			MinAvailability: avail.MinAvailability,
+			SortWindow:      avail.SortWindow,
+			SortDesc:        avail.SortDesc,
+			CursorValue:     cursorValue,
 		}
 
 		supernodes, hasMore, err := db.ListSupernodeMetricsFiltered(r.Context(), pool, filter)
@@ -205,6 +221,9 @@ func ListSupernodesMetrics(pool *db.Pool) http.HandlerFunc {
 				LastSuccessfulProbe:    sn.LastSuccessfulProbe,
 				FailedProbeCounter:     sn.FailedProbeCounter,
 				LastKnownActualVersion: sn.LastKnownActualVersion,
+				Availability24h:        sn.Availability24h,
+				Availability7d:         sn.Availability7d,
+				Availability30d:        sn.Availability30d,
 			}
 
 			if sn.MetricsReport != nil {
@@ -237,17 +256,18 @@ func ListSupernodesMetrics(pool *db.Pool) http.HandlerFunc {
 		}
 
 		if hasMore && len(supernodes) > 0 {
-			cursorPayload := struct {
-				Account string `json:"account"`
-			}{
-				Account: supernodes[len(supernodes)-1].SupernodeAccount,
+			last := supernodes[len(supernodes)-1]
+			var value *float64
+			if avail.SortWindow != "" {
+				v := availabilitySortValue(last, avail.SortWindow)
+				value = &v
 			}
-			buf, err := json.Marshal(cursorPayload)
+			cursor, err := encodeSupernodeCursor(last.SupernodeAccount, value)
 			if err != nil {
 				util.WriteJSONError(w, http.StatusInternalServerError, "failed to encode pagination cursor")
 				return
 			}
-			response.NextCursor = base64.StdEncoding.EncodeToString(buf)
+			response.NextCursor = cursor
 		}
 
 		lastModified := time.Now().UTC()
@@ -259,6 +279,33 @@ func ListSupernodesMetrics(pool *db.Pool) http.HandlerFunc {
 	}
 }
 
+// supernodeCursor is the keyset pagination cursor of supernode lists (base64 JSON).
+// Value carries the sort key when sorting by availability.
+type supernodeCursor struct {
+	Account string   `json:"account"`
+	Value   *float64 `json:"value,omitempty"`
+}
+
+func encodeSupernodeCursor(account string, value *float64) (string, error) {
+	buf, err := json.Marshal(supernodeCursor{Account: account, Value: value})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+func decodeSupernodeCursor(s string) (string, *float64, error) {
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", nil, errors.New("invalid cursor parameter: must be base64 encoded JSON")
+	}
+	var payload supernodeCursor
+	if err := json.Unmarshal(decoded, &payload); err != nil || payload.Account == "" {
+		return "", nil, errors.New("invalid cursor parameter: must be base64 encoded JSON with account")
+	}
+	return payload.Account, payload.Value, nil
+}
+
 // ListUnavailableSupernodes returns supernodes where isStatusApiAvailable=false,
 // filtered by currentState query parameter (running|stopped|any, default: running)
 func ListUnavailableSupernodes(pool *db.Pool) http.HandlerFunc {
@@ -338,6 +385,9 @@ func GetSupernodeMetrics(pool *db.Pool) http.HandlerFunc {
 			LastSuccessfulProbe:    sn.LastSuccessfulProbe,
 			FailedProbeCounter:     sn.FailedProbeCounter,
 			LastKnownActualVersion: sn.LastKnownActualVersion,
+			Availability24h:        sn.Availability24h,
+			Availability7d:         sn.Availability7d,
+			Availability30d:        sn.Availability30d,
 		}
 
 		// Add metrics report if available
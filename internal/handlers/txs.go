@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+)
+
+// TxFetcher fetches a transaction from the chain by hash. It returns db.ErrNotFound
+// when the chain has no transaction with that hash.
+type TxFetcher interface {
+	GetTx(ctx context.Context, hash string) (*db.ChainTx, error)
+}
+
+// Sources of a /v1/txs/{hash} response.
+const (
+	txSourceCache = "cache" // chain_txs, fetched earlier
+	txSourceChain = "chain" // fetched from the LCD by this request
+	txSourceIndex = "index" // action_transactions only; the LCD was unavailable
+)
+
+// TxActionDTO is an action lifecycle message carried by a transaction.
+type TxActionDTO struct {
+	ActionID         string  `json:"action_id"`
+	TxType           string  `json:"tx_type"`
+	MsgIndex         int     `json:"msg_index"`
+	ActionPrice      *string `json:"action_price,omitempty"`
+	ActionPriceDenom *string `json:"action_price_denom,omitempty"`
+	FlowPayer        *string `json:"flow_payer,omitempty"`
+	FlowPayee        *string `json:"flow_payee,omitempty"`
+}
+
+// TxDetailResponse is returned by /v1/txs/{hash}. Code, memo, signer, message types
+// and events are only known when source is "cache" or "chain".
+type TxDetailResponse struct {
+	TxHash        string           `json:"tx_hash"`
+	Height        int64            `json:"height"`
+	BlockTime     time.Time        `json:"block_time"`
+	Code          *uint32          `json:"code,omitempty"`
+	GasWanted     *int64           `json:"gas_wanted,omitempty"`
+	GasUsed       *int64           `json:"gas_used,omitempty"`
+	Memo          string           `json:"memo,omitempty"`
+	Signer        string           `json:"signer,omitempty"`
+	Fee           []DenomAmountDTO `json:"fee"`
+	MessageTypes  []string         `json:"message_types"`
+	Actions       []TxActionDTO    `json:"actions"`
+	Flows         []TxFlowDTO      `json:"flows"`
+	Events        []db.TxEvent     `json:"events"`
+	Source        string           `json:"source"`
+	SchemaVersion string           `json:"schema_version"`
+}
+
+var txHashPattern = regexp.MustCompile(`^[0-9A-Fa-f]{64}$`)
+
+// txHashFromPath extracts {hash} from /v1/txs/{hash} and normalizes it to upper case,
+// or returns "" if it isn't a 64-character hex hash.
+func txHashFromPath(path string) string {
+	const prefix = "/v1/txs/"
+	if !strings.HasPrefix(path, prefix) {
+		return ""
+	}
+	s := strings.TrimPrefix(path, prefix)
+	if !txHashPattern.MatchString(s) {
+		return ""
+	}
+	return strings.ToUpper(s)
+}
+
+// GetTx returns a transaction by hash with its fee, gas, events, every coin it moved
+// and the actions it carries. Transactions not in the chain_txs cache are fetched from
+// the LCD and cached; indexed action transactions provide the actions and their
+// transfer flows, and the rest of the response when the LCD is unavailable.
+func GetTx(pool *db.Pool, fetcher TxFetcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hash := txHashFromPath(r.URL.Path)
+		if hash == "" {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid tx hash: must be 64 hex characters")
+			return
+		}
+		ctx := r.Context()
+
+		indexed, err := db.GetActionTransactionsByHash(ctx, pool, hash)
+		if err != nil {
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch indexed transactions")
+			return
+		}
+
+		source := txSourceCache
+		tx, err := db.GetChainTx(ctx, pool, hash)
+		var fetchErr error
+		switch {
+		case err == nil:
+		case !errors.Is(err, db.ErrNotFound):
+			util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch cached transaction")
+			return
+		case fetcher != nil:
+			tx, fetchErr = fetcher.GetTx(ctx, hash)
+			switch {
+			case fetchErr == nil:
+				source = txSourceChain
+				if err := db.UpsertChainTx(ctx, pool, tx); err != nil {
+					log.Printf("txs: failed to cache %s: %v", hash, err)
+				}
+			case errors.Is(fetchErr, db.ErrNotFound):
+				fetchErr = nil
+			}
+		}
+
+		if tx == nil && len(indexed) == 0 {
+			if fetchErr != nil {
+				util.WriteJSONError(w, http.StatusBadGateway, "failed to fetch transaction from chain")
+				return
+			}
+			util.WriteJSONError(w, http.StatusNotFound, "transaction not found")
+			return
+		}
+
+		var flows []db.TxFlow
+		if source == txSourceChain && tx != nil {
+			flows = tx.Flows
+		} else {
+			stored, err := db.GetTxFlows(ctx, pool, []string{hash})
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch transaction flows")
+				return
+			}
+			flows = stored[hash]
+		}
+
+		resp := txDetailResponse(tx, indexed)
+		resp.Flows = txFlowsToDTO(flows)
+		if tx == nil {
+			resp.Source = txSourceIndex
+		} else {
+			resp.Source = source
+		}
+		lm := resp.BlockTime.UTC()
+		util.WriteJSON(w, r, http.StatusOK, resp, &lm)
+	}
+}
+
+// txDetailResponse builds the response from a fetched transaction and its indexed
+// action transactions; either may be missing, but not both.
+func txDetailResponse(tx *db.ChainTx, indexed []db.ActionTransaction) TxDetailResponse {
+	resp := TxDetailResponse{
+		Fee:           []DenomAmountDTO{},
+		MessageTypes:  []string{},
+		Actions:       make([]TxActionDTO, 0, len(indexed)),
+		Flows:         []TxFlowDTO{},
+		Events:        []db.TxEvent{},
+		SchemaVersion: "v1.0",
+	}
+
+	if tx != nil {
+		code := tx.Code
+		resp.TxHash = tx.TxHash
+		resp.Height = tx.Height
+		resp.BlockTime = tx.BlockTime
+		resp.Code = &code
+		resp.GasWanted = tx.GasWanted
+		resp.GasUsed = tx.GasUsed
+		resp.Memo = tx.Memo
+		resp.Signer = tx.Signer
+		for _, c := range tx.Fee {
+			resp.Fee = append(resp.Fee, DenomAmountDTO{Denom: c.Denom, Amount: c.Amount})
+		}
+		if tx.MessageTypes != nil {
+			resp.MessageTypes = tx.MessageTypes
+		}
+		if tx.Events != nil {
+			resp.Events = tx.Events
+		}
+	} else {
+		first := indexed[0]
+		resp.TxHash = first.TxHash
+		resp.Height = first.Height
+		resp.BlockTime = first.BlockTime
+		resp.GasWanted = first.GasWanted
+		resp.GasUsed = first.GasUsed
+		if first.TxFee != nil && first.TxFeeDenom != nil {
+			resp.Fee = append(resp.Fee, DenomAmountDTO{Denom: *first.TxFeeDenom, Amount: *first.TxFee})
+		}
+	}
+
+	// The enricher resolves flows knowing the action's supernode, so indexed rows win
+	if len(indexed) > 0 {
+		for _, at := range indexed {
+			resp.Actions = append(resp.Actions, TxActionDTO{
+				ActionID:         strconv.FormatUint(at.ActionID, 10),
+				TxType:           at.TxType,
+				MsgIndex:         at.MsgIndex,
+				ActionPrice:      at.ActionPrice,
+				ActionPriceDenom: at.ActionPriceDenom,
+				FlowPayer:        at.FlowPayer,
+				FlowPayee:        at.FlowPayee,
+			})
+		}
+	} else if tx != nil {
+		for _, a := range tx.Actions {
+			resp.Actions = append(resp.Actions, TxActionDTO{
+				ActionID:         strconv.FormatUint(a.ActionID, 10),
+				TxType:           a.TxType,
+				MsgIndex:         a.MsgIndex,
+				ActionPrice:      a.ActionPrice,
+				ActionPriceDenom: a.ActionPriceDenom,
+				FlowPayer:        a.FlowPayer,
+				FlowPayee:        a.FlowPayee,
+			})
+		}
+	}
+	return resp
+}
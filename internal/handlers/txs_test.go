@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestTxHashFromPath verifies hashes are validated and upper-cased
+func TestTxHashFromPath(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/txs/" + hash, strings.ToUpper(hash)},
+		{"/v1/txs/" + strings.ToUpper(hash), strings.ToUpper(hash)},
+		{"/v1/txs/", ""},
+		{"/v1/txs/" + hash[:62], ""},
+		{"/v1/txs/" + hash + "/events", ""},
+		{"/v1/txs/" + strings.Repeat("zz", 32), ""},
+	}
+	for _, tt := range tests {
+		if got := txHashFromPath(tt.path); got != tt.want {
+			t.Errorf("txHashFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestGetTxRejects verifies malformed hashes are rejected before any query
+func TestGetTxRejects(t *testing.T) {
+	rec := httptest.NewRecorder()
+	GetTx(nil, nil)(rec, httptest.NewRequest(http.MethodGet, "/v1/txs/not-a-hash", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid tx hash") {
+		t.Errorf("got %d %s", rec.Code, rec.Body)
+	}
+}
+
+// TestTxDetailResponse verifies indexed actions take precedence and index-only
+// responses fall back to the indexed fee
+func TestTxDetailResponse(t *testing.T) {
+	payee := "lumera1supernode"
+	fee, denom := "500", "ulume"
+	bt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	indexed := []db.ActionTransaction{{ActionID: 7, TxType: "finalize", TxHash: "H", Height: 10, BlockTime: bt, FlowPayee: &payee, TxFee: &fee, TxFeeDenom: &denom}}
+	chain := &db.ChainTx{
+		TxHash: "H", Height: 10, BlockTime: bt, Code: 0,
+		Fee:     []db.DenomAmount{{Denom: "ulume", Amount: "500"}},
+		Events:  []db.TxEvent{{Type: "action_finalized"}},
+		Actions: []db.ChainTxAction{{ActionID: 7, TxType: "finalize"}},
+	}
+
+	resp := txDetailResponse(chain, indexed)
+	if len(resp.Actions) != 1 || resp.Actions[0].FlowPayee == nil || *resp.Actions[0].FlowPayee != payee {
+		t.Errorf("actions = %+v, want indexed flow", resp.Actions)
+	}
+	if resp.Code == nil || len(resp.Events) != 1 {
+		t.Errorf("code %v, events %+v", resp.Code, resp.Events)
+	}
+
+	resp = txDetailResponse(nil, indexed)
+	if resp.Code != nil || len(resp.Events) != 0 || len(resp.Fee) != 1 || resp.Fee[0].Amount != "500" || resp.Height != 10 {
+		t.Errorf("index-only response = %+v", resp)
+	}
+
+	resp = txDetailResponse(chain, nil)
+	if len(resp.Actions) != 1 || resp.Actions[0].ActionID != "7" {
+		t.Errorf("chain-only actions = %+v", resp.Actions)
+	}
+}
@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/util"
+	"lumescope/internal/webhooks"
+)
+
+// maxWebhookBodyBytes bounds admin request bodies.
+const maxWebhookBodyBytes = 64 << 10
+
+// WebhookSubscriptionDTO is a webhook subscription as returned by the admin API.
+// Secret is only populated in the create response.
+type WebhookSubscriptionDTO struct {
+	ID          int64             `json:"id"`
+	URL         string            `json:"url"`
+	Secret      string            `json:"secret,omitempty"`
+	EventTypes  []string          `json:"event_types"`
+	Filters     db.WebhookFilters `json:"filters"`
+	Active      bool              `json:"active"`
+	Description string            `json:"description,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+type WebhookSubscriptionsResponse struct {
+	Items         []WebhookSubscriptionDTO `json:"items"`
+	SchemaVersion string                   `json:"schema_version"`
+}
+
+// WebhookDeliveryDTO is one entry in a subscription's delivery log.
+type WebhookDeliveryDTO struct {
+	ID             int64           `json:"id"`
+	EventKey       string          `json:"event_key"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Items         []WebhookDeliveryDTO `json:"items"`
+	NextCursor    string               `json:"next_cursor,omitempty"`
+	SchemaVersion string               `json:"schema_version"`
+}
+
+type WebhookDeadLetterDTO struct {
+	ID         int64           `json:"id"`
+	DeliveryID int64           `json:"delivery_id"`
+	EventType  string          `json:"event_type"`
+	Attempts   int             `json:"attempts"`
+	LastError  string          `json:"last_error"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type WebhookDeadLettersResponse struct {
+	Items         []WebhookDeadLetterDTO `json:"items"`
+	SchemaVersion string                 `json:"schema_version"`
+}
+
+// webhookRequest is the create/update body. All fields are optional on update.
+type webhookRequest struct {
+	URL         *string            `json:"url"`
+	Secret      *string            `json:"secret"`
+	EventTypes  []string           `json:"event_types"`
+	Filters     *db.WebhookFilters `json:"filters"`
+	Active      *bool              `json:"active"`
+	Description *string            `json:"description"`
+}
+
+// AdminWebhooks handles /v1/admin/webhooks: GET lists subscriptions, POST creates one.
+func AdminWebhooks(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			subs, err := db.ListWebhookSubscriptions(r.Context(), pool, false)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch webhook subscriptions")
+				return
+			}
+			items := make([]WebhookSubscriptionDTO, 0, len(subs))
+			for _, s := range subs {
+				items = append(items, toWebhookSubscriptionDTO(s, false))
+			}
+			writeNoStoreJSON(w, http.StatusOK, WebhookSubscriptionsResponse{Items: items, SchemaVersion: "v1.0"})
+
+		case http.MethodPost:
+			var req webhookRequest
+			if err := decodeJSONBody(w, r, &req); err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid JSON body")
+				return
+			}
+			sub := db.WebhookSubscription{Active: true}
+			if req.URL == nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "url is required")
+				return
+			}
+			if len(req.EventTypes) == 0 {
+				util.WriteJSONError(w, http.StatusBadRequest, "event_types is required")
+				return
+			}
+			if msg := applyWebhookRequest(&sub, req); msg != "" {
+				util.WriteJSONError(w, http.StatusBadRequest, msg)
+				return
+			}
+			if sub.Secret == "" {
+				secret, err := webhooks.NewSecret()
+				if err != nil {
+					util.WriteJSONError(w, http.StatusInternalServerError, "failed to generate secret")
+					return
+				}
+				sub.Secret = secret
+			}
+			created, err := db.CreateWebhookSubscription(r.Context(), pool, sub)
+			if err != nil {
+				util.WriteJSONError(w, http.StatusInternalServerError, "failed to create webhook subscription")
+				return
+			}
+			writeNoStoreJSON(w, http.StatusCreated, toWebhookSubscriptionDTO(created, true))
+
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// AdminWebhook handles /v1/admin/webhooks/{id} (GET, PATCH, DELETE),
+// /v1/admin/webhooks/{id}/deliveries and /v1/admin/webhooks/{id}/dead-letters (GET).
+func AdminWebhook(pool *db.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/admin/webhooks/"), "/")
+		parts := strings.Split(rest, "/")
+		id, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || id <= 0 || len(parts) > 2 {
+			util.WriteJSONError(w, http.StatusNotFound, "not_found")
+			return
+		}
+
+		if len(parts) == 2 {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", "GET")
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			switch parts[1] {
+			case "deliveries":
+				listWebhookDeliveries(pool, id, w, r)
+			case "dead-letters":
+				listWebhookDeadLetters(pool, id, w, r)
+			default:
+				util.WriteJSONError(w, http.StatusNotFound, "not_found")
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			sub, err := db.GetWebhookSubscription(r.Context(), pool, id)
+			if err != nil {
+				writeWebhookLookupError(w, err)
+				return
+			}
+			writeNoStoreJSON(w, http.StatusOK, toWebhookSubscriptionDTO(sub, false))
+
+		case http.MethodPatch:
+			var req webhookRequest
+			if err := decodeJSONBody(w, r, &req); err != nil {
+				util.WriteJSONError(w, http.StatusBadRequest, "invalid JSON body")
+				return
+			}
+			sub, err := db.GetWebhookSubscription(r.Context(), pool, id)
+			if err != nil {
+				writeWebhookLookupError(w, err)
+				return
+			}
+			if msg := applyWebhookRequest(&sub, req); msg != "" {
+				util.WriteJSONError(w, http.StatusBadRequest, msg)
+				return
+			}
+			updated, err := db.UpdateWebhookSubscription(r.Context(), pool, sub)
+			if err != nil {
+				writeWebhookLookupError(w, err)
+				return
+			}
+			writeNoStoreJSON(w, http.StatusOK, toWebhookSubscriptionDTO(updated, false))
+
+		case http.MethodDelete:
+			if err := db.DeleteWebhookSubscription(r.Context(), pool, id); err != nil {
+				writeWebhookLookupError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.Header().Set("Allow", "GET, PATCH, DELETE")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func listWebhookDeliveries(pool *db.Pool, id int64, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := db.WebhookDeliveriesFilter{SubscriptionID: id, Limit: 50}
+	if val := query.Get("status"); val != "" {
+		switch val {
+		case db.DeliveryPending, db.DeliverySucceeded, db.DeliveryDead:
+		default:
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid status parameter: must be 'pending', 'succeeded' or 'dead'")
+			return
+		}
+		filter.Status = &val
+	}
+	if val := query.Get("limit"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil || parsed < 1 || parsed > 200 {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid limit parameter: must be between 1 and 200")
+			return
+		}
+		filter.Limit = parsed
+	}
+	if val := query.Get("cursor"); val != "" {
+		decoded, err := base64.StdEncoding.DecodeString(val)
+		var payload struct {
+			ID int64 `json:"id"`
+		}
+		if err != nil || json.Unmarshal(decoded, &payload) != nil || payload.ID <= 0 {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid cursor parameter: must be base64 encoded JSON with id")
+			return
+		}
+		filter.CursorID = &payload.ID
+	}
+	includePayload := query.Get("include_payload") == "true" || query.Get("include_payload") == "1"
+
+	if _, err := db.GetWebhookSubscription(r.Context(), pool, id); err != nil {
+		writeWebhookLookupError(w, err)
+		return
+	}
+	deliveries, hasMore, err := db.ListWebhookDeliveries(r.Context(), pool, filter)
+	if err != nil {
+		util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch webhook deliveries")
+		return
+	}
+
+	items := make([]WebhookDeliveryDTO, 0, len(deliveries))
+	for _, d := range deliveries {
+		dto := WebhookDeliveryDTO{
+			ID:             d.ID,
+			EventKey:       d.EventKey,
+			EventType:      d.EventType,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			LastStatusCode: d.LastStatusCode,
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt,
+			DeliveredAt:    d.DeliveredAt,
+		}
+		if d.Status == db.DeliveryPending {
+			next := d.NextAttemptAt
+			dto.NextAttemptAt = &next
+		}
+		if includePayload {
+			dto.Payload = json.RawMessage(d.Payload)
+		}
+		items = append(items, dto)
+	}
+	resp := WebhookDeliveriesResponse{Items: items, SchemaVersion: "v1.0"}
+	if hasMore && len(deliveries) > 0 {
+		buf, _ := json.Marshal(struct {
+			ID int64 `json:"id"`
+		}{ID: deliveries[len(deliveries)-1].ID})
+		resp.NextCursor = base64.StdEncoding.EncodeToString(buf)
+	}
+	writeNoStoreJSON(w, http.StatusOK, resp)
+}
+
+func listWebhookDeadLetters(pool *db.Pool, id int64, w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if val := r.URL.Query().Get("limit"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil || parsed < 1 || parsed > 500 {
+			util.WriteJSONError(w, http.StatusBadRequest, "invalid limit parameter: must be between 1 and 500")
+			return
+		}
+		limit = parsed
+	}
+	if _, err := db.GetWebhookSubscription(r.Context(), pool, id); err != nil {
+		writeWebhookLookupError(w, err)
+		return
+	}
+	letters, err := db.ListWebhookDeadLetters(r.Context(), pool, id, limit)
+	if err != nil {
+		util.WriteJSONError(w, http.StatusInternalServerError, "failed to fetch webhook dead letters")
+		return
+	}
+	items := make([]WebhookDeadLetterDTO, 0, len(letters))
+	for _, d := range letters {
+		items = append(items, WebhookDeadLetterDTO{
+			ID:         d.ID,
+			DeliveryID: d.DeliveryID,
+			EventType:  d.EventType,
+			Attempts:   d.Attempts,
+			LastError:  d.LastError,
+			CreatedAt:  d.CreatedAt,
+			Payload:    json.RawMessage(d.Payload),
+		})
+	}
+	writeNoStoreJSON(w, http.StatusOK, WebhookDeadLettersResponse{Items: items, SchemaVersion: "v1.0"})
+}
+
+// applyWebhookRequest copies the provided fields onto sub and validates them.
+// Returns a client-facing error message, or "" if the result is valid.
+func applyWebhookRequest(sub *db.WebhookSubscription, req webhookRequest) string {
+	if req.URL != nil {
+		u, err := url.Parse(*req.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "invalid url: must be an absolute http(s) URL"
+		}
+		sub.URL = *req.URL
+	}
+	if req.Secret != nil {
+		sub.Secret = *req.Secret
+	}
+	if req.EventTypes != nil {
+		if len(req.EventTypes) == 0 {
+			return "event_types must not be empty"
+		}
+		for _, t := range req.EventTypes {
+			if t != "*" && !webhooks.IsEventType(t) {
+				return "unknown event type: " + t + " (expected one of " + strings.Join(webhooks.EventTypes, ", ") + " or *)"
+			}
+		}
+		sub.EventTypes = req.EventTypes
+	}
+	if req.Filters != nil {
+		sub.Filters = *req.Filters
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	if req.Description != nil {
+		sub.Description = *req.Description
+	}
+	return ""
+}
+
+func toWebhookSubscriptionDTO(s db.WebhookSubscription, withSecret bool) WebhookSubscriptionDTO {
+	dto := WebhookSubscriptionDTO{
+		ID:          s.ID,
+		URL:         s.URL,
+		EventTypes:  s.EventTypes,
+		Filters:     s.Filters,
+		Active:      s.Active,
+		Description: s.Description,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	if withSecret {
+		dto.Secret = s.Secret
+	}
+	return dto
+}
+
+func writeWebhookLookupError(w http.ResponseWriter, err error) {
+	if errors.Is(err, db.ErrNotFound) {
+		util.WriteJSONError(w, http.StatusNotFound, "webhook subscription not found")
+		return
+	}
+	util.WriteJSONError(w, http.StatusInternalServerError, "webhook subscription lookup failed")
+}
+
+// decodeJSONBody decodes a size-limited JSON request body into v, rejecting unknown fields.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// writeNoStoreJSON writes an uncached JSON response; admin data must not be cached by proxies.
+func writeNoStoreJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
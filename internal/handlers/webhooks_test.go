@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// testPool connects to a fresh schema of the Postgres database named by
+// LUMESCOPE_TEST_DSN, dropped when the test ends. Without it the test is skipped.
+func testPool(t *testing.T) *db.Pool {
+	t.Helper()
+	dsn := os.Getenv("LUMESCOPE_TEST_DSN")
+	if dsn == "" {
+		t.Skip("LUMESCOPE_TEST_DSN not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	schema := fmt.Sprintf("lumescope_test_%d", time.Now().UnixNano())
+	pool, err := db.ConnectSchema(ctx, dsn, 4, schema)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := pool.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Logf("drop schema %s: %v", schema, err)
+		}
+		pool.Close()
+	})
+	if err := db.Bootstrap(ctx, pool); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	return pool
+}
+
+// serve runs one request against h and returns the recorder.
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+// TestAdminWebhooksRejects verifies invalid create bodies, paths and delivery log
+// parameters fail before the database is touched
+func TestAdminWebhooksRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		url     string
+		body    string
+		code    int
+		want    string
+	}{
+		{"invalid json", AdminWebhooks(nil), http.MethodPost, "/v1/admin/webhooks", `{"url":`, 400, "invalid JSON body"},
+		{"unknown field", AdminWebhooks(nil), http.MethodPost, "/v1/admin/webhooks", `{"url":"https://example.com","event_types":["*"],"extra":1}`, 400, "invalid JSON body"},
+		{"missing url", AdminWebhooks(nil), http.MethodPost, "/v1/admin/webhooks", `{"event_types":["*"]}`, 400, "url is required"},
+		{"missing event types", AdminWebhooks(nil), http.MethodPost, "/v1/admin/webhooks", `{"url":"https://example.com"}`, 400, "event_types is required"},
+		{"relative url", AdminWebhooks(nil), http.MethodPost, "/v1/admin/webhooks", `{"url":"/hook","event_types":["*"]}`, 400, "invalid url"},
+		{"ftp url", AdminWebhooks(nil), http.MethodPost, "/v1/admin/webhooks", `{"url":"ftp://example.com","event_types":["*"]}`, 400, "invalid url"},
+		{"unknown event type", AdminWebhooks(nil), http.MethodPost, "/v1/admin/webhooks", `{"url":"https://example.com","event_types":["action.deleted"]}`, 400, "unknown event type: action.deleted"},
+		{"collection method", AdminWebhooks(nil), http.MethodPut, "/v1/admin/webhooks", ``, 405, ""},
+		{"bad id", AdminWebhook(nil), http.MethodGet, "/v1/admin/webhooks/abc", ``, 404, "not_found"},
+		{"zero id", AdminWebhook(nil), http.MethodGet, "/v1/admin/webhooks/0", ``, 404, "not_found"},
+		{"unknown subresource", AdminWebhook(nil), http.MethodGet, "/v1/admin/webhooks/1/attempts", ``, 404, "not_found"},
+		{"nested path", AdminWebhook(nil), http.MethodGet, "/v1/admin/webhooks/1/deliveries/2", ``, 404, "not_found"},
+		{"subresource method", AdminWebhook(nil), http.MethodPost, "/v1/admin/webhooks/1/deliveries", ``, 405, ""},
+		{"item method", AdminWebhook(nil), http.MethodPost, "/v1/admin/webhooks/1", ``, 405, ""},
+		{"patch invalid json", AdminWebhook(nil), http.MethodPatch, "/v1/admin/webhooks/1", `[]`, 400, "invalid JSON body"},
+		{"delivery status", AdminWebhook(nil), http.MethodGet, "/v1/admin/webhooks/1/deliveries?status=failed", ``, 400, "invalid status parameter"},
+		{"delivery limit", AdminWebhook(nil), http.MethodGet, "/v1/admin/webhooks/1/deliveries?limit=201", ``, 400, "invalid limit parameter"},
+		{"delivery cursor", AdminWebhook(nil), http.MethodGet, "/v1/admin/webhooks/1/deliveries?cursor=eyJpZCI6MH0=", ``, 400, "invalid cursor parameter"},
+		{"dead letter limit", AdminWebhook(nil), http.MethodGet, "/v1/admin/webhooks/1/dead-letters?limit=0", ``, 400, "invalid limit parameter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.handler, tt.method, tt.url, tt.body)
+			if rec.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("body = %s, want %q", rec.Body, tt.want)
+			}
+			if tt.code == http.StatusMethodNotAllowed && rec.Header().Get("Allow") == "" {
+				t.Error("405 without an Allow header")
+			}
+		})
+	}
+}
+
+// TestAdminWebhooksCRUD verifies subscriptions are created, listed, updated and
+// deleted, that secrets are only returned on create and that the delivery log pages
+func TestAdminWebhooksCRUD(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	collection, item := AdminWebhooks(pool), AdminWebhook(pool)
+
+	rec := serve(collection, http.MethodPost, "/v1/admin/webhooks",
+		`{"url":"https://example.com/hook","event_types":["action.created"],"filters":{"creator":"lumera1creator"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Cache-Control = %q", rec.Header().Get("Cache-Control"))
+	}
+	var created WebhookSubscriptionDTO
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 || created.Secret == "" || !created.Active || created.Filters.Creator != "lumera1creator" {
+		t.Fatalf("created = %+v", created)
+	}
+	path := fmt.Sprintf("/v1/admin/webhooks/%d", created.ID)
+
+	rec = serve(collection, http.MethodGet, "/v1/admin/webhooks", "")
+	var list WebhookSubscriptionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("list = %d %s, %v", rec.Code, rec.Body, err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != created.ID || list.Items[0].Secret != "" {
+		t.Errorf("list items = %+v", list.Items)
+	}
+
+	// An invalid update changes nothing
+	if rec = serve(item, http.MethodPatch, path, `{"event_types":[]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty event_types status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec = serve(item, http.MethodPatch, path, `{"url":"not a url"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid url status = %d: %s", rec.Code, rec.Body)
+	}
+	rec = serve(item, http.MethodPatch, path, `{"active":false,"event_types":["*"]}`)
+	var updated WebhookSubscriptionDTO
+	if err := json.Unmarshal(rec.Body.Bytes(), &updated); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("patch = %d %s, %v", rec.Code, rec.Body, err)
+	}
+	if updated.Active || updated.URL != "https://example.com/hook" || len(updated.EventTypes) != 1 || updated.EventTypes[0] != "*" || updated.Secret != "" {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := db.EnqueueWebhookDelivery(ctx, pool, created.ID, fmt.Sprintf("action:%d", i), "action.created", []byte(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var seen []int64
+	next := path + "/deliveries?limit=2"
+	for next != "" {
+		rec = serve(item, http.MethodGet, next, "")
+		var page WebhookDeliveriesResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("deliveries = %d %s, %v", rec.Code, rec.Body, err)
+		}
+		for _, d := range page.Items {
+			if d.Status != db.DeliveryPending || d.NextAttemptAt == nil || d.Payload != nil {
+				t.Errorf("delivery = %+v", d)
+			}
+			seen = append(seen, d.ID)
+		}
+		next = ""
+		if page.NextCursor != "" {
+			next = path + "/deliveries?limit=2&cursor=" + page.NextCursor
+		}
+	}
+	if len(seen) != 3 || seen[0] < seen[1] || seen[1] < seen[2] {
+		t.Errorf("paged delivery ids = %v, want 3 newest first", seen)
+	}
+	if rec = serve(item, http.MethodGet, path+"/deliveries?status=succeeded", ""); !strings.Contains(rec.Body.String(), `"items":[]`) {
+		t.Errorf("succeeded deliveries = %s", rec.Body)
+	}
+
+	if rec = serve(item, http.MethodDelete, path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d", rec.Code)
+	}
+	for _, p := range []string{path, path + "/deliveries", path + "/dead-letters"} {
+		if rec = serve(item, http.MethodGet, p, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("%s after delete = %d", p, rec.Code)
+		}
+	}
+}
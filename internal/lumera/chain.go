@@ -0,0 +1,40 @@
+package lumera
+
+import (
+	"context"
+
+	"lumescope/internal/db"
+)
+
+// Chain is the chain data source of the background loops and the tx lookup. Client
+// reads it from the LCD REST API and GRPCClient from the node's gRPC endpoint; both
+// return the same types.
+type Chain interface {
+	GetValidators(ctx context.Context, nextKey string, limit int) ([]Validator, string, error)
+	GetSupernodes(ctx context.Context, nextKey string, limit int) ([]Supernode, string, error)
+	GetActions(ctx context.Context, actionType, actionState, nextKey string, limit int) ([]Action, string, error)
+	GetAction(ctx context.Context, actionID string) (*Action, error)
+	GetActionModuleAccount(ctx context.Context) (string, error)
+	GetLatestHeight(ctx context.Context) (int64, error)
+	GetActionTransactions(ctx context.Context, action *db.Action) ([]*db.ActionTransaction, error)
+	GetActionTransactionsInRange(ctx context.Context, minHeight, maxHeight int64) ([]*db.ActionTransaction, error)
+	GetTx(ctx context.Context, hash string) (*db.ChainTx, error)
+	GetTxActionTransactions(ctx context.Context, hash string) ([]*db.ActionTransaction, error)
+}
+
+var (
+	_ Chain = (*Client)(nil)
+	_ Chain = (*GRPCClient)(nil)
+)
+
+// txBackend is the transport under the transaction decoding shared by both clients.
+// Results come in the LCD JSON shapes, which the gRPC client converts to.
+type txBackend interface {
+	// searchTxs pages through the transactions matching a tx_search query oldest
+	// first, reading at most maxPages pages (0 for no limit). On error, the
+	// transactions read so far are returned with it.
+	searchTxs(ctx context.Context, query string, maxPages int) (*TxSearchResponse, error)
+	// fetchTx fetches a transaction by hash, or returns db.ErrNotFound.
+	fetchTx(ctx context.Context, hash string) (*GetTxResponse, error)
+	GetActionModuleAccount(ctx context.Context) (string, error)
+}
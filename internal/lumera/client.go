@@ -10,28 +10,49 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"lumescope/internal/db"
 )
 
-// Client is a minimal Lumera/Cosmos SDK REST client using stdlib only.
+// Client is a minimal Lumera/Cosmos SDK REST client using stdlib only. Requests are
+// spread over one or more LCD endpoints by health and latency (see endpoints.go).
 type Client struct {
-	BaseURL           string
-	HTTP              *http.Client
-	UserAgent         string
-	actionModuleAddr  string // cached action module address
-	moduleAddrFetched bool   // whether we've fetched the module address
+	HTTP      *http.Client
+	UserAgent string
+	// MaxRetries is how many times a request failing with a transport error, timeout
+	// or 5xx/429 is retried, on another endpoint when one is up. RetryBackoff is the
+	// base of the jittered exponential delay between attempts.
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	endpoints         []*endpoint
+	mu                sync.Mutex // guards the module address cache
+	actionModuleAddr  string     // cached action module address
+	moduleAddrFetched bool       // whether we've fetched the module address
 }
 
 func NewClient(baseURL string, timeout time.Duration) *Client {
-	return &Client{
-		BaseURL:   strings.TrimRight(baseURL, "/"),
-		HTTP:      &http.Client{Timeout: timeout},
-		UserAgent: "lumescope/preview",
+	return NewClientEndpoints([]string{baseURL}, timeout)
+}
+
+// NewClientEndpoints returns a client balancing requests over several LCD base URLs
+// of the same chain.
+func NewClientEndpoints(baseURLs []string, timeout time.Duration) *Client {
+	c := &Client{
+		HTTP:         &http.Client{Timeout: timeout},
+		UserAgent:    "lumescope/preview",
+		MaxRetries:   2,
+		RetryBackoff: 250 * time.Millisecond,
+	}
+	for _, u := range baseURLs {
+		c.endpoints = append(c.endpoints, newEndpoint(u))
 	}
+	return c
 }
 
 // ModuleAccountResponse represents the response from /cosmos/auth/v1beta1/module_accounts/{name}
@@ -50,9 +71,13 @@ type ModuleAccountResponse struct {
 // The address is cached after the first successful fetch.
 func (c *Client) GetActionModuleAccount(ctx context.Context) (string, error) {
 	// Return cached value if available
+	c.mu.Lock()
 	if c.moduleAddrFetched && c.actionModuleAddr != "" {
-		return c.actionModuleAddr, nil
+		addr := c.actionModuleAddr
+		c.mu.Unlock()
+		return addr, nil
 	}
+	c.mu.Unlock()
 
 	// Fetch from API
 	var resp ModuleAccountResponse
@@ -66,21 +91,67 @@ func (c *Client) GetActionModuleAccount(ctx context.Context) (string, error) {
 	}
 
 	// Cache the result
-	c.actionModuleAddr = resp.Account.BaseAccount.Address
+	addr := resp.Account.BaseAccount.Address
+	c.mu.Lock()
+	c.actionModuleAddr = addr
 	c.moduleAddrFetched = true
+	c.mu.Unlock()
 
-	log.Printf("Fetched and cached action module address: %s", c.actionModuleAddr)
-	return c.actionModuleAddr, nil
+	log.Printf("Fetched and cached action module address: %s", addr)
+	return addr, nil
 }
 
 // SetActionModuleAccount sets the action module address (useful for testing).
 func (c *Client) SetActionModuleAccount(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.actionModuleAddr = addr
 	c.moduleAddrFetched = true
 }
 
+// doJSON sends a request to an LCD endpoint picked by pick and decodes the JSON
+// response into v. Failures that retryable blames on the endpoint take it out of
+// rotation and are retried up to MaxRetries times, on endpoints not tried yet while
+// any remain; other errors, such as 404s, are returned at once.
 func (c *Client) doJSON(ctx context.Context, method, path string, q url.Values, v any) error {
-	u := c.BaseURL + path
+	if len(c.endpoints) == 0 {
+		return ErrInvalidBaseURL
+	}
+	tried := make(map[*endpoint]bool, len(c.endpoints))
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && !sleepCtx(ctx, c.retryBackoff(attempt)) {
+			return ctx.Err()
+		}
+		ep := c.pick(tried, time.Now())
+		if ep == nil {
+			clear(tried)
+			ep = c.pick(tried, time.Now())
+		}
+		tried[ep] = true
+
+		start := time.Now()
+		err := c.doJSONAt(ctx, ep.url, method, path, q, v)
+		switch {
+		case err == nil:
+			ep.succeed(time.Since(start), time.Now())
+			return nil
+		case ctx.Err() != nil:
+			return err
+		case !retryable(err):
+			ep.succeed(time.Since(start), time.Now())
+			return err
+		}
+		ep.fail(err, time.Now())
+		if attempt >= c.MaxRetries {
+			return err
+		}
+		log.Printf("lcd %s %s: %v; retrying", method, path, err)
+	}
+}
+
+// doJSONAt performs a single request against the LCD at baseURL.
+func (c *Client) doJSONAt(ctx context.Context, baseURL, method, path string, q url.Values, v any) error {
+	u := baseURL + path
 	if len(q) > 0 {
 		u += "?" + q.Encode()
 	}
@@ -92,19 +163,40 @@ func (c *Client) doJSON(ctx context.Context, method, path string, q url.Values,
 	if c.UserAgent != "" {
 		req.Header.Set("User-Agent", c.UserAgent)
 	}
+	start := time.Now()
 	resp, err := c.HTTP.Do(req)
 	if err != nil {
+		observeLCD(path, "error", start)
 		return err
 	}
 	defer resp.Body.Close()
+	observeLCD(path, strconv.Itoa(resp.StatusCode), start)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return fmt.Errorf("http %s %s: %d: %s", method, u, resp.StatusCode, strings.TrimSpace(string(b)))
+		return &HTTPError{Method: method, URL: u, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
 	}
 	dec := json.NewDecoder(resp.Body)
 	return dec.Decode(v)
 }
 
+// HTTPError is returned by doJSON when the LCD answers with a non-2xx status.
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http %s %s: %d: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// isNotFound reports whether err is an LCD 404 response.
+func isNotFound(err error) bool {
+	var he *HTTPError
+	return errors.As(err, &he) && he.StatusCode == http.StatusNotFound
+}
+
 // Validators
 
 type ValidatorsResponse struct {
@@ -306,6 +398,27 @@ func (c *Client) GetActions(ctx context.Context, actionType, actionState, nextKe
 	return out.Actions, newNextKey, nil
 }
 
+type GetActionResponse struct {
+	Action *Action `json:"action"`
+}
+
+// GetAction fetches a single action by its on-chain ID.
+// Returns ErrNotFound if the chain has no action with that ID.
+func (c *Client) GetAction(ctx context.Context, actionID string) (*Action, error) {
+	var out GetActionResponse
+	err := c.doJSON(ctx, http.MethodGet, "/LumeraProtocol/lumera/action/v1/get_action/"+url.PathEscape(actionID), nil, &out)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if out.Action == nil || out.Action.ActionID == "" {
+		return nil, ErrNotFound
+	}
+	return out.Action, nil
+}
+
 // Shared
 
 type Pagination struct {
@@ -320,6 +433,9 @@ func IsValidIP(s string) bool { return net.ParseIP(s) != nil }
 
 var ErrInvalidBaseURL = errors.New("invalid base URL")
 
+// ErrNotFound is returned when the requested chain object does not exist.
+var ErrNotFound = errors.New("not found")
+
 // Transaction search types for Cosmos SDK tx_search endpoint
 
 // TxSearchResponse represents the response from /cosmos/tx/v1beta1/txs
@@ -327,6 +443,21 @@ type TxSearchResponse struct {
 	Txs         []TxResponse `json:"txs"`
 	TxResponses []TxResult   `json:"tx_responses"`
 	Pagination  *Pagination  `json:"pagination"`
+	Total       string       `json:"total"`
+}
+
+// total returns the number of matching transactions the node reported, or 0 if it
+// didn't say.
+func (r *TxSearchResponse) total() int {
+	if n, err := strconv.Atoi(r.Total); err == nil {
+		return n
+	}
+	if r.Pagination != nil {
+		if n, err := strconv.Atoi(r.Pagination.Total); err == nil {
+			return n
+		}
+	}
+	return 0
 }
 
 // TxResponse contains the raw transaction
@@ -338,6 +469,7 @@ type TxResponse struct {
 // TxBody contains transaction messages
 type TxBody struct {
 	Messages []json.RawMessage `json:"messages"`
+	Memo     string            `json:"memo"`
 }
 
 // AuthInfo contains fee information
@@ -367,6 +499,7 @@ type Coin struct {
 type TxResult struct {
 	TxHash    string    `json:"txhash"`
 	Height    string    `json:"height"`
+	Code      uint32    `json:"code"`
 	Timestamp string    `json:"timestamp"`
 	GasWanted string    `json:"gas_wanted"`
 	GasUsed   string    `json:"gas_used"`
@@ -397,10 +530,15 @@ type ABCILog struct {
 // It queries for register, finalize, and approve transactions based on action events.
 // Returns ActionTransaction records ready to be persisted.
 func (c *Client) GetActionTransactions(ctx context.Context, action *db.Action) ([]*db.ActionTransaction, error) {
+	return actionTransactions(ctx, c, action)
+}
+
+// actionTransactions implements GetActionTransactions over either client's transport.
+func actionTransactions(ctx context.Context, b txBackend, action *db.Action) ([]*db.ActionTransaction, error) {
 	var results []*db.ActionTransaction
 
 	// Fetch module account address for proper transfer flow parsing
-	moduleAddr, err := c.GetActionModuleAccount(ctx)
+	moduleAddr, err := b.GetActionModuleAccount(ctx)
 	if err != nil {
 		// Log but continue - we can still parse with fallbacks
 		log.Printf("GetActionTransactions: failed to get module account address: %v", err)
@@ -418,7 +556,7 @@ func (c *Client) GetActionTransactions(ctx context.Context, action *db.Action) (
 
 	for _, q := range queries {
 		// Convert uint64 ActionID to string for API query
-		txs, err := c.searchTxsByEvent(ctx, q.eventType, strconv.FormatUint(action.ActionID, 10))
+		txs, err := searchTxsByEvent(ctx, b, q.eventType, strconv.FormatUint(action.ActionID, 10))
 		if err != nil {
 			// Log but continue with other queries
 			continue
@@ -430,9 +568,19 @@ func (c *Client) GetActionTransactions(ctx context.Context, action *db.Action) (
 				tx = &txs.Txs[i]
 			}
 
-			actionTx := c.parseTxResult(action, q.txType, txResult, tx, moduleAddr)
-			if actionTx != nil {
-				results = append(results, actionTx)
+			// One row per message carrying the event, with the flow of that message
+			events := txMsgEvents(txResult)
+			flows := parseTxFlows(txResult)
+			eventType := strings.TrimSuffix(q.eventType, ".action_id")
+			for _, msgIndex := range actionMessageIndexes(events, eventType, strconv.FormatUint(action.ActionID, 10)) {
+				msgResult := txResult
+				msgResult.Events, msgResult.Logs = messageEvents(events, msgIndex), nil
+				actionTx := parseTxResult(action, q.txType, msgResult, tx, moduleAddr)
+				if actionTx != nil {
+					actionTx.MsgIndex = msgIndex
+					actionTx.Flows = flows
+					results = append(results, actionTx)
+				}
 			}
 		}
 	}
@@ -440,36 +588,366 @@ func (c *Client) GetActionTransactions(ctx context.Context, action *db.Action) (
 	return results, nil
 }
 
+// Tx search paging: results are fetched txSearchPageSize at a time, up to
+// txSearchMaxPages pages per action event.
+const (
+	txSearchPageSize = 50
+	txSearchMaxPages = 20
+)
+
 // searchTxsByEvent queries the Cosmos SDK tx_search endpoint for transactions
-// matching a specific event type and value.
-// It implements a fallback strategy: first tries without quotes, then with quotes if 0 results.
-func (c *Client) searchTxsByEvent(ctx context.Context, eventType, value string) (*TxSearchResponse, error) {
-	// First attempt: without quotes around value
+// matching a specific event type and value, paging through every result oldest first.
+// If a later page fails, the transactions of the earlier pages are returned.
+func searchTxsByEvent(ctx context.Context, b txBackend, eventType, value string) (*TxSearchResponse, error) {
 	// Format: query=action_registered.action_id=ACTION_ID
-	q := url.Values{}
-	q.Set("query", fmt.Sprintf("%s=%s", eventType, value))
-	q.Set("pagination.limit", "10")
+	all, err := b.searchTxs(ctx, fmt.Sprintf("%s=%s", eventType, value), txSearchMaxPages)
+	if err != nil && len(all.TxResponses) == 0 {
+		return nil, err
+	}
+	if len(all.TxResponses) > 0 {
+		log.Printf("searchTxsByEvent: got %d tx_responses for event %s=%s", len(all.TxResponses), eventType, value)
+	}
+	return all, nil
+}
+
+// searchTxs pages through the transactions matching a tx_search query oldest first,
+// reading at most maxPages pages (0 for no limit). Pages are requested with both
+// page/limit and pagination.offset/limit so either SDK generation returns the same
+// window; paging stops at a short page, at the reported total, or when a page brings
+// no new transactions. On error, the transactions read so far are returned with it.
+func (c *Client) searchTxs(ctx context.Context, query string, maxPages int) (*TxSearchResponse, error) {
+	all := &TxSearchResponse{}
+	seen := make(map[string]bool)
+	for page := 1; maxPages == 0 || page <= maxPages; page++ {
+		q := url.Values{}
+		q.Set("query", query)
+		q.Set("order_by", "ORDER_BY_ASC")
+		q.Set("page", strconv.Itoa(page))
+		q.Set("limit", strconv.Itoa(txSearchPageSize))
+		q.Set("pagination.offset", strconv.Itoa((page-1)*txSearchPageSize))
+		q.Set("pagination.limit", strconv.Itoa(txSearchPageSize))
+
+		fullURL := "/cosmos/tx/v1beta1/txs?" + q.Encode()
+		log.Printf("searchTxs: querying %s", fullURL)
+
+		var out TxSearchResponse
+		if err := c.doJSON(ctx, http.MethodGet, "/cosmos/tx/v1beta1/txs", q, &out); err != nil {
+			log.Printf("searchTxs: error querying %s: %v", fullURL, err)
+			return all, err
+		}
+
+		added := 0
+		for i, res := range out.TxResponses {
+			if seen[res.TxHash] {
+				continue
+			}
+			seen[res.TxHash] = true
+			added++
+			all.TxResponses = append(all.TxResponses, res)
+			var tx TxResponse
+			if i < len(out.Txs) {
+				tx = out.Txs[i]
+			}
+			all.Txs = append(all.Txs, tx)
+		}
+
+		total := out.total()
+		if added == 0 || len(out.TxResponses) < txSearchPageSize || (total > 0 && len(all.TxResponses) >= total) {
+			break
+		}
+	}
+	return all, nil
+}
+
+// LatestBlockResponse represents the response from /cosmos/base/tendermint/v1beta1/blocks/latest.
+// SDK 0.47+ returns the header under sdk_block as well as the deprecated block.
+type LatestBlockResponse struct {
+	Block    *BlockHeaderWrapper `json:"block"`
+	SDKBlock *BlockHeaderWrapper `json:"sdk_block"`
+}
+
+// BlockHeaderWrapper holds the header of a block.
+type BlockHeaderWrapper struct {
+	Header struct {
+		Height string `json:"height"`
+	} `json:"header"`
+}
+
+// GetLatestHeight returns the height of the latest block.
+func (c *Client) GetLatestHeight(ctx context.Context) (int64, error) {
+	var out LatestBlockResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/cosmos/base/tendermint/v1beta1/blocks/latest", nil, &out); err != nil {
+		return 0, err
+	}
+	for _, b := range []*BlockHeaderWrapper{out.SDKBlock, out.Block} {
+		if b == nil {
+			continue
+		}
+		if h, err := strconv.ParseInt(b.Header.Height, 10, 64); err == nil && h > 0 {
+			return h, nil
+		}
+	}
+	return 0, errors.New("latest block has no height")
+}
+
+// GetActionTransactionsInRange returns the action lifecycle transactions included in
+// blocks minHeight to maxHeight, one per message carrying a register, finalize or
+// approve event, ordered by height. Each lifecycle event is searched once for the whole
+// range instead of once per action. Unlike GetActionTransactions it fails if any page
+// can't be read, so the caller can retry the range.
+func (c *Client) GetActionTransactionsInRange(ctx context.Context, minHeight, maxHeight int64) ([]*db.ActionTransaction, error) {
+	return actionTransactionsInRange(ctx, c, minHeight, maxHeight)
+}
+
+// actionTransactionsInRange implements GetActionTransactionsInRange over either
+// client's transport.
+func actionTransactionsInRange(ctx context.Context, b txBackend, minHeight, maxHeight int64) ([]*db.ActionTransaction, error) {
+	moduleAddr, err := b.GetActionModuleAccount(ctx)
+	if err != nil {
+		// Log but continue - we can still parse with fallbacks
+		log.Printf("GetActionTransactionsInRange: failed to get module account address: %v", err)
+	}
+
+	var results []*db.ActionTransaction
+	seen := make(map[string]bool) // a tx can carry several lifecycle events
+	for _, eventType := range []string{"action_registered", "action_finalized", "action_approved"} {
+		query := fmt.Sprintf("%s.action_id EXISTS AND tx.height>=%d AND tx.height<=%d", eventType, minHeight, maxHeight)
+		txs, err := b.searchTxs(ctx, query, 0)
+		if err != nil {
+			return nil, fmt.Errorf("search %s in heights %d-%d: %w", eventType, minHeight, maxHeight, err)
+		}
+		for i, txResult := range txs.TxResponses {
+			if seen[txResult.TxHash] {
+				continue
+			}
+			seen[txResult.TxHash] = true
+			var tx *TxResponse
+			if i < len(txs.Txs) {
+				tx = &txs.Txs[i]
+			}
+			results = append(results, txActionTransactions(txResult, tx, moduleAddr)...)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Height != results[j].Height {
+			return results[i].Height < results[j].Height
+		}
+		return results[i].MsgIndex < results[j].MsgIndex
+	})
+	return results, nil
+}
+
+// txActionTransactions returns an ActionTransaction for every action lifecycle event
+// of a transaction, with the transfer flow of the event's message and the flows of the
+// whole transaction. The actions may not be indexed yet, so the transaction signer
+// stands in for the creator, as in GetTx.
+func txActionTransactions(txResult TxResult, tx *TxResponse, moduleAddr string) []*db.ActionTransaction {
+	if txResult.Code != 0 {
+		return nil
+	}
+	events := txMsgEvents(txResult)
+	flows := parseTxFlows(txResult)
+	signer := extractTxSigner(tx)
+
+	var out []*db.ActionTransaction
+	seen := make(map[string]bool)
+	for _, e := range events {
+		txType, ok := lifecycleEvents[e.Type]
+		if !ok {
+			continue
+		}
+		actionID, err := strconv.ParseUint(eventAttribute(e.Event, "action_id"), 10, 64)
+		if err != nil {
+			continue
+		}
+		msgIndex := 0
+		if e.msgIndex != nil {
+			msgIndex = *e.msgIndex
+		}
+		key := fmt.Sprintf("%d/%s/%d", actionID, txType, msgIndex)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		msgResult := txResult
+		msgResult.Events, msgResult.Logs = messageEvents(events, msgIndex), nil
+		actionTx := parseTxResult(&db.Action{ActionID: actionID, Creator: signer}, txType, msgResult, tx, moduleAddr)
+		actionTx.MsgIndex = msgIndex
+		actionTx.Flows = flows
+		out = append(out, actionTx)
+	}
+	return out
+}
+
+// GetTxResponse represents the response from /cosmos/tx/v1beta1/txs/{hash}
+type GetTxResponse struct {
+	Tx         *TxResponse `json:"tx"`
+	TxResponse *TxResult   `json:"tx_response"`
+}
 
-	fullURL := c.BaseURL + "/cosmos/tx/v1beta1/txs?" + q.Encode()
-	log.Printf("searchTxsByEvent: querying %s", fullURL)
+// lifecycleEvents maps action lifecycle event types to transaction types.
+var lifecycleEvents = map[string]string{
+	"action_registered": "register",
+	"action_finalized":  "finalize",
+	"action_approved":   "approve",
+}
+
+// GetTx fetches a transaction by hash and decodes its fee, messages, events and the
+// action lifecycle messages it carries. Returns db.ErrNotFound if the chain has no
+// transaction with that hash.
+func (c *Client) GetTx(ctx context.Context, hash string) (*db.ChainTx, error) {
+	return getTx(ctx, c, hash)
+}
 
-	var out TxSearchResponse
-	err := c.doJSON(ctx, http.MethodGet, "/cosmos/tx/v1beta1/txs", q, &out)
+// getTx implements GetTx over either client's transport.
+func getTx(ctx context.Context, b txBackend, hash string) (*db.ChainTx, error) {
+	out, err := b.fetchTx(ctx, hash)
 	if err != nil {
-		log.Printf("searchTxsByEvent: error querying %s: %v", fullURL, err)
 		return nil, err
 	}
+	res := *out.TxResponse
+
+	height, _ := strconv.ParseInt(res.Height, 10, 64)
+	gasWanted, _ := strconv.ParseInt(res.GasWanted, 10, 64)
+	gasUsed, _ := strconv.ParseInt(res.GasUsed, 10, 64)
+	blockTime, _ := time.Parse(time.RFC3339, res.Timestamp)
+
+	chainTx := &db.ChainTx{
+		TxHash:    res.TxHash,
+		Height:    height,
+		BlockTime: blockTime,
+		Code:      res.Code,
+		GasWanted: &gasWanted,
+		GasUsed:   &gasUsed,
+		Signer:    extractTxSigner(out.Tx),
+	}
+	if out.Tx != nil {
+		chainTx.Memo = out.Tx.Body.Memo
+		for _, coin := range out.Tx.AuthInfo.Fee.Amount {
+			chainTx.Fee = append(chainTx.Fee, db.DenomAmount{Denom: coin.Denom, Amount: coin.Amount})
+		}
+		for _, msg := range out.Tx.Body.Messages {
+			var m struct {
+				Type string `json:"@type"`
+			}
+			if err := json.Unmarshal(msg, &m); err == nil && m.Type != "" {
+				chainTx.MessageTypes = append(chainTx.MessageTypes, m.Type)
+			}
+		}
+	}
+
+	events := txMsgEvents(res)
+	for _, e := range events {
+		ev := db.TxEvent{Type: e.Type, Attributes: make([]db.TxAttribute, 0, len(e.Attributes))}
+		for _, a := range e.Attributes {
+			ev.Attributes = append(ev.Attributes, db.TxAttribute{Key: a.Key, Value: a.Value})
+		}
+		chainTx.Events = append(chainTx.Events, ev)
+	}
 
-	// If we got results, return them
-	if len(out.TxResponses) > 0 {
-		log.Printf("searchTxsByEvent: got %d tx_responses for event %s=%s", len(out.TxResponses), eventType, value)
+	chainTx.Flows = parseTxFlows(res)
+	if res.Code == 0 {
+		chainTx.Actions = txLifecycleActions(ctx, b, events, chainTx.Signer, out.Tx)
 	}
+	return chainTx, nil
+}
+
+// GetTxActionTransactions fetches a transaction by hash and returns an ActionTransaction
+// for every action lifecycle message it carries, as GetActionTransactionsInRange does.
+// Returns db.ErrNotFound if the chain has no transaction with that hash.
+func (c *Client) GetTxActionTransactions(ctx context.Context, hash string) ([]*db.ActionTransaction, error) {
+	return txActionTransactionsByHash(ctx, c, hash)
+}
+
+// txActionTransactionsByHash implements GetTxActionTransactions over either client's
+// transport.
+func txActionTransactionsByHash(ctx context.Context, b txBackend, hash string) ([]*db.ActionTransaction, error) {
+	out, err := b.fetchTx(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	moduleAddr, err := b.GetActionModuleAccount(ctx)
+	if err != nil {
+		// Log but continue - we can still parse with fallbacks
+		log.Printf("GetTxActionTransactions: failed to get module account address: %v", err)
+	}
+	return txActionTransactions(*out.TxResponse, out.Tx, moduleAddr), nil
+}
 
+// fetchTx fetches a transaction by hash, or returns db.ErrNotFound.
+func (c *Client) fetchTx(ctx context.Context, hash string) (*GetTxResponse, error) {
+	var out GetTxResponse
+	err := c.doJSON(ctx, http.MethodGet, "/cosmos/tx/v1beta1/txs/"+url.PathEscape(hash), nil, &out)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, db.ErrNotFound
+		}
+		return nil, err
+	}
+	if out.TxResponse == nil || out.TxResponse.TxHash == "" {
+		return nil, db.ErrNotFound
+	}
 	return &out, nil
 }
 
+// txLifecycleActions finds the action lifecycle events in a transaction and resolves the
+// transfer flow of each from the transfer events of the same message.
+func txLifecycleActions(ctx context.Context, b txBackend, events []msgEvent, signer string, tx *TxResponse) []db.ChainTxAction {
+	var (
+		actions    []db.ChainTxAction
+		moduleAddr string
+		fetched    bool
+	)
+	for _, e := range events {
+		txType, ok := lifecycleEvents[e.Type]
+		if !ok {
+			continue
+		}
+		actionID, err := strconv.ParseUint(eventAttribute(e.Event, "action_id"), 10, 64)
+		if err != nil {
+			continue
+		}
+		if !fetched {
+			fetched = true
+			if moduleAddr, err = b.GetActionModuleAccount(ctx); err != nil {
+				log.Printf("GetTx: failed to get module account address: %v", err)
+			}
+		}
+
+		// Restrict transfers to the event's message
+		msgIndex := 0
+		if e.msgIndex != nil {
+			msgIndex = *e.msgIndex
+		}
+		action := &db.Action{ActionID: actionID, Creator: signer}
+		at := parseTxResult(action, txType, TxResult{Events: messageEvents(events, msgIndex)}, tx, moduleAddr)
+		actions = append(actions, db.ChainTxAction{
+			ActionID:         actionID,
+			TxType:           txType,
+			MsgIndex:         msgIndex,
+			ActionPrice:      at.ActionPrice,
+			ActionPriceDenom: at.ActionPriceDenom,
+			FlowPayer:        at.FlowPayer,
+			FlowPayee:        at.FlowPayee,
+		})
+	}
+	return actions
+}
+
+// eventAttribute returns the value of the first attribute of e named key, or "".
+func eventAttribute(e Event, key string) string {
+	for _, a := range e.Attributes {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return ""
+}
+
 // parseTxResult extracts transaction details and flow information from a transaction result.
-func (c *Client) parseTxResult(action *db.Action, txType string, txResult TxResult, tx *TxResponse, moduleAddr string) *db.ActionTransaction {
+func parseTxResult(action *db.Action, txType string, txResult TxResult, tx *TxResponse, moduleAddr string) *db.ActionTransaction {
 	height, _ := strconv.ParseInt(txResult.Height, 10, 64)
 	gasWanted, _ := strconv.ParseInt(txResult.GasWanted, 10, 64)
 	gasUsed, _ := strconv.ParseInt(txResult.GasUsed, 10, 64)
@@ -498,7 +976,7 @@ func (c *Client) parseTxResult(action *db.Action, txType string, txResult TxResu
 	txSigner := extractTxSigner(tx)
 
 	// Extract flow information from transfer events
-	flow := c.extractTransferFlow(action, txType, txResult, moduleAddr, txSigner)
+	flow := extractTransferFlow(action, txType, txResult, moduleAddr, txSigner)
 	if flow != nil {
 		actionTx.ActionPrice = flow.Amount
 		actionTx.ActionPriceDenom = flow.Denom
@@ -547,7 +1025,7 @@ type TransferFlow struct {
 // For 'register': finds transfer where recipient == Action Module Address (creator pays to module)
 // For 'finalize': finds transfer where sender == Action Module Address AND recipient == tx signer
 // For 'approve': similar to finalize
-func (c *Client) extractTransferFlow(action *db.Action, txType string, txResult TxResult, moduleAddr, txSigner string) *TransferFlow {
+func extractTransferFlow(action *db.Action, txType string, txResult TxResult, moduleAddr, txSigner string) *TransferFlow {
 	// Look through all events for transfer events
 	var transfers []TransferFlow
 
@@ -660,6 +1138,153 @@ func (c *Client) extractTransferFlow(action *db.Action, txType string, txResult
 	return nil
 }
 
+// msgEvent is a transaction event with the index of the message that emitted it; nil
+// for events outside any message, such as fee deduction, or when the SDK doesn't say.
+type msgEvent struct {
+	Event
+	msgIndex *int
+}
+
+// txMsgEvents lists a transaction's events with their message index. Recent SDKs
+// report every event at the top level with a msg_index attribute; older ones only
+// group message events in logs.
+func txMsgEvents(res TxResult) []msgEvent {
+	var events []msgEvent
+	if len(res.Events) > 0 {
+		for _, e := range res.Events {
+			me := msgEvent{Event: e}
+			if idx, err := strconv.Atoi(eventAttribute(e, "msg_index")); err == nil {
+				me.msgIndex = &idx
+			}
+			events = append(events, me)
+		}
+		return events
+	}
+	for _, l := range res.Logs {
+		idx := l.MsgIndex
+		for _, e := range l.Events {
+			events = append(events, msgEvent{Event: e, msgIndex: &idx})
+		}
+	}
+	return events
+}
+
+// messageEvents returns the events of message idx, or every event when none carries a
+// message index.
+func messageEvents(events []msgEvent, idx int) []Event {
+	var all, msg []Event
+	indexed := false
+	for _, e := range events {
+		all = append(all, e.Event)
+		if e.msgIndex != nil {
+			indexed = true
+			if *e.msgIndex == idx {
+				msg = append(msg, e.Event)
+			}
+		}
+	}
+	if !indexed {
+		return all
+	}
+	return msg
+}
+
+// actionMessageIndexes returns the indexes of the messages that emitted eventType for
+// actionID, in order. A transaction found by searching for the event carries it, so
+// when no event says which message did, the first message is assumed.
+func actionMessageIndexes(events []msgEvent, eventType, actionID string) []int {
+	var idxs []int
+	seen := make(map[int]bool)
+	for _, e := range events {
+		if e.Type != eventType || eventAttribute(e.Event, "action_id") != actionID {
+			continue
+		}
+		idx := 0
+		if e.msgIndex != nil {
+			idx = *e.msgIndex
+		}
+		if !seen[idx] {
+			seen[idx] = true
+			idxs = append(idxs, idx)
+		}
+	}
+	if len(idxs) == 0 {
+		idxs = []int{0}
+	}
+	return idxs
+}
+
+// flowSenderKeys and flowRecipientKeys are the bank event attributes naming the
+// account coins leave and enter.
+var (
+	flowSenderKeys    = map[string]bool{"sender": true, "spender": true, "burner": true}
+	flowRecipientKeys = map[string]bool{"recipient": true, "receiver": true}
+)
+
+// parseTxFlows records every coin moved by the transfer, coin_spent, coin_received and
+// burn events of a transaction, including fee deduction and multi-recipient
+// transfers. Event indexes follow txMsgEvents. Older SDKs merge same-typed events in logs, so a repeated attribute key
+// starts a new flow within the event.
+func parseTxFlows(txResult TxResult) []db.TxFlow {
+	events := txMsgEvents(txResult)
+
+	var flows []db.TxFlow
+	for i, me := range events {
+		switch me.Type {
+		case db.FlowTransfer, db.FlowCoinSpent, db.FlowCoinReceived, db.FlowBurn:
+		default:
+			continue
+		}
+		coinIndex := 0
+		var sender, recipient, amount *string
+		emit := func() {
+			if amount != nil {
+				for _, coin := range strings.Split(*amount, ",") {
+					amt, denom := parseCoinString(strings.TrimSpace(coin))
+					if amt == "" {
+						continue
+					}
+					flows = append(flows, db.TxFlow{
+						TxHash:     txResult.TxHash,
+						EventIndex: i,
+						CoinIndex:  coinIndex,
+						MsgIndex:   me.msgIndex,
+						EventType:  me.Type,
+						Sender:     sender,
+						Recipient:  recipient,
+						Amount:     amt,
+						Denom:      denom,
+					})
+					coinIndex++
+				}
+			}
+			sender, recipient, amount = nil, nil, nil
+		}
+		for _, a := range me.Attributes {
+			value := a.Value
+			switch {
+			case flowSenderKeys[a.Key]:
+				if sender != nil {
+					emit()
+				}
+				sender = &value
+			case flowRecipientKeys[a.Key]:
+				if recipient != nil {
+					emit()
+				}
+				recipient = &value
+			case a.Key == "amount":
+				if amount != nil {
+					emit()
+				}
+				amount = &value
+			}
+		}
+		emit()
+	}
+	return flows
+}
+
 // parseTransferEvent extracts transfer details from event attributes.
 // Expects attributes: sender, recipient, amount
 func parseTransferEvent(attrs []Attribute) *TransferFlow {
@@ -0,0 +1,52 @@
+package lumera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestGetAction verifies single-action lookups and not-found handling
+func TestGetAction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/LumeraProtocol/lumera/action/v1/get_action/42":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"action":{"creator":"lumera1creator","actionID":"42","actionType":"ACTION_TYPE_CASCADE","price":"10090ulume","state":"ACTION_STATE_PENDING","blockHeight":"1234"}}`))
+		case "/LumeraProtocol/lumera/action/v1/get_action/43":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":5,"message":"failed to get action by ID","details":[]}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	ctx := context.Background()
+
+	a, err := client.GetAction(ctx, "42")
+	if err != nil {
+		t.Fatalf("GetAction(42) error: %v", err)
+	}
+	if a.ActionID != "42" || a.State != "ACTION_STATE_PENDING" || a.BlockHeight != "1234" {
+		t.Errorf("GetAction(42) = %+v, unexpected fields", a)
+	}
+	if a.Price.Amount != "10090" || a.Price.Denom != "ulume" {
+		t.Errorf("Price = %+v, want 10090ulume", a.Price)
+	}
+
+	if _, err := client.GetAction(ctx, "43"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetAction(43) error = %v, want ErrNotFound", err)
+	}
+
+	_, err = client.GetAction(ctx, "44")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("GetAction(44) error = %v, want HTTPError 500", err)
+	}
+}
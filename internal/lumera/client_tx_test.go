@@ -3,8 +3,11 @@ package lumera
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -113,7 +116,6 @@ func TestParseTransferEvent(t *testing.T) {
 
 // TestExtractTransferFlow tests the extractTransferFlow function
 func TestExtractTransferFlow(t *testing.T) {
-	client := &Client{}
 	moduleAddr := "lumera1module"
 
 	tests := []struct {
@@ -368,7 +370,7 @@ func TestExtractTransferFlow(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := client.extractTransferFlow(tt.action, tt.txType, tt.txResult, tt.moduleAddr, tt.txSigner)
+			got := extractTransferFlow(tt.action, tt.txType, tt.txResult, tt.moduleAddr, tt.txSigner)
 			if tt.wantNil {
 				if got != nil {
 					t.Errorf("extractTransferFlow() = %v, want nil", got)
@@ -467,7 +469,6 @@ func TestExtractTxSigner(t *testing.T) {
 
 // TestParseTxResult tests the parseTxResult function
 func TestParseTxResult(t *testing.T) {
-	client := &Client{}
 	moduleAddr := "lumera1module"
 
 	action := &db.Action{
@@ -508,7 +509,7 @@ func TestParseTxResult(t *testing.T) {
 		},
 	}
 
-	got := client.parseTxResult(action, "register", txResult, tx, moduleAddr)
+	got := parseTxResult(action, "register", txResult, tx, moduleAddr)
 
 	if got == nil {
 		t.Fatal("parseTxResult() = nil, want non-nil")
@@ -832,3 +833,269 @@ func strPtrVal(s *string) string {
 	}
 	return *s
 }
+
+// TestGetTx verifies tx lookups by hash decode fee, events and per-message action flows
+func TestGetTx(t *testing.T) {
+	const hash = "ABCDEF0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF0123456789"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cosmos/tx/v1beta1/txs/" + hash:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{
+				"tx": {
+					"body": {"messages": [
+						{"@type": "/lumera.action.v1.MsgRequestAction", "creator": "lumera1creator"},
+						{"@type": "/lumera.action.v1.MsgRequestAction", "creator": "lumera1creator"}
+					], "memo": "hello"},
+					"auth_info": {"fee": {"amount": [{"denom": "ulume", "amount": "500"}]}}
+				},
+				"tx_response": {
+					"txhash": "` + hash + `", "height": "1234", "code": 0,
+					"timestamp": "2026-01-02T03:04:05Z", "gas_wanted": "200000", "gas_used": "150000",
+					"events": [
+						{"type": "transfer", "attributes": [{"key": "sender", "value": "lumera1creator"}, {"key": "recipient", "value": "lumera1module"}, {"key": "amount", "value": "100ulume"}, {"key": "msg_index", "value": "0"}]},
+						{"type": "action_registered", "attributes": [{"key": "action_id", "value": "7"}, {"key": "msg_index", "value": "0"}]},
+						{"type": "transfer", "attributes": [{"key": "sender", "value": "lumera1creator"}, {"key": "recipient", "value": "lumera1module"}, {"key": "amount", "value": "200ulume"}, {"key": "msg_index", "value": "1"}]},
+						{"type": "action_registered", "attributes": [{"key": "action_id", "value": "8"}, {"key": "msg_index", "value": "1"}]}
+					]
+				}
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":5,"message":"tx not found","details":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	client.SetActionModuleAccount("lumera1module")
+	ctx := context.Background()
+
+	tx, err := client.GetTx(ctx, hash)
+	if err != nil {
+		t.Fatalf("GetTx error: %v", err)
+	}
+	if tx.Height != 1234 || tx.Memo != "hello" || tx.Signer != "lumera1creator" || *tx.GasUsed != 150000 {
+		t.Errorf("GetTx = %+v, unexpected fields", tx)
+	}
+	if len(tx.Fee) != 1 || tx.Fee[0].Amount != "500" || len(tx.MessageTypes) != 2 || len(tx.Events) != 4 {
+		t.Errorf("fee %+v, message types %v, %d events", tx.Fee, tx.MessageTypes, len(tx.Events))
+	}
+	if len(tx.Actions) != 2 {
+		t.Fatalf("got %d actions, want 2", len(tx.Actions))
+	}
+	for i, want := range []struct {
+		id     uint64
+		amount string
+	}{{7, "100"}, {8, "200"}} {
+		a := tx.Actions[i]
+		if a.ActionID != want.id || a.TxType != "register" || a.ActionPrice == nil || *a.ActionPrice != want.amount {
+			t.Errorf("action %d = %+v, want %d paying %s", i, a, want.id, want.amount)
+		}
+	}
+
+	if _, err := client.GetTx(ctx, "00"+hash[2:]); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetTx(unknown) error = %v, want db.ErrNotFound", err)
+	}
+}
+
+// TestParseTxFlows verifies every bank event coin is recorded with its message index
+func TestParseTxFlows(t *testing.T) {
+	attrs := func(kv ...string) []Attribute {
+		var out []Attribute
+		for i := 0; i+1 < len(kv); i += 2 {
+			out = append(out, Attribute{Key: kv[i], Value: kv[i+1]})
+		}
+		return out
+	}
+
+	t.Run("top-level events", func(t *testing.T) {
+		flows := parseTxFlows(TxResult{TxHash: "H", Events: []Event{
+			{Type: "coin_spent", Attributes: attrs("spender", "lumera1creator", "amount", "500ulume")},
+			{Type: "transfer", Attributes: attrs("sender", "lumera1creator", "recipient", "lumera1feecollector", "amount", "500ulume")},
+			{Type: "message", Attributes: attrs("action", "/lumera.action.v1.MsgFinalizeAction", "msg_index", "0")},
+			{Type: "transfer", Attributes: attrs("sender", "lumera1module", "recipient", "lumera1supernode", "amount", "80ulume,1uatom", "msg_index", "0")},
+			{Type: "burn", Attributes: attrs("burner", "lumera1module", "amount", "20ulume", "msg_index", "0")},
+		}})
+		if len(flows) != 5 {
+			t.Fatalf("got %d flows, want 5: %+v", len(flows), flows)
+		}
+		if flows[0].EventType != "coin_spent" || flows[0].MsgIndex != nil || *flows[0].Sender != "lumera1creator" || flows[0].Recipient != nil {
+			t.Errorf("fee coin_spent = %+v", flows[0])
+		}
+		if f := flows[3]; f.EventIndex != 3 || f.CoinIndex != 1 || f.Denom != "uatom" || f.MsgIndex == nil || *f.MsgIndex != 0 {
+			t.Errorf("second coin = %+v", f)
+		}
+		if f := flows[4]; f.EventType != "burn" || f.Amount != "20" || f.TxHash != "H" {
+			t.Errorf("burn = %+v", f)
+		}
+	})
+
+	t.Run("merged log events", func(t *testing.T) {
+		flows := parseTxFlows(TxResult{Logs: []ABCILog{{MsgIndex: 1, Events: []Event{
+			{Type: "transfer", Attributes: attrs(
+				"recipient", "lumera1a", "sender", "lumera1module", "amount", "60ulume",
+				"recipient", "lumera1b", "sender", "lumera1module", "amount", "40ulume")},
+		}}}})
+		if len(flows) != 2 {
+			t.Fatalf("got %d flows, want 2: %+v", len(flows), flows)
+		}
+		if *flows[0].Recipient != "lumera1a" || flows[0].Amount != "60" || *flows[1].Recipient != "lumera1b" || flows[1].CoinIndex != 1 {
+			t.Errorf("flows = %+v", flows)
+		}
+		if flows[1].MsgIndex == nil || *flows[1].MsgIndex != 1 {
+			t.Errorf("msg index = %v, want 1", flows[1].MsgIndex)
+		}
+	})
+}
+
+// TestGetActionTransactionsPagingAndMessages verifies every search page is read and a
+// transaction yields one row per message carrying the action event
+func TestGetActionTransactionsPagingAndMessages(t *testing.T) {
+	const total = 60
+	event := func(typ string, msgIndex int, kv ...string) Event {
+		e := Event{Type: typ}
+		for i := 0; i+1 < len(kv); i += 2 {
+			e.Attributes = append(e.Attributes, Attribute{Key: kv[i], Value: kv[i+1]})
+		}
+		e.Attributes = append(e.Attributes, Attribute{Key: "msg_index", Value: strconv.Itoa(msgIndex)})
+		return e
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var out TxSearchResponse
+		if r.URL.Query().Get("query") == "action_finalized.action_id=7" {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			out.Total = strconv.Itoa(total)
+			for i := (page - 1) * limit; i < page*limit && i < total; i++ {
+				res := TxResult{TxHash: fmt.Sprintf("TX%02d", i), Height: strconv.Itoa(100 + i), Timestamp: "2024-01-15T10:00:00Z"}
+				res.Events = append(res.Events,
+					event("transfer", 0, "sender", "lumera1module", "recipient", "lumera1sn0", "amount", "10ulume"),
+					event("action_finalized", 0, "action_id", "7"))
+				if i == 0 {
+					// A second supernode's finalization in the same tx
+					res.Events = append(res.Events,
+						event("transfer", 1, "sender", "lumera1module", "recipient", "lumera1sn1", "amount", "20ulume"),
+						event("action_finalized", 1, "action_id", "7"))
+				}
+				out.TxResponses = append(out.TxResponses, res)
+				out.Txs = append(out.Txs, TxResponse{})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	client.SetActionModuleAccount("lumera1module")
+	txs, err := client.GetActionTransactions(context.Background(), &db.Action{ActionID: 7, Creator: "lumera1creator"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != total+1 {
+		t.Fatalf("got %d transactions, want %d", len(txs), total+1)
+	}
+	first, second := txs[0], txs[1]
+	if first.TxHash != "TX00" || first.MsgIndex != 0 || first.FlowPayee == nil || *first.FlowPayee != "lumera1sn0" {
+		t.Errorf("first = %+v", first)
+	}
+	if second.TxHash != "TX00" || second.MsgIndex != 1 || second.FlowPayee == nil || *second.FlowPayee != "lumera1sn1" {
+		t.Errorf("second = %+v", second)
+	}
+	if len(first.Flows) != 2 || txs[total].TxHash != "TX59" {
+		t.Errorf("flows %d, last %s", len(first.Flows), txs[total].TxHash)
+	}
+}
+
+// TestGetActionTransactionsInRange verifies a height range is searched once per lifecycle
+// event and a tx carrying several events yields every action once
+func TestGetActionTransactionsInRange(t *testing.T) {
+	attrs := func(kv ...string) []Attribute {
+		var out []Attribute
+		for i := 0; i+1 < len(kv); i += 2 {
+			out = append(out, Attribute{Key: kv[i], Value: kv[i+1]})
+		}
+		return out
+	}
+	register := TxResult{TxHash: "REG", Height: "105", Timestamp: "2024-01-15T10:00:00Z", Events: []Event{
+		{Type: "transfer", Attributes: attrs("sender", "lumera1creator", "recipient", "lumera1module", "amount", "100ulume", "msg_index", "0")},
+		{Type: "action_registered", Attributes: attrs("action_id", "1", "msg_index", "0")},
+	}}
+	// Finalizes action 1 and registers action 2 in one tx
+	mixed := TxResult{TxHash: "MIX", Height: "101", Timestamp: "2024-01-15T09:00:00Z", Events: []Event{
+		{Type: "action_finalized", Attributes: attrs("action_id", "1", "msg_index", "0")},
+		{Type: "action_registered", Attributes: attrs("action_id", "2", "msg_index", "1")},
+	}}
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var out TxSearchResponse
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+		switch query {
+		case "action_registered.action_id EXISTS AND tx.height>=100 AND tx.height<=199":
+			out.TxResponses = []TxResult{mixed, register}
+		case "action_finalized.action_id EXISTS AND tx.height>=100 AND tx.height<=199":
+			out.TxResponses = []TxResult{mixed}
+		}
+		out.Txs = make([]TxResponse, len(out.TxResponses))
+		json.NewEncoder(w).Encode(out)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	client.SetActionModuleAccount("lumera1module")
+	txs, err := client.GetActionTransactionsInRange(context.Background(), 100, 199)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 3 {
+		t.Errorf("got %d searches, want 3: %v", len(queries), queries)
+	}
+	var got []string
+	for _, tx := range txs {
+		got = append(got, fmt.Sprintf("%s:%d:%s:%d", tx.TxHash, tx.ActionID, tx.TxType, tx.MsgIndex))
+	}
+	want := []string{"MIX:1:finalize:0", "MIX:2:register:1", "REG:1:register:0"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if reg := txs[2]; reg.ActionPrice == nil || *reg.ActionPrice != "100" || reg.Height != 105 {
+		t.Errorf("register tx = %+v", reg)
+	}
+}
+
+// TestGetActionTransactionsInRangeError verifies a failed search fails the whole range
+func TestGetActionTransactionsInRangeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	client.SetActionModuleAccount("lumera1module")
+	if _, err := client.GetActionTransactionsInRange(context.Background(), 1, 10); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestGetLatestHeight verifies the height is read from sdk_block or block
+func TestGetLatestHeight(t *testing.T) {
+	for _, body := range []string{
+		`{"sdk_block":{"header":{"height":"1234"}}}`,
+		`{"block":{"header":{"height":"1234"}}}`,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/cosmos/base/tendermint/v1beta1/blocks/latest" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(body))
+		}))
+		h, err := NewClient(server.URL, 5*time.Second).GetLatestHeight(context.Background())
+		server.Close()
+		if err != nil || h != 1234 {
+			t.Errorf("%s: got %d, %v", body, h, err)
+		}
+	}
+}
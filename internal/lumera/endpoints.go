@@ -0,0 +1,225 @@
+package lumera
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// endpointLatencyAlpha is the weight of a new sample in an endpoint's latency average.
+	endpointLatencyAlpha = 0.3
+	// endpointDefaultLatency stands in for the latency of an endpoint with no successful
+	// request yet, so it still gets a share of the traffic.
+	endpointDefaultLatency = 200 * time.Millisecond
+	// A failing endpoint is passed over for endpointCooldownBase, doubled per consecutive
+	// failure up to endpointCooldownMax.
+	endpointCooldownBase = 5 * time.Second
+	endpointCooldownMax  = 2 * time.Minute
+	// maxRetryBackoff caps the delay between two attempts of a request.
+	maxRetryBackoff = 5 * time.Second
+)
+
+// endpoint is one LCD base URL and its health.
+type endpoint struct {
+	url   string
+	label string // url without credentials, for metrics and status
+
+	mu          sync.Mutex
+	latency     time.Duration // moving average of successful requests; 0 before the first
+	failures    int           // consecutive failures
+	downUntil   time.Time     // passed over until then while other endpoints are up
+	requests    int64
+	errors      int64
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+func newEndpoint(baseURL string) *endpoint {
+	e := &endpoint{url: strings.TrimRight(baseURL, "/")}
+	e.label = e.url
+	if u, err := url.Parse(e.url); err == nil {
+		e.label = u.Redacted()
+	}
+	lcdEndpointUp.Set(1, e.label)
+	return e
+}
+
+// succeed records an answered request.
+func (e *endpoint) succeed(elapsed time.Duration, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests++
+	if e.latency == 0 {
+		e.latency = elapsed
+	} else {
+		e.latency += time.Duration(endpointLatencyAlpha * float64(elapsed-e.latency))
+	}
+	e.failures = 0
+	e.downUntil = time.Time{}
+	e.lastSuccess = now
+	lcdEndpointUp.Set(1, e.label)
+}
+
+// fail records a failed request and takes the endpoint out of rotation for a cooldown.
+func (e *endpoint) fail(err error, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests++
+	e.errors++
+	e.failures++
+	cooldown := endpointCooldownBase
+	for i := 1; i < e.failures && cooldown < endpointCooldownMax; i++ {
+		cooldown *= 2
+	}
+	e.downUntil = now.Add(min(cooldown, endpointCooldownMax))
+	e.lastFailure = now
+	e.lastError = err.Error()
+	lcdEndpointUp.Set(0, e.label)
+}
+
+// EndpointHealth is a snapshot of an LCD endpoint's health.
+type EndpointHealth struct {
+	URL                 string     `json:"url"`
+	Healthy             bool       `json:"healthy"`
+	LatencyMs           *float64   `json:"latency_ms,omitempty"` // moving average of successful requests
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Requests            int64      `json:"requests"`
+	Errors              int64      `json:"errors"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	DownUntil           *time.Time `json:"down_until,omitempty"`
+}
+
+func (e *endpoint) health(now time.Time) EndpointHealth {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	h := EndpointHealth{
+		URL:                 e.label,
+		Healthy:             !now.Before(e.downUntil),
+		ConsecutiveFailures: e.failures,
+		Requests:            e.requests,
+		Errors:              e.errors,
+		LastError:           e.lastError,
+	}
+	if e.latency > 0 {
+		ms := float64(e.latency) / float64(time.Millisecond)
+		h.LatencyMs = &ms
+	}
+	if !e.lastSuccess.IsZero() {
+		t := e.lastSuccess.UTC()
+		h.LastSuccess = &t
+	}
+	if !e.lastFailure.IsZero() {
+		t := e.lastFailure.UTC()
+		h.LastFailure = &t
+	}
+	if !h.Healthy {
+		t := e.downUntil.UTC()
+		h.DownUntil = &t
+	}
+	return h
+}
+
+// EndpointHealth returns the health of each LCD endpoint, in configuration order.
+func (c *Client) EndpointHealth() []EndpointHealth {
+	now := time.Now()
+	out := make([]EndpointHealth, 0, len(c.endpoints))
+	for _, e := range c.endpoints {
+		out = append(out, e.health(now))
+	}
+	return out
+}
+
+// pick returns an endpoint not in tried. Endpoints that are up are chosen at random,
+// weighted by the inverse of their average latency; when every untried endpoint is
+// down, the one due back soonest is returned. It returns nil once all were tried.
+func (c *Client) pick(tried map[*endpoint]bool, now time.Time) *endpoint {
+	var (
+		up       []*endpoint
+		weights  []float64
+		total    float64
+		soonest  *endpoint
+		soonestT time.Time
+	)
+	for _, e := range c.endpoints {
+		if tried[e] {
+			continue
+		}
+		e.mu.Lock()
+		latency, downUntil := e.latency, e.downUntil
+		e.mu.Unlock()
+		if now.Before(downUntil) {
+			if soonest == nil || downUntil.Before(soonestT) {
+				soonest, soonestT = e, downUntil
+			}
+			continue
+		}
+		if latency <= 0 {
+			latency = endpointDefaultLatency
+		}
+		w := 1 / max(latency, time.Millisecond).Seconds()
+		up = append(up, e)
+		weights = append(weights, w)
+		total += w
+	}
+	if len(up) == 0 {
+		return soonest
+	}
+	x := rand.Float64() * total
+	for i, w := range weights {
+		if x < w {
+			return up[i]
+		}
+		x -= w
+	}
+	return up[len(up)-1]
+}
+
+// retryable reports whether a request failed because of the endpoint: a transport
+// error or timeout, or a 5xx or 429 response.
+func retryable(err error) bool {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he.StatusCode >= 500 || he.StatusCode == http.StatusTooManyRequests
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// retryBackoff returns the delay before retry number attempt (1-based): RetryBackoff
+// doubled per attempt, capped at maxRetryBackoff, with the upper half jittered.
+func (c *Client) retryBackoff(attempt int) time.Duration {
+	d := c.RetryBackoff
+	for i := 1; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxRetryBackoff)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// sleepCtx waits for d, returning false if ctx is done first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
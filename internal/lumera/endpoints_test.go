@@ -0,0 +1,110 @@
+package lumera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer answers the latest block request with status, counting hits.
+func countingServer(t *testing.T, status int, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"block":{"header":{"height":"77"}}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestDoJSONFailover verifies a failing endpoint is retried on a healthy one, then kept
+// out of rotation until its cooldown ends
+func TestDoJSONFailover(t *testing.T) {
+	var badHits, goodHits atomic.Int32
+	bad := countingServer(t, http.StatusServiceUnavailable, &badHits)
+	good := countingServer(t, http.StatusOK, &goodHits)
+	c := NewClientEndpoints([]string{bad.URL, good.URL}, 5*time.Second)
+	c.RetryBackoff = time.Millisecond
+
+	for i := 0; i < 10; i++ {
+		if h, err := c.GetLatestHeight(context.Background()); err != nil || h != 77 {
+			t.Fatalf("request %d = %d, %v", i, h, err)
+		}
+	}
+	if badHits.Load() > 1 || goodHits.Load() != 10 {
+		t.Errorf("hits bad=%d good=%d, want the bad endpoint tried at most once", badHits.Load(), goodHits.Load())
+	}
+
+	health := c.EndpointHealth()
+	if len(health) != 2 || !health[1].Healthy || health[1].Requests != 10 || health[1].LatencyMs == nil {
+		t.Errorf("good endpoint health = %+v", health[1])
+	}
+	if badHits.Load() == 1 && (health[0].Healthy || health[0].ConsecutiveFailures != 1 || health[0].DownUntil == nil || health[0].LastError == "") {
+		t.Errorf("bad endpoint health = %+v", health[0])
+	}
+}
+
+// TestDoJSONRetries verifies retries stop after MaxRetries and that answers such as
+// 404s are neither retried nor held against the endpoint
+func TestDoJSONRetries(t *testing.T) {
+	var hits atomic.Int32
+	down := countingServer(t, http.StatusBadGateway, &hits)
+	c := NewClient(down.URL, 5*time.Second)
+	c.MaxRetries, c.RetryBackoff = 3, time.Millisecond
+
+	var httpErr *HTTPError
+	if _, err := c.GetLatestHeight(context.Background()); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("err = %v, want HTTPError 502", err)
+	}
+	if hits.Load() != 4 {
+		t.Errorf("hits = %d, want 1 + 3 retries", hits.Load())
+	}
+	if h := c.EndpointHealth()[0]; h.Healthy || h.ConsecutiveFailures != 4 || h.Errors != 4 {
+		t.Errorf("health = %+v", h)
+	}
+
+	var missHits atomic.Int32
+	missing := countingServer(t, http.StatusNotFound, &missHits)
+	c = NewClient(missing.URL, 5*time.Second)
+	c.RetryBackoff = time.Millisecond
+	if _, err := c.GetAction(context.Background(), "1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+	if missHits.Load() != 1 || !c.EndpointHealth()[0].Healthy {
+		t.Errorf("404: hits = %d, health = %+v", missHits.Load(), c.EndpointHealth()[0])
+	}
+}
+
+// TestPickWeightsLatency verifies faster endpoints get more traffic and endpoints
+// that are down are only picked when no other is up
+func TestPickWeightsLatency(t *testing.T) {
+	c := NewClientEndpoints([]string{"http://fast", "http://slow", "http://down"}, time.Second)
+	fast, slow, down := c.endpoints[0], c.endpoints[1], c.endpoints[2]
+	now := time.Now()
+	fast.succeed(10*time.Millisecond, now)
+	slow.succeed(100*time.Millisecond, now)
+	down.fail(errors.New("boom"), now)
+
+	counts := map[*endpoint]int{}
+	for i := 0; i < 2000; i++ {
+		counts[c.pick(map[*endpoint]bool{}, now)]++
+	}
+	if counts[down] != 0 || counts[fast] < 5*counts[slow] {
+		t.Errorf("picks fast=%d slow=%d down=%d, want ~10:1 and none down", counts[fast], counts[slow], counts[down])
+	}
+
+	if got := c.pick(map[*endpoint]bool{fast: true, slow: true}, now); got != down {
+		t.Errorf("pick with only the down endpoint untried = %v", got)
+	}
+	if got := c.pick(map[*endpoint]bool{fast: true, slow: true, down: true}, now); got != nil {
+		t.Errorf("pick with every endpoint tried = %v", got)
+	}
+	if got := c.pick(map[*endpoint]bool{fast: true, slow: true}, now.Add(endpointCooldownBase)); got != down {
+		t.Errorf("pick after the cooldown = %v", got)
+	}
+}
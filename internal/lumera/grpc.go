@@ -0,0 +1,502 @@
+package lumera
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	actiontypes "github.com/LumeraProtocol/lumera/x/action/v1/types"
+	sntypes "github.com/LumeraProtocol/lumera/x/supernode/v1/types"
+	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/cosmos/gogoproto/jsonpb"
+	gogoproto "github.com/cosmos/gogoproto/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"lumescope/internal/db"
+)
+
+// GRPCClient reads the same data as Client from the node's gRPC endpoint, through the
+// generated query clients of the action, supernode and Cosmos SDK modules. Responses
+// are typed protobuf messages converted to the shared types, so none of the LCD JSON
+// quirks apply. Fields the chain added after the pinned lumera module version (such
+// as an action's fileSizeKbs) are not decoded.
+type GRPCClient struct {
+	Addr string
+
+	conn      *grpc.ClientConn
+	action    actiontypes.QueryClient
+	supernode sntypes.QueryClient
+	staking   stakingtypes.QueryClient
+	auth      authtypes.QueryClient
+	tx        txtypes.ServiceClient
+	cmt       cmtservice.ServiceClient
+
+	mu               sync.Mutex // guards the module address cache
+	actionModuleAddr string
+}
+
+// NewGRPCClient connects lazily to a node's gRPC endpoint (host:port), with TLS when
+// useTLS is set. Each call is bounded by timeout.
+func NewGRPCClient(addr string, timeout time.Duration, useTLS bool) (*GRPCClient, error) {
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	return dialGRPC(addr, timeout, grpc.WithTransportCredentials(creds))
+}
+
+func dialGRPC(addr string, timeout time.Duration, opts ...grpc.DialOption) (*GRPCClient, error) {
+	opts = append(opts,
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(gogoCodec{})),
+		grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			start := time.Now()
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			observeGRPC(method, status.Code(err).String(), start)
+			return err
+		}),
+	)
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("grpc %s: %w", addr, err)
+	}
+	return &GRPCClient{
+		Addr:      addr,
+		conn:      conn,
+		action:    actiontypes.NewQueryClient(conn),
+		supernode: sntypes.NewQueryClient(conn),
+		staking:   stakingtypes.NewQueryClient(conn),
+		auth:      authtypes.NewQueryClient(conn),
+		tx:        txtypes.NewServiceClient(conn),
+		cmt:       cmtservice.NewServiceClient(conn),
+	}, nil
+}
+
+// Close closes the connection.
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+// gogoCodec marshals the gogoproto-generated chain types, which the default codec of
+// grpc-go can't handle.
+type gogoCodec struct{}
+
+func (gogoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(gogoproto.Message)
+	if !ok {
+		return nil, fmt.Errorf("grpc codec: %T is not a gogoproto message", v)
+	}
+	return gogoproto.Marshal(m)
+}
+
+func (gogoCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(gogoproto.Message)
+	if !ok {
+		return fmt.Errorf("grpc codec: %T is not a gogoproto message", v)
+	}
+	return gogoproto.Unmarshal(data, m)
+}
+
+func (gogoCodec) Name() string { return "proto" }
+
+// isGRPCNotFound reports whether err is a gRPC NotFound status.
+func isGRPCNotFound(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
+
+// pageRequest builds a query page from the base64 next key used by the LCD, so keys
+// are interchangeable between the clients.
+func pageRequest(nextKey string, limit int) (*query.PageRequest, error) {
+	p := &query.PageRequest{}
+	if limit > 0 {
+		p.Limit = uint64(limit)
+	}
+	if nextKey != "" {
+		key, err := base64.StdEncoding.DecodeString(nextKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page key: %w", err)
+		}
+		p.Key = key
+	}
+	return p, nil
+}
+
+func nextPageKey(p *query.PageResponse) string {
+	if p == nil || len(p.NextKey) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(p.NextKey)
+}
+
+// GetValidators fetches validators (all statuses).
+func (c *GRPCClient) GetValidators(ctx context.Context, nextKey string, limit int) ([]Validator, string, error) {
+	page, err := pageRequest(nextKey, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	out, err := c.staking.Validators(ctx, &stakingtypes.QueryValidatorsRequest{Pagination: page})
+	if err != nil {
+		return nil, "", err
+	}
+	vals := make([]Validator, 0, len(out.Validators))
+	for _, v := range out.Validators {
+		val := Validator{OperatorAddress: v.OperatorAddress, Jailed: v.Jailed, Status: v.Status.String()}
+		val.Description.Moniker = v.Description.Moniker
+		vals = append(vals, val)
+	}
+	return vals, nextPageKey(out.Pagination), nil
+}
+
+func (c *GRPCClient) GetSupernodes(ctx context.Context, nextKey string, limit int) ([]Supernode, string, error) {
+	page, err := pageRequest(nextKey, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	out, err := c.supernode.ListSuperNodes(ctx, &sntypes.QueryListSuperNodesRequest{Pagination: page})
+	if err != nil {
+		return nil, "", err
+	}
+	sns := make([]Supernode, 0, len(out.Supernodes))
+	for _, sn := range out.Supernodes {
+		if sn != nil {
+			sns = append(sns, supernodeFromProto(sn))
+		}
+	}
+	return sns, nextPageKey(out.Pagination), nil
+}
+
+func supernodeFromProto(sn *sntypes.SuperNode) Supernode {
+	out := Supernode{
+		ValidatorAddress: sn.ValidatorAddress,
+		Note:             sn.Note,
+		SupernodeAccount: sn.SupernodeAccount,
+		P2PPortStr:       sn.P2PPort,
+	}
+	for _, s := range sn.States {
+		if s != nil {
+			out.States = append(out.States, SupernodeState{State: s.State.String(), Height: strconv.FormatInt(s.Height, 10)})
+		}
+	}
+	for _, e := range sn.Evidence {
+		if e != nil {
+			out.Evidence = append(out.Evidence, Evidence{
+				ActionID:         e.ActionId,
+				Description:      e.Description,
+				EvidenceType:     e.EvidenceType,
+				Height:           e.Height,
+				ReporterAddress:  e.ReporterAddress,
+				Severity:         strconv.FormatUint(e.Severity, 10),
+				ValidatorAddress: e.ValidatorAddress,
+			})
+		}
+	}
+	for _, ip := range sn.PrevIpAddresses {
+		if ip != nil {
+			out.PrevIPAddresses = append(out.PrevIPAddresses, PrevIPAddress{Address: ip.Address, Height: strconv.FormatInt(ip.Height, 10)})
+		}
+	}
+	for _, a := range sn.PrevSupernodeAccounts {
+		if a != nil {
+			out.PrevSupernodeAccounts = append(out.PrevSupernodeAccounts, PrevSupernodeAccount{Account: a.Account, Height: strconv.FormatInt(a.Height, 10)})
+		}
+	}
+	if m := sn.Metrics; m != nil {
+		out.Metrics.ReportCount = strconv.FormatUint(m.ReportCount, 10)
+		out.Metrics.Height = strconv.FormatInt(m.Height, 10)
+		if len(m.Metrics) > 0 {
+			out.Metrics.Metrics = make(map[string]any, len(m.Metrics))
+			for k, v := range m.Metrics {
+				out.Metrics.Metrics[k] = v
+			}
+		}
+	}
+	return out
+}
+
+func (c *GRPCClient) GetActions(ctx context.Context, actionType, actionState, nextKey string, limit int) ([]Action, string, error) {
+	req := &actiontypes.QueryListActionsRequest{}
+	if actionType != "" {
+		v, ok := actiontypes.ActionType_value[actionType]
+		if !ok {
+			return nil, "", fmt.Errorf("unknown action type %q", actionType)
+		}
+		req.ActionType = actiontypes.ActionType(v)
+	}
+	if actionState != "" {
+		v, ok := actiontypes.ActionState_value[actionState]
+		if !ok {
+			return nil, "", fmt.Errorf("unknown action state %q", actionState)
+		}
+		req.ActionState = actiontypes.ActionState(v)
+	}
+	page, err := pageRequest(nextKey, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Pagination = page
+	out, err := c.action.ListActions(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+	as := make([]Action, 0, len(out.Actions))
+	for _, a := range out.Actions {
+		if a != nil {
+			as = append(as, actionFromProto(a))
+		}
+	}
+	return as, nextPageKey(out.Pagination), nil
+}
+
+// GetAction fetches a single action by its on-chain ID.
+// Returns ErrNotFound if the chain has no action with that ID.
+func (c *GRPCClient) GetAction(ctx context.Context, actionID string) (*Action, error) {
+	out, err := c.action.GetAction(ctx, &actiontypes.QueryGetActionRequest{ActionID: actionID})
+	if err != nil {
+		if isGRPCNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if out.Action == nil || out.Action.ActionID == "" {
+		return nil, ErrNotFound
+	}
+	a := actionFromProto(out.Action)
+	return &a, nil
+}
+
+func actionFromProto(a *actiontypes.Action) Action {
+	out := Action{
+		Creator:        a.Creator,
+		ActionID:       a.ActionID,
+		ActionType:     a.ActionType.String(),
+		MetadataB64:    base64.StdEncoding.EncodeToString(a.Metadata),
+		ExpirationTime: strconv.FormatInt(a.ExpirationTime, 10),
+		State:          a.State.String(),
+		BlockHeight:    strconv.FormatInt(a.BlockHeight, 10),
+		SuperNodes:     a.SuperNodes,
+	}
+	out.Price.Amount, out.Price.Denom = parseCoinString(a.Price)
+	return out
+}
+
+// GetActionModuleAccount returns the cached action module address, fetching it if necessary.
+func (c *GRPCClient) GetActionModuleAccount(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if c.actionModuleAddr != "" {
+		addr := c.actionModuleAddr
+		c.mu.Unlock()
+		return addr, nil
+	}
+	c.mu.Unlock()
+
+	out, err := c.auth.ModuleAccountByName(ctx, &authtypes.QueryModuleAccountByNameRequest{Name: "action"})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch action module account: %w", err)
+	}
+	var acc authtypes.ModuleAccount
+	if out.Account == nil {
+		return "", errors.New("action module account is empty")
+	}
+	if err := gogoproto.Unmarshal(out.Account.Value, &acc); err != nil {
+		return "", fmt.Errorf("decode action module account: %w", err)
+	}
+	if acc.BaseAccount == nil || acc.Address == "" {
+		return "", errors.New("action module account address is empty")
+	}
+
+	c.mu.Lock()
+	c.actionModuleAddr = acc.Address
+	c.mu.Unlock()
+	log.Printf("Fetched and cached action module address: %s", acc.Address)
+	return acc.Address, nil
+}
+
+// GetLatestHeight returns the height of the latest block.
+func (c *GRPCClient) GetLatestHeight(ctx context.Context) (int64, error) {
+	out, err := c.cmt.GetLatestBlock(ctx, &cmtservice.GetLatestBlockRequest{})
+	if err != nil {
+		return 0, err
+	}
+	if out.SdkBlock != nil && out.SdkBlock.Header.Height > 0 {
+		return out.SdkBlock.Header.Height, nil
+	}
+	if out.Block != nil && out.Block.Header.Height > 0 {
+		return out.Block.Header.Height, nil
+	}
+	return 0, errors.New("latest block has no height")
+}
+
+func (c *GRPCClient) GetActionTransactions(ctx context.Context, action *db.Action) ([]*db.ActionTransaction, error) {
+	return actionTransactions(ctx, c, action)
+}
+
+func (c *GRPCClient) GetActionTransactionsInRange(ctx context.Context, minHeight, maxHeight int64) ([]*db.ActionTransaction, error) {
+	return actionTransactionsInRange(ctx, c, minHeight, maxHeight)
+}
+
+func (c *GRPCClient) GetTx(ctx context.Context, hash string) (*db.ChainTx, error) {
+	return getTx(ctx, c, hash)
+}
+
+func (c *GRPCClient) GetTxActionTransactions(ctx context.Context, hash string) ([]*db.ActionTransaction, error) {
+	return txActionTransactionsByHash(ctx, c, hash)
+}
+
+// searchTxs pages through GetTxsEvent like the LCD client's tx_search.
+func (c *GRPCClient) searchTxs(ctx context.Context, query string, maxPages int) (*TxSearchResponse, error) {
+	all := &TxSearchResponse{}
+	seen := make(map[string]bool)
+	for page := 1; maxPages == 0 || page <= maxPages; page++ {
+		out, err := c.tx.GetTxsEvent(ctx, &txtypes.GetTxsEventRequest{
+			Query:   query,
+			OrderBy: txtypes.OrderBy_ORDER_BY_ASC,
+			Page:    uint64(page),
+			Limit:   txSearchPageSize,
+		})
+		if err != nil {
+			log.Printf("searchTxs: error querying %q page %d over grpc: %v", query, page, err)
+			return all, err
+		}
+
+		added := 0
+		for i, res := range out.TxResponses {
+			if res == nil || seen[res.TxHash] {
+				continue
+			}
+			seen[res.TxHash] = true
+			added++
+			all.TxResponses = append(all.TxResponses, txResultFromProto(res))
+			var tx TxResponse
+			if i < len(out.Txs) && out.Txs[i] != nil {
+				tx = txFromProto(out.Txs[i])
+			}
+			all.Txs = append(all.Txs, tx)
+		}
+
+		if added == 0 || len(out.TxResponses) < txSearchPageSize || (out.Total > 0 && uint64(len(all.TxResponses)) >= out.Total) {
+			break
+		}
+	}
+	return all, nil
+}
+
+func (c *GRPCClient) fetchTx(ctx context.Context, hash string) (*GetTxResponse, error) {
+	out, err := c.tx.GetTx(ctx, &txtypes.GetTxRequest{Hash: hash})
+	if err != nil {
+		if isGRPCNotFound(err) {
+			return nil, db.ErrNotFound
+		}
+		return nil, err
+	}
+	if out.TxResponse == nil || out.TxResponse.TxHash == "" {
+		return nil, db.ErrNotFound
+	}
+	res := txResultFromProto(out.TxResponse)
+	resp := &GetTxResponse{TxResponse: &res}
+	if out.Tx != nil {
+		tx := txFromProto(out.Tx)
+		resp.Tx = &tx
+	}
+	return resp, nil
+}
+
+// txResultFromProto converts a transaction result to its LCD shape.
+func txResultFromProto(r *sdk.TxResponse) TxResult {
+	res := TxResult{
+		TxHash:    r.TxHash,
+		Height:    strconv.FormatInt(r.Height, 10),
+		Code:      r.Code,
+		Timestamp: r.Timestamp,
+		GasWanted: strconv.FormatInt(r.GasWanted, 10),
+		GasUsed:   strconv.FormatInt(r.GasUsed, 10),
+		RawLog:    r.RawLog,
+	}
+	for _, e := range r.Events {
+		ev := Event{Type: e.Type, Attributes: make([]Attribute, 0, len(e.Attributes))}
+		for _, a := range e.Attributes {
+			ev.Attributes = append(ev.Attributes, Attribute{Key: a.Key, Value: a.Value})
+		}
+		res.Events = append(res.Events, ev)
+	}
+	for _, l := range r.Logs {
+		abciLog := ABCILog{MsgIndex: int(l.MsgIndex)}
+		for _, e := range l.Events {
+			ev := Event{Type: e.Type, Attributes: make([]Attribute, 0, len(e.Attributes))}
+			for _, a := range e.Attributes {
+				ev.Attributes = append(ev.Attributes, Attribute{Key: a.Key, Value: a.Value})
+			}
+			abciLog.Events = append(abciLog.Events, ev)
+		}
+		res.Logs = append(res.Logs, abciLog)
+	}
+	return res
+}
+
+// txFromProto converts a transaction to its LCD shape. Messages are rendered as JSON
+// with their "@type" when their type is registered, or as the "@type" alone.
+func txFromProto(tx *txtypes.Tx) TxResponse {
+	var out TxResponse
+	if tx.Body != nil {
+		out.Body.Memo = tx.Body.Memo
+		for _, m := range tx.Body.Messages {
+			if m != nil {
+				out.Body.Messages = append(out.Body.Messages, anyJSON(m))
+			}
+		}
+	}
+	if tx.AuthInfo != nil {
+		if tx.AuthInfo.Fee != nil {
+			for _, coin := range tx.AuthInfo.Fee.Amount {
+				out.AuthInfo.Fee.Amount = append(out.AuthInfo.Fee.Amount, Coin{Denom: coin.Denom, Amount: coin.Amount.String()})
+			}
+		}
+		for _, si := range tx.AuthInfo.SignerInfos {
+			if si != nil {
+				out.AuthInfo.SignerInfos = append(out.AuthInfo.SignerInfos, SignerInfo{Sequence: strconv.FormatUint(si.Sequence, 10)})
+			}
+		}
+	}
+	return out
+}
+
+// anyJSON renders a packed message as LCD-style JSON: its fields by proto name plus
+// "@type".
+func anyJSON(a *codectypes.Any) json.RawMessage {
+	fields := make(map[string]any)
+	if t := gogoproto.MessageType(strings.TrimPrefix(a.TypeUrl, "/")); t != nil && t.Kind() == reflect.Ptr {
+		msg, ok := reflect.New(t.Elem()).Interface().(gogoproto.Message)
+		if ok && gogoproto.Unmarshal(a.Value, msg) == nil {
+			var buf bytes.Buffer
+			if err := (&jsonpb.Marshaler{OrigName: true}).Marshal(&buf, msg); err == nil {
+				_ = json.Unmarshal(buf.Bytes(), &fields)
+			}
+		}
+	}
+	fields["@type"] = a.TypeUrl
+	b, _ := json.Marshal(fields)
+	return b
+}
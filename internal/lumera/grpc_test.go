@@ -0,0 +1,277 @@
+package lumera
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	actiontypes "github.com/LumeraProtocol/lumera/x/action/v1/types"
+	sntypes "github.com/LumeraProtocol/lumera/x/supernode/v1/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	gogoproto "github.com/cosmos/gogoproto/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"lumescope/internal/db"
+)
+
+// fakeNode serves the chain queries used by GRPCClient.
+type fakeNode struct {
+	actiontypes.UnimplementedQueryServer
+	txQueries []string
+}
+
+func (n *fakeNode) ListActions(ctx context.Context, req *actiontypes.QueryListActionsRequest) (*actiontypes.QueryListActionsResponse, error) {
+	if req.ActionState != actiontypes.ActionStateDone {
+		return nil, status.Error(codes.InvalidArgument, "unexpected state filter")
+	}
+	if string(req.Pagination.Key) == "" {
+		return &actiontypes.QueryListActionsResponse{
+			Actions:    []*actiontypes.Action{{ActionID: "1", ActionType: actiontypes.ActionTypeCascade, State: actiontypes.ActionStateDone, Price: "10090ulume", BlockHeight: 100, ExpirationTime: 1700000000, Metadata: []byte{1, 2}}},
+			Pagination: &query.PageResponse{NextKey: []byte("k2")},
+		}, nil
+	}
+	return &actiontypes.QueryListActionsResponse{Actions: []*actiontypes.Action{{ActionID: "2"}}}, nil
+}
+
+func (n *fakeNode) GetAction(ctx context.Context, req *actiontypes.QueryGetActionRequest) (*actiontypes.QueryGetActionResponse, error) {
+	if req.ActionID != "42" {
+		return nil, status.Error(codes.NotFound, "failed to get action by ID")
+	}
+	return &actiontypes.QueryGetActionResponse{Action: &actiontypes.Action{ActionID: "42", Creator: "lumera1creator", State: actiontypes.ActionStatePending, SuperNodes: []string{"lumeravaloper1sn"}}}, nil
+}
+
+type fakeSupernodes struct {
+	sntypes.UnimplementedQueryServer
+}
+
+func (fakeSupernodes) ListSuperNodes(ctx context.Context, req *sntypes.QueryListSuperNodesRequest) (*sntypes.QueryListSuperNodesResponse, error) {
+	return &sntypes.QueryListSuperNodesResponse{Supernodes: []*sntypes.SuperNode{{
+		ValidatorAddress: "lumeravaloper1sn",
+		States:           []*sntypes.SuperNodeStateRecord{{State: sntypes.SuperNodeStateActive, Height: 10}},
+		PrevIpAddresses:  []*sntypes.IPAddressHistory{{Address: "1.2.3.4", Height: 10}},
+		Metrics:          &sntypes.MetricsAggregate{Metrics: map[string]float64{"cpu": 0.5}, ReportCount: 3},
+		SupernodeAccount: "lumera1sn",
+		P2PPort:          "4445",
+	}}}, nil
+}
+
+type fakeStaking struct {
+	stakingtypes.UnimplementedQueryServer
+}
+
+func (fakeStaking) Validators(ctx context.Context, req *stakingtypes.QueryValidatorsRequest) (*stakingtypes.QueryValidatorsResponse, error) {
+	return &stakingtypes.QueryValidatorsResponse{Validators: []stakingtypes.Validator{{
+		OperatorAddress: "lumeravaloper1sn",
+		Status:          stakingtypes.Bonded,
+		Description:     stakingtypes.Description{Moniker: "node-1"},
+	}}}, nil
+}
+
+type fakeAuth struct {
+	authtypes.UnimplementedQueryServer
+}
+
+func (fakeAuth) ModuleAccountByName(ctx context.Context, req *authtypes.QueryModuleAccountByNameRequest) (*authtypes.QueryModuleAccountByNameResponse, error) {
+	acc := &authtypes.ModuleAccount{BaseAccount: &authtypes.BaseAccount{Address: "lumera1module"}, Name: req.Name}
+	bz, err := gogoproto.Marshal(acc)
+	if err != nil {
+		return nil, err
+	}
+	return &authtypes.QueryModuleAccountByNameResponse{Account: &codectypes.Any{TypeUrl: "/cosmos.auth.v1beta1.ModuleAccount", Value: bz}}, nil
+}
+
+type fakeTxs struct {
+	txtypes.UnimplementedServiceServer
+	node *fakeNode
+}
+
+// registerTx registers action 7 in a MsgRequestAction paying the action module.
+func registerTx() (*txtypes.Tx, *sdk.TxResponse) {
+	msg, _ := gogoproto.Marshal(&actiontypes.MsgRequestAction{Creator: "lumera1creator", ActionType: "CASCADE"})
+	tx := &txtypes.Tx{
+		Body:     &txtypes.TxBody{Messages: []*codectypes.Any{{TypeUrl: "/lumera.action.v1.MsgRequestAction", Value: msg}}, Memo: "hi"},
+		AuthInfo: &txtypes.AuthInfo{Fee: &txtypes.Fee{Amount: sdk.NewCoins(sdk.NewInt64Coin("ulume", 500))}},
+	}
+	attr := func(kv ...string) []abci.EventAttribute {
+		var out []abci.EventAttribute
+		for i := 0; i+1 < len(kv); i += 2 {
+			out = append(out, abci.EventAttribute{Key: kv[i], Value: kv[i+1]})
+		}
+		return out
+	}
+	res := &sdk.TxResponse{
+		TxHash: "REG", Height: 150, Timestamp: "2024-01-15T10:00:00Z", GasWanted: 200000, GasUsed: 150000,
+		Events: []abci.Event{
+			{Type: "transfer", Attributes: attr("sender", "lumera1creator", "recipient", "lumera1module", "amount", "10090ulume", "msg_index", "0")},
+			{Type: "action_registered", Attributes: attr("action_id", "7", "msg_index", "0")},
+		},
+	}
+	return tx, res
+}
+
+func (s *fakeTxs) GetTxsEvent(ctx context.Context, req *txtypes.GetTxsEventRequest) (*txtypes.GetTxsEventResponse, error) {
+	s.node.txQueries = append(s.node.txQueries, req.Query)
+	if req.OrderBy != txtypes.OrderBy_ORDER_BY_ASC || req.Page != 1 {
+		return nil, status.Error(codes.InvalidArgument, "unexpected paging")
+	}
+	out := &txtypes.GetTxsEventResponse{}
+	if req.Query == "action_registered.action_id EXISTS AND tx.height>=100 AND tx.height<=199" {
+		tx, res := registerTx()
+		out.Txs, out.TxResponses, out.Total = []*txtypes.Tx{tx}, []*sdk.TxResponse{res}, 1
+	}
+	return out, nil
+}
+
+func (s *fakeTxs) GetTx(ctx context.Context, req *txtypes.GetTxRequest) (*txtypes.GetTxResponse, error) {
+	if req.Hash != "REG" {
+		return nil, status.Errorf(codes.NotFound, "tx not found: %s", req.Hash)
+	}
+	tx, res := registerTx()
+	return &txtypes.GetTxResponse{Tx: tx, TxResponse: res}, nil
+}
+
+type fakeCmt struct {
+	cmtservice.UnimplementedServiceServer
+}
+
+func (fakeCmt) GetLatestBlock(ctx context.Context, req *cmtservice.GetLatestBlockRequest) (*cmtservice.GetLatestBlockResponse, error) {
+	return &cmtservice.GetLatestBlockResponse{SdkBlock: &cmtservice.Block{Header: cmtservice.Header{Height: 1234}}}, nil
+}
+
+// newTestGRPCClient serves a fakeNode in process and connects a GRPCClient to it.
+func newTestGRPCClient(t *testing.T) (*GRPCClient, *fakeNode) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ForceServerCodec(gogoCodec{}))
+	node := &fakeNode{}
+	actiontypes.RegisterQueryServer(srv, node)
+	sntypes.RegisterQueryServer(srv, &fakeSupernodes{})
+	stakingtypes.RegisterQueryServer(srv, &fakeStaking{})
+	authtypes.RegisterQueryServer(srv, &fakeAuth{})
+	txtypes.RegisterServiceServer(srv, &fakeTxs{node: node})
+	cmtservice.RegisterServiceServer(srv, &fakeCmt{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	c, err := dialGRPC("passthrough:///bufnet", 5*time.Second,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, node
+}
+
+// TestGRPCClientQueries verifies actions, supernodes, validators, the module account and
+// the latest height are converted to the shared types, with LCD-compatible page keys
+func TestGRPCClientQueries(t *testing.T) {
+	c, _ := newTestGRPCClient(t)
+	ctx := context.Background()
+
+	as, next, err := c.GetActions(ctx, "ACTION_TYPE_UNSPECIFIED", "ACTION_STATE_DONE", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 1 || next != base64.StdEncoding.EncodeToString([]byte("k2")) {
+		t.Fatalf("GetActions = %+v, next %q", as, next)
+	}
+	a := as[0]
+	if a.ActionType != "ACTION_TYPE_CASCADE" || a.State != "ACTION_STATE_DONE" || a.BlockHeight != "100" || a.ExpirationTime != "1700000000" || a.MetadataB64 != "AQI=" {
+		t.Errorf("action = %+v", a)
+	}
+	if a.Price.Amount != "10090" || a.Price.Denom != "ulume" {
+		t.Errorf("price = %+v, want 10090ulume", a.Price)
+	}
+	if as, next, err = c.GetActions(ctx, "", "ACTION_STATE_DONE", next, 1); err != nil || len(as) != 1 || as[0].ActionID != "2" || next != "" {
+		t.Errorf("second page = %+v, %q, %v", as, next, err)
+	}
+	if _, _, err := c.GetActions(ctx, "ACTION_TYPE_BOGUS", "", "", 0); err == nil {
+		t.Error("expected unknown action type to fail")
+	}
+
+	if a, err := c.GetAction(ctx, "42"); err != nil || a.Creator != "lumera1creator" || a.State != "ACTION_STATE_PENDING" || len(a.SuperNodes) != 1 {
+		t.Errorf("GetAction(42) = %+v, %v", a, err)
+	}
+	if _, err := c.GetAction(ctx, "43"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetAction(43) error = %v, want ErrNotFound", err)
+	}
+
+	sns, _, err := c.GetSupernodes(ctx, "", 10)
+	if err != nil || len(sns) != 1 {
+		t.Fatalf("GetSupernodes = %+v, %v", sns, err)
+	}
+	sn := sns[0]
+	if sn.States[0].State != "SUPERNODE_STATE_ACTIVE" || sn.States[0].Height != "10" || sn.PrevIPAddresses[0].Address != "1.2.3.4" || sn.P2PPortStr != "4445" {
+		t.Errorf("supernode = %+v", sn)
+	}
+	if sn.Metrics.ReportCount != "3" || sn.Metrics.Metrics["cpu"] != 0.5 {
+		t.Errorf("metrics = %+v", sn.Metrics)
+	}
+
+	vals, _, err := c.GetValidators(ctx, "", 10)
+	if err != nil || len(vals) != 1 || vals[0].Status != "BOND_STATUS_BONDED" || vals[0].Description.Moniker != "node-1" {
+		t.Errorf("GetValidators = %+v, %v", vals, err)
+	}
+
+	if addr, err := c.GetActionModuleAccount(ctx); err != nil || addr != "lumera1module" {
+		t.Errorf("GetActionModuleAccount = %q, %v", addr, err)
+	}
+	if h, err := c.GetLatestHeight(ctx); err != nil || h != 1234 {
+		t.Errorf("GetLatestHeight = %d, %v", h, err)
+	}
+}
+
+// TestGRPCClientTxs verifies transactions decode through the shared lifecycle parsing,
+// with the signer read from the packed message
+func TestGRPCClientTxs(t *testing.T) {
+	c, node := newTestGRPCClient(t)
+	ctx := context.Background()
+
+	txs, err := c.GetActionTransactionsInRange(ctx, 100, 199)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(node.txQueries) != 3 {
+		t.Errorf("queries = %q, want one per lifecycle event", node.txQueries)
+	}
+	if len(txs) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(txs))
+	}
+	at := txs[0]
+	if at.ActionID != 7 || at.TxType != "register" || at.Height != 150 || at.TxFee == nil || *at.TxFee != "500" || len(at.Flows) != 1 {
+		t.Errorf("tx = %+v", at)
+	}
+	if at.FlowPayee == nil || *at.FlowPayee != "lumera1module" || at.ActionPrice == nil || *at.ActionPrice != "10090" {
+		t.Errorf("flow payee %v, price %v, want module and 10090", at.FlowPayee, at.ActionPrice)
+	}
+
+	tx, err := c.GetTx(ctx, "REG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Signer != "lumera1creator" || tx.Memo != "hi" || len(tx.MessageTypes) != 1 || tx.MessageTypes[0] != "/lumera.action.v1.MsgRequestAction" {
+		t.Errorf("GetTx = %+v", tx)
+	}
+	if len(tx.Actions) != 1 || tx.Actions[0].ActionID != 7 || tx.Actions[0].TxType != "register" {
+		t.Errorf("actions = %+v", tx.Actions)
+	}
+	if _, err := c.GetTx(ctx, "MISSING"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetTx(MISSING) error = %v, want db.ErrNotFound", err)
+	}
+}
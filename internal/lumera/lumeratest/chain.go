@@ -0,0 +1,496 @@
+// Package lumeratest provides an in-memory lumera.Chain for tests of the
+// ingestion loops.
+package lumeratest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"lumescope/internal/db"
+	"lumescope/internal/lumera"
+)
+
+// DefaultModuleAccount is the action module account of a new Chain.
+const DefaultModuleAccount = "lumera1actionmodule"
+
+// Chain is a deterministic, scriptable chain. Every scripted transaction is committed
+// in a block of its own; block times start at Genesis and advance BlockInterval per
+// block. Lists are paged with the decimal offset of the next item as the next key.
+// It is safe for concurrent use.
+type Chain struct {
+	mu            sync.Mutex
+	height        int64
+	moduleAccount string
+	validators    []lumera.Validator
+	supernodes    []lumera.Supernode
+	actions       map[uint64]*lumera.Action
+	lastActionID  uint64
+	txs           map[string]*chainTx
+	txOrder       []string
+	failures      map[string]*failure
+	calls         map[string]int
+}
+
+type chainTx struct {
+	tx        *db.ChainTx
+	actionTxs []*db.ActionTransaction
+}
+
+type failure struct {
+	remaining int // 0 fails every call
+	err       error
+}
+
+// Genesis is the block time of height 0 of a new Chain.
+var Genesis = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// BlockInterval is the time between two blocks of a new Chain.
+const BlockInterval = 6 * time.Second
+
+// NewChain returns an empty chain at height 1.
+func NewChain() *Chain {
+	return &Chain{
+		height:        1,
+		moduleAccount: DefaultModuleAccount,
+		actions:       make(map[uint64]*lumera.Action),
+		txs:           make(map[string]*chainTx),
+		failures:      make(map[string]*failure),
+		calls:         make(map[string]int),
+	}
+}
+
+// Height returns the latest block height.
+func (c *Chain) Height() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.height
+}
+
+// AdvanceBlocks commits n empty blocks and returns the new height.
+func (c *Chain) AdvanceBlocks(n int) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.height += int64(n)
+	return c.height
+}
+
+// BlockTime returns the time of the block at height.
+func (c *Chain) BlockTime(height int64) time.Time {
+	return Genesis.Add(time.Duration(height) * BlockInterval)
+}
+
+// AddValidator adds a bonded validator.
+func (c *Chain) AddValidator(operator, moniker string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v := lumera.Validator{OperatorAddress: operator, Status: "BOND_STATUS_BONDED"}
+	v.Description.Moniker = moniker
+	c.validators = append(c.validators, v)
+}
+
+// SetSupernode adds a supernode, or replaces the one with the same account.
+func (c *Chain) SetSupernode(sn lumera.Supernode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.supernodes {
+		if c.supernodes[i].SupernodeAccount == sn.SupernodeAccount {
+			c.supernodes[i] = sn
+			return
+		}
+	}
+	c.supernodes = append(c.supernodes, sn)
+}
+
+// Supernode returns a supernode at address ip:port in state at the current height.
+func (c *Chain) Supernode(account, validator, address, p2pPort, state string) lumera.Supernode {
+	h := strconv.FormatInt(c.Height(), 10)
+	return lumera.Supernode{
+		SupernodeAccount: account,
+		ValidatorAddress: validator,
+		States:           []lumera.SupernodeState{{State: state, Height: h}},
+		PrevIPAddresses:  []lumera.PrevIPAddress{{Address: address, Height: h}},
+		P2PPortStr:       p2pPort,
+		Note:             "1.0.0",
+	}
+}
+
+// RegisterAction commits a register transaction for a new pending action paying price
+// (a coin string such as "10000ulume") to the module account, and returns the action ID.
+// IDs are assigned sequentially from 1.
+func (c *Chain) RegisterAction(creator, actionType, price string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastActionID++
+	id := c.lastActionID
+	amount, denom := splitCoin(price)
+	height := c.height + 1
+	a := &lumera.Action{
+		Creator:        creator,
+		ActionID:       strconv.FormatUint(id, 10),
+		ActionType:     actionType,
+		Price:          lumera.PriceField{Denom: denom, Amount: amount},
+		ExpirationTime: strconv.FormatInt(c.BlockTime(height).Add(time.Hour).Unix(), 10),
+		State:          "ACTION_STATE_PENDING",
+		BlockHeight:    strconv.FormatInt(height, 10),
+		SuperNodes:     []string{},
+	}
+	c.actions[id] = a
+	c.commitTx(id, "register", creator, c.moduleAccount, price, "/lumera.action.v1.MsgRequestAction")
+	return id
+}
+
+// FinalizeAction commits a finalize transaction by supernode, which moves the action
+// to DONE and receives its price from the module account. It returns the tx hash.
+func (c *Chain) FinalizeAction(id uint64, supernode string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a := c.mustAction(id)
+	a.State = "ACTION_STATE_DONE"
+	a.SuperNodes = append(a.SuperNodes, supernode)
+	return c.commitTx(id, "finalize", c.moduleAccount, supernode, a.Price.Amount+a.Price.Denom, "/lumera.action.v1.MsgFinalizeAction")
+}
+
+// ApproveAction commits an approve transaction by the creator, which moves the action
+// to APPROVED. It returns the tx hash.
+func (c *Chain) ApproveAction(id uint64) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a := c.mustAction(id)
+	a.State = "ACTION_STATE_APPROVED"
+	return c.commitTx(id, "approve", a.Creator, "", "", "/lumera.action.v1.MsgApproveAction")
+}
+
+// SetActionState changes an action's state without a transaction, as the chain does
+// when it expires or fails an action at the end of a block.
+func (c *Chain) SetActionState(id uint64, state string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mustAction(id).State = state
+}
+
+// Action returns a copy of an action.
+func (c *Chain) Action(id uint64) lumera.Action {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return copyAction(c.mustAction(id))
+}
+
+// TxHashes returns the hashes of the transactions committed so far, oldest first.
+func (c *Chain) TxHashes() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.txOrder...)
+}
+
+// Fail makes the next n calls to method, a Chain method name such as "GetAction",
+// return err. With n <= 0 every call fails until Recover.
+func (c *Chain) Fail(method string, n int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures[method] = &failure{remaining: max(n, 0), err: err}
+}
+
+// Recover clears the failures scripted for method.
+func (c *Chain) Recover(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.failures, method)
+}
+
+// Calls returns how many times method was called, failed calls included.
+func (c *Chain) Calls(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[method]
+}
+
+func (c *Chain) mustAction(id uint64) *lumera.Action {
+	a, ok := c.actions[id]
+	if !ok {
+		panic(fmt.Sprintf("lumeratest: no action %d", id))
+	}
+	return a
+}
+
+// commitTx commits a single-message transaction for an action in a new block. A
+// non-empty amount records a transfer from payer to payee.
+func (c *Chain) commitTx(actionID uint64, txType, payer, payee, amount, msgType string) string {
+	c.height++
+	height := c.height
+	hash := fmt.Sprintf("%064X", len(c.txOrder)+1)
+	blockTime := c.BlockTime(height)
+	gasWanted, gasUsed := int64(200000), int64(150000)
+	fee, feeDenom := "5000", "ulume"
+	msgIndex := 0
+
+	at := &db.ActionTransaction{
+		ActionID:   actionID,
+		TxType:     txType,
+		TxHash:     hash,
+		Height:     height,
+		BlockTime:  blockTime,
+		GasWanted:  &gasWanted,
+		GasUsed:    &gasUsed,
+		TxFee:      &fee,
+		TxFeeDenom: &feeDenom,
+		MsgIndex:   msgIndex,
+	}
+	action := db.ChainTxAction{ActionID: actionID, TxType: txType, MsgIndex: msgIndex}
+	events := []db.TxEvent{{Type: "message", Attributes: []db.TxAttribute{
+		{Key: "action", Value: msgType},
+		{Key: "sender", Value: payer},
+		{Key: "msg_index", Value: "0"},
+	}}}
+	var flows []db.TxFlow
+	if amount != "" {
+		value, denom := splitCoin(amount)
+		from, to := payer, payee
+		at.ActionPrice, at.ActionPriceDenom = &value, &denom
+		at.FlowPayer, at.FlowPayee = &from, &to
+		action.ActionPrice, action.ActionPriceDenom = &value, &denom
+		action.FlowPayer, action.FlowPayee = &from, &to
+		events = append(events, db.TxEvent{Type: db.FlowTransfer, Attributes: []db.TxAttribute{
+			{Key: "recipient", Value: payee},
+			{Key: "sender", Value: payer},
+			{Key: "amount", Value: amount},
+			{Key: "msg_index", Value: "0"},
+		}})
+		flows = append(flows, db.TxFlow{
+			TxHash:     hash,
+			EventIndex: len(events) - 1,
+			MsgIndex:   &msgIndex,
+			EventType:  db.FlowTransfer,
+			Sender:     &from,
+			Recipient:  &to,
+			Amount:     value,
+			Denom:      denom,
+		})
+	}
+	at.Flows = flows
+
+	c.txs[hash] = &chainTx{
+		tx: &db.ChainTx{
+			TxHash:       hash,
+			Height:       height,
+			BlockTime:    blockTime,
+			GasWanted:    &gasWanted,
+			GasUsed:      &gasUsed,
+			Signer:       payer,
+			Fee:          []db.DenomAmount{{Denom: feeDenom, Amount: fee}},
+			MessageTypes: []string{msgType},
+			Events:       events,
+			Actions:      []db.ChainTxAction{action},
+			Flows:        flows,
+		},
+		actionTxs: []*db.ActionTransaction{at},
+	}
+	c.txOrder = append(c.txOrder, hash)
+	return hash
+}
+
+// call records a call to method and returns its scripted failure, if any.
+func (c *Chain) call(ctx context.Context, method string) error {
+	c.calls[method]++
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f, ok := c.failures[method]
+	if !ok {
+		return nil
+	}
+	if f.remaining > 0 {
+		f.remaining--
+		if f.remaining == 0 {
+			delete(c.failures, method)
+		}
+	}
+	return f.err
+}
+
+// page returns the bounds of the page starting at nextKey and the key of the next one.
+func page(n int, nextKey string, limit int) (int, int, string, error) {
+	start := 0
+	if nextKey != "" {
+		var err error
+		if start, err = strconv.Atoi(nextKey); err != nil || start < 0 {
+			return 0, 0, "", fmt.Errorf("invalid pagination key %q", nextKey)
+		}
+	}
+	start = min(start, n)
+	end := n
+	if limit > 0 {
+		end = min(start+limit, n)
+	}
+	if end < n {
+		return start, end, strconv.Itoa(end), nil
+	}
+	return start, end, "", nil
+}
+
+func (c *Chain) GetValidators(ctx context.Context, nextKey string, limit int) ([]lumera.Validator, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetValidators"); err != nil {
+		return nil, "", err
+	}
+	start, end, next, err := page(len(c.validators), nextKey, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return append([]lumera.Validator(nil), c.validators[start:end]...), next, nil
+}
+
+func (c *Chain) GetSupernodes(ctx context.Context, nextKey string, limit int) ([]lumera.Supernode, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetSupernodes"); err != nil {
+		return nil, "", err
+	}
+	start, end, next, err := page(len(c.supernodes), nextKey, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return append([]lumera.Supernode(nil), c.supernodes[start:end]...), next, nil
+}
+
+func (c *Chain) GetActions(ctx context.Context, actionType, actionState, nextKey string, limit int) ([]lumera.Action, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetActions"); err != nil {
+		return nil, "", err
+	}
+	var matched []lumera.Action
+	for id := uint64(1); id <= c.lastActionID; id++ {
+		a, ok := c.actions[id]
+		if !ok {
+			continue
+		}
+		if actionType != "" && actionType != "ACTION_TYPE_UNSPECIFIED" && a.ActionType != actionType {
+			continue
+		}
+		if actionState != "" && actionState != "ACTION_STATE_UNSPECIFIED" && a.State != actionState {
+			continue
+		}
+		matched = append(matched, copyAction(a))
+	}
+	start, end, next, err := page(len(matched), nextKey, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return matched[start:end], next, nil
+}
+
+func (c *Chain) GetAction(ctx context.Context, actionID string) (*lumera.Action, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetAction"); err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseUint(actionID, 10, 64)
+	if err != nil {
+		return nil, lumera.ErrNotFound
+	}
+	a, ok := c.actions[id]
+	if !ok {
+		return nil, lumera.ErrNotFound
+	}
+	cp := copyAction(a)
+	return &cp, nil
+}
+
+func (c *Chain) GetActionModuleAccount(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetActionModuleAccount"); err != nil {
+		return "", err
+	}
+	return c.moduleAccount, nil
+}
+
+func (c *Chain) GetLatestHeight(ctx context.Context) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetLatestHeight"); err != nil {
+		return 0, err
+	}
+	return c.height, nil
+}
+
+func (c *Chain) GetActionTransactions(ctx context.Context, action *db.Action) ([]*db.ActionTransaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetActionTransactions"); err != nil {
+		return nil, err
+	}
+	return c.actionTxs(func(at *db.ActionTransaction) bool { return at.ActionID == action.ActionID }), nil
+}
+
+func (c *Chain) GetActionTransactionsInRange(ctx context.Context, minHeight, maxHeight int64) ([]*db.ActionTransaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetActionTransactionsInRange"); err != nil {
+		return nil, err
+	}
+	return c.actionTxs(func(at *db.ActionTransaction) bool { return at.Height >= minHeight && at.Height <= maxHeight }), nil
+}
+
+func (c *Chain) GetTx(ctx context.Context, hash string) (*db.ChainTx, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetTx"); err != nil {
+		return nil, err
+	}
+	t, ok := c.txs[hash]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	tx := *t.tx
+	tx.FetchedAt = time.Now().UTC()
+	return &tx, nil
+}
+
+func (c *Chain) GetTxActionTransactions(ctx context.Context, hash string) ([]*db.ActionTransaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call(ctx, "GetTxActionTransactions"); err != nil {
+		return nil, err
+	}
+	if _, ok := c.txs[hash]; !ok {
+		return nil, db.ErrNotFound
+	}
+	return c.actionTxs(func(at *db.ActionTransaction) bool { return at.TxHash == hash }), nil
+}
+
+// actionTxs returns copies of the action transactions matching keep, oldest first.
+func (c *Chain) actionTxs(keep func(*db.ActionTransaction) bool) []*db.ActionTransaction {
+	var out []*db.ActionTransaction
+	for _, hash := range c.txOrder {
+		for _, at := range c.txs[hash].actionTxs {
+			if keep(at) {
+				cp := *at
+				out = append(out, &cp)
+			}
+		}
+	}
+	return out
+}
+
+func copyAction(a *lumera.Action) lumera.Action {
+	cp := *a
+	cp.SuperNodes = append([]string{}, a.SuperNodes...)
+	return cp
+}
+
+// splitCoin splits a coin string such as "10000ulume" into amount and denom.
+func splitCoin(s string) (string, string) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+var _ lumera.Chain = (*Chain)(nil)
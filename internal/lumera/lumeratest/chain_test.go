@@ -0,0 +1,92 @@
+package lumeratest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lumescope/internal/db"
+	"lumescope/internal/lumera"
+)
+
+// TestChainActions verifies scripted actions are listed, paged and filtered, and
+// their transactions found by action, height range and hash
+func TestChainActions(t *testing.T) {
+	ctx := context.Background()
+	c := NewChain()
+	first := c.RegisterAction("lumera1creator", "ACTION_TYPE_CASCADE", "10000ulume")
+	second := c.RegisterAction("lumera1creator", "ACTION_TYPE_SENSE", "500ulume")
+	third := c.RegisterAction("lumera1creator", "ACTION_TYPE_CASCADE", "10000ulume")
+	finalize := c.FinalizeAction(first, "lumera1sn")
+	c.SetActionState(second, "ACTION_STATE_EXPIRED")
+
+	page1, next, err := c.GetActions(ctx, "", "", "", 2)
+	if err != nil || len(page1) != 2 || next == "" || page1[0].ActionID != "1" {
+		t.Fatalf("page 1 = %+v, %q, %v", page1, next, err)
+	}
+	page2, next, err := c.GetActions(ctx, "", "", next, 2)
+	if err != nil || len(page2) != 1 || next != "" || page2[0].ActionID != "3" {
+		t.Fatalf("page 2 = %+v, %q, %v", page2, next, err)
+	}
+	done, _, _ := c.GetActions(ctx, "ACTION_TYPE_UNSPECIFIED", "ACTION_STATE_DONE", "", 0)
+	if len(done) != 1 || done[0].ActionID != "1" || done[0].SuperNodes[0] != "lumera1sn" {
+		t.Errorf("done actions = %+v", done)
+	}
+	if _, err := c.GetAction(ctx, "4"); !errors.Is(err, lumera.ErrNotFound) {
+		t.Errorf("GetAction(4) err = %v", err)
+	}
+	if a, err := c.GetAction(ctx, "3"); err != nil || a.State != "ACTION_STATE_PENDING" || a.BlockHeight != "4" {
+		t.Errorf("GetAction(3) = %+v, %v", a, err)
+	}
+
+	txs, err := c.GetActionTransactions(ctx, &db.Action{ActionID: first})
+	if err != nil || len(txs) != 2 || txs[0].TxType != "register" || txs[1].TxType != "finalize" {
+		t.Fatalf("action %d txs = %+v, %v", first, txs, err)
+	}
+	if fin := txs[1]; *fin.FlowPayer != DefaultModuleAccount || *fin.FlowPayee != "lumera1sn" || *fin.ActionPrice != "10000" {
+		t.Errorf("finalize tx = %+v", fin)
+	}
+	inRange, _ := c.GetActionTransactionsInRange(ctx, 3, 4)
+	if len(inRange) != 2 || inRange[0].ActionID != second || inRange[1].ActionID != third {
+		t.Errorf("txs in range = %+v", inRange)
+	}
+	tx, err := c.GetTx(ctx, finalize)
+	if err != nil || tx.Height != c.Height() || len(tx.Flows) != 1 || !tx.BlockTime.Equal(c.BlockTime(tx.Height)) {
+		t.Errorf("GetTx = %+v, %v", tx, err)
+	}
+	if _, err := c.GetTx(ctx, "ABSENT"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetTx(ABSENT) err = %v", err)
+	}
+}
+
+// TestChainFailures verifies scripted failures and call counting
+func TestChainFailures(t *testing.T) {
+	ctx := context.Background()
+	c := NewChain()
+	c.AddValidator("lumeravaloper1a", "a")
+	boom := errors.New("boom")
+
+	c.Fail("GetValidators", 2, boom)
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.GetValidators(ctx, "", 0); !errors.Is(err, boom) {
+			t.Fatalf("call %d err = %v", i, err)
+		}
+	}
+	if vals, _, err := c.GetValidators(ctx, "", 0); err != nil || len(vals) != 1 {
+		t.Errorf("after failures = %+v, %v", vals, err)
+	}
+
+	c.Fail("GetLatestHeight", 0, boom)
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetLatestHeight(ctx); !errors.Is(err, boom) {
+			t.Fatalf("call %d err = %v", i, err)
+		}
+	}
+	c.Recover("GetLatestHeight")
+	if h, err := c.GetLatestHeight(ctx); err != nil || h != 1 {
+		t.Errorf("GetLatestHeight = %d, %v", h, err)
+	}
+	if c.Calls("GetValidators") != 3 || c.Calls("GetLatestHeight") != 4 {
+		t.Errorf("calls = %d, %d", c.Calls("GetValidators"), c.Calls("GetLatestHeight"))
+	}
+}
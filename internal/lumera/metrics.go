@@ -0,0 +1,74 @@
+package lumera
+
+import (
+	"strings"
+	"time"
+
+	"lumescope/internal/metrics"
+)
+
+var (
+	lcdRequestsTotal = metrics.NewCounterVec(
+		"lumescope_lcd_requests_total",
+		"Total Lumera LCD requests by normalized path and status (\"error\" for transport failures).",
+		"path", "status")
+	lcdRequestDuration = metrics.NewHistogramVec(
+		"lumescope_lcd_request_duration_seconds",
+		"Lumera LCD request latency by normalized path.",
+		nil, "path")
+	rpcRequestsTotal = metrics.NewCounterVec(
+		"lumescope_rpc_requests_total",
+		"Total CometBFT RPC requests by method and status (\"error\" for transport failures).",
+		"method", "status")
+	lcdEndpointUp = metrics.NewGaugeVec(
+		"lumescope_lcd_endpoint_up",
+		"Whether an LCD endpoint is in rotation (1) or cooling down after a failure (0).",
+		"endpoint")
+	grpcRequestsTotal = metrics.NewCounterVec(
+		"lumescope_grpc_requests_total",
+		"Total chain gRPC requests by full method and status code.",
+		"method", "code")
+)
+
+func observeLCD(path, status string, start time.Time) {
+	p := normalizeLCDPath(path)
+	lcdRequestsTotal.Inc(p, status)
+	lcdRequestDuration.Observe(time.Since(start).Seconds(), p)
+}
+
+func observeRPC(method, status string, start time.Time) {
+	rpcRequestsTotal.Inc(method, status)
+	lcdRequestDuration.Observe(time.Since(start).Seconds(), "rpc:"+method)
+}
+
+func observeGRPC(method, code string, start time.Time) {
+	grpcRequestsTotal.Inc(method, code)
+	lcdRequestDuration.Observe(time.Since(start).Seconds(), "grpc:"+method)
+}
+
+// normalizeLCDPath replaces per-entity path segments (numeric IDs, hashes, bech32
+// addresses) with placeholders so metric labels stay low-cardinality.
+func normalizeLCDPath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, s := range segs {
+		switch {
+		case s == "":
+		case isDigits(s):
+			segs[i] = "{id}"
+		case strings.HasPrefix(s, "lumera1") || strings.HasPrefix(s, "lumeravaloper1"):
+			segs[i] = "{address}"
+		case len(s) >= 32:
+			segs[i] = "{hash}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,20 @@
+package lumera
+
+import "testing"
+
+// TestNormalizeLCDPath verifies per-entity segments are collapsed for metric labels
+func TestNormalizeLCDPath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/LumeraProtocol/lumera/action/v1/get_action/42", "/LumeraProtocol/lumera/action/v1/get_action/{id}"},
+		{"/LumeraProtocol/lumera/action/v1/list_actions", "/LumeraProtocol/lumera/action/v1/list_actions"},
+		{"/cosmos/tx/v1beta1/txs/0A1B2C3D4E5F60718293A4B5C6D7E8F90A1B2C3D4E5F60718293A4B5C6D7E8F9", "/cosmos/tx/v1beta1/txs/{hash}"},
+		{"/cosmos/bank/v1beta1/balances/lumera1abcdef", "/cosmos/bank/v1beta1/balances/{address}"},
+	}
+	for _, tt := range tests {
+		if got := normalizeLCDPath(tt.in); got != tt.want {
+			t.Errorf("normalizeLCDPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
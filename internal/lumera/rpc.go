@@ -0,0 +1,347 @@
+package lumera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RPCClient is a minimal CometBFT RPC client: URI-style JSON-RPC over HTTP for queries,
+// and a websocket for event subscriptions.
+type RPCClient struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func NewRPCClient(baseURL string, timeout time.Duration) *RPCClient {
+	return &RPCClient{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: timeout},
+	}
+}
+
+// RPCError is a JSON-RPC error returned by the node.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data"`
+}
+
+func (e *RPCError) Error() string {
+	if e.Data != "" {
+		return fmt.Sprintf("rpc error %d: %s: %s", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// call invokes an RPC method with URI parameters; string parameters must be quoted.
+func (c *RPCClient) call(ctx context.Context, method string, q url.Values, v any) error {
+	u := c.BaseURL + "/" + method
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	start := time.Now()
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		observeRPC(method, "error", start)
+		return err
+	}
+	defer resp.Body.Close()
+	observeRPC(method, strconv.Itoa(resp.StatusCode), start)
+
+	var out rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("rpc %s: http %d", method, resp.StatusCode)
+		}
+		return fmt.Errorf("rpc %s: decode: %w", method, err)
+	}
+	if out.Error != nil {
+		return fmt.Errorf("rpc %s: %w", method, out.Error)
+	}
+	return json.Unmarshal(out.Result, v)
+}
+
+// LatestHeight returns the height of the node's latest block.
+func (c *RPCClient) LatestHeight(ctx context.Context) (int64, error) {
+	var out struct {
+		SyncInfo struct {
+			LatestBlockHeight string `json:"latest_block_height"`
+		} `json:"sync_info"`
+	}
+	if err := c.call(ctx, "status", nil, &out); err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(out.SyncInfo.LatestBlockHeight, 10, 64)
+}
+
+// RPCTx is a transaction found by TxSearch.
+type RPCTx struct {
+	Hash   string
+	Height int64
+	Code   uint32
+	Events []Event
+}
+
+// TxSearch returns every transaction matching query, oldest first, reading at most
+// maxPages pages of 100 (0 for no limit).
+func (c *RPCClient) TxSearch(ctx context.Context, query string, maxPages int) ([]RPCTx, error) {
+	const perPage = 100
+	var txs []RPCTx
+	for page := 1; maxPages == 0 || page <= maxPages; page++ {
+		q := url.Values{}
+		q.Set("query", strconv.Quote(query))
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(perPage))
+		q.Set("order_by", strconv.Quote("asc"))
+		var out struct {
+			Txs []struct {
+				Hash     string `json:"hash"`
+				Height   string `json:"height"`
+				TxResult struct {
+					Code   uint32  `json:"code"`
+					Events []Event `json:"events"`
+				} `json:"tx_result"`
+			} `json:"txs"`
+			TotalCount string `json:"total_count"`
+		}
+		if err := c.call(ctx, "tx_search", q, &out); err != nil {
+			return txs, err
+		}
+		for _, t := range out.Txs {
+			h, _ := strconv.ParseInt(t.Height, 10, 64)
+			txs = append(txs, RPCTx{Hash: strings.ToUpper(t.Hash), Height: h, Code: t.TxResult.Code, Events: t.TxResult.Events})
+		}
+		total, _ := strconv.Atoi(out.TotalCount)
+		if len(out.Txs) < perPage || len(txs) >= total {
+			break
+		}
+	}
+	return txs, nil
+}
+
+// BlockResults holds the events of a block: those of each transaction and those
+// emitted outside any transaction (begin/end block on older CometBFT, finalize block
+// on 0.38+).
+type BlockResults struct {
+	Height      int64
+	TxEvents    [][]Event
+	BlockEvents []Event
+}
+
+// BlockResults returns the events of the block at height.
+func (c *RPCClient) BlockResults(ctx context.Context, height int64) (*BlockResults, error) {
+	q := url.Values{}
+	q.Set("height", strconv.FormatInt(height, 10))
+	var out struct {
+		Height     string `json:"height"`
+		TxsResults []struct {
+			Events []Event `json:"events"`
+		} `json:"txs_results"`
+		BeginBlockEvents    []Event `json:"begin_block_events"`
+		EndBlockEvents      []Event `json:"end_block_events"`
+		FinalizeBlockEvents []Event `json:"finalize_block_events"`
+	}
+	if err := c.call(ctx, "block_results", q, &out); err != nil {
+		return nil, err
+	}
+	res := &BlockResults{Height: height}
+	for _, t := range out.TxsResults {
+		res.TxEvents = append(res.TxEvents, t.Events)
+	}
+	res.BlockEvents = append(res.BlockEvents, out.BeginBlockEvents...)
+	res.BlockEvents = append(res.BlockEvents, out.EndBlockEvents...)
+	res.BlockEvents = append(res.BlockEvents, out.FinalizeBlockEvents...)
+	return res, nil
+}
+
+// RPCEvent is an event delivered by a websocket subscription. Events maps
+// "<event type>.<attribute key>" to the values of every matching attribute, such as
+// "tx.hash" or "action_registered.action_id".
+type RPCEvent struct {
+	Query  string
+	Height int64
+	Events map[string][]string
+}
+
+// Subscription is a live websocket subscription. Events is closed when the
+// connection drops or the subscription is closed; Err then reports why.
+type Subscription struct {
+	Events <-chan RPCEvent
+
+	conn *wsConn
+	mu   sync.Mutex
+	err  error
+}
+
+// Err returns the error that ended the subscription, or nil while it is live.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close closes the websocket.
+func (s *Subscription) Close() error {
+	return s.conn.Close()
+}
+
+// Subscribe opens a websocket to the node's /websocket endpoint and subscribes to
+// each query. It fails unless every subscription is acknowledged. The subscription
+// ends when ctx is cancelled.
+func (c *RPCClient) Subscribe(ctx context.Context, queries []string) (*Subscription, error) {
+	wsURL, err := websocketURL(c.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialWebsocket(ctx, wsURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cancelling ctx closes the connection and so ends the subscription
+	context.AfterFunc(ctx, func() { conn.Close() })
+
+	for i, q := range queries {
+		req, _ := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"method":  "subscribe",
+			"id":      i,
+			"params":  map[string]string{"query": q},
+		})
+		if err := conn.WriteText(req); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	// Events of earlier subscriptions may arrive before later ones are acknowledged
+	var early []RPCEvent
+	for acked := 0; acked < len(queries); {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		var resp rpcResponse
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("subscribe: decode: %w", err)
+		}
+		if resp.Error != nil {
+			conn.Close()
+			return nil, fmt.Errorf("subscribe: %w", resp.Error)
+		}
+		if ev, ok := parseRPCEvent(resp.Result); ok {
+			early = append(early, ev)
+			continue
+		}
+		acked++
+	}
+
+	events := make(chan RPCEvent, 64)
+	sub := &Subscription{Events: events, conn: conn}
+	go sub.read(early, events)
+	return sub, nil
+}
+
+// read delivers events until the connection fails.
+func (s *Subscription) read(early []RPCEvent, events chan<- RPCEvent) {
+	defer close(events)
+	for _, ev := range early {
+		events <- ev
+	}
+	for {
+		msg, err := s.conn.ReadMessage()
+		if err != nil {
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+			return
+		}
+		var resp rpcResponse
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			log.Printf("rpc subscription: ignoring undecodable message: %v", err)
+			continue
+		}
+		if resp.Error != nil {
+			s.mu.Lock()
+			s.err = resp.Error
+			s.mu.Unlock()
+			return
+		}
+		ev, ok := parseRPCEvent(resp.Result)
+		if !ok {
+			continue
+		}
+		events <- ev
+	}
+}
+
+// parseRPCEvent decodes a subscription result; acknowledgements and other results
+// without events are skipped.
+func parseRPCEvent(raw json.RawMessage) (RPCEvent, bool) {
+	var res struct {
+		Query string `json:"query"`
+		Data  struct {
+			Value struct {
+				Height   json.Number `json:"height"`
+				TxResult *struct {
+					Height json.Number `json:"height"`
+				} `json:"TxResult"`
+			} `json:"value"`
+		} `json:"data"`
+		Events map[string][]string `json:"events"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil || len(res.Events) == 0 {
+		return RPCEvent{}, false
+	}
+	ev := RPCEvent{Query: res.Query, Events: res.Events}
+	height := res.Data.Value.Height
+	if res.Data.Value.TxResult != nil {
+		height = res.Data.Value.TxResult.Height
+	}
+	ev.Height, _ = height.Int64()
+	if ev.Height == 0 {
+		if hs := res.Events["tx.height"]; len(hs) > 0 {
+			ev.Height, _ = strconv.ParseInt(hs[0], 10, 64)
+		}
+	}
+	return ev, true
+}
+
+// websocketURL maps an RPC base URL to its /websocket endpoint.
+func websocketURL(base string) (*url.URL, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return nil, errors.New("rpc base URL must be http(s) or ws(s)")
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/websocket"
+	return u, nil
+}
@@ -0,0 +1,221 @@
+package lumera
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsServerConn is the server side of a test websocket.
+type wsServerConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func acceptWebsocket(t *testing.T, w http.ResponseWriter, r *http.Request) *wsServerConn {
+	t.Helper()
+	conn, brw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accept := wsAcceptKey(r.Header.Get("Sec-WebSocket-Key"))
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	brw.Flush()
+	return &wsServerConn{conn: conn, br: brw.Reader}
+}
+
+// readText reads one masked client frame and returns its payload.
+func (c *wsServerConn) readText() ([]byte, error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return nil, err
+	}
+	n := int(h[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.br, ext[:])
+		n = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	var mask [4]byte
+	io.ReadFull(c.br, mask[:])
+	p := make([]byte, n)
+	if _, err := io.ReadFull(c.br, p); err != nil {
+		return nil, err
+	}
+	for i := range p {
+		p[i] ^= mask[i%4]
+	}
+	return p, nil
+}
+
+// writeFrame writes an unmasked server frame.
+func (c *wsServerConn) writeFrame(fin bool, opcode byte, p []byte) {
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	h := []byte{b0}
+	if len(p) < 126 {
+		h = append(h, byte(len(p)))
+	} else {
+		h = append(h, 126)
+		h = binary.BigEndian.AppendUint16(h, uint16(len(p)))
+	}
+	c.conn.Write(append(h, p...))
+}
+
+// TestSubscribe verifies subscriptions are acknowledged, events decoded across
+// fragmented frames and pings, and the channel closed when the server disconnects
+func TestSubscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/websocket" {
+			http.NotFound(w, r)
+			return
+		}
+		ws := acceptWebsocket(t, w, r)
+		defer ws.conn.Close()
+		for i := 0; i < 2; i++ {
+			msg, err := ws.readText()
+			if err != nil {
+				return
+			}
+			var req struct {
+				ID     int               `json:"id"`
+				Params map[string]string `json:"params"`
+			}
+			json.Unmarshal(msg, &req)
+			if req.Params["query"] == "" {
+				t.Errorf("subscribe without query: %s", msg)
+			}
+			ack, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{}})
+			ws.writeFrame(true, wsText, ack)
+		}
+
+		ws.writeFrame(true, wsPing, []byte("hi"))
+		event := `{"jsonrpc":"2.0","id":0,"result":{"query":"tm.event='Tx' AND action_registered.action_id EXISTS",` +
+			`"data":{"type":"tendermint/event/Tx","value":{"TxResult":{"height":"42"}}},` +
+			`"events":{"tx.hash":["ABC"],"action_registered.action_id":["7"]}}}`
+		ws.writeFrame(false, wsText, []byte(event[:20]))
+		ws.writeFrame(true, wsContinuation, []byte(event[20:]))
+		block := `{"jsonrpc":"2.0","id":1,"result":{"query":"tm.event='NewBlockEvents'",` +
+			`"data":{"type":"tendermint/event/NewBlockEvents","value":{"height":"43"}},"events":{"tm.event":["NewBlockEvents"]}}}`
+		ws.writeFrame(true, wsText, []byte(block))
+
+		// Expect the pong before hanging up
+		ws.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if pong, err := ws.readText(); err != nil || string(pong) != "hi" {
+			t.Errorf("pong = %q, %v", pong, err)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sub, err := NewRPCClient(server.URL, 5*time.Second).Subscribe(ctx, []string{
+		"tm.event='Tx' AND action_registered.action_id EXISTS",
+		"tm.event='NewBlockEvents'",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	var got []RPCEvent
+	for ev := range sub.Events {
+		got = append(got, ev)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(got), got)
+	}
+	if got[0].Height != 42 || got[0].Events["tx.hash"][0] != "ABC" || got[0].Events["action_registered.action_id"][0] != "7" {
+		t.Errorf("tx event = %+v", got[0])
+	}
+	if got[1].Height != 43 {
+		t.Errorf("block event = %+v", got[1])
+	}
+	if sub.Err() == nil {
+		t.Error("expected the disconnect to be reported")
+	}
+}
+
+// TestSubscribeError verifies a rejected subscription fails Subscribe
+func TestSubscribeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws := acceptWebsocket(t, w, r)
+		defer ws.conn.Close()
+		ws.readText()
+		ws.writeFrame(true, wsText, []byte(`{"jsonrpc":"2.0","id":0,"error":{"code":-32603,"message":"Internal error","data":"max subscriptions reached"}}`))
+		ws.readText()
+	}))
+	defer server.Close()
+
+	_, err := NewRPCClient(server.URL, 5*time.Second).Subscribe(context.Background(), []string{"tm.event='Tx'"})
+	if err == nil || !strings.Contains(err.Error(), "max subscriptions") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// TestRPCQueries verifies status, tx_search paging and block_results decoding
+func TestRPCQueries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var result string
+		switch r.URL.Path {
+		case "/status":
+			result = `{"sync_info":{"latest_block_height":"1234"}}`
+		case "/tx_search":
+			if q.Get("query") != `"action_registered.action_id EXISTS"` {
+				t.Errorf("query = %s", q.Get("query"))
+			}
+			if q.Get("page") == "1" {
+				var txs []string
+				for i := 0; i < 100; i++ {
+					txs = append(txs, `{"hash":"aa","height":"10","tx_result":{"code":0,"events":[]}}`)
+				}
+				result = `{"txs":[` + strings.Join(txs, ",") + `],"total_count":"101"}`
+			} else {
+				result = `{"txs":[{"hash":"bb","height":"11","tx_result":{"code":0,"events":[{"type":"action_registered","attributes":[{"key":"action_id","value":"5"}]}]}}],"total_count":"101"}`
+			}
+		case "/block_results":
+			result = `{"height":"` + q.Get("height") + `","txs_results":[{"events":[]}],"finalize_block_events":[{"type":"action_expired","attributes":[{"key":"action_id","value":"9"}]}]}`
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":-1,"error":{"code":-32601,"message":"Method not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":-1,"result":` + result + `}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewRPCClient(server.URL, 5*time.Second)
+	if h, err := c.LatestHeight(ctx); err != nil || h != 1234 {
+		t.Errorf("LatestHeight = %d, %v", h, err)
+	}
+	txs, err := c.TxSearch(ctx, "action_registered.action_id EXISTS", 0)
+	if err != nil || len(txs) != 101 {
+		t.Fatalf("TxSearch = %d txs, %v", len(txs), err)
+	}
+	if last := txs[100]; last.Hash != "BB" || last.Height != 11 || len(last.Events) != 1 {
+		t.Errorf("last tx = %+v", last)
+	}
+	res, err := c.BlockResults(ctx, 77)
+	if err != nil || res.Height != 77 || len(res.TxEvents) != 1 || len(res.BlockEvents) != 1 || res.BlockEvents[0].Type != "action_expired" {
+		t.Errorf("BlockResults = %+v, %v", res, err)
+	}
+	if err := c.call(ctx, "nope", nil, &struct{}{}); err == nil || !strings.Contains(err.Error(), "Method not found") {
+		t.Errorf("unknown method err = %v", err)
+	}
+}
@@ -0,0 +1,376 @@
+// Package metrics is a minimal Prometheus text-exposition implementation using stdlib only.
+// It supports labelled counters, gauges, histograms and scrape-time gauge functions,
+// which is all LumeScope needs without pulling in the Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the default histogram buckets (seconds), matching the Prometheus client defaults.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector is anything that can write its series in exposition format.
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds a set of metrics exposed together on one endpoint.
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default is the process-wide registry served by Handler.
+var Default = NewRegistry()
+
+// register adds c to the registry. Registering a second metric under the same
+// name returns the existing one so package-level vars stay idempotent.
+func (r *Registry) register(c collector) collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.collectors[c.name()]; ok {
+		return existing
+	}
+	r.collectors[c.name()] = c
+	return c
+}
+
+// WriteTo writes all registered metrics in Prometheus text format, sorted by name.
+func (r *Registry) WriteTo(w *bufio.Writer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for n := range r.collectors {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	cs := make([]collector, 0, len(names))
+	for _, n := range names {
+		cs = append(cs, r.collectors[n])
+	}
+	r.mu.Unlock()
+
+	for _, c := range cs {
+		c.write(w)
+	}
+}
+
+// Handler serves the registry in Prometheus text exposition format (version 0.0.4).
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		bw := bufio.NewWriter(w)
+		r.WriteTo(bw)
+		bw.Flush()
+	}
+}
+
+// Handler serves the Default registry.
+func Handler() http.HandlerFunc { return Default.Handler() }
+
+// vec holds the label schema and per-series values shared by all metric kinds.
+type vec struct {
+	metricName string
+	help       string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64   // counter / gauge value
+	counts      []uint64  // histogram bucket counts (non-cumulative)
+	sum         float64   // histogram sum
+	count       uint64    // histogram observation count
+	buckets     []float64 // histogram upper bounds
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{metricName: name, help: help, labels: labels, series: make(map[string]*series)}
+}
+
+func (v *vec) name() string { return v.metricName }
+
+// get returns the series for the given label values, creating it if needed. Caller holds v.mu.
+func (v *vec) get(labelValues []string) *series {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.metricName, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sorted returns a snapshot of series ordered by label values. Caller holds v.mu.
+func (v *vec) sorted() []*series {
+	out := make([]*series, 0, len(v.series))
+	for _, s := range v.series {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.Join(out[i].labelValues, "\xff") < strings.Join(out[j].labelValues, "\xff")
+	})
+	return out
+}
+
+func (v *vec) writeHeader(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.metricName, escapeHelp(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.metricName, typ)
+}
+
+// CounterVec is a monotonically increasing counter partitioned by labels.
+type CounterVec struct{ vec }
+
+// NewCounterVec creates and registers a counter on the Default registry.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec creates and registers a counter on r.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return r.register(&CounterVec{newVec(name, help, labels)}).(*CounterVec)
+}
+
+// Inc adds one to the series identified by labelValues.
+func (c *CounterVec) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add adds delta (which must be non-negative) to the series identified by labelValues.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.get(labelValues).value += delta
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w, "counter")
+	for _, s := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, formatLabels(c.labels, s.labelValues, "", ""), formatFloat(s.value))
+	}
+}
+
+// GaugeVec is a value that can go up and down, partitioned by labels.
+type GaugeVec struct{ vec }
+
+// NewGaugeVec creates and registers a gauge on the Default registry.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+// NewGaugeVec creates and registers a gauge on r.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return r.register(&GaugeVec{newVec(name, help, labels)}).(*GaugeVec)
+}
+
+// Set sets the series identified by labelValues to val.
+func (g *GaugeVec) Set(val float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value = val
+	g.mu.Unlock()
+}
+
+// Add adds delta (positive or negative) to the series identified by labelValues.
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value += delta
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(w, "gauge")
+	for _, s := range g.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, formatLabels(g.labels, s.labelValues, "", ""), formatFloat(s.value))
+	}
+}
+
+// HistogramVec samples observations into cumulative buckets, partitioned by labels.
+type HistogramVec struct {
+	vec
+	buckets []float64
+}
+
+// NewHistogramVec creates and registers a histogram on the Default registry.
+// If buckets is nil, DefBuckets is used.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogramVec creates and registers a histogram on r.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return r.register(&HistogramVec{vec: newVec(name, help, labels), buckets: b}).(*HistogramVec)
+}
+
+// Observe records val in the series identified by labelValues.
+func (h *HistogramVec) Observe(val float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues)
+	if s.counts == nil {
+		s.buckets = h.buckets
+		s.counts = make([]uint64, len(h.buckets))
+	}
+	for i, ub := range s.buckets {
+		if val <= ub {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += val
+	s.count++
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w, "histogram")
+	for _, s := range h.sorted() {
+		var cum uint64
+		for i, ub := range s.buckets {
+			cum += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues, "le", formatFloat(ub)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, formatLabels(h.labels, s.labelValues, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, formatLabels(h.labels, s.labelValues, "", ""), s.count)
+	}
+}
+
+// GaugeFunc is an unlabelled gauge whose value is computed at scrape time.
+type GaugeFunc struct {
+	metricName string
+	help       string
+	fn         func() float64
+}
+
+// NewGaugeFunc creates and registers a scrape-time gauge on the Default registry.
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	return Default.NewGaugeFunc(name, help, fn)
+}
+
+// NewGaugeFunc creates and registers a scrape-time gauge on r.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	return r.register(&GaugeFunc{metricName: name, help: help, fn: fn}).(*GaugeFunc)
+}
+
+func (g *GaugeFunc) name() string { return g.metricName }
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", g.metricName, escapeHelp(g.help))
+	fmt.Fprintf(w, "# TYPE %s gauge\n", g.metricName)
+	fmt.Fprintf(w, "%s %s\n", g.metricName, formatFloat(g.fn()))
+}
+
+// GaugeFuncVec is a gauge partitioned by labels whose series are computed at scrape time.
+type GaugeFuncVec struct {
+	vec
+	fns map[string]func() float64 // by series key
+}
+
+// NewGaugeFuncVec creates and registers a labelled scrape-time gauge on the Default registry.
+func NewGaugeFuncVec(name, help string, labels ...string) *GaugeFuncVec {
+	return Default.NewGaugeFuncVec(name, help, labels...)
+}
+
+// NewGaugeFuncVec creates and registers a labelled scrape-time gauge on r.
+func (r *Registry) NewGaugeFuncVec(name, help string, labels ...string) *GaugeFuncVec {
+	return r.register(&GaugeFuncVec{vec: newVec(name, help, labels), fns: make(map[string]func() float64)}).(*GaugeFuncVec)
+}
+
+// Set makes fn compute the series identified by labelValues, replacing any previous one.
+func (g *GaugeFuncVec) Set(fn func() float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(labelValues)
+	g.fns[strings.Join(labelValues, "\xff")] = fn
+}
+
+func (g *GaugeFuncVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(w, "gauge")
+	for _, s := range g.sorted() {
+		fn := g.fns[strings.Join(s.labelValues, "\xff")]
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, formatLabels(g.labels, s.labelValues, "", ""), formatFloat(fn()))
+	}
+}
+
+// formatLabels renders {k="v",...}, optionally appending one extra label (used for "le").
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(n)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabelValue(values[i]))
+		sb.WriteByte('"')
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(extraName)
+		sb.WriteString(`="`)
+		sb.WriteString(extraValue)
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string { return labelEscaper.Replace(s) }
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func render(r *Registry) string {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	r.WriteTo(w)
+	w.Flush()
+	return buf.String()
+}
+
+// TestCounterExposition verifies counter output format and label ordering
+func TestCounterExposition(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "Total requests.", "route", "code")
+	c.Inc("/v1/b", "200")
+	c.Inc("/v1/a", "200")
+	c.Add(2, "/v1/a", "200")
+	c.Add(-1, "/v1/a", "200") // negative deltas are ignored
+
+	want := `# HELP test_requests_total Total requests.
+# TYPE test_requests_total counter
+test_requests_total{route="/v1/a",code="200"} 3
+test_requests_total{route="/v1/b",code="200"} 1
+`
+	if got := render(r); got != want {
+		t.Errorf("exposition mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestHistogramExposition verifies cumulative buckets, sum and count
+func TestHistogramExposition(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_duration_seconds", "Durations.", []float64{1, 0.1}, "loop")
+	h.Observe(0.05, "x")
+	h.Observe(0.5, "x")
+	h.Observe(5, "x")
+
+	want := `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{loop="x",le="0.1"} 1
+test_duration_seconds_bucket{loop="x",le="1"} 2
+test_duration_seconds_bucket{loop="x",le="+Inf"} 3
+test_duration_seconds_sum{loop="x"} 5.55
+test_duration_seconds_count{loop="x"} 3
+`
+	if got := render(r); got != want {
+		t.Errorf("exposition mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestGaugeAndGaugeFunc verifies gauges, scrape-time gauges (plain and labelled) and
+// label escaping
+func TestGaugeAndGaugeFunc(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("test_backlog", "Backlog.", "name")
+	g.Set(10, `a"b`)
+	g.Add(-3, `a"b`)
+	r.NewGaugeFunc("test_open_conns", "Open connections.", func() float64 { return 4 })
+	pools := r.NewGaugeFuncVec("test_pool_conns", "Pool connections.", "network")
+	pools.Set(func() float64 { return 2 }, "testnet")
+	pools.Set(func() float64 { return 1 }, "mainnet")
+	pools.Set(func() float64 { return 3 }, "mainnet")
+
+	out := render(r)
+	if !strings.Contains(out, `test_backlog{name="a\"b"} 7`) {
+		t.Errorf("missing escaped gauge series in:\n%s", out)
+	}
+	if !strings.Contains(out, "# TYPE test_open_conns gauge\ntest_open_conns 4\n") {
+		t.Errorf("missing gauge func in:\n%s", out)
+	}
+	if !strings.Contains(out, "# TYPE test_pool_conns gauge\ntest_pool_conns{network=\"mainnet\"} 3\ntest_pool_conns{network=\"testnet\"} 2\n") {
+		t.Errorf("missing labelled gauge func series in:\n%s", out)
+	}
+}
+
+// TestRegisterIdempotent verifies re-registering a name returns the existing metric
+func TestRegisterIdempotent(t *testing.T) {
+	r := NewRegistry()
+	a := r.NewCounterVec("test_total", "A.")
+	b := r.NewCounterVec("test_total", "B.")
+	if a != b {
+		t.Error("expected second registration to return the existing counter")
+	}
+}
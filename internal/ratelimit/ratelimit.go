@@ -0,0 +1,133 @@
+// Package ratelimit implements in-memory token buckets keyed by client (API key or IP).
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit is a sustained rate plus a burst allowance. A non-positive PerMinute means unlimited.
+type Limit struct {
+	PerMinute float64
+	Burst     int
+}
+
+// Unlimited reports whether l imposes no limit.
+func (l Limit) Unlimited() bool { return l.PerMinute <= 0 }
+
+func (l Limit) burst() float64 {
+	if l.Burst < 1 {
+		return 1
+	}
+	return float64(l.Burst)
+}
+
+func (l Limit) perSecond() float64 { return l.PerMinute / 60 }
+
+// ParseLimit parses "<perMinute>:<burst>", e.g. "60:20". "0:0" means unlimited.
+func ParseLimit(s string) (Limit, error) {
+	rate, burst, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q: want <perMinute>:<burst>", s)
+	}
+	pm, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || pm < 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: invalid per-minute rate", s)
+	}
+	b, err := strconv.Atoi(strings.TrimSpace(burst))
+	if err != nil || b < 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: invalid burst", s)
+	}
+	return Limit{PerMinute: pm, Burst: b}, nil
+}
+
+// Decision is the outcome of one Allow call, used to fill X-RateLimit-* headers.
+type Decision struct {
+	Allowed    bool
+	Limit      Limit
+	Remaining  int           // whole tokens left after this request
+	RetryAfter time.Duration // until one token is available (0 when allowed)
+	ResetAfter time.Duration // until the bucket is full again
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Duration // time to refill from empty under the bucket's last limit
+}
+
+// sweepInterval is how often idle, fully refilled buckets are dropped.
+const sweepInterval = time.Minute
+
+// Limiter holds one token bucket per key. It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// New returns an empty Limiter.
+func New() *Limiter {
+	return &Limiter{buckets: make(map[string]*bucket)}
+}
+
+// Allow takes one token from key's bucket under limit l.
+func (lim *Limiter) Allow(key string, l Limit, now time.Time) Decision {
+	if l.Unlimited() {
+		return Decision{Allowed: true, Limit: l}
+	}
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+
+	if now.Sub(lim.lastSweep) >= sweepInterval {
+		lim.sweep(now)
+		lim.lastSweep = now
+	}
+
+	capacity, rate := l.burst(), l.perSecond()
+	b, ok := lim.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		lim.buckets[key] = b
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+		b.last = now
+	}
+	b.full = secondsToDuration(capacity / rate)
+
+	d := Decision{Limit: l}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+	d.Remaining = int(math.Floor(b.tokens))
+	d.ResetAfter = secondsToDuration((capacity - b.tokens) / rate)
+	return d
+}
+
+// sweep drops buckets that have been idle long enough to be full again under their
+// own limit, so dropping them doesn't hand out tokens early.
+func (lim *Limiter) sweep(now time.Time) {
+	for k, b := range lim.buckets {
+		if now.Sub(b.last) > b.full {
+			delete(lim.buckets, k)
+		}
+	}
+}
+
+// Len returns the number of tracked buckets.
+func (lim *Limiter) Len() int {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	return len(lim.buckets)
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAllowBurstAndRefill verifies burst consumption, rejection and refill over time
+func TestAllowBurstAndRefill(t *testing.T) {
+	lim := New()
+	l := Limit{PerMinute: 60, Burst: 3} // 1 token/s
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		d := lim.Allow("ip:1.2.3.4", l, now)
+		if !d.Allowed || d.Remaining != 2-i {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i, d, 2-i)
+		}
+	}
+	d := lim.Allow("ip:1.2.3.4", l, now)
+	if d.Allowed || d.RetryAfter != time.Second || d.ResetAfter != 3*time.Second {
+		t.Fatalf("over burst: %+v, want rejected, retry after 1s, reset after 3s", d)
+	}
+
+	// Other keys have their own bucket
+	if d := lim.Allow("ip:5.6.7.8", l, now); !d.Allowed {
+		t.Errorf("independent key rejected: %+v", d)
+	}
+
+	// One second later one token is back
+	d = lim.Allow("ip:1.2.3.4", l, now.Add(time.Second))
+	if !d.Allowed || d.Remaining != 0 {
+		t.Errorf("after refill: %+v, want allowed with 0 remaining", d)
+	}
+}
+
+// TestAllowUnlimited verifies a zero rate never rejects or tracks buckets
+func TestAllowUnlimited(t *testing.T) {
+	lim := New()
+	for i := 0; i < 100; i++ {
+		if d := lim.Allow("key:1", Limit{}, time.Now()); !d.Allowed {
+			t.Fatalf("unlimited rejected at %d", i)
+		}
+	}
+	if lim.Len() != 0 {
+		t.Errorf("unlimited created %d buckets", lim.Len())
+	}
+}
+
+// TestSweep verifies idle buckets are dropped once refilled
+func TestSweep(t *testing.T) {
+	lim := New()
+	l := Limit{PerMinute: 60, Burst: 5}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	lim.Allow("a", l, now)
+	lim.Allow("b", l, now.Add(2*time.Minute))
+	if lim.Len() != 1 {
+		t.Errorf("buckets = %d, want idle bucket swept", lim.Len())
+	}
+}
+
+// TestSweepMixedLimits verifies a bucket under a slow limit outlives a sweep triggered
+// by a key with a fast one, so it isn't refilled early
+func TestSweepMixedLimits(t *testing.T) {
+	lim := New()
+	slow := Limit{PerMinute: 6, Burst: 100} // full after 1000s
+	fast := Limit{PerMinute: 60, Burst: 20} // full after 20s
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		lim.Allow("key:slow", slow, now)
+	}
+	lim.Allow("ip:1.2.3.4", fast, now.Add(2*time.Minute))
+	if lim.Len() != 2 {
+		t.Fatalf("buckets = %d, want the slow bucket kept", lim.Len())
+	}
+	if d := lim.Allow("key:slow", slow, now.Add(2*time.Minute)); !d.Allowed || d.Remaining != 11 {
+		t.Errorf("slow bucket after 2 minutes: %+v, want 12 tokens refilled", d)
+	}
+	lim.Allow("ip:1.2.3.4", fast, now.Add(time.Hour))
+	if lim.Len() != 1 {
+		t.Errorf("buckets = %d, want the refilled slow bucket swept", lim.Len())
+	}
+}
+
+// TestParseLimit verifies "<perMinute>:<burst>" parsing
+func TestParseLimit(t *testing.T) {
+	l, err := ParseLimit("600:100")
+	if err != nil || l.PerMinute != 600 || l.Burst != 100 {
+		t.Errorf("ParseLimit = %+v, %v", l, err)
+	}
+	if l, err := ParseLimit("0:0"); err != nil || !l.Unlimited() {
+		t.Errorf("0:0 = %+v, %v; want unlimited", l, err)
+	}
+	for _, bad := range []string{"600", "x:1", "10:-1", "-5:1"} {
+		if _, err := ParseLimit(bad); err == nil {
+			t.Errorf("ParseLimit(%q): expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumescope/internal/apikeys"
+	"lumescope/internal/config"
+	"lumescope/internal/metrics"
+	"lumescope/internal/ratelimit"
+	"lumescope/internal/util"
+)
+
+// APIKeyHeader carries an API key; the api_key query parameter is also accepted
+// (e.g. for EventSource, which cannot set headers).
+const APIKeyHeader = "X-API-Key"
+
+var httpRejectedTotal = metrics.NewCounterVec(
+	"lumescope_http_rejected_total",
+	"Requests rejected by API key or rate limit checks.",
+	"reason")
+
+// withAPIKeys authenticates API keys and enforces per-key and per-IP token-bucket
+// rate limits. Health, readiness and metrics endpoints are exempt; admin endpoints
+// have their own token and don't need an API key, but are still rate limited. A
+// rejected key is charged to the caller's IP bucket, so guessing keys is limited like
+// anonymous traffic.
+func withAPIKeys(cfg config.Config, store *apikeys.Store, limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz", "/readyz", "/metrics":
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		presented := r.Header.Get(APIKeyHeader)
+		if presented == "" {
+			presented = r.URL.Query().Get("api_key")
+		}
+
+		var (
+			bucket string
+			limit  ratelimit.Limit
+		)
+		if presented != "" {
+			k, ok, err := store.Lookup(r.Context(), presented)
+			if err != nil {
+				log.Printf("api key lookup: %v", err)
+				util.WriteJSONError(w, http.StatusServiceUnavailable, "api key lookup failed")
+				return
+			}
+			if !ok {
+				if cfg.RateLimitEnabled && !allowRequest(w, limiter, "ip:"+clientIP(r, cfg.TrustProxyHeaders), cfg.RateLimitAnonymous) {
+					return
+				}
+				httpRejectedTotal.Inc("invalid_key")
+				util.WriteJSONError(w, http.StatusUnauthorized, "invalid api key")
+				return
+			}
+			bucket = "key:" + strconv.FormatInt(k.ID, 10)
+			limit = apikeys.Limit(k, cfg.RateLimitTiers, cfg.RateLimitAnonymous)
+		} else {
+			if cfg.APIKeyRequired && !isAdminPath(r.URL.Path) {
+				httpRejectedTotal.Inc("missing_key")
+				util.WriteJSONError(w, http.StatusUnauthorized, "api key required: send it in the "+APIKeyHeader+" header or api_key query parameter")
+				return
+			}
+			bucket = "ip:" + clientIP(r, cfg.TrustProxyHeaders)
+			limit = cfg.RateLimitAnonymous
+		}
+
+		if cfg.RateLimitEnabled && !allowRequest(w, limiter, bucket, limit) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowRequest takes a token from bucket and sets the rate limit headers. When the
+// bucket is empty it writes a 429 and returns false.
+func allowRequest(w http.ResponseWriter, limiter *ratelimit.Limiter, bucket string, limit ratelimit.Limit) bool {
+	if limit.Unlimited() {
+		return true
+	}
+	d := limiter.Allow(bucket, limit, time.Now())
+	setRateLimitHeaders(w.Header(), d)
+	if !d.Allowed {
+		httpRejectedTotal.Inc("rate_limited")
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(d.RetryAfter)))
+		util.WriteJSONError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return false
+	}
+	return true
+}
+
+// setRateLimitHeaders reports the sustained limit per minute, whole tokens left and
+// seconds until the bucket is full again.
+func setRateLimitHeaders(h http.Header, d ratelimit.Decision) {
+	h.Set("X-RateLimit-Limit", strconv.Itoa(int(math.Round(d.Limit.PerMinute))))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(d.ResetAfter)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// isAdminPath reports whether path is an admin endpoint, with or without a network prefix.
+func isAdminPath(path string) bool {
+	const prefix = "/v1/admin/"
+	if strings.HasPrefix(path, prefix) {
+		return true
+	}
+	_, rest, ok := splitNetworkPath(path)
+	return ok && strings.HasPrefix(rest, prefix)
+}
+
+// clientIP returns the caller's IP. Proxy headers are only trusted when configured,
+// since clients can set them freely.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
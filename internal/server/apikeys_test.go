@@ -0,0 +1,113 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lumescope/internal/config"
+	"lumescope/internal/ratelimit"
+)
+
+// TestWithAPIKeysAnonymousRateLimit verifies per-IP limits, 429 responses and rate limit headers
+func TestWithAPIKeysAnonymousRateLimit(t *testing.T) {
+	cfg := config.Config{
+		RateLimitEnabled:   true,
+		RateLimitAnonymous: ratelimit.Limit{PerMinute: 60, Burst: 2},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	h := withAPIKeys(cfg, nil, ratelimit.New(), ok)
+
+	do := func(path, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := do("/v1/actions", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, rec.Code)
+		}
+	}
+	rec := do("/v1/actions", "10.0.0.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "1" || rec.Header().Get("X-RateLimit-Limit") != "60" || rec.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("headers = %v", rec.Header())
+	}
+
+	if rec := do("/v1/actions", "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("other IP: status %d, want 200", rec.Code)
+	}
+	if rec := do("/healthz", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("healthz: status %d, want exempt", rec.Code)
+	}
+}
+
+// TestWithAPIKeysInvalidKeyRateLimit verifies rejected keys are charged to the caller's
+// IP bucket, so a flood of bogus keys is rate limited and can't bypass the IP limit
+func TestWithAPIKeysInvalidKeyRateLimit(t *testing.T) {
+	cfg := config.Config{
+		RateLimitEnabled:   true,
+		RateLimitAnonymous: ratelimit.Limit{PerMinute: 60, Burst: 2},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	h := withAPIKeys(cfg, nil, ratelimit.New(), ok)
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/actions", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if rec := do("bogus"); rec.Code != want {
+			t.Fatalf("bogus key %d: status %d, want %d", i, rec.Code, want)
+		}
+	}
+	if rec := do(""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("anonymous after bogus keys: status %d, want 429", rec.Code)
+	}
+}
+
+// TestWithAPIKeysRequired verifies anonymous requests are rejected when keys are required
+func TestWithAPIKeysRequired(t *testing.T) {
+	cfg := config.Config{APIKeyRequired: true}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	h := withAPIKeys(cfg, nil, ratelimit.New(), ok)
+
+	for path, want := range map[string]int{
+		"/v1/actions":                 http.StatusUnauthorized,
+		"/v1/admin/webhooks":          http.StatusOK,
+		"/v1/testnet/admin/webhooks/": http.StatusOK,
+		"/readyz":                     http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status %d, want %d", path, rec.Code, want)
+		}
+	}
+}
+
+// TestClientIP verifies proxy headers are only honoured when trusted
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	if ip := clientIP(req, false); ip != "10.0.0.1" {
+		t.Errorf("untrusted: %q, want 10.0.0.1", ip)
+	}
+	if ip := clientIP(req, true); ip != "203.0.113.7" {
+		t.Errorf("trusted: %q, want 203.0.113.7", ip)
+	}
+}
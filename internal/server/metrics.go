@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"lumescope/internal/metrics"
+)
+
+var (
+	httpRequestsTotal = metrics.NewCounterVec(
+		"lumescope_http_requests_total",
+		"Total HTTP requests by route pattern, method and status code.",
+		"route", "method", "code")
+	httpRequestDuration = metrics.NewHistogramVec(
+		"lumescope_http_request_duration_seconds",
+		"HTTP request latency by route pattern and method.",
+		nil, "route", "method")
+)
+
+// statusRecorder captures the status code written by downstream handlers.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (flush, deadlines).
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+// withMetrics records request counts and latency per route. It must wrap the mux
+// directly so the matched pattern (r.Pattern) is available after dispatch; using
+// the pattern rather than the raw path keeps label cardinality bounded.
+func withMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		code := rec.status
+		if code == 0 {
+			code = http.StatusOK
+		}
+		httpRequestsTotal.Inc(route, r.Method, strconv.Itoa(code))
+		httpRequestDuration.Observe(time.Since(start).Seconds(), route, r.Method)
+	})
+}
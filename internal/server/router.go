@@ -1,35 +1,142 @@
 package server
 
 import (
+	"crypto/subtle"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"lumescope/internal/apikeys"
+	"lumescope/internal/background"
 	"lumescope/internal/config"
 	"lumescope/internal/db"
 	"lumescope/internal/handlers"
+	"lumescope/internal/metrics"
+	"lumescope/internal/ratelimit"
+	"lumescope/internal/util"
 )
 
+// Backend is the per-network state served by the API.
+type Backend struct {
+	Cfg         config.Config // the network's config
+	Pool        *db.Pool
+	SyncTrigger handlers.SyncTrigger
+	LoopStatus  handlers.LoopStatus
+	TxFetcher   handlers.TxFetcher
+	// ChainEndpoints reports LCD endpoint health; nil when the chain client has none
+	ChainEndpoints handlers.ChainEndpoints
+	// Exports bounds bulk exports; NewRouter shares one across all networks
+	Exports handlers.ExportLimits
+}
+
 // NewRouter builds the HTTP router using only net/http ServeMux and stdlib middleware.
-func NewRouter(cfg config.Config, pool *db.Pool, syncTrigger handlers.SyncTrigger) http.Handler {
+// backends[0] is the default network, served on the unprefixed /v1 routes; every
+// network is also served under /v1/{network}/...
+func NewRouter(cfg config.Config, backends []Backend) http.Handler {
 	mux := http.NewServeMux()
 
 	// Health
 	mux.HandleFunc("/healthz", handlers.Healthz)
-	mux.HandleFunc("/readyz", handlers.Readyz)
+	mux.HandleFunc("/readyz", handlers.Readyz(readinessTargets(backends)...))
 
-	// Optional metrics stub (no third-party dependency)
+	// Prometheus metrics (text exposition, no third-party dependency)
 	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			methodNotAllowed(w)
 			return
 		}
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		metrics.Handler()(w, r)
+	})
+
+	networks := make([]handlers.NetworkDTO, 0, len(backends))
+	for i, b := range backends {
+		networks = append(networks, handlers.NetworkDTO{
+			Name:     b.Cfg.Network,
+			ChainID:  b.Cfg.ChainID,
+			Default:  i == 0,
+			BasePath: "/v1/" + b.Cfg.Network,
+		})
+	}
+	mux.HandleFunc("/v1/networks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.ListNetworks(networks)(w, r)
+	})
+
+	// OpenAPI spec endpoint
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		http.ServeFile(w, r, "docs/openapi.json")
+	})
+
+	// Swagger UI documentation
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("# metrics disabled (no third-party deps)\n"))
+		w.Write([]byte(swaggerUIPage))
 	})
 
+	// GraphQL for the default network; every network also serves /v1/graphql
+	mux.HandleFunc("/graphql", graphQLHandler(backends[0]))
+
+	// Network-scoped API: /v1/{network}/... for every network, unprefixed for the default
+	exports := handlers.ExportLimits{
+		Slots:        handlers.NewExportSlots(cfg.ExportMaxConcurrent),
+		MaxDuration:  cfg.ExportMaxDuration,
+		WriteTimeout: cfg.ExportWriteTimeout,
+	}
+	backends = append([]Backend(nil), backends...)
+	for i := range backends {
+		backends[i].Exports = exports
+	}
+	def := newNetworkMux(backends[0])
+	nets := make(map[string]http.Handler, len(backends))
+	for i, b := range backends {
+		name := b.Cfg.Network
+		if routeExists(mux, name) || routeExists(def, name) {
+			log.Printf("network %q collides with an existing /v1/%s route; it is only reachable as the default network", name, name)
+			continue
+		}
+		if i == 0 {
+			nets[name] = def
+		} else {
+			nets[name] = newNetworkMux(b)
+		}
+	}
+	mux.Handle("/", networkDispatch(def, nets))
+
+	// Wrap mux with stdlib middlewares
+	var h http.Handler = mux
+	h = withMetrics(h)
+	h = withServerHeader(h)
+	h = withDefaultCacheControl(h)
+	h = withDateHeader(h)
+	if cfg.RateLimitEnabled || cfg.APIKeyRequired {
+		h = withAPIKeys(cfg, apikeys.NewStore(backends[0].Pool, cfg.APIKeyCacheTTL), ratelimit.New(), h)
+	}
+	h = withCORS(cfg, h)
+	h = withRecover(h)
+	h = withTimeout(cfg, h)
+
+	return h
+}
+
+// newNetworkMux registers the /v1 API routes of one network.
+func newNetworkMux(b Backend) *http.ServeMux {
+	mux := http.NewServeMux()
+	cfg, pool, syncTrigger := b.Cfg, b.Pool, b.SyncTrigger
+
 	// Actions list (exact path)
 	mux.HandleFunc("/v1/actions", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/v1/actions" && r.URL.Path != "/v1/actions/" {
@@ -52,6 +159,24 @@ func NewRouter(cfg config.Config, pool *db.Pool, syncTrigger handlers.SyncTrigge
 		handlers.GetActionStats(pool)(w, r)
 	})
 
+	// Time-bucketed action activity: /v1/actions/timeseries
+	mux.HandleFunc("/v1/actions/timeseries", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.GetActionTimeseries(pool)(w, r)
+	})
+
+	// Action lifecycle latency percentiles: /v1/actions/latency
+	mux.HandleFunc("/v1/actions/latency", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.GetActionLatency(pool)(w, r)
+	})
+
 	// Actions detail: /v1/actions/{id}
 	mux.HandleFunc("/v1/actions/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -67,6 +192,34 @@ func NewRouter(cfg config.Config, pool *db.Pool, syncTrigger handlers.SyncTrigge
 		handlers.GetAction(pool)(w, r)
 	})
 
+	// Live action events (Server-Sent Events)
+	mux.HandleFunc("/v1/stream/actions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.StreamActions(pool, cfg.StreamPollInterval)(w, r)
+	})
+
+	mux.HandleFunc("/v1/stream/supernodes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.StreamSupernodeEvents(pool, cfg.StreamPollInterval)(w, r)
+	})
+
+	// Bulk exports (CSV, NDJSON, Parquet): /v1/export/{dataset}
+	for _, ds := range db.ExportDatasets {
+		mux.HandleFunc("/v1/export/"+ds.Name, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				methodNotAllowed(w)
+				return
+			}
+			handlers.Export(pool, ds, b.Exports)(w, r)
+		})
+	}
+
 	// Conditionally register sync endpoint (disabled by default)
 	if cfg.EnableSyncEndpoint {
 		mux.HandleFunc("/v1/supernodes/sync", func(w http.ResponseWriter, r *http.Request) {
@@ -79,6 +232,15 @@ func NewRouter(cfg config.Config, pool *db.Pool, syncTrigger handlers.SyncTrigge
 		})
 	}
 
+	// Admin API (webhook management, chain endpoint health); only registered when ADMIN_TOKEN is set
+	if cfg.AdminToken != "" {
+		mux.Handle("/v1/admin/webhooks", requireAdmin(cfg, handlers.AdminWebhooks(pool)))
+		mux.Handle("/v1/admin/webhooks/", requireAdmin(cfg, handlers.AdminWebhook(pool)))
+		if b.ChainEndpoints != nil {
+			mux.Handle("/v1/admin/chain/endpoints", requireAdmin(cfg, handlers.AdminChainEndpoints(b.ChainEndpoints)))
+		}
+	}
+
 	mux.HandleFunc("/v1/supernodes/metrics", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			methodNotAllowed(w)
@@ -87,6 +249,14 @@ func NewRouter(cfg config.Config, pool *db.Pool, syncTrigger handlers.SyncTrigge
 		handlers.ListSupernodesMetrics(pool)(w, r)
 	})
 
+	mux.HandleFunc("/v1/supernodes/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.ListSupernodeEvents(pool)(w, r)
+	})
+
 	mux.HandleFunc("/v1/supernodes/stats", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			methodNotAllowed(w)
@@ -103,12 +273,74 @@ func NewRouter(cfg config.Config, pool *db.Pool, syncTrigger handlers.SyncTrigge
 		handlers.GetSupernodeActionStats(pool)(w, r)
 	})
 
-	// Supernode detail endpoints: /v1/supernodes/{id}/metrics, /v1/supernodes/{id}/paymentInfo
+	// Search across actions, transactions, accounts, supernodes and files: /v1/search
+	mux.HandleFunc("/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.Search(pool)(w, r)
+	})
+
+	// Transaction lookup with LCD fallback: /v1/txs/{hash}
+	mux.HandleFunc("/v1/txs/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.GetTx(pool, b.TxFetcher)(w, r)
+	})
+
+	// Account profiles: /v1/accounts/{address}, /v1/accounts/{address}/actions,
+	// /v1/accounts/{address}/transfers
+	mux.HandleFunc("/v1/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		rest := strings.TrimPrefix(r.URL.Path, "/v1/accounts/")
+		address, sub, _ := strings.Cut(rest, "/")
+		if address == "" {
+			http.NotFound(w, r)
+			return
+		}
+		switch sub {
+		case "":
+			handlers.GetAccount(pool)(w, r)
+		case "actions":
+			handlers.ListAccountActions(pool)(w, r)
+		case "transfers":
+			handlers.ListAccountTransfers(pool)(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	// Cross-network supernode ranking: /v1/supernodes/leaderboard
+	mux.HandleFunc("/v1/supernodes/leaderboard", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		handlers.GetSupernodeLeaderboard(pool)(w, r)
+	})
+
+	// Supernode detail endpoints: /v1/supernodes/{id}/metrics, /v1/supernodes/{id}/metrics/history,
+	// /v1/supernodes/{id}/availability, /v1/supernodes/{id}/paymentInfo
+	probeRetention := handlers.ProbeHistoryRetention{Raw: cfg.ProbeSamplesRetention, Rollup5m: cfg.ProbeRollup5mRetention}
 	mux.HandleFunc("/v1/supernodes/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			methodNotAllowed(w)
 			return
 		}
+		if strings.HasSuffix(r.URL.Path, "/availability") {
+			handlers.GetSupernodeAvailability(pool)(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/metrics/history") {
+			handlers.GetSupernodeMetricsHistory(pool, probeRetention)(w, r)
+			return
+		}
 		// Check if path ends with /metrics
 		if strings.HasSuffix(r.URL.Path, "/metrics") {
 			handlers.GetSupernodeMetrics(pool)(w, r)
@@ -131,32 +363,94 @@ func NewRouter(cfg config.Config, pool *db.Pool, syncTrigger handlers.SyncTrigge
 		handlers.ListUnavailableSupernodes(pool)(w, r)
 	})
 
-	mux.HandleFunc("/v1/version/matrix", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/v1/graphql", graphQLHandler(b))
+	mux.HandleFunc("/v1/graphql/schema", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			methodNotAllowed(w)
 			return
 		}
-		handlers.VersionMatrix(pool)(w, r)
+		handlers.GraphQLSchema()(w, r)
 	})
 
-	// OpenAPI spec endpoint
-	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/v1/version/matrix", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			methodNotAllowed(w)
 			return
 		}
-		http.ServeFile(w, r, "docs/openapi.json")
+		handlers.VersionMatrix(pool)(w, r)
 	})
 
-	// Swagger UI documentation
-	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			methodNotAllowed(w)
+	// Fallback 404 handler for any other path
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not_found"}`))
+	})
+
+	return mux
+}
+
+// graphQLHandler serves GraphQL queries for one network over GET and POST.
+func graphQLHandler(b Backend) http.HandlerFunc {
+	limits := handlers.GraphQLLimits{MaxDepth: b.Cfg.GraphQLMaxDepth, MaxComplexity: b.Cfg.GraphQLMaxComplexity}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST, OPTIONS")
+			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`<!DOCTYPE html>
+		handlers.GraphQL(b.Pool, limits)(w, r)
+	}
+}
+
+// routeExists reports whether mux has a route other than the catch-all for /v1/{name}
+// or /v1/{name}/.
+func routeExists(mux *http.ServeMux, name string) bool {
+	for _, path := range []string{"/v1/" + name, "/v1/" + name + "/"} {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			return true
+		}
+		if _, pattern := mux.Handler(req); pattern != "" && pattern != "/" {
+			return true
+		}
+	}
+	return false
+}
+
+// splitNetworkPath splits "/v1/{network}/rest" into the network and "/v1/rest".
+func splitNetworkPath(path string) (network, rest string, ok bool) {
+	s, found := strings.CutPrefix(path, "/v1/")
+	if !found {
+		return "", "", false
+	}
+	network, tail, _ := strings.Cut(s, "/")
+	return network, "/v1/" + tail, network != ""
+}
+
+// networkDispatch routes /v1/{network}/... to that network's mux with the network
+// segment stripped, and everything else to the default network.
+func networkDispatch(def http.Handler, nets map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, rest, ok := splitNetworkPath(r.URL.Path); ok {
+			if h, ok := nets[name]; ok {
+				r2 := new(http.Request)
+				*r2 = *r
+				r2.URL = new(url.URL)
+				*r2.URL = *r.URL
+				r2.URL.Path = rest
+				r2.URL.RawPath = ""
+				h.ServeHTTP(w, r2)
+				// Surface the inner route pattern to withMetrics
+				r.Pattern = r2.Pattern
+				return
+			}
+		}
+		def.ServeHTTP(w, r)
+	})
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
 <html lang="en">
 <head>
 	 <meta charset="UTF-8">
@@ -190,26 +484,71 @@ func NewRouter(cfg config.Config, pool *db.Pool, syncTrigger handlers.SyncTrigge
 	   };
 	 </script>
 </body>
-</html>`))
-	})
+</html>`
 
-	// Fallback 404 handler for any other path
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte(`{"error":"not_found"}`))
+// streamPathPrefixes mark long-lived streaming endpoints (event streams and bulk
+// exports) that must not be cut off by the request timeout.
+var streamPathPrefixes = []string{"/v1/stream/", "/v1/export/"}
+
+// withTimeout applies cfg.RequestTimeout to every request except streaming endpoints.
+// http.TimeoutHandler buffers the response and doesn't support flushing, so streams bypass it.
+func withTimeout(cfg config.Config, next http.Handler) http.Handler {
+	timed := http.TimeoutHandler(next, cfg.RequestTimeout, "request timeout\n")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isStreamPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		timed.ServeHTTP(w, r)
 	})
+}
 
-	// Wrap mux with stdlib middlewares
-	var h http.Handler = mux
-	h = withServerHeader(h)
-	h = withDefaultCacheControl(h)
-	h = withDateHeader(h)
-	h = withCORS(cfg, h)
-	h = withRecover(h)
-	h = http.TimeoutHandler(h, cfg.RequestTimeout, "request timeout\n")
+// isStreamPath reports whether path is a streaming endpoint, with or without a network prefix.
+func isStreamPath(path string) bool {
+	_, rest, ok := splitNetworkPath(path)
+	for _, prefix := range streamPathPrefixes {
+		if strings.HasPrefix(path, prefix) || ok && strings.HasPrefix(rest, prefix) {
+			return true
+		}
+	}
+	return false
+}
 
-	return h
+// readinessTargets checks every network; components of non-default networks are
+// prefixed with "<network>/".
+func readinessTargets(backends []Backend) []handlers.ReadinessTarget {
+	targets := make([]handlers.ReadinessTarget, 0, len(backends))
+	for i, b := range backends {
+		prefix := ""
+		if i > 0 {
+			prefix = b.Cfg.Network + "/"
+		}
+		targets = append(targets, handlers.ReadinessTarget{
+			Prefix: prefix,
+			Pool:   b.Pool,
+			Loops:  b.LoopStatus,
+			Checks: readinessChecks(b.Cfg),
+		})
+	}
+	return targets
+}
+
+// readinessChecks lists the background loops /readyz requires to be fresh.
+// Loops with a zero max age are not checked.
+func readinessChecks(cfg config.Config) []handlers.FreshnessCheck {
+	all := []handlers.FreshnessCheck{
+		{Loop: background.LoopValidators, MaxAge: cfg.ReadyValidatorsMaxAge},
+		{Loop: background.LoopSupernodes, MaxAge: cfg.ReadySupernodesMaxAge},
+		{Loop: background.LoopActions, MaxAge: cfg.ReadyActionsMaxAge},
+		{Loop: background.LoopProbes, MaxAge: cfg.ReadyProbesMaxAge},
+	}
+	checks := make([]handlers.FreshnessCheck, 0, len(all))
+	for _, c := range all {
+		if c.MaxAge > 0 {
+			checks = append(checks, c)
+		}
+	}
+	return checks
 }
 
 func methodNotAllowed(w http.ResponseWriter) {
@@ -257,9 +596,9 @@ func withCORS(cfg config.Config, next http.Handler) http.Handler {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				w.Header().Set("Vary", "Origin")
 			}
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, If-None-Match, If-Modified-Since")
-			w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, If-None-Match, If-Modified-Since, Last-Event-ID, X-API-Key")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
 		}
 
 		if r.Method == http.MethodOptions {
@@ -271,6 +610,20 @@ func withCORS(cfg config.Config, next http.Handler) http.Handler {
 	})
 }
 
+// requireAdmin rejects requests that don't carry "Authorization: Bearer <ADMIN_TOKEN>".
+func requireAdmin(cfg config.Config, next http.Handler) http.Handler {
+	want := []byte("Bearer " + cfg.AdminToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="lumescope-admin"`)
+			util.WriteJSONError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func withRecover(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
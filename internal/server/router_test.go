@@ -0,0 +1,109 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lumescope/internal/config"
+)
+
+// TestNetworkDispatch verifies /v1/{network}/... routing and the default-network fallback
+func TestNetworkDispatch(t *testing.T) {
+	tag := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + " " + r.URL.Path))
+		})
+	}
+	h := networkDispatch(tag("default"), map[string]http.Handler{
+		"mainnet": tag("mainnet"),
+		"testnet": tag("testnet"),
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/actions", "default /v1/actions"},
+		{"/v1/testnet/actions/42", "testnet /v1/actions/42"},
+		{"/v1/mainnet/supernodes/metrics", "mainnet /v1/supernodes/metrics"},
+		{"/v1/unknown/actions", "default /v1/unknown/actions"},
+		{"/healthz", "default /healthz"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if got := rec.Body.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestIsStreamPath verifies streaming endpoints bypass the request timeout with or without a network prefix
+func TestIsStreamPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"/v1/stream/actions":         true,
+		"/v1/testnet/stream/actions": true,
+		"/v1/export/actions":         true,
+		"/v1/testnet/export/actions": true,
+		"/v1/actions":                false,
+		"/v1/testnet/actions":        false,
+		"/stream/actions":            false,
+	} {
+		if got := isStreamPath(path); got != want {
+			t.Errorf("isStreamPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// TestRouteExists verifies network names that shadow existing /v1 routes are detected
+func TestRouteExists(t *testing.T) {
+	mux := newNetworkMux(Backend{})
+	if !routeExists(mux, "supernodes") {
+		t.Error("expected supernodes to collide")
+	}
+	if !routeExists(mux, "graphql") {
+		t.Error("expected graphql to collide")
+	}
+	if routeExists(mux, "testnet") {
+		t.Error("did not expect testnet to collide")
+	}
+}
+
+// TestRequireAdmin verifies admin routes reject requests without the exact bearer token
+func TestRequireAdmin(t *testing.T) {
+	h := requireAdmin(config.Config{AdminToken: "s3cret"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for header, want := range map[string]int{
+		"":               http.StatusUnauthorized,
+		"s3cret":         http.StatusUnauthorized,
+		"Bearer wrong":   http.StatusUnauthorized,
+		"Bearer s3cret ": http.StatusUnauthorized,
+		"Basic s3cret":   http.StatusUnauthorized,
+		"Bearer s3cret":  http.StatusNoContent,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Authorization %q: status = %d, want %d", header, rec.Code, want)
+		}
+		if want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: 401 without WWW-Authenticate", header)
+		}
+	}
+}
+
+// TestAdminRoutes verifies admin routes are only served with ADMIN_TOKEN set
+func TestAdminRoutes(t *testing.T) {
+	if routeExists(newNetworkMux(Backend{}), "admin/webhooks") {
+		t.Error("admin routes registered without an admin token")
+	}
+	if !routeExists(newNetworkMux(Backend{Cfg: config.Config{AdminToken: "s3cret"}}), "admin/webhooks") {
+		t.Error("admin routes missing with an admin token")
+	}
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SSEWriter writes Server-Sent Events to a long-lived response.
+type SSEWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// NewSSEWriter sets event-stream headers, clears the server write deadline so the
+// connection can outlive WriteTimeout, and flushes the headers to the client.
+func NewSSEWriter(w http.ResponseWriter) (*SSEWriter, error) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil, fmt.Errorf("streaming not supported: %w", err)
+	}
+	return &SSEWriter{w: w, rc: rc}, nil
+}
+
+// Event writes one event with the given id and type, JSON-encoding data, and flushes it.
+func (s *SSEWriter) Event(id, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	if id != "" {
+		sb.WriteString("id: " + id + "\n")
+	}
+	if event != "" {
+		sb.WriteString("event: " + event + "\n")
+	}
+	sb.WriteString("data: ")
+	sb.Write(b)
+	sb.WriteString("\n\n")
+	if _, err := s.w.Write([]byte(sb.String())); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Retry tells the client how long to wait before reconnecting.
+func (s *SSEWriter) Retry(d time.Duration) error {
+	if _, err := fmt.Fprintf(s.w, "retry: %d\n\n", d.Milliseconds()); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Comment writes an SSE comment line, used as a keepalive.
+func (s *SSEWriter) Comment(text string) error {
+	if _, err := fmt.Fprintf(s.w, ": %s\n\n", text); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
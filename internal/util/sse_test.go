@@ -0,0 +1,29 @@
+package util
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSSEWriter verifies event framing and headers
+func TestSSEWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse, err := NewSSEWriter(rec)
+	if err != nil {
+		t.Fatalf("NewSSEWriter: %v", err)
+	}
+	if err := sse.Event("5", "action_created", map[string]string{"action_id": "42"}); err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+	if err := sse.Comment("keepalive"); err != nil {
+		t.Fatalf("Comment: %v", err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	want := "id: 5\nevent: action_created\ndata: {\"action_id\":\"42\"}\n\n: keepalive\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,272 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lumescope/internal/config"
+	"lumescope/internal/db"
+	"lumescope/internal/metrics"
+)
+
+// fanoutBatchSize caps how many source events are processed per fan-out pass.
+const fanoutBatchSize = 500
+
+// deliveryBatchSize caps how many due deliveries are claimed per delivery pass.
+const deliveryBatchSize = 50
+
+// pruneInterval is how often finished deliveries past their retention are deleted.
+const pruneInterval = time.Hour
+
+var deliveriesTotal = metrics.NewCounterVec(
+	"lumescope_webhook_deliveries_total",
+	"Webhook delivery attempts by result (succeeded, retry, dead).",
+	"result")
+
+// Dispatcher fans events out to subscriptions and delivers them.
+type Dispatcher struct {
+	Cfg  config.Config
+	DB   *db.Pool
+	HTTP *http.Client
+}
+
+// NewDispatcher creates a dispatcher using cfg.WebhookTimeout for outbound requests.
+func NewDispatcher(cfg config.Config, pool *db.Pool) *Dispatcher {
+	return &Dispatcher{Cfg: cfg, DB: pool, HTTP: &http.Client{Timeout: cfg.WebhookTimeout}}
+}
+
+// Start launches the fan-out, delivery and pruning loops.
+func (d *Dispatcher) Start(ctx context.Context) {
+	go d.loop(ctx, "webhook fanout", d.Cfg.WebhookPollInterval, d.fanout)
+	go d.loop(ctx, "webhook delivery", d.Cfg.WebhookPollInterval, d.deliverDue)
+	go d.loop(ctx, "webhook prune", pruneInterval, d.prune)
+}
+
+func (d *Dispatcher) loop(ctx context.Context, name string, every time.Duration, fn func(context.Context) error) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("%s error: %v", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// fanout reads new events from both event logs and enqueues a delivery per matching subscription.
+func (d *Dispatcher) fanout(ctx context.Context) error {
+	subs, err := db.ListWebhookSubscriptions(ctx, d.DB, true)
+	if err != nil {
+		return err
+	}
+	if err := d.fanoutLog(ctx, db.CheckpointWebhookActions, subs, d.actionEventsAfter, db.LatestActionEventID); err != nil {
+		return err
+	}
+	return d.fanoutLog(ctx, db.CheckpointWebhookSupernodes, subs, d.supernodeEventsAfter, db.LatestSupernodeEventID)
+}
+
+// fanoutLog processes one event log from its checkpoint. The first run starts at the
+// current end of the log so existing history is not replayed to subscribers. Event
+// inserts are serialized, so no event can commit below the checkpoint once it moved on.
+func (d *Dispatcher) fanoutLog(ctx context.Context, checkpoint string, subs []db.WebhookSubscription,
+	after func(context.Context, int64) ([]Event, []int64, error),
+	latest func(context.Context, *db.Pool) (int64, error)) error {
+	cp, err := db.GetSyncCheckpoint(ctx, d.DB, checkpoint)
+	if errors.Is(err, db.ErrNotFound) {
+		head, err := latest(ctx, d.DB)
+		if err != nil {
+			return err
+		}
+		return db.SaveSyncCheckpoint(ctx, d.DB, db.SyncCheckpoint{Name: checkpoint, LastEventID: head})
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		events, ids, err := after(ctx, cp.LastEventID)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		for i, e := range events {
+			if err := d.enqueue(ctx, subs, e); err != nil {
+				return err
+			}
+			cp.LastEventID = ids[i]
+		}
+		if err := db.SaveSyncCheckpoint(ctx, d.DB, cp); err != nil {
+			return err
+		}
+		if len(events) < fanoutBatchSize {
+			return nil
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, subs []db.WebhookSubscription, e Event) error {
+	var body []byte
+	for _, sub := range subs {
+		if !Matches(sub, e) {
+			continue
+		}
+		if body == nil {
+			b, err := json.Marshal(Envelope{ID: e.Key, Type: e.Type, CreatedAt: e.CreatedAt, Data: e.Data})
+			if err != nil {
+				return err
+			}
+			body = b
+		}
+		if err := db.EnqueueWebhookDelivery(ctx, d.DB, sub.ID, e.Key, e.Type, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) actionEventsAfter(ctx context.Context, afterID int64) ([]Event, []int64, error) {
+	rows, err := db.ListActionEventsAfter(ctx, d.DB, afterID, db.ActionEventsFilter{}, fanoutBatchSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	events := make([]Event, 0, len(rows))
+	ids := make([]int64, 0, len(rows))
+	for _, r := range rows {
+		events = append(events, FromActionEvent(r))
+		ids = append(ids, r.ID)
+	}
+	return events, ids, nil
+}
+
+func (d *Dispatcher) supernodeEventsAfter(ctx context.Context, afterID int64) ([]Event, []int64, error) {
+	rows, err := db.ListSupernodeEventsAfter(ctx, d.DB, afterID, db.SupernodeEventsFilter{}, fanoutBatchSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	events := make([]Event, 0, len(rows))
+	ids := make([]int64, 0, len(rows))
+	for _, r := range rows {
+		events = append(events, FromSupernodeEvent(r))
+		ids = append(ids, r.ID)
+	}
+	return events, ids, nil
+}
+
+// prune deletes succeeded and dead deliveries older than WebhookDeliveriesRetention.
+func (d *Dispatcher) prune(ctx context.Context) error {
+	retention := d.Cfg.WebhookDeliveriesRetention
+	if retention <= 0 {
+		return nil
+	}
+	n, err := db.PruneWebhookDeliveries(ctx, d.DB, time.Now().UTC().Add(-retention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("pruned %d webhook deliveries older than %v", n, retention)
+	}
+	return nil
+}
+
+// deliverDue claims due deliveries and attempts each one.
+func (d *Dispatcher) deliverDue(ctx context.Context) error {
+	for {
+		// Lease long enough to cover every request in the batch timing out.
+		lease := d.Cfg.WebhookTimeout*deliveryBatchSize + time.Minute
+		batch, err := db.ClaimDueWebhookDeliveries(ctx, d.DB, deliveryBatchSize, lease)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		subs := make(map[int64]db.WebhookSubscription)
+		for _, del := range batch {
+			sub, ok := subs[del.SubscriptionID]
+			if !ok {
+				sub, err = db.GetWebhookSubscription(ctx, d.DB, del.SubscriptionID)
+				if err != nil {
+					log.Printf("webhook delivery %d: load subscription %d: %v", del.ID, del.SubscriptionID, err)
+					continue
+				}
+				subs[del.SubscriptionID] = sub
+			}
+			d.attempt(ctx, sub, del)
+		}
+		if len(batch) < deliveryBatchSize {
+			return nil
+		}
+	}
+}
+
+// attempt posts one delivery and records the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, sub db.WebhookSubscription, del db.WebhookDelivery) {
+	code, err := Send(ctx, d.HTTP, sub.URL, sub.Secret, del, time.Now())
+	if err == nil {
+		deliveriesTotal.Inc("succeeded")
+		if err := db.MarkWebhookDeliverySucceeded(ctx, d.DB, del.ID, code); err != nil {
+			log.Printf("webhook delivery %d: record success: %v", del.ID, err)
+		}
+		return
+	}
+
+	var codePtr *int
+	if code != 0 {
+		codePtr = &code
+	}
+	attempts := del.Attempts + 1
+	if attempts >= d.Cfg.WebhookMaxAttempts {
+		deliveriesTotal.Inc("dead")
+		log.Printf("webhook delivery %d to %s: giving up after %d attempts: %v", del.ID, sub.URL, attempts, err)
+		if err := db.MarkWebhookDeliveryDead(ctx, d.DB, del.ID, codePtr, err.Error()); err != nil {
+			log.Printf("webhook delivery %d: record dead letter: %v", del.ID, err)
+		}
+		return
+	}
+	deliveriesTotal.Inc("retry")
+	next := time.Now().UTC().Add(Backoff(attempts, d.Cfg.WebhookBackoffBase, d.Cfg.WebhookBackoffMax))
+	if err := db.MarkWebhookDeliveryRetry(ctx, d.DB, del.ID, codePtr, err.Error(), next); err != nil {
+		log.Printf("webhook delivery %d: record retry: %v", del.ID, err)
+	}
+}
+
+// Send posts a delivery's payload to url, signed with secret at time now. Any non-2xx
+// response is an error; the status code is returned when a response was received.
+func Send(ctx context.Context, client *http.Client, url, secret string, del db.WebhookDelivery, now time.Time) (int, error) {
+	ts := now.Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(del.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "LumeScope-Webhooks/1")
+	req.Header.Set(HeaderEvent, del.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(del.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, ts, del.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
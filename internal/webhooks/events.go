@@ -0,0 +1,103 @@
+package webhooks
+
+import (
+	"strconv"
+
+	"lumescope/internal/db"
+)
+
+// ActionData is the data field of action.* events.
+type ActionData struct {
+	ActionID   string   `json:"action_id"`
+	ActionType string   `json:"action_type"`
+	Creator    string   `json:"creator"`
+	State      string   `json:"state"`
+	PrevState  *string  `json:"prev_state,omitempty"`
+	Supernodes []string `json:"supernodes,omitempty"`
+	TxType     *string  `json:"tx_type,omitempty"`
+	TxHash     *string  `json:"tx_hash,omitempty"`
+	Height     *int64   `json:"height,omitempty"`
+}
+
+// SupernodeData is the data field of supernode.* events.
+type SupernodeData struct {
+	SupernodeAccount string `json:"supernode_account"`
+	ValidatorAddress string `json:"validator_address,omitempty"`
+	OldValue         string `json:"old_value,omitempty"`
+	NewValue         string `json:"new_value,omitempty"`
+	Height           *int64 `json:"height,omitempty"`
+	Details          any    `json:"details,omitempty"`
+}
+
+var actionEventTypes = map[string]string{
+	db.ActionEventCreated:      EventActionCreated,
+	db.ActionEventStateChanged: EventActionStateChanged,
+	db.ActionEventTxAttached:   EventActionTxAttached,
+}
+
+var supernodeEventTypes = map[string]string{
+	db.SupernodeEventStateChanged:   EventSupernodeStateChanged,
+	db.SupernodeEventPortsDown:      EventSupernodeUnavailable,
+	db.SupernodeEventPortsUp:        EventSupernodeAvailable,
+	db.SupernodeEventIPChanged:      EventSupernodeIPChanged,
+	db.SupernodeEventVersionChanged: EventSupernodeVersion,
+	db.SupernodeEventEvidenceAdded:  EventSupernodeEvidence,
+}
+
+// FromActionEvent converts an action event log row into a webhook event.
+func FromActionEvent(e db.ActionEvent) Event {
+	sns := stringSlice(e.SuperNodes)
+	return Event{
+		Key:        "action:" + strconv.FormatInt(e.ID, 10),
+		Type:       actionEventTypes[e.EventType],
+		CreatedAt:  e.CreatedAt.UTC(),
+		ActionType: e.ActionType,
+		Creator:    e.Creator,
+		Supernodes: sns,
+		Data: ActionData{
+			ActionID:   strconv.FormatUint(e.ActionID, 10),
+			ActionType: e.ActionType,
+			Creator:    e.Creator,
+			State:      e.State,
+			PrevState:  e.PrevState,
+			Supernodes: sns,
+			TxType:     e.TxType,
+			TxHash:     e.TxHash,
+			Height:     e.Height,
+		},
+	}
+}
+
+// FromSupernodeEvent converts a supernode event log row into a webhook event.
+func FromSupernodeEvent(e db.SupernodeEvent) Event {
+	return Event{
+		Key:        "supernode:" + strconv.FormatInt(e.ID, 10),
+		Type:       supernodeEventTypes[e.EventType],
+		CreatedAt:  e.CreatedAt.UTC(),
+		Supernodes: []string{e.SupernodeAccount},
+		Validator:  e.ValidatorAddress,
+		Data: SupernodeData{
+			SupernodeAccount: e.SupernodeAccount,
+			ValidatorAddress: e.ValidatorAddress,
+			OldValue:         e.OldValue,
+			NewValue:         e.NewValue,
+			Height:           e.Height,
+			Details:          e.Details,
+		},
+	}
+}
+
+// stringSlice converts a decoded JSONB array into []string, skipping non-strings.
+func stringSlice(v any) []string {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(arr))
+	for _, x := range arr {
+		if s, ok := x.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+// Package webhooks delivers action and supernode events to subscriber URLs.
+//
+// Events are read from the persisted action_events and supernode_events logs, fanned
+// out into one webhook_deliveries row per matching subscription, and posted with an
+// HMAC-SHA256 signature. Failed deliveries are retried with exponential backoff and
+// moved to webhook_dead_letters once the attempt budget is spent.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// Event types a subscription can ask for.
+const (
+	EventActionCreated         = "action.created"
+	EventActionStateChanged    = "action.state_changed"
+	EventActionTxAttached      = "action.tx_attached"
+	EventSupernodeStateChanged = "supernode.state_changed"
+	EventSupernodeUnavailable  = "supernode.unavailable"
+	EventSupernodeAvailable    = "supernode.available"
+	EventSupernodeIPChanged    = "supernode.ip_changed"
+	EventSupernodeVersion      = "supernode.version_changed"
+	EventSupernodeEvidence     = "supernode.evidence_added"
+)
+
+// EventTypes lists every event type, in documentation order.
+var EventTypes = []string{
+	EventActionCreated,
+	EventActionStateChanged,
+	EventActionTxAttached,
+	EventSupernodeStateChanged,
+	EventSupernodeUnavailable,
+	EventSupernodeAvailable,
+	EventSupernodeIPChanged,
+	EventSupernodeVersion,
+	EventSupernodeEvidence,
+}
+
+// IsEventType reports whether t is a known webhook event type.
+func IsEventType(t string) bool {
+	for _, e := range EventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Headers set on every delivery.
+const (
+	HeaderEvent     = "X-LumeScope-Event"
+	HeaderDelivery  = "X-LumeScope-Delivery"
+	HeaderTimestamp = "X-LumeScope-Timestamp"
+	HeaderSignature = "X-LumeScope-Signature"
+)
+
+// Sign returns the signature header value for body sent at timestamp ts (unix seconds):
+// "sha256=" followed by hex(HMAC-SHA256(secret, ts + "." + body)).
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature produced by Sign. Receivers should also reject timestamps
+// too far from their own clock to limit replay.
+func Verify(secret string, ts int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// NewSecret returns a random 32-byte hex secret for subscriptions created without one.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Backoff returns the delay before retry number attempt (1-based): base doubled per
+// attempt, capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= max || d <= 0 {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// Event is a webhook event ready to be matched against subscriptions.
+type Event struct {
+	Key        string // Unique per source event, e.g. "action:42"
+	Type       string
+	CreatedAt  time.Time
+	ActionType string
+	Creator    string
+	Supernodes []string
+	Validator  string
+	Data       any
+}
+
+// Envelope is the JSON body posted to subscribers.
+type Envelope struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Matches reports whether sub wants e: the type must be subscribed, every non-empty filter
+// must match, and the event must not predate the subscription.
+func Matches(sub db.WebhookSubscription, e Event) bool {
+	if !sub.Active || e.CreatedAt.Before(sub.CreatedAt) {
+		return false
+	}
+	typeOK := false
+	for _, t := range sub.EventTypes {
+		if t == e.Type || t == "*" {
+			typeOK = true
+			break
+		}
+	}
+	if !typeOK {
+		return false
+	}
+	f := sub.Filters
+	if f.ActionType != "" && f.ActionType != e.ActionType {
+		return false
+	}
+	if f.Creator != "" && f.Creator != e.Creator {
+		return false
+	}
+	if f.Validator != "" && f.Validator != e.Validator {
+		return false
+	}
+	if f.Supernode != "" {
+		found := false
+		for _, sn := range e.Supernodes {
+			if sn == f.Supernode {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"lumescope/internal/db"
+)
+
+// TestSendSignsPayload delivers to a local receiver and verifies the signature headers
+func TestSendSignsPayload(t *testing.T) {
+	const secret = "s3cret"
+	var gotVerified bool
+	var gotEvent string
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		gotVerified = Verify(secret, ts, body, r.Header.Get(HeaderSignature))
+		gotEvent = r.Header.Get(HeaderEvent)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	del := db.WebhookDelivery{ID: 7, EventType: EventActionCreated, Payload: []byte(`{"id":"action:1"}`)}
+	code, err := Send(context.Background(), receiver.Client(), receiver.URL, secret, del, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+	if code != http.StatusNoContent {
+		t.Errorf("code = %d, want 204", code)
+	}
+	if !gotVerified {
+		t.Error("receiver could not verify signature")
+	}
+	if gotEvent != EventActionCreated {
+		t.Errorf("event header = %q, want %q", gotEvent, EventActionCreated)
+	}
+}
+
+// TestSendNon2xxIsError verifies receiver failures are reported with their status code
+func TestSendNon2xxIsError(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	code, err := Send(context.Background(), receiver.Client(), receiver.URL, "x", db.WebhookDelivery{Payload: []byte(`{}`)}, time.Now())
+	if err == nil || code != http.StatusServiceUnavailable {
+		t.Errorf("Send = (%d, %v), want (503, error)", code, err)
+	}
+}
+
+// TestVerifyRejectsTampering verifies signatures are bound to body, timestamp and secret
+func TestVerifyRejectsTampering(t *testing.T) {
+	body := []byte(`{"a":1}`)
+	sig := Sign("k", 100, body)
+	if !Verify("k", 100, body, sig) {
+		t.Fatal("valid signature rejected")
+	}
+	if Verify("k", 101, body, sig) || Verify("other", 100, body, sig) || Verify("k", 100, []byte(`{"a":2}`), sig) {
+		t.Error("tampered signature accepted")
+	}
+}
+
+// TestBackoff verifies exponential growth and the cap
+func TestBackoff(t *testing.T) {
+	base, max := 10*time.Second, time.Minute
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, w := range want {
+		if got := Backoff(i+1, base, max); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := Backoff(100, base, max); got != max {
+		t.Errorf("Backoff(100) = %v, want cap %v", got, max)
+	}
+}
+
+// TestMatches verifies event type, filter and creation-time matching
+func TestMatches(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := db.WebhookSubscription{
+		Active:     true,
+		EventTypes: []string{EventActionCreated, EventSupernodeUnavailable},
+		Filters:    db.WebhookFilters{Supernode: "lumera1sn"},
+		CreatedAt:  created,
+	}
+	action := FromActionEvent(db.ActionEvent{
+		ID: 1, EventType: db.ActionEventCreated, ActionID: 42, ActionType: "ACTION_TYPE_CASCADE",
+		SuperNodes: []any{"lumera1other", "lumera1sn"}, CreatedAt: created.Add(time.Minute),
+	})
+	if action.Type != EventActionCreated || action.Key != "action:1" {
+		t.Fatalf("FromActionEvent = %+v", action)
+	}
+
+	tests := []struct {
+		name string
+		sub  func(s db.WebhookSubscription) db.WebhookSubscription
+		ev   Event
+		want bool
+	}{
+		{"matching action", nil, action, true},
+		{"inactive", func(s db.WebhookSubscription) db.WebhookSubscription { s.Active = false; return s }, action, false},
+		{"type not subscribed", func(s db.WebhookSubscription) db.WebhookSubscription {
+			s.EventTypes = []string{EventActionTxAttached}
+			return s
+		}, action, false},
+		{"wildcard type", func(s db.WebhookSubscription) db.WebhookSubscription { s.EventTypes = []string{"*"}; return s }, action, true},
+		{"supernode filter miss", func(s db.WebhookSubscription) db.WebhookSubscription {
+			s.Filters.Supernode = "lumera1nope"
+			return s
+		}, action, false},
+		{"action type filter", func(s db.WebhookSubscription) db.WebhookSubscription {
+			s.Filters.ActionType = "ACTION_TYPE_SENSE"
+			return s
+		}, action, false},
+		{"event before subscription", func(s db.WebhookSubscription) db.WebhookSubscription {
+			s.CreatedAt = created.Add(time.Hour)
+			return s
+		}, action, false},
+		{"supernode unavailable", nil, FromSupernodeEvent(db.SupernodeEvent{
+			ID: 2, EventType: db.SupernodeEventPortsDown, SupernodeAccount: "lumera1sn", CreatedAt: created.Add(time.Minute),
+		}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sub
+			if tt.sub != nil {
+				s = tt.sub(s)
+			}
+			if got := Matches(s, tt.ev); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}